@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aas "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// Most activities --show-activities can print; DescribeScalingActivities returns at most 50 per page
+const maxShowActivities = 50
+
+// Read the n most recent scaling activities for the resource, newest first
+func describeScalingActivities(ctx context.Context, client AASClient, res resourceRef, n int) ([]aasTypes.ScalingActivity, error) {
+	out, err := client.DescribeScalingActivities(ctx, &aas.DescribeScalingActivitiesInput{
+		ServiceNamespace:  res.Namespace,
+		ScalableDimension: res.Dimension,
+		ResourceId:        aws.String(res.ID),
+		MaxResults:        aws.Int32(int32(n)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.ScalingActivities) > n {
+		return out.ScalingActivities[:n], nil
+	}
+	return out.ScalingActivities, nil
+}
+
+// Print activities as an aligned table: start and end time, status and cause. An activity still in progress has
+// no end time.
+func printActivities(w io.Writer, resourceID string, activities []aasTypes.ScalingActivity) error {
+	fmt.Fprintf(w, "\nRecent scaling activities for %s (%d)\n", resourceID, len(activities))
+	if len(activities) == 0 {
+		return nil
+	}
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  START\tEND\tSTATUS\tCAUSE")
+	for _, a := range activities {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", formatTime(a.StartTime), formatTime(a.EndTime), a.StatusCode, aws.ToString(a.Cause))
+	}
+	return tw.Flush()
+}
+
+// Print the last --show-activities scaling activities after an apply. The report is read-only and only there to
+// help confirm the policies fire, so failing to read it is logged rather than failing the run.
+func (r *runner) showActivities(ctx context.Context) error {
+	activities, err := describeScalingActivities(ctx, r.aas, r.resource, r.cfg.ShowActivities)
+	if err != nil {
+		r.log.Warn("failed to describe scaling activities", awsErrorFields(err)...)
+		return nil
+	}
+	return printActivities(r.out, r.resource.ID, activities)
+}
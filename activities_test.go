@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// TestShowActivities tests that --show-activities prints the most recent activities after applying, and that a
+// failure to read them does not fail the run
+func TestShowActivities(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	newAAS := func() *mockAASClient {
+		return &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+				ScalingPolicies: []aasTypes.ScalingPolicy{
+					{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+					{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:in")},
+				},
+			},
+			describeActivitiesOutput: &applicationautoscaling.DescribeScalingActivitiesOutput{
+				ScalingActivities: []aasTypes.ScalingActivity{
+					{
+						Cause:      aws.String("monitor alarm test-cluster-test-service-scale-out in state ALARM triggered policy test-cluster-test-service-scale-out"),
+						StatusCode: aasTypes.ScalingActivityStatusCodeInProgress,
+						StartTime:  aws.Time(start.Add(time.Minute)),
+					},
+					{
+						Cause:      aws.String("monitor alarm test-cluster-test-service-scale-in in state ALARM triggered policy test-cluster-test-service-scale-in"),
+						StatusCode: aasTypes.ScalingActivityStatusCodeSuccessful,
+						StartTime:  aws.Time(start),
+						EndTime:    aws.Time(start.Add(30 * time.Second)),
+					},
+				},
+			},
+		}
+	}
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TargetCPUOut:     75,
+		TargetCPUIn:      65,
+		TargetMemOut:     80,
+		TargetMemIn:      70,
+		AlarmsEnabled:    true,
+		ShowActivities:   2,
+	}
+
+	mockAAS := newAAS()
+	var out bytes.Buffer
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}}, &out); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(mockAAS.describeActivitiesCalls) != 1 {
+		t.Fatalf("DescribeScalingActivities called %d times, want 1", len(mockAAS.describeActivitiesCalls))
+	}
+	in := mockAAS.describeActivitiesCalls[0]
+	if aws.ToString(in.ResourceId) != "service/test-cluster/test-service" || aws.ToInt32(in.MaxResults) != 2 {
+		t.Errorf("DescribeScalingActivities input = %s max %d, want service/test-cluster/test-service max 2", aws.ToString(in.ResourceId), aws.ToInt32(in.MaxResults))
+	}
+	for _, want := range []string{
+		"Recent scaling activities for service/test-cluster/test-service (2)",
+		"2024-05-01T12:01:00Z  -                     InProgress  monitor alarm test-cluster-test-service-scale-out",
+		"2024-05-01T12:00:00Z  2024-05-01T12:00:30Z  Successful  monitor alarm test-cluster-test-service-scale-in",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// Reading the activities is best effort
+	mockAAS = newAAS()
+	mockAAS.describeActivitiesError = errors.New("throttled")
+	out.Reset()
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}}, &out); err != nil {
+		t.Errorf("Run() error = %v, want a failure to read activities only logged", err)
+	}
+
+	// Without the flag nothing is read
+	cfg.ShowActivities = 0
+	mockAAS = newAAS()
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}}, &out); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(mockAAS.describeActivitiesCalls) != 0 {
+		t.Errorf("DescribeScalingActivities called %d times without show-activities, want 0", len(mockAAS.describeActivitiesCalls))
+	}
+}
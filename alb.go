@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// Build the ResourceLabel ALBRequestCountPerTarget needs, app/<lb-name>/<lb-id>/targetgroup/<tg-name>/<tg-id>, from
+// the load balancer and target group ARNs. Both must be in the same account and region, and the load balancer must
+// be an Application Load Balancer.
+func albResourceLabel(loadBalancerARN, targetGroupARN string) (string, error) {
+	lb, err := arn.Parse(loadBalancerARN)
+	if err != nil || lb.Service != "elasticloadbalancing" || !strings.HasPrefix(lb.Resource, "loadbalancer/") {
+		return "", fmt.Errorf("invalid load-balancer-arn %q: expected arn:aws:elasticloadbalancing:<region>:<account>:loadbalancer/app/<name>/<id>", loadBalancerARN)
+	}
+	tg, err := arn.Parse(targetGroupARN)
+	if err != nil || tg.Service != "elasticloadbalancing" || !strings.HasPrefix(tg.Resource, "targetgroup/") {
+		return "", fmt.Errorf("invalid target-group-arn %q: expected arn:aws:elasticloadbalancing:<region>:<account>:targetgroup/<name>/<id>", targetGroupARN)
+	}
+
+	lbParts := strings.Split(strings.TrimPrefix(lb.Resource, "loadbalancer/"), "/")
+	if len(lbParts) != 3 || lbParts[1] == "" || lbParts[2] == "" {
+		return "", fmt.Errorf("invalid load-balancer-arn %q: expected loadbalancer/app/<name>/<id>", loadBalancerARN)
+	}
+	if lbParts[0] != "app" {
+		return "", fmt.Errorf("invalid load-balancer-arn %q: ALBRequestCountPerTarget needs an Application Load Balancer, not %s", loadBalancerARN, lbParts[0])
+	}
+	tgParts := strings.Split(strings.TrimPrefix(tg.Resource, "targetgroup/"), "/")
+	if len(tgParts) != 2 || tgParts[0] == "" || tgParts[1] == "" {
+		return "", fmt.Errorf("invalid target-group-arn %q: expected targetgroup/<name>/<id>", targetGroupARN)
+	}
+	if lb.Region != tg.Region || lb.AccountID != tg.AccountID {
+		return "", errors.New("load-balancer-arn and target-group-arn must be in the same account and region")
+	}
+	return strings.Join(append(lbParts, "targetgroup", tgParts[0], tgParts[1]), "/"), nil
+}
+
+// Fill in label on every ALBRequestCountPerTarget policy that does not set resource_label itself. Fails when no
+// policy tracks ALBRequestCountPerTarget, since the ARNs would otherwise be silently ignored.
+func setALBResourceLabel(policies []PolicyDef, label string) error {
+	found := false
+	for _, p := range policies {
+		tt := p.TargetTrackingConfiguration
+		if tt == nil || aasTypes.MetricType(tt.PredefinedMetricSpecification) != aasTypes.MetricTypeALBRequestCountPerTarget {
+			continue
+		}
+		found = true
+		if tt.ResourceLabel == "" {
+			tt.ResourceLabel = label
+		}
+	}
+	if !found {
+		return errors.New("load-balancer-arn and target-group-arn need a target-tracking policy on ALBRequestCountPerTarget")
+	}
+	return nil
+}
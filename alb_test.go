@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const (
+	testLoadBalancerARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188"
+	testTargetGroupARN  = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/my-tg/943f017f100becff"
+)
+
+// TestALBResourceLabel tests building the ALBRequestCountPerTarget resource label from the two ARNs
+func TestALBResourceLabel(t *testing.T) {
+	got, err := albResourceLabel(testLoadBalancerARN, testTargetGroupARN)
+	if err != nil {
+		t.Fatalf("albResourceLabel() unexpected error: %v", err)
+	}
+	if want := "app/my-alb/50dc6c495c0c9188/targetgroup/my-tg/943f017f100becff"; got != want {
+		t.Errorf("albResourceLabel() = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name, lb, tg, wantErr string
+	}{
+		{"not an ARN", "my-alb", testTargetGroupARN, "invalid load-balancer-arn"},
+		{"target group as load balancer", testTargetGroupARN, testTargetGroupARN, "invalid load-balancer-arn"},
+		{"load balancer as target group", testLoadBalancerARN, testLoadBalancerARN, "invalid target-group-arn"},
+		{"network load balancer", strings.Replace(testLoadBalancerARN, "/app/", "/net/", 1), testTargetGroupARN, "needs an Application Load Balancer, not net"},
+		{"listener ARN", testLoadBalancerARN + "/f2f7dc8efc522ab2", testTargetGroupARN, "expected loadbalancer/app/<name>/<id>"},
+		{"target group without id", testLoadBalancerARN, "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/my-tg", "expected targetgroup/<name>/<id>"},
+		{"different region", testLoadBalancerARN, strings.Replace(testTargetGroupARN, "us-east-1", "eu-west-1", 1), "same account and region"},
+		{"different account", testLoadBalancerARN, strings.Replace(testTargetGroupARN, "123456789012", "210987654321", 1), "same account and region"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := albResourceLabel(tt.lb, tt.tg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("albResourceLabel() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestALBResourceLabelPolicies tests that the label from the ARNs reaches ALBRequestCountPerTarget policies
+// without their own resource_label, and that the flags are checked when parsing
+func TestALBResourceLabelPolicies(t *testing.T) {
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		AlarmsEnabled:    true,
+		LoadBalancerARN:  testLoadBalancerARN,
+		TargetGroupARN:   testTargetGroupARN,
+		PoliciesRaw: `[
+			{"policy_name": "requests", "policy_type": "TargetTrackingScaling", "target_tracking_configuration": {"target_value": 1000, "predefined_metric_specification": "ALBRequestCountPerTarget"}},
+			{"policy_name": "other-tg", "policy_type": "TargetTrackingScaling", "target_tracking_configuration": {"target_value": 500, "predefined_metric_specification": "ALBRequestCountPerTarget", "resource_label": "app/other/1/targetgroup/other/2"}}
+		]`,
+	}
+	r, err := newRunner(cfg, Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard)
+	if err != nil {
+		t.Fatalf("newRunner() unexpected error: %v", err)
+	}
+	in, err := buildPolicyInput(r.policies[0], r.resource)
+	if err != nil {
+		t.Fatalf("buildPolicyInput() unexpected error: %v", err)
+	}
+	if got := *in.TargetTrackingScalingPolicyConfiguration.PredefinedMetricSpecification.ResourceLabel; got != "app/my-alb/50dc6c495c0c9188/targetgroup/my-tg/943f017f100becff" {
+		t.Errorf("ResourceLabel = %s, want the label built from the ARNs", got)
+	}
+	if got := r.policies[1].TargetTrackingConfiguration.ResourceLabel; got != "app/other/1/targetgroup/other/2" {
+		t.Errorf("explicit resource_label = %s, want it kept", got)
+	}
+
+	// The ARNs without a policy to use them are an error rather than silently ignored
+	cfg.PoliciesRaw = ""
+	if _, err := newRunner(cfg, Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard); err == nil || !strings.Contains(err.Error(), "need a target-tracking policy on ALBRequestCountPerTarget") {
+		t.Errorf("newRunner() error = %v, want the ARNs to need an ALBRequestCountPerTarget policy", err)
+	}
+
+	if _, err := parseArgs(append(testPositionalArgs(), "--load-balancer-arn="+testLoadBalancerARN)); err == nil || !strings.Contains(err.Error(), "must be set together") {
+		t.Errorf("parseArgs() error = %v, want the ARNs to be set together", err)
+	}
+}
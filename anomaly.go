@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cw "github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// AnomalyDetection makes a custom policy's alarm fire when its metric leaves the CloudWatch anomaly detection band,
+// instead of crossing the static threshold from the target-cpu-utilization flags
+type AnomalyDetection struct {
+	BandWidth float64 `json:"band_width,omitempty"` // band width in standard deviations; defaults to 2
+}
+
+// CloudWatch's default anomaly detection band width, in standard deviations
+const defaultAnomalyBandWidth = 2
+
+// Metric math IDs of the alarm's metric and the band computed from it
+const (
+	anomalyMetricID = "m1"
+	anomalyBandID   = "ad1"
+)
+
+// ANOMALY_DETECTION_BAND(m1, 2) as written by anomalyBandExpression
+var anomalyBandPattern = regexp.MustCompile(`^ANOMALY_DETECTION_BAND\(\s*(\w+)\s*(?:,\s*([0-9.]+)\s*)?\)$`)
+
+// The band width in standard deviations, with the default applied
+func (a *AnomalyDetection) bandWidth() float64 {
+	if a.BandWidth == 0 {
+		return defaultAnomalyBandWidth
+	}
+	return a.BandWidth
+}
+
+func anomalyBandExpression(metricID string, width float64) string {
+	return fmt.Sprintf("ANOMALY_DETECTION_BAND(%s, %s)", metricID, strconv.FormatFloat(width, 'f', -1, 64))
+}
+
+// Check a policy's anomaly_detection; it needs a step policy with an alarm metric to watch
+func validateAnomalyDetection(p PolicyDef) error {
+	a := p.AnomalyDetection
+	if a == nil {
+		return nil
+	}
+	if p.PolicyType != "StepScaling" {
+		return fmt.Errorf("policy %s: anomaly_detection requires policy_type StepScaling", p.PolicyName)
+	}
+	if p.MetricName == "" || p.MetricNamespace == "" {
+		return fmt.Errorf("policy %s: anomaly_detection requires metric_name and metric_namespace", p.PolicyName)
+	}
+	if a.BandWidth < 0 {
+		return fmt.Errorf("policy %s: anomaly_detection band_width must be positive, got %v", p.PolicyName, a.BandWidth)
+	}
+	return nil
+}
+
+// Turn a static-threshold alarm into one on the anomaly detection band: the metric moves into the Metrics array
+// next to the band expression, which replaces the threshold. Scale-out alarms fire above the band, scale-in
+// alarms below it.
+func setAnomalyDetection(in *cw.PutMetricAlarmInput, a *AnomalyDetection) {
+	stat := string(in.Statistic)
+	if in.ExtendedStatistic != nil {
+		stat = *in.ExtendedStatistic
+	}
+	in.Metrics = []cwTypes.MetricDataQuery{
+		{
+			Id: aws.String(anomalyMetricID),
+			MetricStat: &cwTypes.MetricStat{
+				Metric: &cwTypes.Metric{
+					Namespace:  in.Namespace,
+					MetricName: in.MetricName,
+					Dimensions: in.Dimensions,
+				},
+				Period: in.Period,
+				Stat:   aws.String(stat),
+				Unit:   in.Unit,
+			},
+			ReturnData: aws.Bool(true),
+		},
+		{
+			Id:         aws.String(anomalyBandID),
+			Expression: aws.String(anomalyBandExpression(anomalyMetricID, a.bandWidth())),
+			ReturnData: aws.Bool(true),
+		},
+	}
+	in.ThresholdMetricId = aws.String(anomalyBandID)
+	if in.ComparisonOperator == cwTypes.ComparisonOperatorLessThanOrEqualToThreshold {
+		in.ComparisonOperator = cwTypes.ComparisonOperatorLessThanLowerThreshold
+	} else {
+		in.ComparisonOperator = cwTypes.ComparisonOperatorGreaterThanUpperThreshold
+	}
+
+	// PutMetricAlarm rejects a single-metric definition alongside Metrics
+	in.Namespace, in.MetricName, in.Dimensions = nil, nil, nil
+	in.Period, in.Statistic, in.ExtendedStatistic, in.Unit, in.Threshold = nil, "", nil, "", nil
+}
+
+// The metric an anomaly detection alarm watches and the band's width, or ok false for a static-threshold alarm
+func anomalyAlarmMetric(metrics []cwTypes.MetricDataQuery, thresholdMetricID *string) (stat *cwTypes.MetricStat, width float64, ok bool) {
+	if thresholdMetricID == nil {
+		return nil, 0, false
+	}
+	var expr string
+	for _, m := range metrics {
+		if aws.ToString(m.Id) == *thresholdMetricID {
+			expr = aws.ToString(m.Expression)
+		}
+	}
+	match := anomalyBandPattern.FindStringSubmatch(expr)
+	if match == nil {
+		return nil, 0, false
+	}
+	width = defaultAnomalyBandWidth
+	if match[2] != "" {
+		if w, err := strconv.ParseFloat(match[2], 64); err == nil {
+			width = w
+		}
+	}
+	for _, m := range metrics {
+		if aws.ToString(m.Id) == match[1] && m.MetricStat != nil && m.MetricStat.Metric != nil {
+			return m.MetricStat, width, true
+		}
+	}
+	return nil, 0, false
+}
+
+// Summarise an alarm's metric math for diff output, e.g. "m1=AWS/SQS/ApproximateAgeOfOldestMessage:Average:60[QueueName=jobs] ad1=ANOMALY_DETECTION_BAND(m1, 2)"
+func alarmMetricsString(metrics []cwTypes.MetricDataQuery) string {
+	if len(metrics) == 0 {
+		return "<unset>"
+	}
+	parts := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		part := aws.ToString(m.Id) + "=" + aws.ToString(m.Expression)
+		if ms := m.MetricStat; ms != nil && ms.Metric != nil {
+			part = fmt.Sprintf("%s=%s/%s:%s:%d", aws.ToString(m.Id), aws.ToString(ms.Metric.Namespace), aws.ToString(ms.Metric.MetricName),
+				aws.ToString(ms.Stat), aws.ToInt32(ms.Period)) + alarmDimensionsString(ms.Metric.Dimensions)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
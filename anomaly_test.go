@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// TestValidateAnomalyDetection tests that anomaly_detection is only accepted on step policies with an alarm metric
+func TestValidateAnomalyDetection(t *testing.T) {
+	step := func(a *AnomalyDetection) PolicyDef {
+		return PolicyDef{PolicyName: "p", PolicyType: "StepScaling", MetricName: "Latency", MetricNamespace: "App", AnomalyDetection: a}
+	}
+	noMetric := step(&AnomalyDetection{})
+	noMetric.MetricNamespace = ""
+	tracking := step(&AnomalyDetection{})
+	tracking.PolicyType = "TargetTrackingScaling"
+
+	tests := []struct {
+		name    string
+		policy  PolicyDef
+		wantErr string
+	}{
+		{"not set", step(nil), ""},
+		{"default band", step(&AnomalyDetection{}), ""},
+		{"custom band", step(&AnomalyDetection{BandWidth: 3.5}), ""},
+		{"negative band", step(&AnomalyDetection{BandWidth: -1}), "band_width must be positive"},
+		{"no metric", noMetric, "requires metric_name and metric_namespace"},
+		{"target tracking", tracking, "requires policy_type StepScaling"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePolicy(tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePolicy() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestAnomalyAlarmInput tests that an anomaly detection policy's alarm compares against the band instead of a
+// static threshold, and that the alarm exports back to the same policy
+func TestAnomalyAlarmInput(t *testing.T) {
+	policy := PolicyDef{
+		PolicyName:      "latency-out",
+		PolicyType:      "StepScaling",
+		MetricName:      "TargetResponseTime",
+		MetricNamespace: "AWS/ApplicationELB",
+		Cooldown:        aws.Int32(60),
+		Statistic:       "p90",
+		Dimensions:      map[string]string{"LoadBalancer": "app/web/123"},
+		AnomalyDetection: &AnomalyDetection{
+			BandWidth: 3,
+		},
+	}
+	r := newTestRunner(t, true, []PolicyDef{policy}, &mockAASClient{}, &mockCWClient{})
+
+	in, err := r.customAlarmInput(policy, "arn:policy")
+	if err != nil {
+		t.Fatalf("customAlarmInput() unexpected error: %v", err)
+	}
+	if in.Threshold != nil || in.MetricName != nil || in.Namespace != nil || in.Dimensions != nil || in.Period != nil || in.ExtendedStatistic != nil {
+		t.Errorf("customAlarmInput() kept single-metric fields alongside Metrics: %+v", in)
+	}
+	if aws.ToString(in.ThresholdMetricId) != "ad1" {
+		t.Errorf("ThresholdMetricId = %q, want ad1", aws.ToString(in.ThresholdMetricId))
+	}
+	if in.ComparisonOperator != cwTypes.ComparisonOperatorGreaterThanUpperThreshold {
+		t.Errorf("ComparisonOperator = %s, want GreaterThanUpperThreshold", in.ComparisonOperator)
+	}
+	want := "m1=AWS/ApplicationELB/TargetResponseTime:p90:60[LoadBalancer=app/web/123] ad1=ANOMALY_DETECTION_BAND(m1, 3)"
+	if got := alarmMetricsString(in.Metrics); got != want {
+		t.Errorf("Metrics = %s, want %s", got, want)
+	}
+
+	// Scale-in alarms fire below the band
+	policy.ScaleDirection = "in"
+	in, err = r.customAlarmInput(policy, "arn:policy")
+	if err != nil {
+		t.Fatalf("customAlarmInput() unexpected error: %v", err)
+	}
+	if in.ComparisonOperator != cwTypes.ComparisonOperatorLessThanLowerThreshold {
+		t.Errorf("ComparisonOperator = %s, want LessThanLowerThreshold", in.ComparisonOperator)
+	}
+
+	// The alarm as AWS reports it matches the desired input and exports back to the policy
+	existing := &cwTypes.MetricAlarm{
+		AlarmName:          in.AlarmName,
+		ComparisonOperator: in.ComparisonOperator,
+		EvaluationPeriods:  in.EvaluationPeriods,
+		Metrics:            in.Metrics,
+		ThresholdMetricId:  in.ThresholdMetricId,
+		AlarmActions:       in.AlarmActions,
+		ActionsEnabled:     in.ActionsEnabled,
+	}
+	if diffs := compareAlarm(existing, in); len(diffs) != 0 {
+		t.Errorf("compareAlarm() = %v, want no differences", diffs)
+	}
+	existing.Metrics = existing.Metrics[:1:1]
+	existing.Metrics = append(existing.Metrics, cwTypes.MetricDataQuery{Id: aws.String("ad1"), Expression: aws.String("ANOMALY_DETECTION_BAND(m1, 2)")})
+	if diffs := compareAlarm(existing, in); len(diffs) != 1 || diffs[0].Field != "Metrics" {
+		t.Errorf("compareAlarm() = %v, want a Metrics difference", diffs)
+	}
+
+	sp := aasTypes.ScalingPolicy{
+		PolicyName:                     aws.String("latency-out"),
+		PolicyType:                     aasTypes.PolicyTypeStepScaling,
+		StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{Cooldown: aws.Int32(60)},
+	}
+	got := policyDefFromScalingPolicy(sp, existing, r.resource)
+	if got.MetricName != "TargetResponseTime" || got.MetricNamespace != "AWS/ApplicationELB" || got.Statistic != "p90" {
+		t.Errorf("exported metric = %s/%s %s, want AWS/ApplicationELB/TargetResponseTime p90", got.MetricNamespace, got.MetricName, got.Statistic)
+	}
+	if got.AnomalyDetection == nil || got.AnomalyDetection.BandWidth != 0 {
+		t.Errorf("exported anomaly_detection = %+v, want the default band", got.AnomalyDetection)
+	}
+	if got.Dimensions["LoadBalancer"] != "app/web/123" || got.ScaleDirection != "in" {
+		t.Errorf("exported dimensions %v and direction %q, want the load balancer and in", got.Dimensions, got.ScaleDirection)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// SNSClient publishes --audit-topic-arn events
+type SNSClient interface {
+	Publish(ctx context.Context, topicARN, message string) error
+}
+
+// auditEvent is the JSON message published for each enable or disable run: who ran what against which resource,
+// when, whether it succeeded and what it changed
+type auditEvent struct {
+	Time       time.Time    `json:"time"`
+	Actor      string       `json:"actor,omitempty"`
+	Command    string       `json:"command"`
+	Cluster    string       `json:"cluster"`
+	Service    string       `json:"service"`
+	Resource   string       `json:"resource"`
+	Region     string       `json:"region"`
+	Success    bool         `json:"success"`
+	Error      string       `json:"error,omitempty"`
+	Summary    string       `json:"summary"`
+	Changes    auditChanges `json:"changes"`
+	ConfigHash string       `json:"config_hash"`
+}
+
+// Policies and alarms changed by the run, by action: created, updated or deleted
+type auditChanges struct {
+	Policies map[string]int `json:"policies"`
+	Alarms   map[string]int `json:"alarms"`
+}
+
+// Who started the run: the GitHub actor in Actions, otherwise the local user
+func auditActor() string {
+	if actor := os.Getenv("GITHUB_ACTOR"); actor != "" {
+		return actor
+	}
+	return os.Getenv("USER")
+}
+
+// Publish the audit event for an enable or disable run that ended with runErr. Publishing is best effort: a
+// failure is logged as a warning and never changes the run's result.
+func (r *runner) publishAudit(ctx context.Context, runErr error) {
+	if command := r.cfg.command(); command != commandEnable && command != commandDisable {
+		return
+	}
+	event := r.auditEvent(runErr)
+	message, err := json.Marshal(event)
+	if err != nil {
+		r.log.Warn("failed to encode audit event", "error", err)
+		return
+	}
+	if err := r.sns.Publish(ctx, r.cfg.AuditTopicARN, string(message)); err != nil {
+		r.log.Warn("failed to publish audit event", append(awsErrorFields(err), "topic_arn", r.cfg.AuditTopicARN)...)
+		return
+	}
+	r.log.Debug("published audit event", "topic_arn", r.cfg.AuditTopicARN)
+}
+
+// The audit event of a run that ended with runErr, also written by --summary-file
+func (r *runner) auditEvent(runErr error) auditEvent {
+	event := auditEvent{
+		Time:       time.Now().UTC(),
+		Actor:      auditActor(),
+		Command:    r.cfg.command(),
+		Cluster:    r.cfg.Cluster,
+		Service:    r.cfg.Service,
+		Resource:   r.resource.ID,
+		Region:     r.cfg.Region,
+		Success:    runErr == nil,
+		Summary:    r.changeSummary(),
+		Changes:    auditChanges{Policies: r.metrics.policies, Alarms: r.metrics.alarms},
+		ConfigHash: r.configHash,
+	}
+	if runErr != nil {
+		event.Error = runErr.Error()
+	}
+	return event
+}
+
+// Check --audit-topic-arn: an SNS topic ARN
+func validateTopicARN(topicARN string) error {
+	a, err := arn.Parse(topicARN)
+	if err != nil || a.Service != "sns" || a.Region == "" || a.Resource == "" || strings.Contains(a.Resource, ":") {
+		return fmt.Errorf("invalid audit-topic-arn %q: expected arn:aws:sns:<region>:<account>:<topic>", topicARN)
+	}
+	return nil
+}
+
+// snsPublisher publishes through the SNS SDK client, in the topic's own region, which need not be the run's
+type snsPublisher struct {
+	client *sns.Client
+}
+
+func newSNSClient(awsCfg aws.Config, endpointURL string) snsPublisher {
+	var opts []func(*sns.Options)
+	if endpointURL != "" {
+		opts = append(opts, func(o *sns.Options) { o.BaseEndpoint = aws.String(endpointURL) })
+	}
+	return snsPublisher{client: sns.NewFromConfig(awsCfg, opts...)}
+}
+
+func (p snsPublisher) Publish(ctx context.Context, topicARN, message string) error {
+	var opts []func(*sns.Options)
+	if topic, err := arn.Parse(topicARN); err == nil && topic.Region != "" {
+		opts = append(opts, func(o *sns.Options) { o.Region = topic.Region })
+	}
+	_, err := p.client.Publish(ctx, &sns.PublishInput{TopicArn: aws.String(topicARN), Message: aws.String(message)}, opts...)
+	return err
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+const testTopicARN = "arn:aws:sns:us-east-1:123456789012:autoscaling-audit"
+
+type mockSNSClient struct {
+	publishError error
+	topics       []string
+	messages     []string
+}
+
+func (m *mockSNSClient) Publish(ctx context.Context, topicARN, message string) error {
+	m.topics = append(m.topics, topicARN)
+	m.messages = append(m.messages, message)
+	return m.publishError
+}
+
+// TestRunAudit tests that an enable run publishes one audit event with its summary, that a failed publish does not
+// fail the run, and that read-only runs publish nothing
+func TestRunAudit(t *testing.T) {
+	t.Setenv("GITHUB_ACTOR", "octocat")
+	ctx := context.Background()
+	newConfig := func() *Config {
+		return &Config{
+			Cluster:          "test-cluster",
+			Service:          "test-service",
+			Region:           "us-east-1",
+			Enabled:          true,
+			MinCapacity:      1,
+			MaxCapacity:      10,
+			ScaleOutCooldown: 300,
+			ScaleInCooldown:  300,
+			TargetCPUOut:     75,
+			TargetCPUIn:      65,
+			TargetMemOut:     80,
+			TargetMemIn:      70,
+			AlarmsEnabled:    true,
+			AuditTopicARN:    testTopicARN,
+		}
+	}
+	newClients := func(sns SNSClient) Clients {
+		return Clients{
+			AAS: &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+				putScalingPolicyARNs:          map[string]string{"test-cluster-test-service-scale-out": "arn:out", "test-cluster-test-service-scale-in": "arn:in"},
+			},
+			CW:  &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}},
+			SNS: sns,
+		}
+	}
+
+	sns := &mockSNSClient{}
+	if err := Run(ctx, newConfig(), newClients(sns), io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(sns.messages) != 1 || sns.topics[0] != testTopicARN {
+		t.Fatalf("Publish called %d times on %v, want once on %s", len(sns.messages), sns.topics, testTopicARN)
+	}
+	var event auditEvent
+	if err := json.Unmarshal([]byte(sns.messages[0]), &event); err != nil {
+		t.Fatalf("audit event is not JSON: %v", err)
+	}
+	if event.Actor != "octocat" || event.Command != commandEnable || event.Resource != "service/test-cluster/test-service" || !event.Success {
+		t.Errorf("audit event = %+v, want octocat enabling service/test-cluster/test-service successfully", event)
+	}
+	if event.Summary != "applied 7 changes" || event.Changes.Policies["created"] != 2 || event.Changes.Alarms["created"] != 4 {
+		t.Errorf("audit event summary %q, changes %+v, want 7 changes: 2 policies and 4 alarms created", event.Summary, event.Changes)
+	}
+	if event.ConfigHash == "" || event.Time.IsZero() {
+		t.Errorf("audit event = %+v, want the config hash and time set", event)
+	}
+
+	// Publishing is best effort
+	if err := Run(ctx, newConfig(), newClients(&mockSNSClient{publishError: errors.New("throttled")}), io.Discard); err != nil {
+		t.Errorf("Run() with a failing publish error = %v, want nil", err)
+	}
+
+	// Read-only runs are not audited
+	sns = &mockSNSClient{}
+	plan := newConfig()
+	plan.Plan = true
+	if err := Run(ctx, plan, newClients(sns), io.Discard); err != nil {
+		t.Fatalf("Run() plan unexpected error: %v", err)
+	}
+	if len(sns.messages) != 0 {
+		t.Errorf("plan published %d audit events, want none", len(sns.messages))
+	}
+
+	if _, err := parseArgs(append(testPositionalArgs(), "--audit-topic-arn=arn:aws:sqs:us-east-1:123456789012:queue")); err == nil || !strings.Contains(err.Error(), "invalid audit-topic-arn") {
+		t.Errorf("parseArgs() error = %v, want an invalid audit-topic-arn", err)
+	}
+}
+
+// TestSNSClient tests that Publish sends the topic and message signed for the topic's region, and that an error
+// response becomes an AWS API error naming the missing permission
+func TestSNSClient(t *testing.T) {
+	var mu sync.Mutex
+	var form url.Values
+	var authorization string
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "text/xml")
+		if fail {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AuthorizationError</Code><Message>not authorized to perform SNS:Publish</Message></Error></ErrorResponse>`)
+			return
+		}
+		_, _ = io.WriteString(w, `<PublishResponse><PublishResult><MessageId>1</MessageId></PublishResult></PublishResponse>`)
+	}))
+	defer server.Close()
+
+	awsCfg := aws.Config{Region: "eu-west-1", Credentials: credentials.NewStaticCredentialsProvider("key", "secret", "")}
+	client := newSNSClient(awsCfg, server.URL)
+	if err := client.Publish(context.Background(), testTopicARN, `{"command":"enable"}`); err != nil {
+		t.Fatalf("Publish() unexpected error: %v", err)
+	}
+	if form.Get("Action") != "Publish" || form.Get("TopicArn") != testTopicARN || form.Get("Message") != `{"command":"enable"}` {
+		t.Errorf("Publish() sent %v, want the Publish action, topic and message", form)
+	}
+	if !strings.Contains(authorization, "/us-east-1/sns/aws4_request") {
+		t.Errorf("Authorization = %q, want a SigV4 signature for sns in the topic's region us-east-1", authorization)
+	}
+
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	err := client.Publish(context.Background(), testTopicARN, "{}")
+	if err == nil || !strings.Contains(err.Error(), "AuthorizationError") {
+		t.Fatalf("Publish() error = %v, want AuthorizationError", err)
+	}
+	if permission, ok := missingPermission(err); !ok || permission != "sns:Publish" {
+		t.Errorf("missingPermission() = %q, %v, want sns:Publish", permission, ok)
+	}
+}
+
+// TestSNSClientRetries tests that a throttled Publish is retried through the SDK retryer
+func TestSNSClientRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `<ErrorResponse><Error><Type>Sender</Type><Code>Throttling</Code><Message>Rate exceeded</Message></Error></ErrorResponse>`)
+			return
+		}
+		_, _ = io.WriteString(w, `<PublishResponse><PublishResult><MessageId>1</MessageId></PublishResult></PublishResponse>`)
+	}))
+	defer server.Close()
+
+	awsCfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		Retryer: func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			})
+		},
+	}
+	if err := newSNSClient(awsCfg, server.URL).Publish(context.Background(), testTopicARN, "{}"); err != nil {
+		t.Fatalf("Publish() unexpected error: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Publish() sent %d requests, want 2", got)
+	}
+}
+
+// hostRecorder answers every request with a successful Publish response and records the hosts it was sent to
+type hostRecorder struct {
+	hosts []string
+}
+
+func (h *hostRecorder) Do(req *http.Request) (*http.Response, error) {
+	h.hosts = append(h.hosts, req.URL.Host)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/xml"}},
+		Body:       io.NopCloser(strings.NewReader(`<PublishResponse><PublishResult><MessageId>1</MessageId></PublishResult></PublishResponse>`)),
+		Request:    req,
+	}, nil
+}
+
+// TestSNSEndpoint tests that Publish goes to the topic region's endpoint in its partition, following the FIPS and
+// dual-stack settings and AWS_ENDPOINT_URL_SNS of the loaded config
+func TestSNSEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []func(*config.LoadOptions) error
+		env      string // AWS_ENDPOINT_URL_SNS
+		topicARN string
+		want     string
+	}{
+		{"standard", nil, "", testTopicARN, "sns.us-east-1.amazonaws.com"},
+		{"topic in another region", nil, "", "arn:aws:sns:eu-west-1:123456789012:audit", "sns.eu-west-1.amazonaws.com"},
+		{"china", nil, "", "arn:aws-cn:sns:cn-north-1:123456789012:audit", "sns.cn-north-1.amazonaws.com.cn"},
+		{"iso", nil, "", "arn:aws-iso:sns:us-iso-east-1:123456789012:audit", "sns.us-iso-east-1.c2s.ic.gov"},
+		{"fips", []func(*config.LoadOptions) error{config.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled)}, "", "arn:aws-us-gov:sns:us-gov-west-1:123456789012:audit", "sns.us-gov-west-1.amazonaws.com"},
+		{"dual-stack", []func(*config.LoadOptions) error{config.WithUseDualStackEndpoint(aws.DualStackEndpointStateEnabled)}, "", testTopicARN, "sns.us-east-1.api.aws"},
+		{"service endpoint", nil, "http://localhost:4566", testTopicARN, "localhost:4566"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+			t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+			t.Setenv("AWS_CA_BUNDLE", "")
+			t.Setenv("AWS_ENDPOINT_URL", "")
+			t.Setenv("AWS_ENDPOINT_URL_SNS", tt.env)
+			recorder := &hostRecorder{}
+			opts := append([]func(*config.LoadOptions) error{
+				config.WithRegion("us-east-1"),
+				config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("key", "secret", "")),
+				config.WithHTTPClient(recorder),
+			}, tt.opts...)
+			awsCfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+			if err != nil {
+				t.Fatalf("LoadDefaultConfig() unexpected error: %v", err)
+			}
+			if err := newSNSClient(awsCfg, "").Publish(context.Background(), tt.topicARN, "{}"); err != nil {
+				t.Fatalf("Publish() unexpected error: %v", err)
+			}
+			if len(recorder.hosts) != 1 || recorder.hosts[0] != tt.want {
+				t.Errorf("Publish() sent to %v, want %s", recorder.hosts, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// errorCategory is a coarse classification of an AWS API error, for log fields and messages
+type errorCategory string
+
+const (
+	errorAccessDenied       errorCategory = "access-denied"
+	errorInvalidCredentials errorCategory = "invalid-credentials"
+	errorThrottling         errorCategory = "throttling"
+	errorNotFound           errorCategory = "not-found"
+	errorValidation         errorCategory = "validation"
+	errorOther              errorCategory = "other"
+)
+
+// Classify an error by its AWS error code; errors that are not AWS API errors are errorOther
+func classifyAWSError(err error) errorCategory {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return errorOther
+	}
+	switch apiErr.ErrorCode() {
+	case "AccessDeniedException", "AccessDenied", "AuthorizationError", "UnauthorizedOperation":
+		return errorAccessDenied
+	case "UnrecognizedClientException", "InvalidClientTokenId", "ExpiredToken", "ExpiredTokenException", "InvalidSignatureException", "SignatureDoesNotMatch":
+		return errorInvalidCredentials
+	case "ThrottlingException", "Throttling", "TooManyRequestsException", "RequestLimitExceeded":
+		return errorThrottling
+	case "ObjectNotFoundException", "ResourceNotFound", "ResourceNotFoundException":
+		return errorNotFound
+	case "ValidationException", "ValidationError", "InvalidParameterValue", "InvalidParameterCombination":
+		return errorValidation
+	default:
+		return errorOther
+	}
+}
+
+// IAM action prefixes for the SDK service IDs this tool calls
+var iamServicePrefixes = map[string]string{
+	"Application Auto Scaling": "application-autoscaling",
+	"CloudWatch":               "cloudwatch",
+	"ECS":                      "ecs",
+	"SNS":                      "sns",
+}
+
+// The IAM action an access-denied error is missing, e.g. application-autoscaling:PutScalingPolicy
+func missingPermission(err error) (string, bool) {
+	var opErr *smithy.OperationError
+	if classifyAWSError(err) != errorAccessDenied || !errors.As(err, &opErr) {
+		return "", false
+	}
+	prefix, ok := iamServicePrefixes[opErr.ServiceID]
+	if !ok {
+		prefix = strings.ToLower(strings.ReplaceAll(opErr.ServiceID, " ", "-"))
+	}
+	return prefix + ":" + opErr.OperationName, true
+}
+
+// Explain an access-denied error in terms of the IAM permission to add, and a rejected-credentials error in terms
+// of the credentials to fix; other errors are returned unchanged
+func explainAccessDenied(err error, res resourceRef) error {
+	if classifyAWSError(err) == errorInvalidCredentials {
+		return fmt.Errorf("invalid credentials: AWS did not recognise the access key, or the session token has expired; check the credentials the run uses: %w", err)
+	}
+	permission, ok := missingPermission(err)
+	if !ok {
+		return err
+	}
+	return fmt.Errorf("access denied: the IAM role or user is missing %s for %s; add it to its policy: %w", permission, res.ID, err)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/smithy-go"
+)
+
+// accessDenied returns an AccessDeniedException as the SDK would for the given operation
+func accessDenied(service, operation string) error {
+	return &smithy.OperationError{
+		ServiceID:     service,
+		OperationName: operation,
+		Err:           &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "User is not authorized to perform " + operation},
+	}
+}
+
+// TestClassifyAWSError tests the categories of common AWS error codes
+func TestClassifyAWSError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want errorCategory
+	}{
+		{accessDenied("Application Auto Scaling", "PutScalingPolicy"), errorAccessDenied},
+		{&smithy.GenericAPIError{Code: "UnrecognizedClientException"}, errorInvalidCredentials},
+		{&smithy.GenericAPIError{Code: "ExpiredTokenException"}, errorInvalidCredentials},
+		{fmt.Errorf("wrapped: %w", &smithy.GenericAPIError{Code: "ThrottlingException"}), errorThrottling},
+		{&smithy.GenericAPIError{Code: "ObjectNotFoundException"}, errorNotFound},
+		{&smithy.GenericAPIError{Code: "ValidationException"}, errorValidation},
+		{&smithy.GenericAPIError{Code: "InternalServiceException"}, errorOther},
+		{errors.New("not an AWS error"), errorOther},
+		{nil, errorOther},
+	}
+
+	for _, tt := range tests {
+		if got := classifyAWSError(tt.err); got != tt.want {
+			t.Errorf("classifyAWSError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestRunAccessDenied tests that a run denied an IAM permission names the missing action and the resource
+func TestRunAccessDenied(t *testing.T) {
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		registerScalableTargetError:   accessDenied("Application Auto Scaling", "RegisterScalableTarget"),
+	}
+	r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}})
+
+	err := Run(context.Background(), r.cfg, Clients{AAS: mockAAS, CW: &mockCWClient{}}, nil)
+	if err == nil {
+		t.Fatal("Run() expected error, got nil")
+	}
+	for _, want := range []string{"application-autoscaling:RegisterScalableTarget", "service/test-cluster/test-service"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Run() error = %q, want it to name %s", err, want)
+		}
+	}
+	if classifyAWSError(err) != errorAccessDenied {
+		t.Errorf("classifyAWSError(Run() error) = %s, want the original error kept in the chain", classifyAWSError(err))
+	}
+
+	// Other errors pass through unchanged
+	other := errors.New("boom")
+	if got := explainAccessDenied(other, r.resource); got != other {
+		t.Errorf("explainAccessDenied() = %v, want the error unchanged", got)
+	}
+}
+
+// TestRunInvalidCredentials tests that rejected credentials are reported as such, not as a missing permission
+func TestRunInvalidCredentials(t *testing.T) {
+	mockAAS := &mockAASClient{describeScalableTargetsError: &smithy.OperationError{
+		ServiceID:     "Application Auto Scaling",
+		OperationName: "DescribeScalableTargets",
+		Err:           &smithy.GenericAPIError{Code: "UnrecognizedClientException", Message: "The security token included in the request is invalid."},
+	}}
+	r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+
+	err := Run(context.Background(), r.cfg, Clients{AAS: mockAAS, CW: &mockCWClient{}}, nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid credentials:") {
+		t.Fatalf("Run() error = %v, want an invalid credentials message", err)
+	}
+	if strings.Contains(err.Error(), "missing") {
+		t.Errorf("Run() error = %q, want no missing permission", err)
+	}
+	if _, ok := missingPermission(err); ok {
+		t.Error("missingPermission() found a permission for rejected credentials")
+	}
+	if classifyAWSError(err) != errorInvalidCredentials {
+		t.Errorf("classifyAWSError(Run() error) = %s, want %s", classifyAWSError(err), errorInvalidCredentials)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Suffixes of the two AWS policies a bidirectional policy expands into
+const (
+	bidirectionalOutSuffix = "-out"
+	bidirectionalInSuffix  = "-in"
+)
+
+// Check a bidirectional policy: a step policy with an alarm metric whose steps split by sign into a scale-out
+// half above the out threshold (lower bounds of 0 or more) and a scale-in half below the in threshold (upper
+// bounds of 0 or less). The direction comes from the sign, so scale_direction cannot be set as well.
+func validateBidirectional(p PolicyDef) error {
+	if !p.Bidirectional {
+		return nil
+	}
+	if p.PolicyType != "StepScaling" {
+		return fmt.Errorf("policy %s: bidirectional requires policy_type StepScaling", p.PolicyName)
+	}
+	if p.MetricName == "" || p.MetricNamespace == "" {
+		return fmt.Errorf("policy %s: bidirectional requires metric_name and metric_namespace for its two alarms", p.PolicyName)
+	}
+	if p.ScaleDirection != "" {
+		return fmt.Errorf("policy %s: bidirectional and scale_direction are mutually exclusive; the sign of each step's ScalingAdjustment picks its direction", p.PolicyName)
+	}
+
+	var out, in int
+	for _, step := range p.StepAdjustments {
+		switch {
+		case step.ScalingAdjustment > 0:
+			if step.MetricIntervalLowerBound == nil || *step.MetricIntervalLowerBound < 0 {
+				return fmt.Errorf("policy %s: bidirectional scale-out step %+d needs a MetricIntervalLowerBound of 0 or more", p.PolicyName, step.ScalingAdjustment)
+			}
+			out++
+		case step.ScalingAdjustment < 0:
+			if step.MetricIntervalUpperBound == nil || *step.MetricIntervalUpperBound > 0 {
+				return fmt.Errorf("policy %s: bidirectional scale-in step %+d needs a MetricIntervalUpperBound of 0 or less", p.PolicyName, step.ScalingAdjustment)
+			}
+			in++
+		default:
+			return fmt.Errorf("policy %s: bidirectional steps need a non-zero ScalingAdjustment", p.PolicyName)
+		}
+	}
+	if out == 0 || in == 0 {
+		return fmt.Errorf("policy %s: bidirectional needs at least one positive (scale-out) and one negative (scale-in) step", p.PolicyName)
+	}
+	return nil
+}
+
+// Replace each bidirectional policy with its two halves, <name>-out and <name>-in, each with its own alarm.
+// Everything downstream (apply, plan, cleanup) then sees two ordinary step policies. Fails when a half's name
+// is already taken by another policy.
+func expandBidirectional(policies []PolicyDef) ([]PolicyDef, error) {
+	if !slices.ContainsFunc(policies, func(p PolicyDef) bool { return p.Bidirectional }) {
+		return policies, nil
+	}
+
+	expanded := make([]PolicyDef, 0, len(policies)+1)
+	for _, p := range policies {
+		if !p.Bidirectional {
+			expanded = append(expanded, p)
+			continue
+		}
+		out, in := p, p
+		out.Bidirectional, in.Bidirectional = false, false
+		out.PolicyName, in.PolicyName = p.PolicyName+bidirectionalOutSuffix, p.PolicyName+bidirectionalInSuffix
+		out.ScaleDirection, in.ScaleDirection = "out", "in"
+		out.StepAdjustments, in.StepAdjustments = nil, nil
+		for _, step := range p.StepAdjustments {
+			if step.ScalingAdjustment > 0 {
+				out.StepAdjustments = append(out.StepAdjustments, step)
+			} else {
+				in.StepAdjustments = append(in.StepAdjustments, step)
+			}
+		}
+		expanded = append(expanded, out, in)
+	}
+
+	var errs []error
+	names := make([]string, 0, len(expanded))
+	for _, p := range expanded {
+		if slices.Contains(names, p.PolicyName) {
+			errs = append(errs, fmt.Errorf("policy %s: the name is used twice; bidirectional policies take <name>%s and <name>%s", p.PolicyName, bidirectionalOutSuffix, bidirectionalInSuffix))
+			continue
+		}
+		names = append(names, p.PolicyName)
+	}
+	return expanded, errors.Join(errs...)
+}
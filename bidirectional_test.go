@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+const testBidirectionalPolicy = `[{
+	"policy_name": "cpu",
+	"policy_type": "StepScaling",
+	"bidirectional": true,
+	"metric_name": "CPUUtilization",
+	"metric_namespace": "AWS/ECS",
+	"adjustment_type": "ChangeInCapacity",
+	"cooldown": 60,
+	"step_adjustments": [
+		{"MetricIntervalUpperBound": -10, "ScalingAdjustment": -2},
+		{"MetricIntervalLowerBound": -10, "MetricIntervalUpperBound": 0, "ScalingAdjustment": -1},
+		{"MetricIntervalLowerBound": 0, "MetricIntervalUpperBound": 15, "ScalingAdjustment": 1},
+		{"MetricIntervalLowerBound": 15, "ScalingAdjustment": 3}
+	]
+}]`
+
+// TestValidateBidirectional tests that a bidirectional policy's steps must split cleanly by sign around the threshold
+func TestValidateBidirectional(t *testing.T) {
+	policy := func(steps ...StepAdj) PolicyDef {
+		return PolicyDef{PolicyName: "cpu", PolicyType: "StepScaling", MetricName: "CPUUtilization", MetricNamespace: "AWS/ECS", Bidirectional: true, StepAdjustments: steps}
+	}
+	out := StepAdj{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 1}
+	in := StepAdj{MetricIntervalUpperBound: aws.Float64(0), ScalingAdjustment: -1}
+	tracking := policy(out, in)
+	tracking.PolicyType = "TargetTrackingScaling"
+	noMetric := policy(out, in)
+	noMetric.MetricName = ""
+	direction := policy(out, in)
+	direction.ScaleDirection = "out"
+
+	tests := []struct {
+		name    string
+		policy  PolicyDef
+		wantErr string
+	}{
+		{"valid", policy(out, in), ""},
+		{"target tracking", tracking, "requires policy_type StepScaling"},
+		{"no metric", noMetric, "requires metric_name and metric_namespace"},
+		{"scale direction", direction, "mutually exclusive"},
+		{"scale-out only", policy(out), "at least one positive (scale-out) and one negative (scale-in) step"},
+		{"zero adjustment", policy(out, in, StepAdj{MetricIntervalLowerBound: aws.Float64(5)}), "non-zero ScalingAdjustment"},
+		{"scale-out below the threshold", policy(StepAdj{MetricIntervalLowerBound: aws.Float64(-5), ScalingAdjustment: 1}, in), "MetricIntervalLowerBound of 0 or more"},
+		{"unbounded scale-in", policy(out, StepAdj{ScalingAdjustment: -1}), "MetricIntervalUpperBound of 0 or less"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePolicy(tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePolicy() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestExpandBidirectional tests that parsing splits a bidirectional policy into its scale-out and scale-in halves
+func TestExpandBidirectional(t *testing.T) {
+	policies, err := parsePolicies(testBidirectionalPolicy, "")
+	if err != nil {
+		t.Fatalf("parsePolicies() unexpected error: %v", err)
+	}
+	if len(policies) != 2 {
+		t.Fatalf("parsePolicies() = %d policies, want 2", len(policies))
+	}
+	for i, want := range []struct {
+		name, direction string
+		adjustments     []int32
+	}{
+		{"cpu-out", "out", []int32{1, 3}},
+		{"cpu-in", "in", []int32{-2, -1}},
+	} {
+		p := policies[i]
+		var adjustments []int32
+		for _, step := range p.StepAdjustments {
+			adjustments = append(adjustments, step.ScalingAdjustment)
+		}
+		if p.PolicyName != want.name || p.ScaleDirection != want.direction || p.Bidirectional || !slices.Equal(adjustments, want.adjustments) {
+			t.Errorf("policies[%d] = %s %s bidirectional=%v steps %v, want %s %s steps %v", i, p.PolicyName, p.ScaleDirection, p.Bidirectional, adjustments, want.name, want.direction, want.adjustments)
+		}
+		if p.MetricName != "CPUUtilization" || aws.ToInt32(p.Cooldown) != 60 {
+			t.Errorf("policies[%d] lost the shared settings: %+v", i, p)
+		}
+	}
+
+	// A half may not take the name of another policy
+	clash := strings.Replace(testBidirectionalPolicy, "}]", `}, {"policy_name": "cpu-in", "policy_type": "StepScaling", "adjustment_type": "ChangeInCapacity", "step_adjustments": [{"MetricIntervalLowerBound": 0, "ScalingAdjustment": 1}]}]`, 1)
+	if _, err := parsePolicies(clash, ""); err == nil || !strings.Contains(err.Error(), "cpu-in: the name is used twice") {
+		t.Errorf("parsePolicies() error = %v, want cpu-in used twice", err)
+	}
+}
+
+// TestRunBidirectional tests that a bidirectional policy is applied as two policies with opposing alarms, and
+// that disabling deletes both
+func TestRunBidirectional(t *testing.T) {
+	ctx := context.Background()
+	newAAS := func() *mockAASClient {
+		return &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+				ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+			},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+				ScalingPolicies: []aasTypes.ScalingPolicy{
+					{PolicyName: aws.String("cpu-out"), PolicyARN: aws.String("arn:cpu-out")},
+					{PolicyName: aws.String("cpu-in"), PolicyARN: aws.String("arn:cpu-in")},
+				},
+			},
+		}
+	}
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TargetCPUOut:     75,
+		TargetCPUIn:      40,
+		AlarmsEnabled:    true,
+		PoliciesRaw:      testBidirectionalPolicy,
+	}
+
+	mockAAS, mockCW := newAAS(), &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(mockAAS.putScalingPolicyCalls) != 2 {
+		t.Fatalf("PutScalingPolicy called %d times, want 2", len(mockAAS.putScalingPolicyCalls))
+	}
+	want := map[string]struct {
+		threshold float64
+		op        cwTypes.ComparisonOperator
+		action    string
+	}{
+		"test-cluster-test-service-cpu-out": {75, cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold, "arn:cpu-out"},
+		"test-cluster-test-service-cpu-in":  {40, cwTypes.ComparisonOperatorLessThanOrEqualToThreshold, "arn:cpu-in"},
+	}
+	if len(mockCW.putMetricAlarmCalls) != len(want) {
+		t.Fatalf("PutMetricAlarm called %d times, want %d", len(mockCW.putMetricAlarmCalls), len(want))
+	}
+	for _, in := range mockCW.putMetricAlarmCalls {
+		w, ok := want[aws.ToString(in.AlarmName)]
+		if !ok {
+			t.Fatalf("unexpected alarm %s", aws.ToString(in.AlarmName))
+		}
+		if aws.ToFloat64(in.Threshold) != w.threshold || in.ComparisonOperator != w.op || !slices.Equal(in.AlarmActions, []string{w.action}) {
+			t.Errorf("%s = %s %v -> %v, want %s %v -> %s", aws.ToString(in.AlarmName), in.ComparisonOperator, aws.ToFloat64(in.Threshold), in.AlarmActions, w.op, w.threshold, w.action)
+		}
+	}
+
+	// Disabling deletes both halves and both alarms
+	cfg.Enabled, cfg.Yes = false, true
+	mockAAS, mockCW = newAAS(), &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+		MetricAlarms: []cwTypes.MetricAlarm{
+			{AlarmName: aws.String("test-cluster-test-service-cpu-out"), AlarmActions: []string{"arn:cpu-out"}},
+			{AlarmName: aws.String("test-cluster-test-service-cpu-in"), AlarmActions: []string{"arn:cpu-in"}},
+		},
+	}}
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	var deleted []string
+	for _, call := range mockAAS.deleteScalingPolicyCalls {
+		deleted = append(deleted, aws.ToString(call.PolicyName))
+	}
+	if !slices.Contains(deleted, "cpu-out") || !slices.Contains(deleted, "cpu-in") {
+		t.Errorf("disable deleted policies %v, want cpu-out and cpu-in", deleted)
+	}
+	var deletedAlarms []string
+	for _, call := range mockCW.deleteAlarmsCalls {
+		deletedAlarms = append(deletedAlarms, call.AlarmNames...)
+	}
+	for name := range want {
+		if !slices.Contains(deletedAlarms, name) {
+			t.Errorf("disable deleted alarms %v, want %s among them", deletedAlarms, name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+)
+
+// ECSClient is only used by --all-services-in-cluster to discover services and --check-min-healthy-percent
+type ECSClient interface {
+	ListServices(ctx context.Context, params *ecs.ListServicesInput, optFns ...func(*ecs.Options)) (*ecs.ListServicesOutput, error)
+	DescribeServices(ctx context.Context, params *ecs.DescribeServicesInput, optFns ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error)
+}
+
+// List the names of every service in a cluster, following every page of results
+func listClusterServices(ctx context.Context, client ECSClient, cluster string) ([]string, error) {
+	var names []string
+	var nextToken *string
+	for {
+		out, err := client.ListServices(ctx, &ecs.ListServicesInput{
+			Cluster:   aws.String(cluster),
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, arn := range out.ServiceArns {
+			// arn:aws:ecs:REGION:ACCOUNT:service/CLUSTER/NAME, or service/NAME for older ARNs
+			names = append(names, arn[strings.LastIndex(arn, "/")+1:])
+		}
+		if aws.ToString(out.NextToken) == "" {
+			return names, nil
+		}
+		nextToken = out.NextToken
+	}
+}
+
+// Run once for every service in cfg.Cluster except the --exclude ones, each with the same configuration.
+// Every service is attempted and failures are joined, so one broken service does not block the rest.
+// With --detailed-exit-code, errChanged is returned when nothing failed and any service changed.
+// With --interval the services are listed and reconciled again every interval, so new services are picked up.
+func RunCluster(ctx context.Context, cfg *Config, clients Clients, out io.Writer) error {
+	if cfg.Interval > 0 {
+		return reconcileLoop(ctx, cfg.Interval, func(ctx context.Context) error {
+			return runClusterOnce(ctx, cfg, clients, out)
+		})
+	}
+	return runClusterOnce(ctx, cfg, clients, out)
+}
+
+// A single pass of RunCluster
+func runClusterOnce(ctx context.Context, cfg *Config, clients Clients, out io.Writer) error {
+	if clients.ECS == nil {
+		return errors.New("all-services-in-cluster requires an ECS client")
+	}
+	services, err := listClusterServices(ctx, clients.ECS, cfg.Cluster)
+	if err != nil {
+		return withExitCode(explainAccessDenied(fmt.Errorf("failed to list services in cluster %s: %w", cfg.Cluster, err), resourceRef{ID: cfg.Cluster}))
+	}
+	slog.Info("discovered services", "cluster", cfg.Cluster, "services", len(services), "excluded", cfg.Exclude)
+
+	var errs []error
+	var changed error
+	for _, service := range services {
+		// The remaining services are not failures of their own, so stop with what has failed so far
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if slices.Contains(cfg.Exclude, service) {
+			slog.Debug("skipping excluded service", "cluster", cfg.Cluster, "service", service)
+			continue
+		}
+		serviceCfg := *cfg
+		serviceCfg.Service = service
+		err := runOnce(ctx, &serviceCfg, clients, out)
+		if errors.Is(err, errChanged) {
+			changed = err
+			continue
+		}
+		if err != nil {
+			slog.Error("service failed", append(awsErrorFields(err), "service", service)...)
+			errs = append(errs, fmt.Errorf("service %s: %w", service, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return changed
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+)
+
+type mockECSClient struct {
+	listServicesPages      []*ecs.ListServicesOutput // one per call, in order
+	listServicesError      error
+	describeServicesOutput *ecs.DescribeServicesOutput
+	describeServicesError  error
+
+	// Recorded calls
+	listServicesCalls     []*ecs.ListServicesInput
+	describeServicesCalls []*ecs.DescribeServicesInput
+}
+
+func (m *mockECSClient) ListServices(ctx context.Context, params *ecs.ListServicesInput, optFns ...func(*ecs.Options)) (*ecs.ListServicesOutput, error) {
+	m.listServicesCalls = append(m.listServicesCalls, params)
+	if m.listServicesError != nil {
+		return nil, m.listServicesError
+	}
+	return m.listServicesPages[len(m.listServicesCalls)-1], nil
+}
+
+func (m *mockECSClient) DescribeServices(ctx context.Context, params *ecs.DescribeServicesInput, optFns ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error) {
+	m.describeServicesCalls = append(m.describeServicesCalls, params)
+	return m.describeServicesOutput, m.describeServicesError
+}
+
+// TestListClusterServices tests that every page is followed and names are taken from both ARN formats
+func TestListClusterServices(t *testing.T) {
+	mock := &mockECSClient{listServicesPages: []*ecs.ListServicesOutput{
+		{
+			ServiceArns: []string{"arn:aws:ecs:us-east-1:123456789012:service/test-cluster/api"},
+			NextToken:   aws.String("page-2"),
+		},
+		{ServiceArns: []string{"arn:aws:ecs:us-east-1:123456789012:service/worker"}},
+	}}
+
+	got, err := listClusterServices(context.Background(), mock, "test-cluster")
+	if err != nil {
+		t.Fatalf("listClusterServices() unexpected error: %v", err)
+	}
+	if strings.Join(got, ",") != "api,worker" {
+		t.Errorf("listClusterServices() = %v, want [api worker]", got)
+	}
+	if len(mock.listServicesCalls) != 2 || aws.ToString(mock.listServicesCalls[1].NextToken) != "page-2" {
+		t.Errorf("listClusterServices() made %d calls, want 2 with the second token", len(mock.listServicesCalls))
+	}
+	if aws.ToString(mock.listServicesCalls[0].Cluster) != "test-cluster" {
+		t.Errorf("ListServices cluster = %q, want test-cluster", aws.ToString(mock.listServicesCalls[0].Cluster))
+	}
+}
+
+// TestRunCluster tests that every discovered service except the excluded ones is run
+func TestRunCluster(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Enabled:          true,
+		Plan:             true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TargetCPUOut:     75,
+		TargetCPUIn:      65,
+		TargetMemOut:     80,
+		TargetMemIn:      70,
+		TagAlarms:        true,
+		AlarmsEnabled:    true,
+		Exclude:          []string{"batch"},
+	}
+	mockECS := &mockECSClient{listServicesPages: []*ecs.ListServicesOutput{{ServiceArns: []string{
+		"arn:aws:ecs:us-east-1:123456789012:service/test-cluster/api",
+		"arn:aws:ecs:us-east-1:123456789012:service/test-cluster/batch",
+		"arn:aws:ecs:us-east-1:123456789012:service/test-cluster/worker",
+	}}}}
+	clients := Clients{
+		AAS: &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		},
+		CW:  &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}},
+		ECS: mockECS,
+	}
+
+	var out bytes.Buffer
+	if err := RunCluster(ctx, cfg, clients, &out); err != nil {
+		t.Fatalf("RunCluster() unexpected error: %v", err)
+	}
+	for _, want := range []string{"service/test-cluster/api", "service/test-cluster/worker"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("RunCluster() output missing %s:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "batch") {
+		t.Errorf("RunCluster() planned excluded service batch:\n%s", out.String())
+	}
+	if cfg.Service != "" {
+		t.Errorf("RunCluster() modified cfg.Service to %q", cfg.Service)
+	}
+
+	// Listing failures surface as errors
+	clients.ECS = &mockECSClient{listServicesError: errors.New("cluster not found")}
+	if err := RunCluster(ctx, cfg, clients, &out); err == nil || !strings.Contains(err.Error(), "cluster not found") {
+		t.Errorf("RunCluster() error = %v, want wrapped cluster not found", err)
+	}
+
+	// A failing service does not stop the others
+	failing := &mockAASClient{describeScalableTargetsError: errors.New("throttled")}
+	clients = Clients{AAS: failing, CW: clients.CW, ECS: &mockECSClient{listServicesPages: mockECS.listServicesPages}}
+	err := RunCluster(ctx, cfg, clients, &out)
+	if err == nil || !strings.Contains(err.Error(), "service api") || !strings.Contains(err.Error(), "service worker") {
+		t.Errorf("RunCluster() error = %v, want failures for api and worker", err)
+	}
+
+	// Cancelling during one service stops before the next
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c := &cancelOn{operation: "DescribeScalableTargets", cancel: cancel}
+	cancelClients := c.clients(&mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}, clients.CW)
+	cancelClients.ECS = &mockECSClient{listServicesPages: mockECS.listServicesPages}
+	out.Reset()
+	if err := RunCluster(cancelCtx, cfg, cancelClients, &out); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunCluster() error = %v, want context.Canceled", err)
+	}
+	if strings.Contains(out.String(), "service/test-cluster/worker") {
+		t.Errorf("RunCluster() planned worker after the context was cancelled:\n%s", out.String())
+	}
+}
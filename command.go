@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Subcommands, given as the first argument. Without one the legacy positional form applies, where the
+// enabled positional arg picks enable or disable and --plan, --verify, --export, --describe and --selftest pick the
+// read-only modes.
+const (
+	commandEnable   = "enable"
+	commandDisable  = "disable"
+	commandPlan     = "plan"
+	commandVerify   = "verify"
+	commandExport   = "export"
+	commandDescribe = "describe"
+	commandSelftest = "selftest"
+)
+
+var commands = []string{commandEnable, commandDisable, commandPlan, commandVerify, commandExport, commandDescribe, commandSelftest}
+
+// Split a leading subcommand off the args; the legacy form has none and returns ""
+func splitCommand(args []string) (string, []string) {
+	if len(args) > 0 && slices.Contains(commands, args[0]) {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// Flags that only some subcommands accept; every other flag is accepted by all of them.
+// The legacy form accepts everything, since action.yml passes every flag on every run.
+var commandFlags = map[string][]string{
+	"plan":                nil,
+	"verify":              nil,
+	"export":              nil,
+	"describe":            nil,
+	"selftest":            nil,
+	"output":              {commandDescribe},
+	"yes":                 {commandDisable},
+	"keep-target":         {commandDisable, commandPlan},
+	"prefix-cleanup":      {commandDisable, commandPlan},
+	"remove-policy":       {commandEnable},
+	"purge-unmanaged":     {commandEnable, commandPlan, commandVerify},
+	"target-only":         {commandEnable, commandPlan, commandVerify},
+	"show-activities":     {commandEnable},
+	"diff-only-exit-code": {commandVerify},
+	"audit-topic-arn":     {commandEnable, commandDisable},
+	"summary-file":        {commandEnable, commandDisable},
+	"wait":                {commandEnable, commandDisable},
+	"wait-timeout":        {commandEnable, commandDisable},
+	"wait-interval":       {commandEnable, commandDisable},
+	"interval":            {commandEnable},
+}
+
+// Whether a subcommand accepts a flag
+func commandAccepts(command, flagName string) bool {
+	only, restricted := commandFlags[flagName]
+	return command == "" || !restricted || slices.Contains(only, command)
+}
+
+// A FlagSet with just the flags a subcommand accepts, sharing their values with all
+func commandFlagSet(all *flag.FlagSet, command string) *flag.FlagSet {
+	fs := flag.NewFlagSet(all.Name(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	all.VisitAll(func(f *flag.Flag) {
+		if commandAccepts(command, f.Name) {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	return fs
+}
+
+// The -h text for a command's FlagSet: its form, then its flags with their defaults
+func commandUsage(fs *flag.FlagSet) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: %s [positional args] [flags]\n\nFlags:\n", fs.Name())
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
+	return b.String()
+}
+
+// Reconcile the enabled positional arg with the subcommand: enable and disable set it, and a contradicting
+// value is an error rather than silently ignored. plan and verify preview whichever state it asks for.
+func applyCommand(cfg *Config, command, enabledArg string) error {
+	switch command {
+	case commandEnable, commandDisable:
+		want := command == commandEnable
+		if enabledArg != "" && cfg.Enabled != want {
+			return fmt.Errorf("enabled=%s contradicts the %s command", enabledArg, command)
+		}
+		cfg.Enabled = want
+	}
+	cfg.Command = cfg.command()
+	if command != "" {
+		cfg.Command = command
+	}
+	return nil
+}
+
+// The subcommand to run. Configs without one, from the legacy form or built directly, derive it from the
+// mode flags, in the order they have always taken precedence.
+func (c *Config) command() string {
+	switch {
+	case c.Command != "":
+		return c.Command
+	case c.Selftest:
+		return commandSelftest
+	case c.Export:
+		return commandExport
+	case c.Describe:
+		return commandDescribe
+	case c.Verify:
+		return commandVerify
+	case c.Plan:
+		return commandPlan
+	case c.Enabled || len(c.RemovePolicies) > 0:
+		return commandEnable
+	default:
+		return commandDisable
+	}
+}
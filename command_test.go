@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseArgsCommand tests the subcommands and the legacy form's mapping onto them
+func TestParseArgsCommand(t *testing.T) {
+	disabled := testPositionalArgs()
+	disabled[5] = "false"
+	noEnabled := testPositionalArgs()
+	noEnabled[5] = ""
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantCommand string
+		wantEnabled bool
+	}{
+		{"legacy enabled", testPositionalArgs(), commandEnable, true},
+		{"legacy disabled", disabled, commandDisable, false},
+		{"legacy plan", append(testPositionalArgs(), "--plan"), commandPlan, true},
+		{"legacy export wins over plan", append(testPositionalArgs(), "--plan", "--export"), commandExport, true},
+		{"legacy remove-policy while disabled", append(disabled, "--remove-policy=cpu"), commandEnable, false},
+		{"enable", append([]string{"enable"}, testPositionalArgs()...), commandEnable, true},
+		{"disable without enabled", append([]string{"disable"}, append(noEnabled, "--yes")...), commandDisable, false},
+		{"enable without enabled", append([]string{"enable"}, noEnabled...), commandEnable, true},
+		{"plan keeps enabled", append([]string{"plan"}, disabled...), commandPlan, false},
+		{"verify", append([]string{"verify"}, testPositionalArgs()...), commandVerify, true},
+		{"export", append([]string{"export"}, testPositionalArgs()...), commandExport, true},
+		{"legacy describe", append(testPositionalArgs(), "--describe", "--output=json"), commandDescribe, true},
+		{"describe", append([]string{"describe"}, append(testPositionalArgs(), "--output=json")...), commandDescribe, true},
+		{"legacy selftest wins over export", append(testPositionalArgs(), "--export", "--selftest"), commandSelftest, true},
+		{"selftest", append([]string{"selftest"}, testPositionalArgs()...), commandSelftest, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs() unexpected error: %v", err)
+			}
+			if cfg.Command != tt.wantCommand || cfg.Enabled != tt.wantEnabled {
+				t.Errorf("parseArgs() command = %s enabled=%v, want %s enabled=%v", cfg.Command, cfg.Enabled, tt.wantCommand, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+// TestCommandAccepts tests which flags each subcommand accepts
+func TestCommandAccepts(t *testing.T) {
+	tests := []struct {
+		command, flag string
+		want          bool
+	}{
+		{"", "plan", true},
+		{"", "yes", true},
+		{commandPlan, "plan", false},
+		{commandDisable, "yes", true},
+		{commandEnable, "yes", false},
+		{commandEnable, "wait", true},
+		{commandDisable, "wait", true},
+		{commandPlan, "wait", false},
+		{commandDisable, "remove-policy", false},
+		{commandExport, "log-level", true},
+		{commandDescribe, "output", true},
+		{commandPlan, "output", false},
+	}
+
+	for _, tt := range tests {
+		if got := commandAccepts(tt.command, tt.flag); got != tt.want {
+			t.Errorf("commandAccepts(%q, %q) = %v, want %v", tt.command, tt.flag, got, tt.want)
+		}
+	}
+}
+
+// TestCommandFlags tests that each subcommand parses and lists only the flags it accepts
+func TestCommandFlags(t *testing.T) {
+	cfg, err := parseArgs([]string{"disable", "-h"})
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(cfg.Usage, "Usage: ecs-autoscaler disable") || !strings.Contains(cfg.Usage, "-yes") || !strings.Contains(cfg.Usage, "-keep-target") {
+		t.Errorf("disable -h usage = %q, want the disable command's flags", cfg.Usage)
+	}
+	for _, name := range []string{"-remove-policy", "-show-activities", "-plan", "-output"} {
+		if strings.Contains(cfg.Usage, "\n  "+name+" ") || strings.Contains(cfg.Usage, "\n  "+name+"\n") {
+			t.Errorf("disable -h usage lists %s, which disable does not accept", name)
+		}
+	}
+
+	// The legacy form lists every flag
+	cfg, err = parseArgs([]string{"-h"})
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+	if !strings.Contains(cfg.Usage, "-yes") || !strings.Contains(cfg.Usage, "-remove-policy") || !strings.Contains(cfg.Usage, "-plan") {
+		t.Errorf("-h usage = %q, want every flag", cfg.Usage)
+	}
+
+	// A flag that only another command accepts is named as such, an unknown one is reported as undefined
+	if _, err := parseArgs(append([]string{"enable"}, append(testPositionalArgs(), "--yes")...)); err == nil || !strings.Contains(err.Error(), "-yes is not supported by the enable command") {
+		t.Errorf("parseArgs() error = %v, want -yes not supported by enable", err)
+	}
+	if _, err := parseArgs(append([]string{"enable"}, append(testPositionalArgs(), "--no-such-flag")...)); err == nil || !strings.Contains(err.Error(), "not defined: -no-such-flag") {
+		t.Errorf("parseArgs() error = %v, want -no-such-flag not defined", err)
+	}
+
+	// The config file may hold flags for other commands; they are skipped rather than rejected
+	path := writeConfigFile(t, "autoscaler.yml", "flags:\n  yes: true\n  remove-policy: cpu\n")
+	cfg, err = parseArgs(append([]string{"plan"}, append(testPositionalArgs(), "--config-file="+path)...))
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+	if cfg.Yes || len(cfg.RemovePolicies) > 0 {
+		t.Errorf("parseArgs() yes=%v remove-policy=%v, want the other commands' file flags skipped", cfg.Yes, cfg.RemovePolicies)
+	}
+}
@@ -0,0 +1,944 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// Config holds every input for a single run.
+// The first 16 values come from the positional args passed by action.yml, the rest from optional flags.
+//
+// Every value can also come from an ECSAS_* environment variable (see positionalEnv and envName), and most from
+// a --config-file (see FileConfig). Precedence is: a non-empty command-line value, then the environment variable,
+// then the config file, then the built-in default. An explicit flag value beats the environment even when it equals
+// the default, so action.yml gives its inputs empty defaults and an unset input is passed as an empty value.
+type Config struct {
+	// AWS access. SessionToken completes temporary static keys, e.g. from sts:AssumeRole, and is masked like
+	// KeySecret. Profile loads credentials from a named profile in the shared config files instead of static keys;
+	// SharedConfigFile and SharedCredentialsFile replace the default ~/.aws/config and ~/.aws/credentials.
+	// AllowAnyRegion skips the region format check for non-standard partitions;
+	// EndpointURL sends every API call somewhere other than AWS, e.g. http://localhost:4566 for LocalStack.
+	// UseFIPSEndpoint and UseDualStackEndpoint pick the FIPS and IPv6-capable endpoints of the region.
+	// RegionFromInstanceMetadata leaves Region empty until the AWS config is loaded, then fills it in from the
+	// environment, the shared config or EC2 instance metadata (see loadAWSConfig).
+	KeyID        string
+	KeySecret    string
+	SessionToken string
+	Profile      string
+	Region       string
+
+	SharedConfigFile      string
+	SharedCredentialsFile string
+
+	AllowAnyRegion             bool
+	RegionFromInstanceMetadata bool
+	EndpointURL                string
+	UseFIPSEndpoint            bool
+	UseDualStackEndpoint       bool
+
+	// Target service
+	Cluster string
+	Service string
+	Enabled bool
+
+	// Command is the subcommand to run (enable, disable, plan, verify or export); see Config.command
+	Command string
+
+	// ResourceID replaces the ECS service/CLUSTER/SERVICE ID built from Cluster and Service
+	ResourceID string
+
+	// Scalable target outside ECS; ServiceNamespace defaults to ecs (Cluster/Service above)
+	ServiceNamespace  string
+	TableName         string
+	IndexName         string
+	ScalableDimension string
+
+	// Scalable target bounds and default step-scaling settings
+	MinCapacity      int32
+	MaxCapacity      int32
+	ScaleOutCooldown int32
+	ScaleInCooldown  int32
+	TargetCPUOut     float64
+	TargetCPUIn      float64
+	TargetMemOut     float64
+	TargetMemIn      float64
+
+	// Upper bound for every cooldown, in seconds; 0 means defaultMaxCooldown
+	MaxCooldown int32
+
+	// Default alarm evaluation periods and period in seconds; 0 keeps defaultEvaluationPeriods and the matching cooldown
+	DefaultEvaluationPeriods int32
+	DefaultAlarmPeriod       int32
+
+	// Namespace and CPU/memory metric names of the default alarms; empty keeps AWS/ECS, CPUUtilization and
+	// MemoryUtilization, e.g. ECS/ContainerInsights or a custom exporter's namespace instead
+	DefaultMetricNamespace string
+	CPUMetricName          string
+	MemMetricName          string
+
+	// ComparisonOperator for the default scale-out (high) and scale-in (low) alarms; empty keeps
+	// GreaterThanOrEqualToThreshold and LessThanOrEqualToThreshold
+	ScaleOutOperator string
+	ScaleInOperator  string
+
+	// MetricAggregationType of the default step policies; empty keeps Maximum
+	DefaultAggregationType string
+
+	// AggressiveScaleOut replaces the default scale-out policy's single +1 step with AggressiveTiers steps, each
+	// AggressiveStepSize wide, whose adjustment grows by AggressiveMultiplier per tier (see generateSteps)
+	AggressiveScaleOut   bool
+	AggressiveStepSize   float64
+	AggressiveTiers      int32
+	AggressiveMultiplier int32
+
+	// Built-in policies used when no custom policies are given: step (default) or target-tracking.
+	// Blended adds the CPU/memory target-tracking pair alongside any custom policies.
+	DefaultPolicyType string
+	Blended           bool
+
+	// SQSQueue adds a target-tracking policy on the queue's visible messages with MessagesPerTask as the target
+	SQSQueue        string
+	MessagesPerTask float64
+
+	// LoadBalancerARN and TargetGroupARN build the resource_label of ALBRequestCountPerTarget policies that omit it
+	LoadBalancerARN string
+	TargetGroupARN  string
+
+	// Raw policy JSON, inline or read from --policies-file/--default-policies-file; PoliciesRaw takes precedence
+	DefaultPoliciesRaw string
+	PoliciesRaw        string
+
+	// Optional flags
+	NamePrefix      string
+	NameTemplate    string
+	Env             string // appended to every generated policy and alarm name, e.g. staging
+	Tags            map[string]string
+	TagAlarms       bool
+	ReconcileAlarms bool
+	AlarmStatistic  string
+	ForceRecreate   bool
+	Plan            bool
+	Verify          bool
+	Export          bool
+	Describe        bool
+	Output          string // --describe output format: text or json
+	Selftest        bool
+	LogFormat       string
+	LogLevel        string
+	Quiet           bool
+	Yes             bool
+	Version         bool
+	Usage           string // set by -h: the command's usage and flags, printed instead of running
+
+	// --wait polls every WaitInterval until the target and policies are visible, or after a disable until the
+	// target is gone, for at most WaitTimeout
+	Wait         bool
+	WaitTimeout  time.Duration
+	WaitInterval time.Duration
+
+	// ShowActivities prints this many of the most recent scaling activities after applying; 0 prints none
+	ShowActivities int
+
+	// Interval, when set, keeps the process running and reconciles again every Interval until SIGINT/SIGTERM
+	Interval time.Duration
+
+	// Extra alarm actions for the OK and INSUFFICIENT_DATA states; policies can override them.
+	// AlarmsEnabled false keeps alarms in place but stops them firing any action.
+	AlarmOKActions               []string
+	AlarmInsufficientDataActions []string
+	AlarmsEnabled                bool
+	// ExtraAlarmActions are added to the scaling policy in every created alarm's ALARM actions, e.g. an SNS topic
+	ExtraAlarmActions []string
+
+	// ExtraAlarmDimensions are added to the ClusterName/ServiceName dimensions of the default CPU/memory alarms
+	ExtraAlarmDimensions map[string]string
+
+	// Suspend parts of scaling on the scalable target without removing it, e.g. during an incident. Each is
+	// sent on every register, so clearing a flag resumes that part.
+	SuspendScaleIn   bool
+	SuspendScaleOut  bool
+	SuspendScheduled bool
+
+	// NoAlarms leaves every CloudWatch alarm to be managed elsewhere: none are created, updated or deleted.
+	// A policy's manage_alarm overrides it either way.
+	NoAlarms bool
+
+	// TargetOnly registers or updates the scalable target on enable and creates no policies or alarms
+	TargetOnly bool
+
+	// PurgeUnmanaged deletes, after an apply, every scaling policy on the resource with a generated name that is not
+	// in the desired set
+	PurgeUnmanaged bool
+
+	// KeepTarget makes disable delete the policies and alarms but leave the scalable target registered, so its
+	// min and max capacity stay enforced
+	KeepTarget bool
+
+	// PrefixCleanup makes disable also delete every alarm whose name starts with the generated name prefix, not
+	// just the names it predicts
+	PrefixCleanup bool
+
+	// RemovePolicies deletes just these scaling policies and their alarms instead of applying anything
+	RemovePolicies []string
+
+	// AllServicesInCluster applies the configuration to every service in Cluster found by ecs:ListServices,
+	// except the Exclude ones, instead of to Service
+	AllServicesInCluster bool
+	Exclude              []string
+
+	// ValidateService checks the ECS service exists and is ACTIVE before the target is registered, so a typo in
+	// the cluster or service name fails instead of creating a target that never scales anything
+	ValidateService bool
+
+	// CheckMinHealthyPercent reads the ECS service before applying and warns, or fails with Strict, when
+	// MinCapacity is below the tasks its deployment's minimum healthy percent keeps running
+	CheckMinHealthyPercent bool
+	Strict                 bool
+
+	// ProvenanceTag also tags the scalable target with the provenance string recorded in alarm descriptions
+	ProvenanceTag bool
+
+	// DetailedExitCode makes a successful enable or disable that changed something exit with exitChanged
+	DetailedExitCode bool
+	// DiffOnlyExitCode makes verify exit 0 when clean, 2 on drift and 1 on any failure; see diffOnlyExitCode
+	DiffOnlyExitCode bool
+
+	// AuditTopicARN receives a JSON audit event (see auditEvent) at the end of every enable or disable run
+	AuditTopicARN string
+
+	// MetricsFile receives run metrics in Prometheus text format, for node_exporter's textfile collector
+	MetricsFile string
+	// SummaryFile receives the JSON summary (the audit event) of every enable or disable run
+	SummaryFile string
+}
+
+// positionalArgs is the number of positional args action.yml always passes
+const positionalArgs = 16
+
+// Environment variables backing each positional arg, in order
+var positionalEnv = [positionalArgs]string{
+	"ECSAS_ACCESS_KEY_ID",
+	"ECSAS_SECRET_ACCESS_KEY",
+	"ECSAS_REGION",
+	"ECSAS_CLUSTER",
+	"ECSAS_SERVICE",
+	"ECSAS_ENABLED",
+	"ECSAS_MIN_CAPACITY",
+	"ECSAS_MAX_CAPACITY",
+	"ECSAS_SCALE_OUT_COOLDOWN",
+	"ECSAS_SCALE_IN_COOLDOWN",
+	"ECSAS_TARGET_CPU_UTILIZATION_OUT",
+	"ECSAS_TARGET_CPU_UTILIZATION_IN",
+	"ECSAS_TARGET_MEMORY_UTILIZATION_OUT",
+	"ECSAS_TARGET_MEMORY_UTILIZATION_IN",
+	"ECSAS_DEFAULT_POLICIES",
+	"ECSAS_POLICIES",
+}
+
+// Environment variable backing a flag, e.g. --log-level -> ECSAS_LOG_LEVEL
+func envName(flagName string) string {
+	return "ECSAS_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Drop the flags given with an empty value, e.g. --wait= for an action.yml input left unset, so they count as not
+// given and fall back like an omitted flag; bool, number and duration flags would otherwise fail to parse. Args after
+// a -- terminator are kept as they are.
+func withoutEmptyFlags(args []string) []string {
+	kept := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(kept, args[i:]...)
+		}
+		if name, value, ok := strings.Cut(arg, "="); ok && value == "" && strings.HasPrefix(name, "-") {
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept
+}
+
+// Parse an optional subcommand, the positional args (os.Args[1:17]) and the optional flags that follow them.
+// The positional args may be omitted entirely (e.g. to keep credentials off the command line), in which
+// case they all come from the environment. Every error is an ExitError with exitValidation.
+func parseArgs(args []string) (*Config, error) {
+	cfg, err := parseConfig(args)
+	if err != nil {
+		return nil, validationError(err)
+	}
+	return cfg, nil
+}
+
+func parseConfig(args []string) (*Config, error) {
+	command, args := splitCommand(args)
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		args = append(make([]string, positionalArgs), args...)
+	}
+	if len(args) < positionalArgs {
+		return nil, fmt.Errorf("invalid number of arguments: expected at least %d, got %d", positionalArgs, len(args))
+	}
+
+	// Empty positional args fall back to the environment
+	args = slices.Clone(args)
+	for i, env := range positionalEnv {
+		if args[i] == "" {
+			args[i] = os.Getenv(env)
+		}
+	}
+
+	cfg := &Config{}
+
+	// Optional flags follow the positional args
+	fs := flag.NewFlagSet(strings.TrimSpace("ecs-autoscaler "+command), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.NamePrefix, "name-prefix", "", "prefix for generated policy and alarm names (replaces {cluster}-{service})")
+	fs.StringVar(&cfg.Env, "env", "", "environment appended as -<env> to every generated policy and alarm name, so environments sharing cluster and service names do not collide")
+	fs.StringVar(&cfg.NameTemplate, "name-template", "", "Go text/template for generated names, with .Cluster, .Service, .Prefix and .Suffix")
+	fs.StringVar(&cfg.SessionToken, "session-token", "", "session token for temporary access keys, e.g. from sts:AssumeRole")
+	fs.StringVar(&cfg.Profile, "profile", "", "load credentials from this named profile in ~/.aws/config instead of static keys")
+	fs.StringVar(&cfg.SharedConfigFile, "shared-config-file", "", "read the shared AWS config from this file instead of ~/.aws/config")
+	fs.StringVar(&cfg.SharedCredentialsFile, "shared-credentials-file", "", "read shared AWS credentials from this file instead of ~/.aws/credentials")
+	fs.BoolVar(&cfg.AllowAnyRegion, "allow-any-region", false, "accept any non-empty region, skipping the format check")
+	fs.BoolVar(&cfg.RegionFromInstanceMetadata, "region-from-instance-metadata", false, "with an empty aws-region, use AWS_REGION, the shared config or EC2 instance metadata for the region")
+	fs.StringVar(&cfg.EndpointURL, "endpoint-url", "", "send API calls to this URL instead of AWS, e.g. http://localhost:4566 for LocalStack")
+	fs.BoolVar(&cfg.UseFIPSEndpoint, "use-fips-endpoint", false, "call the FIPS 140 endpoints of the region, e.g. in GovCloud")
+	fs.BoolVar(&cfg.UseDualStackEndpoint, "use-dual-stack-endpoint", false, "call the dual-stack (IPv4 and IPv6) endpoints of the region")
+	fs.BoolVar(&cfg.AllServicesInCluster, "all-services-in-cluster", false, "apply to every service in cluster-name, discovered with ecs:ListServices, instead of service-name")
+	fs.Var((*stringList)(&cfg.Exclude), "exclude", "service to skip with --all-services-in-cluster (repeatable or comma-separated)")
+	fs.StringVar(&cfg.ResourceID, "resource-id", "", "ECS resource ID used verbatim instead of service/{cluster}/{service}")
+	fs.StringVar(&cfg.ServiceNamespace, "service-namespace", "ecs", "Application Auto Scaling service namespace: ecs or dynamodb")
+	fs.StringVar(&cfg.TableName, "table-name", "", "DynamoDB table to scale (dynamodb namespace)")
+	fs.StringVar(&cfg.IndexName, "index-name", "", "DynamoDB global secondary index to scale instead of the table (dynamodb namespace)")
+	fs.StringVar(&cfg.ScalableDimension, "scalable-dimension", "", "scalable dimension, e.g. dynamodb:table:ReadCapacityUnits (dynamodb namespace)")
+	maxCooldown := fs.Int("max-cooldown", defaultMaxCooldown, "largest accepted cooldown in seconds, for scale-in, scale-out and policy cooldowns")
+	evaluationPeriods := fs.Int("default-evaluation-periods", defaultEvaluationPeriods, "evaluation periods for the default CPU/memory alarms")
+	alarmPeriod := fs.Int("default-alarm-period", 0, "period in seconds for the default CPU/memory alarms, a multiple of 60; 0 uses the scale-out/scale-in cooldown")
+	fs.StringVar(&cfg.DefaultMetricNamespace, "default-metric-namespace", defaultMetricNamespace, "CloudWatch namespace of the default CPU/memory alarms' metrics")
+	fs.StringVar(&cfg.CPUMetricName, "cpu-metric-name", defaultCPUMetricName, "metric name of the default CPU alarms")
+	fs.StringVar(&cfg.MemMetricName, "mem-metric-name", defaultMemMetricName, "metric name of the default memory alarms")
+	fs.StringVar(&cfg.ScaleOutOperator, "scale-out-operator", string(cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold), "comparison operator for the default scale-out alarms: GreaterThanThreshold or GreaterThanOrEqualToThreshold")
+	fs.StringVar(&cfg.ScaleInOperator, "scale-in-operator", string(cwTypes.ComparisonOperatorLessThanOrEqualToThreshold), "comparison operator for the default scale-in alarms: LessThanThreshold or LessThanOrEqualToThreshold")
+	fs.StringVar(&cfg.DefaultAggregationType, "default-aggregation-type", string(aasTypes.MetricAggregationTypeMaximum), "metric aggregation type of the default step policies: Average, Minimum or Maximum")
+	fs.BoolVar(&cfg.AggressiveScaleOut, "aggressive-scale-out", false, "scale out the default step policy harder the further CPU/memory is over the threshold")
+	fs.Float64Var(&cfg.AggressiveStepSize, "aggressive-step-size", defaultAggressiveStepSize, "width of each --aggressive-scale-out tier, in percentage points over the threshold")
+	aggressiveTiers := fs.Int("aggressive-tiers", defaultAggressiveTiers, "number of --aggressive-scale-out tiers; the last is unbounded")
+	aggressiveMultiplier := fs.Int("aggressive-multiplier", defaultAggressiveMultiplier, "factor the --aggressive-scale-out adjustment grows by per tier, starting at +1")
+	fs.StringVar(&cfg.DefaultPolicyType, "default-policy-type", defaultPolicyTypeStep, "built-in CPU/memory policies when no scaling-policies are given: step or target-tracking")
+	fs.StringVar(&cfg.SQSQueue, "sqs-queue", "", "add a target-tracking policy on this SQS queue's ApproximateNumberOfMessagesVisible")
+	fs.Float64Var(&cfg.MessagesPerTask, "messages-per-task", 0, "target value for the --sqs-queue policy")
+	fs.StringVar(&cfg.LoadBalancerARN, "load-balancer-arn", "", "ALB ARN for the resource_label of ALBRequestCountPerTarget policies; requires --target-group-arn")
+	fs.StringVar(&cfg.TargetGroupARN, "target-group-arn", "", "target group ARN for the resource_label of ALBRequestCountPerTarget policies; requires --load-balancer-arn")
+	fs.BoolVar(&cfg.Blended, "blended", false, "add CPU and memory target-tracking policies (targets from the scale-out thresholds) alongside any scaling-policies")
+	configFile := fs.String("config-file", "", "read settings from this YAML or JSON file; command-line values and the environment override it")
+	policiesFile := fs.String("policies-file", "", "read scaling-policies JSON from this file (- for stdin) instead of the positional arg")
+	defaultPoliciesFile := fs.String("default-policies-file", "", "read default-policies JSON from this file (- for stdin) instead of the positional arg")
+	tagsRaw := fs.String("tags", "", "comma-separated key=value tags applied to the scalable target on every enable")
+	fs.BoolVar(&cfg.ValidateService, "validate-service", false, "before registering the scalable target, fail unless ecs:DescribeServices finds the service ACTIVE")
+	fs.BoolVar(&cfg.CheckMinHealthyPercent, "check-min-healthy-percent", false, "before applying, warn if min-capacity is below the tasks the ECS service's minimum healthy percent keeps running")
+	fs.BoolVar(&cfg.Strict, "strict", false, "fail instead of warning when --check-min-healthy-percent finds a problem")
+	fs.BoolVar(&cfg.ProvenanceTag, "provenance-tag", false, "tag the scalable target with the tool version, time and config hash after every enable run that changes something")
+	fs.BoolVar(&cfg.TagAlarms, "tag-alarms", true, "also apply --tags to created CloudWatch alarms")
+	fs.BoolVar(&cfg.NoAlarms, "no-alarms", false, "manage scaling policies and the scalable target only; never create, update or delete CloudWatch alarms")
+	fs.BoolVar(&cfg.ReconcileAlarms, "reconcile-alarms", false, "update existing CloudWatch alarms whose configuration drifted")
+	fs.StringVar(&cfg.AlarmStatistic, "alarm-statistic", "Average", "statistic for created alarms: Average, Maximum, Sum, ... or a percentile such as p99")
+	extraActionsRaw := fs.String("extra-alarm-actions", "", "comma-separated ARNs added to the scaling policy in created alarms' ALARM actions")
+	okActionsRaw := fs.String("alarm-ok-actions", "", "comma-separated ARNs notified when created alarms return to OK")
+	insufficientDataActionsRaw := fs.String("alarm-insufficient-data-actions", "", "comma-separated ARNs notified when created alarms have insufficient data")
+	extraDimensionsRaw := fs.String("extra-alarm-dimensions", "", "comma-separated name=value dimensions added to the default CPU/memory alarms' ClusterName and ServiceName")
+	fs.BoolVar(&cfg.AlarmsEnabled, "alarms-enabled", true, "let created alarms fire their actions; false keeps them in place but inactive, e.g. during maintenance")
+	fs.BoolVar(&cfg.SuspendScaleIn, "suspend-scale-in", false, "suspend scale-in on the scalable target; policies and alarms stay in place")
+	fs.BoolVar(&cfg.SuspendScaleOut, "suspend-scale-out", false, "suspend scale-out on the scalable target; policies and alarms stay in place")
+	fs.BoolVar(&cfg.SuspendScheduled, "suspend-scheduled", false, "suspend scheduled scaling actions on the scalable target")
+	fs.BoolVar(&cfg.ForceRecreate, "force-recreate", false, "delete and recreate drifted scaling policies (and their alarms) instead of updating them in place")
+	fs.BoolVar(&cfg.Wait, "wait", false, "after applying, poll until the scalable target and scaling policies can be described; after disabling, until the target is gone")
+	fs.DurationVar(&cfg.WaitTimeout, "wait-timeout", 2*time.Minute, "how long --wait polls before failing")
+	fs.DurationVar(&cfg.WaitInterval, "wait-interval", 5*time.Second, "delay between --wait polls")
+	fs.IntVar(&cfg.ShowActivities, "show-activities", 0, "after applying, print this many of the most recent scaling activities (cause, status, start and end time), at most 50; 0 prints none")
+	fs.DurationVar(&cfg.Interval, "interval", 0, "keep running and reconcile every interval (at least 30s) until SIGINT or SIGTERM, e.g. as a sidecar; 0 runs once")
+	fs.BoolVar(&cfg.TargetOnly, "target-only", false, "only register or update the scalable target's min/max capacity; create no scaling policies or alarms")
+	fs.BoolVar(&cfg.PurgeUnmanaged, "purge-unmanaged", false, "after applying, delete every scaling policy on the resource with a generated name (and its alarms) that is not in the desired configuration")
+	fs.Var((*stringList)(&cfg.RemovePolicies), "remove-policy", "delete this scaling policy and its alarm, leaving everything else in place (repeatable or comma-separated)")
+	fs.BoolVar(&cfg.Yes, "yes", false, "disable without asking for confirmation; required when stdin is not a terminal")
+	fs.BoolVar(&cfg.PrefixCleanup, "prefix-cleanup", false, "on disable, also delete every alarm named with the generated prefix (e.g. cluster-service-), including ones renamed or created out of band")
+	fs.BoolVar(&cfg.KeepTarget, "keep-target", false, "on disable, delete scaling policies and alarms but keep the scalable target registered")
+	fs.BoolVar(&cfg.Plan, "plan", false, "print what would be created, updated or deleted without changing anything")
+	fs.BoolVar(&cfg.Verify, "verify", false, "compare against the desired configuration without changing anything; exit 4 on drift")
+	fs.BoolVar(&cfg.Export, "export", false, "print the existing auto-scaling configuration as JSON without changing anything")
+	fs.BoolVar(&cfg.Describe, "describe", false, "print the current scalable target, policies and alarms without changing anything")
+	fs.BoolVar(&cfg.Selftest, "selftest", false, "check AWS connectivity and read permissions with cheap read-only calls, reporting each one's latency; exit 3 if any fails")
+	fs.StringVar(&cfg.Output, "output", outputText, "--describe output format: text or json")
+	fs.BoolVar(&cfg.DetailedExitCode, "detailed-exit-code", false, "exit 5 instead of 0 when an enable or disable run changed something")
+	fs.StringVar(&cfg.AuditTopicARN, "audit-topic-arn", "", "publish a JSON audit event of each enable or disable run to this SNS topic")
+	fs.BoolVar(&cfg.DiffOnlyExitCode, "diff-only-exit-code", false, "with verify, exit 0 when clean, 2 on drift and 1 on any failure")
+	fs.StringVar(&cfg.MetricsFile, "metrics-file", "", "write run metrics in Prometheus text format to this file, e.g. for node_exporter's textfile collector")
+	fs.StringVar(&cfg.SummaryFile, "summary-file", "", "write a JSON summary of each enable or disable run to this file, creating missing directories")
+	fs.StringVar(&cfg.LogFormat, "log-format", "text", "log output format: text or json")
+	fs.StringVar(&cfg.LogLevel, "log-level", "info", "minimum log level: debug, info, warn or error")
+	fs.BoolVar(&cfg.Quiet, "quiet", false, "only log warnings and errors, overriding --log-level")
+	fs.BoolVar(&cfg.Version, "version", false, "print version, commit and build date, then exit")
+
+	// The command line is parsed with just the flags the subcommand accepts, so -h lists only those
+	cmdFlags := commandFlagSet(fs, command)
+	if err := cmdFlags.Parse(withoutEmptyFlags(args[positionalArgs:])); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return &Config{Command: command, Usage: commandUsage(cmdFlags)}, nil
+		}
+		// A flag that another command accepts gets a clearer message than an unknown one
+		if name, ok := strings.CutPrefix(err.Error(), "flag provided but not defined: -"); ok && fs.Lookup(name) != nil {
+			return nil, fmt.Errorf("invalid flags: -%s is not supported by the %s command", name, command)
+		}
+		return nil, fmt.Errorf("invalid flags: %w", err)
+	}
+	if cfg.Version {
+		// Nothing else is needed to print the version, so skip the environment and validation
+		return cfg, nil
+	}
+
+	// Flags not given (or given empty) on the command line fall back to the environment
+	setOnCommandLine := map[string]bool{}
+	cmdFlags.Visit(func(f *flag.Flag) {
+		if f.Value.String() != "" {
+			setOnCommandLine[f.Name] = true
+		}
+	})
+	var envErr error
+	cmdFlags.VisitAll(func(f *flag.Flag) {
+		if setOnCommandLine[f.Name] || envErr != nil {
+			return
+		}
+		if value, ok := os.LookupEnv(envName(f.Name)); ok && value != "" {
+			if err := cmdFlags.Set(f.Name, value); err != nil {
+				envErr = fmt.Errorf("invalid %s: %w", envName(f.Name), err)
+			}
+		}
+	})
+	if envErr != nil {
+		return nil, envErr
+	}
+
+	// Anything still unset falls back to the config file
+	if *configFile != "" {
+		fc, err := loadFileConfig(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		for i, value := range fc.positional() {
+			if args[i] == "" {
+				args[i] = value
+			}
+		}
+		skip := func(name string) bool {
+			value, ok := os.LookupEnv(envName(name))
+			return setOnCommandLine[name] || ok && value != "" || !commandAccepts(command, name)
+		}
+		if err := fc.applyFlags(fs, skip); err != nil {
+			return nil, err
+		}
+	}
+
+	// The positional args, now with every fallback applied
+	cfg.KeyID, cfg.KeySecret, cfg.Region, cfg.Cluster, cfg.Service = args[0], args[1], args[2], args[3], args[4]
+	cfg.Enabled = args[5] == "true"
+	cfg.DefaultPoliciesRaw, cfg.PoliciesRaw = args[14], args[15]
+
+	for _, in := range []struct {
+		arg          string
+		name         string
+		defaultValue int32
+		dest         *int32
+	}{
+		{args[6], "min-capacity", 1, &cfg.MinCapacity},
+		{args[7], "max-capacity", 10, &cfg.MaxCapacity},
+		{args[8], "scale-out-cooldown", 300, &cfg.ScaleOutCooldown},
+		{args[9], "scale-in-cooldown", 300, &cfg.ScaleInCooldown},
+	} {
+		v, err := getInt32WithDefault(in.arg, in.name, in.defaultValue)
+		if err != nil {
+			return nil, err
+		}
+		*in.dest = v
+	}
+
+	for _, in := range []struct {
+		arg          string
+		name         string
+		defaultValue float64
+		dest         *float64
+	}{
+		{args[10], "target-cpu-utilization-out", 75.0, &cfg.TargetCPUOut},
+		{args[11], "target-cpu-utilization-in", 65.0, &cfg.TargetCPUIn},
+		{args[12], "target-memory-utilization-out", 80.0, &cfg.TargetMemOut},
+		{args[13], "target-memory-utilization-in", 70.0, &cfg.TargetMemIn},
+	} {
+		v, err := getFloatWithDefault(in.arg, in.name, in.defaultValue)
+		if err != nil {
+			return nil, err
+		}
+		*in.dest = v
+	}
+
+	if *maxCooldown < 1 || *maxCooldown > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid max-cooldown %d: must be a positive number of seconds", *maxCooldown)
+	}
+	cfg.MaxCooldown = int32(*maxCooldown)
+	for _, c := range []struct {
+		name  string
+		value int32
+	}{
+		{"scale-out-cooldown", cfg.ScaleOutCooldown},
+		{"scale-in-cooldown", cfg.ScaleInCooldown},
+	} {
+		if err := validateCooldown(c.name, c.value, cfg.MaxCooldown); err != nil {
+			return nil, err
+		}
+	}
+
+	if *evaluationPeriods < 1 || *evaluationPeriods > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid default-evaluation-periods %d: must be a positive number", *evaluationPeriods)
+	}
+	cfg.DefaultEvaluationPeriods = int32(*evaluationPeriods)
+	for _, m := range []struct{ name, value string }{
+		{"default-metric-namespace", cfg.DefaultMetricNamespace},
+		{"cpu-metric-name", cfg.CPUMetricName},
+		{"mem-metric-name", cfg.MemMetricName},
+	} {
+		if strings.TrimSpace(m.value) == "" {
+			return nil, fmt.Errorf("invalid %s: must not be empty", m.name)
+		}
+	}
+	if *alarmPeriod != 0 {
+		if *alarmPeriod < 0 || *alarmPeriod > math.MaxInt32 {
+			return nil, fmt.Errorf("invalid default-alarm-period %d: must be a positive number of seconds", *alarmPeriod)
+		}
+		// AWS/ECS metrics, like every AWS/ namespace, have no high-resolution periods
+		if err := validateMetricAlarmPeriod(int32(*alarmPeriod), cfg.DefaultMetricNamespace); err != nil {
+			return nil, fmt.Errorf("invalid default-alarm-period: %w", err)
+		}
+		cfg.DefaultAlarmPeriod = int32(*alarmPeriod)
+	}
+
+	if err := validateAlarmOperator(cfg.ScaleOutOperator, "GreaterThan"); err != nil {
+		return nil, fmt.Errorf("invalid scale-out-operator: %w", err)
+	}
+	if err := validateAlarmOperator(cfg.ScaleInOperator, "LessThan"); err != nil {
+		return nil, fmt.Errorf("invalid scale-in-operator: %w", err)
+	}
+	if valid := enumStrings(aasTypes.MetricAggregationType("").Values()); !slices.Contains(valid, cfg.DefaultAggregationType) {
+		return nil, fmt.Errorf("invalid default-aggregation-type %q: must be one of %s%s", cfg.DefaultAggregationType, strings.Join(valid, ", "), caseHint(cfg.DefaultAggregationType, valid))
+	}
+
+	if err := validateAggressiveSteps(cfg.AggressiveStepSize, *aggressiveTiers, *aggressiveMultiplier); err != nil {
+		return nil, err
+	}
+	cfg.AggressiveTiers = int32(*aggressiveTiers)
+	cfg.AggressiveMultiplier = int32(*aggressiveMultiplier)
+
+	if cfg.Quiet {
+		cfg.LogLevel = "warn"
+	}
+
+	if err := applyCommand(cfg, command, args[5]); err != nil {
+		return nil, err
+	}
+
+	// With --region-from-instance-metadata the region is only known, and checked, once the AWS config is loaded
+	if cfg.RegionFromInstanceMetadata {
+		if cfg.Region != "" {
+			return nil, errors.New("aws-region and region-from-instance-metadata are mutually exclusive; leave aws-region empty")
+		}
+	} else if err := validateRegion(cfg.Region, cfg.AllowAnyRegion); err != nil {
+		return nil, err
+	}
+
+	if cfg.SessionToken != "" && (cfg.KeyID == "" || cfg.KeySecret == "") {
+		return nil, errors.New("session-token requires both the access key ID and the secret access key")
+	}
+	if cfg.Profile != "" && (cfg.KeyID != "" || cfg.KeySecret != "") {
+		return nil, errors.New("profile and static access keys are mutually exclusive")
+	}
+
+	for _, f := range []struct{ name, path string }{
+		{"shared-config-file", cfg.SharedConfigFile},
+		{"shared-credentials-file", cfg.SharedCredentialsFile},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if info, err := os.Stat(f.path); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", f.name, err)
+		} else if info.IsDir() {
+			return nil, fmt.Errorf("invalid %s: %s is a directory", f.name, f.path)
+		}
+	}
+
+	if cfg.EndpointURL != "" {
+		if u, err := url.Parse(cfg.EndpointURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint-url %q: expected an http or https URL", cfg.EndpointURL)
+		}
+	}
+	if (cfg.UseFIPSEndpoint || cfg.UseDualStackEndpoint) && cfg.EndpointURL != "" {
+		return nil, errors.New("use-fips-endpoint and use-dual-stack-endpoint pick AWS endpoints and cannot be combined with endpoint-url")
+	}
+	if err := validateEndpointVariants(cfg.Region, cfg.UseFIPSEndpoint, cfg.UseDualStackEndpoint); err != nil {
+		return nil, err
+	}
+
+	if *policiesFile == "-" && *defaultPoliciesFile == "-" {
+		return nil, errors.New("only one of policies-file and default-policies-file can read from stdin")
+	}
+	for _, src := range []struct {
+		file, inline, flag, input string
+		dest                      *string
+	}{
+		{*policiesFile, cfg.PoliciesRaw, "policies-file", "scaling-policies", &cfg.PoliciesRaw},
+		{*defaultPoliciesFile, cfg.DefaultPoliciesRaw, "default-policies-file", "default-policies", &cfg.DefaultPoliciesRaw},
+	} {
+		if src.file == "" {
+			continue
+		}
+		if src.inline != "" {
+			return nil, fmt.Errorf("%s and %s are mutually exclusive", src.input, src.flag)
+		}
+		raw, err := readPoliciesFile(src.file)
+		if err != nil {
+			return nil, err
+		}
+		*src.dest = raw
+	}
+
+	if _, err := cfg.resource(); err != nil {
+		return nil, err
+	}
+
+	if cfg.AllServicesInCluster {
+		switch {
+		case cfg.ServiceNamespace != string(aasTypes.ServiceNamespaceEcs):
+			return nil, errors.New("all-services-in-cluster is only supported for the ecs service namespace")
+		case cfg.Cluster == "":
+			return nil, errors.New("all-services-in-cluster requires cluster-name")
+		case cfg.Service != "" || cfg.ResourceID != "":
+			return nil, errors.New("all-services-in-cluster and service-name or resource-id are mutually exclusive")
+		case cfg.MetricsFile != "":
+			return nil, errors.New("metrics-file is not supported with all-services-in-cluster")
+		case cfg.SummaryFile != "":
+			return nil, errors.New("summary-file is not supported with all-services-in-cluster")
+		}
+	} else if len(cfg.Exclude) > 0 {
+		return nil, errors.New("exclude requires all-services-in-cluster")
+	}
+	if err := validateSQSQueue(cfg.SQSQueue, cfg.MessagesPerTask); err != nil {
+		return nil, err
+	}
+	if cfg.SQSQueue != "" && cfg.ServiceNamespace != string(aasTypes.ServiceNamespaceEcs) {
+		return nil, errors.New("sqs-queue is only supported for the ecs service namespace")
+	}
+	if (cfg.LoadBalancerARN == "") != (cfg.TargetGroupARN == "") {
+		return nil, errors.New("load-balancer-arn and target-group-arn must be set together")
+	}
+	if cfg.LoadBalancerARN != "" {
+		if _, err := albResourceLabel(cfg.LoadBalancerARN, cfg.TargetGroupARN); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ValidateService && cfg.ServiceNamespace != string(aasTypes.ServiceNamespaceEcs) {
+		return nil, errors.New("validate-service is only supported for the ecs service namespace")
+	}
+	if cfg.CheckMinHealthyPercent && cfg.ServiceNamespace != string(aasTypes.ServiceNamespaceEcs) {
+		return nil, errors.New("check-min-healthy-percent is only supported for the ecs service namespace")
+	}
+
+	if cfg.Env != "" && !envPattern.MatchString(cfg.Env) {
+		return nil, fmt.Errorf("invalid env %q: use only letters, digits, '.', '_' and '-'", cfg.Env)
+	}
+
+	if cfg.Output != outputText && cfg.Output != outputJSON {
+		return nil, fmt.Errorf("invalid output %q: must be text or json", cfg.Output)
+	}
+
+	if cfg.DefaultPolicyType != defaultPolicyTypeStep && cfg.DefaultPolicyType != defaultPolicyTypeTargetTracking {
+		return nil, fmt.Errorf("invalid default-policy-type %q: must be step or target-tracking", cfg.DefaultPolicyType)
+	}
+
+	if cfg.WaitTimeout <= 0 || cfg.WaitInterval <= 0 {
+		return nil, errors.New("wait-timeout and wait-interval must be positive")
+	}
+
+	if cfg.ShowActivities < 0 || cfg.ShowActivities > maxShowActivities {
+		return nil, fmt.Errorf("invalid show-activities %d: must be between 0 and %d", cfg.ShowActivities, maxShowActivities)
+	}
+
+	if cfg.TargetOnly && (cfg.PurgeUnmanaged || len(cfg.RemovePolicies) > 0) {
+		return nil, errors.New("target-only cannot be combined with purge-unmanaged or remove-policy, which delete policies")
+	}
+	if cfg.PurgeUnmanaged && len(cfg.RemovePolicies) > 0 {
+		return nil, errors.New("purge-unmanaged and remove-policy are mutually exclusive")
+	}
+	if cfg.AuditTopicARN != "" {
+		if err := validateTopicARN(cfg.AuditTopicARN); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.DiffOnlyExitCode && cfg.command() != commandVerify {
+		return nil, errors.New("diff-only-exit-code only applies to verify")
+	}
+	if cfg.KeepTarget && cfg.Enabled {
+		return nil, errors.New("keep-target only applies to disable (enabled=false)")
+	}
+	if cfg.PrefixCleanup && cfg.Enabled {
+		return nil, errors.New("prefix-cleanup only applies to disable (enabled=false)")
+	}
+	if cfg.PrefixCleanup && cfg.NoAlarms {
+		return nil, errors.New("prefix-cleanup and no-alarms are mutually exclusive")
+	}
+
+	if cfg.Interval != 0 {
+		switch {
+		case cfg.Interval < minInterval:
+			return nil, fmt.Errorf("invalid interval %s: must be at least %s", cfg.Interval, minInterval)
+		case !cfg.Enabled:
+			return nil, errors.New("interval requires enabled=true")
+		case cfg.Plan || cfg.Verify || cfg.Export || cfg.Describe || cfg.Selftest:
+			return nil, errors.New("interval cannot be combined with plan, verify, export, describe or selftest")
+		case len(cfg.RemovePolicies) > 0:
+			return nil, errors.New("interval and remove-policy are mutually exclusive")
+		case cfg.DetailedExitCode:
+			return nil, errors.New("interval and detailed-exit-code are mutually exclusive")
+		}
+	}
+
+	if err := validateAlarmStatistic(cfg.AlarmStatistic); err != nil {
+		return nil, fmt.Errorf("invalid alarm-statistic: %w", err)
+	}
+
+	okActions, err := parseAlarmActions(*okActionsRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alarm-ok-actions: %w", err)
+	}
+	cfg.AlarmOKActions = okActions
+	insufficientDataActions, err := parseAlarmActions(*insufficientDataActionsRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alarm-insufficient-data-actions: %w", err)
+	}
+	cfg.AlarmInsufficientDataActions = insufficientDataActions
+	extraActions, err := parseAlarmActions(*extraActionsRaw)
+	if err == nil && len(extraActions) > maxAlarmActions-1 {
+		err = fmt.Errorf("too many actions: %d (max %d besides the scaling policy)", len(extraActions), maxAlarmActions-1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid extra-alarm-actions: %w", err)
+	}
+	cfg.ExtraAlarmActions = extraActions
+
+	extraDimensions, err := parseAlarmDimensions(*extraDimensionsRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extra-alarm-dimensions: %w", err)
+	}
+	if len(extraDimensions) > 0 {
+		if cfg.ServiceNamespace != string(aasTypes.ServiceNamespaceEcs) {
+			return nil, errors.New("extra-alarm-dimensions is only supported for the ecs service namespace")
+		}
+		res, _ := cfg.resource() // checked above
+		for _, dim := range res.alarmDimensions() {
+			if _, dup := extraDimensions[*dim.Name]; dup {
+				return nil, fmt.Errorf("invalid extra-alarm-dimensions: %s is already set from the service", *dim.Name)
+			}
+		}
+		if n := len(res.alarmDimensions()) + len(extraDimensions); n > maxAlarmDimensions {
+			return nil, fmt.Errorf("invalid extra-alarm-dimensions: the default alarms would have %d dimensions (max %d)", n, maxAlarmDimensions)
+		}
+	}
+	cfg.ExtraAlarmDimensions = extraDimensions
+
+	tags, err := parseTags(*tagsRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tags: %w", err)
+	}
+	cfg.Tags = tags
+	if cfg.ProvenanceTag && len(tags) >= maxTags {
+		return nil, fmt.Errorf("provenance-tag needs room for one more tag: %d tags given (max %d)", len(tags), maxTags)
+	}
+
+	return cfg, nil
+}
+
+// stringList is a repeatable flag; each value may also hold several comma-separated entries
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// Parse a comma-separated list of alarm action ARNs; empty entries are ignored
+func parseAlarmActions(raw string) ([]string, error) {
+	var actions []string
+	for _, arn := range strings.Split(raw, ",") {
+		if arn = strings.TrimSpace(arn); arn != "" {
+			actions = append(actions, arn)
+		}
+	}
+	if err := validateAlarmActions(actions); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// Parse a comma-separated list of name=value alarm dimensions
+func parseAlarmDimensions(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	dims := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid dimension %q: expected name=value", pair)
+		}
+		if _, dup := dims[name]; dup {
+			return nil, fmt.Errorf("invalid dimension %q: duplicate name", name)
+		}
+		dims[name] = value
+	}
+	return dims, nil
+}
+
+// Read policy JSON from a file, or stdin for "-", and check it parses so errors name the file
+func readPoliciesFile(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read policies file: %w", err)
+	}
+
+	var policies []PolicyDef
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return "", jsonError(path, err)
+	}
+	return string(data), nil
+}
+
+// Describe a JSON decode error with its source and, when known, the byte offset it occurred at
+func jsonError(source string, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("invalid JSON in %s at byte %d: %w", source, syntaxErr.Offset, err)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("invalid JSON in %s at byte %d: %w", source, typeErr.Offset, err)
+	default:
+		return fmt.Errorf("invalid JSON in %s: %w", source, err)
+	}
+}
+
+// The scalable target this config manages
+func (c *Config) resource() (resourceRef, error) {
+	switch c.ServiceNamespace {
+	case "", string(aasTypes.ServiceNamespaceEcs):
+		if c.ResourceID != "" {
+			return ecsResource(c.ResourceID), nil
+		}
+		return ecsServiceResource(c.Cluster, c.Service)
+	case string(aasTypes.ServiceNamespaceDynamodb):
+		if c.ResourceID != "" {
+			return resourceRef{}, errors.New("resource-id is only supported for the ecs service namespace; use table-name and index-name")
+		}
+		return dynamoDBResource(c.TableName, c.IndexName, c.ScalableDimension)
+	default:
+		return resourceRef{}, fmt.Errorf("invalid service-namespace %q: must be ecs or dynamodb", c.ServiceNamespace)
+	}
+}
+
+// Mask a sensitive value, keeping a short prefix of long values for identification, e.g. AKIA****
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) < 12 {
+		return "****"
+	}
+	return value[:4] + "****"
+}
+
+// Mask a secret completely; unlike an access key ID, no part of it is safe to show
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "****"
+}
+
+// Strip the input from a strconv error, which otherwise quotes it verbatim
+func numErr(err error) error {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		return ne.Err
+	}
+	return err
+}
+
+// configView is Config without its methods, so String and LogValue can format it without recursing
+type configView Config
+
+// String formats the config with credentials masked
+func (c Config) String() string {
+	v := configView(c)
+	v.KeyID = redact(v.KeyID)
+	v.KeySecret = redactSecret(v.KeySecret)
+	v.SessionToken = redactSecret(v.SessionToken)
+	return fmt.Sprintf("%+v", v)
+}
+
+// LogValue masks credentials whenever the config is logged with slog
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("key_id", redact(c.KeyID)),
+		slog.String("key_secret", redactSecret(c.KeySecret)),
+		slog.String("session_token", redactSecret(c.SessionToken)),
+		slog.String("profile", c.Profile),
+		slog.String("shared_config_file", c.SharedConfigFile),
+		slog.String("shared_credentials_file", c.SharedCredentialsFile),
+		slog.String("region", c.Region),
+		slog.Bool("region_from_instance_metadata", c.RegionFromInstanceMetadata),
+		slog.Bool("use_fips_endpoint", c.UseFIPSEndpoint),
+		slog.Bool("use_dual_stack_endpoint", c.UseDualStackEndpoint),
+		slog.String("cluster", c.Cluster),
+		slog.String("service", c.Service),
+		slog.Bool("enabled", c.Enabled),
+		slog.Int("min_capacity", int(c.MinCapacity)),
+		slog.Int("max_capacity", int(c.MaxCapacity)),
+		slog.Bool("plan", c.Plan),
+		slog.Bool("verify", c.Verify),
+		slog.Bool("export", c.Export),
+		slog.Bool("describe", c.Describe),
+		slog.Bool("selftest", c.Selftest),
+	)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig is the document read by --config-file, in YAML or JSON. It holds the values otherwise passed as
+// positional args, with the policies as structured lists instead of JSON strings, plus any optional flag by
+// name under flags. Static access keys are deliberately not accepted; use profile or the environment.
+type FileConfig struct {
+	Region  string `json:"region,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Cluster string `json:"cluster,omitempty"`
+	Service string `json:"service,omitempty"`
+	Enabled *bool  `json:"enabled,omitempty"`
+
+	MinCapacity                *int32   `json:"min_capacity,omitempty"`
+	MaxCapacity                *int32   `json:"max_capacity,omitempty"`
+	ScaleOutCooldown           *int32   `json:"scale_out_cooldown,omitempty"`
+	ScaleInCooldown            *int32   `json:"scale_in_cooldown,omitempty"`
+	TargetCPUUtilizationOut    *float64 `json:"target_cpu_utilization_out,omitempty"`
+	TargetCPUUtilizationIn     *float64 `json:"target_cpu_utilization_in,omitempty"`
+	TargetMemoryUtilizationOut *float64 `json:"target_memory_utilization_out,omitempty"`
+	TargetMemoryUtilizationIn  *float64 `json:"target_memory_utilization_in,omitempty"`
+
+	// Kept raw so required-field checks still see exactly what was written
+	DefaultPolicies json.RawMessage `json:"default_policies,omitempty"`
+	ScalingPolicies json.RawMessage `json:"scaling_policies,omitempty"`
+
+	// Any other flag by its command-line name, e.g. no-alarms: true or tags: {team: web}
+	Flags map[string]any `json:"flags,omitempty"`
+}
+
+// Read a config file: JSON when the name ends in .json, YAML otherwise. Unknown fields are rejected so a
+// misspelled setting fails instead of silently keeping its default.
+func loadFileConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		// YAML maps onto the same json tags as a JSON document, so PolicyDef needs no tags of its own
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %w", path, err)
+		}
+	}
+
+	var fc FileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return nil, jsonError(path, err)
+	}
+	if err := fc.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &fc, nil
+}
+
+// Check the parts of the file that the flag parser will not check later: policy lists and flag names
+func (fc *FileConfig) validate() error {
+	for _, p := range []struct {
+		field string
+		raw   json.RawMessage
+	}{
+		{"default_policies", fc.DefaultPolicies},
+		{"scaling_policies", fc.ScalingPolicies},
+	} {
+		if len(p.raw) == 0 || string(p.raw) == "null" {
+			continue
+		}
+		var policies []PolicyDef
+		if err := json.Unmarshal(p.raw, &policies); err != nil {
+			return fmt.Errorf("%s must be a list of policies: %w", p.field, err)
+		}
+	}
+	if fc.Profile != "" {
+		if _, ok := fc.Flags["profile"]; ok {
+			return errors.New("profile is set both at the top level and under flags")
+		}
+	}
+	for name, value := range fc.Flags {
+		if name == "config-file" {
+			return errors.New("flags cannot set config-file")
+		}
+		if _, err := fileFlagValue(value); err != nil {
+			return fmt.Errorf("invalid flags.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// The file's values for the positional args, in positionalEnv order, with "" for anything unset
+func (fc *FileConfig) positional() []string {
+	args := make([]string, positionalArgs)
+	args[2], args[3], args[4] = fc.Region, fc.Cluster, fc.Service
+	if fc.Enabled != nil {
+		args[5] = strconv.FormatBool(*fc.Enabled)
+	}
+	for i, v := range []*int32{fc.MinCapacity, fc.MaxCapacity, fc.ScaleOutCooldown, fc.ScaleInCooldown} {
+		if v != nil {
+			args[6+i] = strconv.Itoa(int(*v))
+		}
+	}
+	for i, v := range []*float64{fc.TargetCPUUtilizationOut, fc.TargetCPUUtilizationIn, fc.TargetMemoryUtilizationOut, fc.TargetMemoryUtilizationIn} {
+		if v != nil {
+			args[10+i] = strconv.FormatFloat(*v, 'f', -1, 64)
+		}
+	}
+	if len(fc.DefaultPolicies) > 0 && string(fc.DefaultPolicies) != "null" {
+		args[14] = string(fc.DefaultPolicies)
+	}
+	if len(fc.ScalingPolicies) > 0 && string(fc.ScalingPolicies) != "null" {
+		args[15] = string(fc.ScalingPolicies)
+	}
+	return args
+}
+
+// Apply the file's flags, except those skip reports as set on the command line or in the environment, or not
+// accepted by the command
+func (fc *FileConfig) applyFlags(fs *flag.FlagSet, skip func(name string) bool) error {
+	values := map[string]any{}
+	for name, value := range fc.Flags {
+		values[name] = value
+	}
+	if fc.Profile != "" {
+		values["profile"] = fc.Profile
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("invalid config file: unknown flag %q", name)
+		}
+		if skip(name) {
+			continue
+		}
+		value, _ := fileFlagValue(values[name]) // checked by validate
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid config file flags.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Format a flag value from the file the way it would be written on the command line: lists comma-separated,
+// maps as sorted key=value pairs (for tags)
+func fileFlagValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := fileFlagValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	case map[string]any:
+		pairs := make([]string, 0, len(v))
+		for key, item := range v {
+			s, err := fileFlagValue(item)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, key+"="+s)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
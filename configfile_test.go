@@ -0,0 +1,237 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testConfigYAML = `
+region: eu-west-1
+profile: staging
+cluster: file-cluster
+service: file-service
+enabled: true
+min_capacity: 2
+max_capacity: 20
+scale_out_cooldown: 60
+target_cpu_utilization_out: 70.5
+scaling_policies:
+  - policy_name: queue-step
+    policy_type: StepScaling
+    adjustment_type: ChangeInCapacity
+    cooldown: 60
+    metric_name: ApproximateNumberOfMessagesVisible
+    metric_namespace: AWS/SQS
+    anomaly_detection:
+      band_width: 3
+    step_adjustments:
+      - MetricIntervalLowerBound: 0
+        ScalingAdjustment: 2
+flags:
+  no-alarms: true
+  alarm-statistic: p99
+  tags:
+    team: web
+    env: staging
+  wait-timeout: 5m
+  log-level: debug
+`
+
+// Write a config file into a test directory
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	return path
+}
+
+// TestConfigFileRoundTrip tests that every kind of setting in a YAML or JSON config file reaches the Config
+func TestConfigFileRoundTrip(t *testing.T) {
+	yamlPath := writeConfigFile(t, "autoscaler.yaml", testConfigYAML)
+	jsonPath := writeConfigFile(t, "autoscaler.json", `{
+		"region": "eu-west-1", "profile": "staging", "cluster": "file-cluster", "service": "file-service", "enabled": true,
+		"min_capacity": 2, "max_capacity": 20, "scale_out_cooldown": 60, "target_cpu_utilization_out": 70.5,
+		"scaling_policies": [{"policy_name": "queue-step", "policy_type": "StepScaling", "adjustment_type": "ChangeInCapacity",
+			"cooldown": 60, "metric_name": "ApproximateNumberOfMessagesVisible", "metric_namespace": "AWS/SQS",
+			"anomaly_detection": {"band_width": 3}, "step_adjustments": [{"MetricIntervalLowerBound": 0, "ScalingAdjustment": 2}]}],
+		"flags": {"no-alarms": true, "alarm-statistic": "p99", "tags": {"team": "web", "env": "staging"}, "wait-timeout": "5m", "log-level": "debug"}
+	}`)
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			cfg, err := parseArgs([]string{"--config-file=" + path})
+			if err != nil {
+				t.Fatalf("parseArgs() unexpected error: %v", err)
+			}
+			if cfg.Region != "eu-west-1" || cfg.Profile != "staging" || cfg.Cluster != "file-cluster" || cfg.Service != "file-service" || !cfg.Enabled {
+				t.Errorf("parseArgs() target = %s %s %s/%s enabled=%v, want the file's", cfg.Region, cfg.Profile, cfg.Cluster, cfg.Service, cfg.Enabled)
+			}
+			if cfg.MinCapacity != 2 || cfg.MaxCapacity != 20 || cfg.ScaleOutCooldown != 60 || cfg.ScaleInCooldown != 300 {
+				t.Errorf("parseArgs() capacities = %d-%d cooldowns %d/%d, want 2-20 60/300", cfg.MinCapacity, cfg.MaxCapacity, cfg.ScaleOutCooldown, cfg.ScaleInCooldown)
+			}
+			if cfg.TargetCPUOut != 70.5 || cfg.TargetCPUIn != 65 {
+				t.Errorf("parseArgs() CPU thresholds = %v/%v, want 70.5 from the file and default 65", cfg.TargetCPUOut, cfg.TargetCPUIn)
+			}
+			if !cfg.NoAlarms || cfg.AlarmStatistic != "p99" || cfg.LogLevel != "debug" || cfg.WaitTimeout.String() != "5m0s" {
+				t.Errorf("parseArgs() flags = no-alarms %v, statistic %s, log level %s, wait-timeout %s, want the file's", cfg.NoAlarms, cfg.AlarmStatistic, cfg.LogLevel, cfg.WaitTimeout)
+			}
+			if cfg.Tags["team"] != "web" || cfg.Tags["env"] != "staging" {
+				t.Errorf("parseArgs() tags = %v, want team=web env=staging", cfg.Tags)
+			}
+
+			policies, err := parsePolicies(cfg.PoliciesRaw, "")
+			if err != nil {
+				t.Fatalf("parsePolicies() unexpected error: %v", err)
+			}
+			if len(policies) != 1 || policies[0].PolicyName != "queue-step" || policies[0].AnomalyDetection == nil || policies[0].AnomalyDetection.BandWidth != 3 {
+				t.Errorf("parsePolicies() = %+v, want queue-step with a band width of 3", policies)
+			}
+			if len(policies[0].StepAdjustments) != 1 || policies[0].StepAdjustments[0].ScalingAdjustment != 2 {
+				t.Errorf("parsePolicies() step adjustments = %+v, want one +2 step", policies[0].StepAdjustments)
+			}
+		})
+	}
+}
+
+// TestConfigFileOverrides tests that command-line values and the environment take precedence over the file
+func TestConfigFileOverrides(t *testing.T) {
+	path := writeConfigFile(t, "autoscaler.yml", testConfigYAML)
+	t.Setenv("ECSAS_LOG_LEVEL", "warn")
+	t.Setenv("ECSAS_MAX_CAPACITY", "30")
+
+	args := make([]string, positionalArgs)
+	args[4] = "cli-service"
+	args[6] = "4"
+	cfg, err := parseArgs(append(args, "--config-file="+path, "--alarm-statistic=Maximum", "--no-alarms=false"))
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+	if cfg.Service != "cli-service" || cfg.MinCapacity != 4 {
+		t.Errorf("parseArgs() service %s min %d, want the command-line cli-service and 4", cfg.Service, cfg.MinCapacity)
+	}
+	if cfg.AlarmStatistic != "Maximum" || cfg.NoAlarms {
+		t.Errorf("parseArgs() statistic %s no-alarms %v, want the command-line Maximum and false", cfg.AlarmStatistic, cfg.NoAlarms)
+	}
+	if cfg.MaxCapacity != 30 || cfg.LogLevel != "warn" {
+		t.Errorf("parseArgs() max %d log level %s, want 30 and warn from the environment", cfg.MaxCapacity, cfg.LogLevel)
+	}
+	if cfg.Cluster != "file-cluster" {
+		t.Errorf("parseArgs() cluster = %s, want file-cluster from the file", cfg.Cluster)
+	}
+
+	// A profile in the file is still exclusive with static keys from the command line
+	args = testPositionalArgs()
+	if _, err := parseArgs(append(args, "--config-file="+path)); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("parseArgs() error = %v, want profile and static keys mutually exclusive", err)
+	}
+}
+
+// actionArgs returns the args action.yml passes to the container, with each input taken from inputs or else its
+// default in action.yml
+func actionArgs(t *testing.T, inputs map[string]string) []string {
+	t.Helper()
+	raw, err := os.ReadFile("action.yml")
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", err)
+	}
+	var action struct {
+		Inputs map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"inputs"`
+		Runs struct {
+			Args []string `yaml:"args"`
+		} `yaml:"runs"`
+	}
+	if err := yaml.Unmarshal(raw, &action); err != nil {
+		t.Fatalf("yaml.Unmarshal(action.yml) unexpected error: %v", err)
+	}
+	expression := regexp.MustCompile(`\$\{\{ inputs\.([a-z0-9-]+) \}\}`)
+	args := make([]string, 0, len(action.Runs.Args))
+	for _, arg := range action.Runs.Args {
+		args = append(args, expression.ReplaceAllStringFunc(arg, func(m string) string {
+			name := expression.FindStringSubmatch(m)[1]
+			if value, ok := inputs[name]; ok {
+				return value
+			}
+			input, ok := action.Inputs[name]
+			if !ok {
+				t.Fatalf("action.yml args use undefined input %s", name)
+			}
+			return input.Default
+		}))
+	}
+	return args
+}
+
+// TestConfigFileActionDefaults tests that a config file used from the action sets what the workflow leaves unset,
+// since action.yml passes no value for inputs left at their default
+func TestConfigFileActionDefaults(t *testing.T) {
+	path := writeConfigFile(t, "autoscaler.yml", `
+min_capacity: 3
+flags:
+  alarms-enabled: false
+  scale-out-operator: GreaterThanThreshold
+  log-level: debug
+`)
+	args := actionArgs(t, map[string]string{
+		"aws-region":   "us-east-1",
+		"cluster-name": "my-cluster",
+		"service-name": "my-service",
+		"enabled":      "true",
+		"config-file":  path,
+		"max-capacity": "12",
+	})
+	cfg, err := parseConfig(args)
+	if err != nil {
+		t.Fatalf("parseConfig() unexpected error: %v", err)
+	}
+	if cfg.AlarmsEnabled || cfg.ScaleOutOperator != "GreaterThanThreshold" || cfg.LogLevel != "debug" || cfg.MinCapacity != 3 {
+		t.Errorf("parseConfig() alarms-enabled %v, operator %s, log level %s, min %d, want the file's false, GreaterThanThreshold, debug and 3",
+			cfg.AlarmsEnabled, cfg.ScaleOutOperator, cfg.LogLevel, cfg.MinCapacity)
+	}
+	if cfg.MaxCapacity != 12 || cfg.ScaleInOperator != "LessThanOrEqualToThreshold" || cfg.ScaleOutCooldown != 300 {
+		t.Errorf("parseConfig() max %d, scale-in operator %s, cooldown %d, want the input's 12 and the defaults", cfg.MaxCapacity, cfg.ScaleInOperator, cfg.ScaleOutCooldown)
+	}
+}
+
+// TestConfigFileErrors tests that mistakes in a config file are reported with the file's name
+func TestConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"unknown field", "c.yaml", "region: eu-west-1\nmax_capcity: 5\n", `unknown field "max_capcity"`},
+		{"static keys", "c.yaml", "aws_access_key_id: AKIA\n", `unknown field "aws_access_key_id"`},
+		{"unknown flag", "c.yaml", "region: eu-west-1\ncluster: c\nservice: s\nflags:\n  no-such-flag: true\n", `unknown flag "no-such-flag"`},
+		{"invalid flag value", "c.yaml", "region: eu-west-1\ncluster: c\nservice: s\nflags:\n  wait-timeout: soon\n", "flags.wait-timeout"},
+		{"nested config file", "c.yaml", "flags:\n  config-file: other.yaml\n", "cannot set config-file"},
+		{"policies not a list", "c.yaml", "scaling_policies: {policy_name: p}\n", "scaling_policies must be a list"},
+		{"invalid YAML", "c.yaml", "region: [eu-west-1\n", "invalid YAML"},
+		{"invalid JSON", "c.json", `{"region": "eu-west-1",}`, "c.json at byte"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.file, tt.content)
+			_, err := parseArgs([]string{"--config-file=" + path})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseArgs() error = %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "config file") && !strings.Contains(err.Error(), tt.file) {
+				t.Errorf("parseArgs() error = %v, want it to name the config file", err)
+			}
+		})
+	}
+
+	if _, err := parseArgs([]string{"--config-file=" + filepath.Join(t.TempDir(), "missing.yaml")}); err == nil || !strings.Contains(err.Error(), "failed to read config file") {
+		t.Errorf("parseArgs() error = %v, want failed to read config file", err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Whether stdin is an interactive terminal rather than a pipe, file or /dev/null
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// List what disabling would delete and ask before going ahead. --yes skips the prompt; without it a
+// non-interactive run refuses, so a stray enabled=false in CI cannot tear everything down.
+func (r *runner) confirmCleanup(ctx context.Context) error {
+	if r.cfg.Yes {
+		return nil
+	}
+
+	items, err := r.buildPlan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to build plan: %w", err)
+	}
+	if len(items) == 0 {
+		// Nothing to delete; cleanup reports that auto-scaling was never enabled
+		return nil
+	}
+
+	fmt.Fprintf(r.out, "Disabling auto-scaling for %s will delete:\n", r.resource.ID)
+	printPlan(r.out, items)
+	if !r.interactive {
+		return fmt.Errorf("refusing to disable auto-scaling for %s without --yes: stdin is not a terminal", r.resource.ID)
+	}
+
+	fmt.Fprint(r.out, "Continue? [y/N] ")
+	answer, err := bufio.NewReader(r.in).ReadString('\n')
+	if err != nil && answer == "" {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return fmt.Errorf("disabling auto-scaling for %s aborted", r.resource.ID)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// TestConfirmCleanup tests that disabling lists what it would delete and only goes ahead with --yes or a
+// "y" answered on a terminal; without a terminal it refuses
+func TestConfirmCleanup(t *testing.T) {
+	tests := []struct {
+		name        string
+		yes         bool
+		interactive bool
+		answer      string
+		wantErr     string
+		wantDeleted bool
+	}{
+		{"non-TTY without --yes", false, false, "", "without --yes", false},
+		{"non-TTY with --yes", true, false, "", "", true},
+		{"TTY answered yes", false, true, "y\n", "", true},
+		{"TTY answered no", false, true, "n\n", "aborted", false},
+		{"TTY closed", false, true, "", "failed to read confirmation", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+					ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+				},
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+					ScalingPolicies: []aasTypes.ScalingPolicy{{PolicyName: aws.String("test-cluster-test-service-scale-out")}},
+				},
+			}
+			mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+			r := newTestRunner(t, false, nil, mockAAS, mockCW)
+			var out bytes.Buffer
+			r.out = &out
+			r.cfg.Yes = tt.yes
+			r.interactive = tt.interactive
+			r.in = strings.NewReader(tt.answer)
+
+			err := r.run(context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("run() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("run() error = %v, want containing %q", err, tt.wantErr)
+			}
+			if deleted := len(mockAAS.deregisterScalableTargetCalls) > 0; deleted != tt.wantDeleted {
+				t.Errorf("scalable target deregistered = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if !tt.yes && !strings.Contains(out.String(), "delete    scaling-policy  test-cluster-test-service-scale-out") {
+				t.Errorf("confirmation output does not list the policy to delete:\n%s", out.String())
+			}
+		})
+	}
+}
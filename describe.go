@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// Output formats for --describe
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// describeDoc is the current state --describe prints. Configured is false when no scalable target is registered.
+type describeDoc struct {
+	ResourceID      string            `json:"resource_id"`
+	Configured      bool              `json:"configured"`
+	MinCapacity     int32             `json:"min_capacity,omitempty"`
+	MaxCapacity     int32             `json:"max_capacity,omitempty"`
+	CurrentCapacity *int32            `json:"current_capacity,omitempty"` // ECS desired count, when an ECS client is available
+	Policies        []describedPolicy `json:"policies,omitempty"`
+	Alarms          []describedAlarm  `json:"alarms,omitempty"`
+}
+
+type describedPolicy struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Settings string `json:"settings"` // key settings, e.g. "target=75 metric=ECSServiceAverageCPUUtilization"
+}
+
+type describedAlarm struct {
+	Name      string  `json:"name"`
+	Policy    string  `json:"policy"`
+	Metric    string  `json:"metric"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	BandWidth float64 `json:"band_width,omitempty"` // anomaly detection band width, in place of the threshold
+	State     string  `json:"state"`
+}
+
+// Read the scalable target, its policies and the alarms driving them
+func (r *runner) buildDescribe(ctx context.Context) (*describeDoc, error) {
+	doc := &describeDoc{ResourceID: r.resource.ID}
+	target, err := describeScalableTarget(ctx, r.aas, r.resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scalable target: %w", err)
+	}
+	if target == nil {
+		return doc, nil
+	}
+	doc.Configured = true
+	doc.MinCapacity = aws.ToInt32(target.MinCapacity)
+	doc.MaxCapacity = aws.ToInt32(target.MaxCapacity)
+
+	// The current count is a nice-to-have, so a failure to read it is only logged
+	if r.ecs != nil && r.resource.Namespace == aasTypes.ServiceNamespaceEcs {
+		if svc, err := r.describeECSService(ctx); err != nil {
+			r.log.Warn("failed to read the current desired count", awsErrorFields(err)...)
+		} else {
+			doc.CurrentCapacity = aws.Int32(svc.DesiredCount)
+		}
+	}
+
+	policies, err := describeScalingPolicies(ctx, r.aas, r.resource, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scaling policies: %w", err)
+	}
+	for _, sp := range policies {
+		policyName := aws.ToString(sp.PolicyName)
+		doc.Policies = append(doc.Policies, describedPolicy{Name: policyName, Type: string(sp.PolicyType), Settings: policySettings(sp)})
+		for _, a := range sp.Alarms {
+			alarm, err := describeAlarm(ctx, r.cw, aws.ToString(a.AlarmName))
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe alarm for policy %s: %w", policyName, err)
+			}
+			if alarm == nil {
+				continue
+			}
+			da := describedAlarm{
+				Name:      aws.ToString(alarm.AlarmName),
+				Policy:    policyName,
+				Metric:    aws.ToString(alarm.Namespace) + "/" + aws.ToString(alarm.MetricName),
+				Operator:  string(alarm.ComparisonOperator),
+				Threshold: aws.ToFloat64(alarm.Threshold),
+				State:     string(alarm.StateValue),
+			}
+			if ms, width, ok := anomalyAlarmMetric(alarm.Metrics, alarm.ThresholdMetricId); ok {
+				da.Metric = aws.ToString(ms.Metric.Namespace) + "/" + aws.ToString(ms.Metric.MetricName)
+				da.BandWidth = width
+			}
+			doc.Alarms = append(doc.Alarms, da)
+		}
+	}
+	return doc, nil
+}
+
+// The key settings of a scaling policy in one line
+func policySettings(sp aasTypes.ScalingPolicy) string {
+	var settings []string
+	if step := sp.StepScalingPolicyConfiguration; step != nil {
+		settings = append(settings, "adjustment="+string(step.AdjustmentType))
+		if step.Cooldown != nil {
+			settings = append(settings, fmt.Sprintf("cooldown=%d", *step.Cooldown))
+		}
+		var steps []string
+		for _, adj := range step.StepAdjustments {
+			steps = append(steps, fmt.Sprintf("%+d", aws.ToInt32(adj.ScalingAdjustment)))
+		}
+		settings = append(settings, "steps=["+strings.Join(steps, ",")+"]")
+	}
+	if tt := sp.TargetTrackingScalingPolicyConfiguration; tt != nil {
+		settings = append(settings, fmt.Sprintf("target=%v", aws.ToFloat64(tt.TargetValue)))
+		if pre := tt.PredefinedMetricSpecification; pre != nil {
+			settings = append(settings, "metric="+string(pre.PredefinedMetricType))
+		} else if custom := tt.CustomizedMetricSpecification; custom != nil {
+			settings = append(settings, "metric="+aws.ToString(custom.Namespace)+"/"+aws.ToString(custom.MetricName))
+		}
+		if aws.ToBool(tt.DisableScaleIn) {
+			settings = append(settings, "scale-in=disabled")
+		}
+	}
+	if ps := sp.PredictiveScalingPolicyConfiguration; ps != nil {
+		settings = append(settings, "mode="+string(ps.Mode))
+		for _, ms := range ps.MetricSpecifications {
+			settings = append(settings, fmt.Sprintf("target=%v", aws.ToFloat64(ms.TargetValue)), predictiveMetricString(ms))
+		}
+	}
+	return strings.Join(settings, " ")
+}
+
+// Print the current state as aligned text tables, or as JSON
+func printDescribe(w io.Writer, doc *describeDoc, format string) error {
+	if format == outputJSON {
+		out, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	}
+
+	if !doc.Configured {
+		_, err := fmt.Fprintf(w, "%s: not configured\n", doc.ResourceID)
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Scalable target\t%s\n", doc.ResourceID)
+	fmt.Fprintf(tw, "  Min capacity\t%d\n", doc.MinCapacity)
+	fmt.Fprintf(tw, "  Max capacity\t%d\n", doc.MaxCapacity)
+	if doc.CurrentCapacity != nil {
+		fmt.Fprintf(tw, "  Current capacity\t%d\n", *doc.CurrentCapacity)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nScaling policies (%d)\n", len(doc.Policies))
+	if len(doc.Policies) > 0 {
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tTYPE\tSETTINGS")
+		for _, p := range doc.Policies {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", p.Name, p.Type, p.Settings)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "\nAlarms (%d)\n", len(doc.Alarms))
+	if len(doc.Alarms) > 0 {
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tPOLICY\tMETRIC\tCONDITION\tSTATE")
+		for _, a := range doc.Alarms {
+			condition := fmt.Sprintf("%s %v", a.Operator, a.Threshold)
+			if a.BandWidth != 0 {
+				condition = fmt.Sprintf("%s band=%v", a.Operator, a.BandWidth)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", a.Name, a.Policy, a.Metric, condition, a.State)
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// Print the current scalable target, policies and alarms without changing anything
+func (r *runner) runDescribe(ctx context.Context) error {
+	doc, err := r.buildDescribe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to describe configuration: %w", err)
+	}
+	return printDescribe(r.out, doc, r.cfg.Output)
+}
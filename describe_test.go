@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// TestDescribe tests the text and JSON output for a configured resource and one with no scalable target
+func TestDescribe(t *testing.T) {
+	ctx := context.Background()
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(12)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{
+				{
+					PolicyName: aws.String("test-cluster-test-service-scale-out"),
+					PolicyType: aasTypes.PolicyTypeStepScaling,
+					StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{
+						AdjustmentType:  aasTypes.AdjustmentTypeChangeInCapacity,
+						Cooldown:        aws.Int32(300),
+						StepAdjustments: []aasTypes.StepAdjustment{{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: aws.Int32(1)}},
+					},
+					Alarms: []aasTypes.Alarm{{AlarmName: aws.String("test-cluster-test-service-cpu-high")}},
+				},
+				{
+					PolicyName: aws.String("cpu-target"),
+					PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+					TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+						TargetValue: aws.Float64(60),
+						PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+							PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+						},
+					},
+				},
+			},
+		},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+		MetricAlarms: []cwTypes.MetricAlarm{{
+			AlarmName:          aws.String("test-cluster-test-service-cpu-high"),
+			Namespace:          aws.String("AWS/ECS"),
+			MetricName:         aws.String("CPUUtilization"),
+			ComparisonOperator: cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
+			Threshold:          aws.Float64(75),
+			StateValue:         cwTypes.StateValueOk,
+		}},
+	}}
+
+	var buf bytes.Buffer
+	r := newTestRunner(t, true, nil, mockAAS, mockCW)
+	r.out = &buf
+	r.ecs = &mockECSClient{describeServicesOutput: &ecs.DescribeServicesOutput{Services: []ecsTypes.Service{{DesiredCount: 4}}}}
+	if err := r.runDescribe(ctx); err != nil {
+		t.Fatalf("runDescribe() unexpected error: %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{
+		"Min capacity      2", "Max capacity      12", "Current capacity  4",
+		"test-cluster-test-service-scale-out", "steps=[+1]", "cpu-target", "target=60",
+		"test-cluster-test-service-cpu-high", "GreaterThanOrEqualToThreshold 75", "OK",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("runDescribe() output missing %q:\n%s", want, output)
+		}
+	}
+	if len(mockAAS.calls) != 0 || len(mockCW.putMetricAlarmCalls) != 0 {
+		t.Error("runDescribe() made mutating API calls")
+	}
+
+	// JSON carries the same state
+	buf.Reset()
+	r.cfg.Output = outputJSON
+	if err := r.runDescribe(ctx); err != nil {
+		t.Fatalf("runDescribe() unexpected error: %v", err)
+	}
+	var doc describeDoc
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("runDescribe() printed invalid JSON: %v\n%s", err, buf.String())
+	}
+	if !doc.Configured || doc.MinCapacity != 2 || doc.MaxCapacity != 12 || len(doc.Policies) != 2 || len(doc.Alarms) != 1 {
+		t.Errorf("runDescribe() JSON = %+v, want min 2, max 12, 2 policies and 1 alarm", doc)
+	}
+
+	// Nothing registered is not an error
+	buf.Reset()
+	r = newTestRunner(t, true, nil, &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+	}, mockCW)
+	r.out = &buf
+	if err := r.runDescribe(ctx); err != nil {
+		t.Fatalf("runDescribe() unexpected error: %v", err)
+	}
+	if got := buf.String(); got != "service/test-cluster/test-service: not configured\n" {
+		t.Errorf("runDescribe() output = %q, want not configured", got)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// Process exit codes, so pipelines can branch on why a run failed
+const (
+	exitFailure    = 1 // any other failure
+	exitValidation = 2 // invalid arguments, policies or configuration; nothing was sent to AWS
+	exitAWS        = 3 // an AWS API call failed
+	exitDrift      = 4 // verify found drift
+	exitChanged    = 5 // the run succeeded and changed something, with --detailed-exit-code
+
+	diffOnlyExitDrift = 2 // verify found drift, with --diff-only-exit-code in place of exitDrift
+)
+
+// errChanged is returned by a successful run that changed something, with --detailed-exit-code
+var errChanged = errors.New("changes applied")
+
+// ExitError carries the exit code main() should use for an error
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Wrap an error from a run with its exit code: drift, a failed AWS API call, or otherwise exitFailure.
+// Errors that already carry a code keep it, as does errChanged.
+func withExitCode(err error) error {
+	var exitErr *ExitError
+	var opErr *smithy.OperationError
+	switch {
+	case err == nil || errors.As(err, &exitErr):
+		return err
+	case errors.Is(err, errDrift):
+		return &ExitError{Code: exitDrift, Err: err}
+	case errors.As(err, &opErr):
+		return &ExitError{Code: exitAWS, Err: err}
+	default:
+		return &ExitError{Code: exitFailure, Err: err}
+	}
+}
+
+// Wrap an invalid input error with exitValidation
+func validationError(err error) error {
+	return &ExitError{Code: exitValidation, Err: err}
+}
+
+// The exit code for an error from a verify run with --diff-only-exit-code, for GitOps controllers that only tell
+// drift from failure: 0 when clean, 2 on drift and 1 on anything else, including invalid configuration
+func diffOnlyExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errDrift):
+		return diffOnlyExitDrift
+	default:
+		return exitFailure
+	}
+}
+
+// The exit code for a failure before Run: invalid arguments, logging settings or an unresolvable region. With
+// --diff-only-exit-code these exit 1 like any other failure, not exitValidation, which a GitOps controller would
+// read as drift. cfg is nil when args did not parse, so the flag is looked for in the raw args instead.
+func earlyExitCode(args []string, cfg *Config, err error) int {
+	diffOnly := diffOnlyRequested(args)
+	if cfg != nil {
+		diffOnly = cfg.DiffOnlyExitCode
+	}
+	if diffOnly {
+		return diffOnlyExitCode(err)
+	}
+	return exitCode(err)
+}
+
+// Whether --diff-only-exit-code is set in args that may not parse, or in ECSAS_DIFF_ONLY_EXIT_CODE; like the flag
+// package, the last setting on the command line wins over the environment
+func diffOnlyRequested(args []string) bool {
+	const name = "diff-only-exit-code"
+	set, _ := strconv.ParseBool(os.Getenv(envName(name)))
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || flagName != name {
+			continue
+		}
+		switch {
+		case !hasValue:
+			set = true
+		case value != "":
+			// An empty value counts as not given, as in parseConfig
+			set, _ = strconv.ParseBool(value)
+		}
+	}
+	return set
+}
+
+// The exit code for an error returned by parseArgs or Run; 0 for nil
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return exitFailure
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/smithy-go"
+)
+
+// TestExitCode tests that validation errors, AWS API failures and drift map to their own exit codes
+func TestExitCode(t *testing.T) {
+	ctx := context.Background()
+	newConfig := func() *Config {
+		return &Config{
+			Cluster:          "test-cluster",
+			Service:          "test-service",
+			Enabled:          true,
+			MinCapacity:      1,
+			MaxCapacity:      10,
+			ScaleOutCooldown: 300,
+			ScaleInCooldown:  300,
+			TargetCPUOut:     75,
+			TargetCPUIn:      65,
+			TargetMemOut:     80,
+			TargetMemIn:      70,
+			TagAlarms:        true,
+			AlarmsEnabled:    true,
+		}
+	}
+	emptyAAS := func() *mockAASClient {
+		return &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		}
+	}
+	cwClient := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	_, parseErr := parseArgs(append(testPositionalArgs(), "--default-policy-type=tracking"))
+
+	invalidPolicies := newConfig()
+	invalidPolicies.PoliciesRaw = `[{"policy_name":"p","policy_type":"Bogus"}]`
+	validationErr := Run(ctx, invalidPolicies, Clients{AAS: emptyAAS(), CW: cwClient}, io.Discard)
+
+	throttled := &mockAASClient{describeScalableTargetsError: &smithy.OperationError{
+		ServiceID:     "Application Auto Scaling",
+		OperationName: "DescribeScalableTargets",
+		Err:           &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"},
+	}}
+	awsErr := Run(ctx, newConfig(), Clients{AAS: throttled, CW: cwClient}, io.Discard)
+
+	verify := newConfig()
+	verify.Verify = true
+	driftErr := Run(ctx, verify, Clients{AAS: emptyAAS(), CW: cwClient}, io.Discard)
+
+	otherErr := Run(ctx, newConfig(), Clients{AAS: &mockAASClient{describeScalableTargetsError: errors.New("boom")}, CW: cwClient}, io.Discard)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, 0},
+		{"invalid arguments", parseErr, exitValidation},
+		{"invalid policies", validationErr, exitValidation},
+		{"AWS API failure", awsErr, exitAWS},
+		{"drift", driftErr, exitDrift},
+		{"other failure", otherErr, exitFailure},
+		{"plain error", errors.New("unexpected"), exitFailure},
+		{"wrapped exit error", fmt.Errorf("service api: %w", &ExitError{Code: exitAWS, Err: errors.New("throttled")}), exitAWS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	// The wrapped error is still reachable and formats unchanged
+	if !errors.Is(driftErr, errDrift) {
+		t.Errorf("Run() error = %v, want errDrift", driftErr)
+	}
+	if got := withExitCode(errors.New("boom")).Error(); got != "boom" {
+		t.Errorf("withExitCode().Error() = %q, want boom", got)
+	}
+}
+
+// TestDiffOnlyExitCode tests the verify exit codes with --diff-only-exit-code: 0 clean, 2 drift, 1 otherwise
+func TestDiffOnlyExitCode(t *testing.T) {
+	ctx := context.Background()
+	newConfig := func(enabled bool) *Config {
+		return &Config{
+			Cluster:          "test-cluster",
+			Service:          "test-service",
+			Enabled:          enabled,
+			MinCapacity:      1,
+			MaxCapacity:      10,
+			ScaleOutCooldown: 300,
+			ScaleInCooldown:  300,
+			TargetCPUOut:     75,
+			TargetCPUIn:      65,
+			TargetMemOut:     80,
+			TargetMemIn:      70,
+			AlarmsEnabled:    true,
+			Verify:           true,
+			DiffOnlyExitCode: true,
+		}
+	}
+	emptyAAS := func() *mockAASClient {
+		return &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		}
+	}
+	cwClient := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	// Nothing registered matches a disabled desired state, and is missing everything for an enabled one
+	clean := Run(ctx, newConfig(false), Clients{AAS: emptyAAS(), CW: cwClient}, io.Discard)
+	drifted := Run(ctx, newConfig(true), Clients{AAS: emptyAAS(), CW: cwClient}, io.Discard)
+	throttled := Run(ctx, newConfig(true), Clients{AAS: &mockAASClient{describeScalableTargetsError: &smithy.OperationError{
+		ServiceID:     "Application Auto Scaling",
+		OperationName: "DescribeScalableTargets",
+		Err:           &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"},
+	}}, CW: cwClient}, io.Discard)
+	invalid := newConfig(true)
+	invalid.PoliciesRaw = `[{"policy_name":"p","policy_type":"Bogus"}]`
+	invalidErr := Run(ctx, invalid, Clients{AAS: emptyAAS(), CW: cwClient}, io.Discard)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"clean", clean, 0},
+		{"drift", drifted, diffOnlyExitDrift},
+		{"AWS API failure", throttled, exitFailure},
+		{"invalid policies", invalidErr, exitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffOnlyExitCode(tt.err); got != tt.want {
+				t.Errorf("diffOnlyExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseArgs(append(testPositionalArgs(), "--diff-only-exit-code")); err == nil {
+		t.Error("parseArgs() with diff-only-exit-code outside verify succeeded, want an error")
+	}
+	if cfg, err := parseArgs(append([]string{commandVerify}, append(testPositionalArgs(), "--diff-only-exit-code")...)); err != nil || !cfg.DiffOnlyExitCode {
+		t.Errorf("parseArgs() verify with diff-only-exit-code = %v, want it set", err)
+	}
+}
+
+// TestEarlyExitCode tests that failures before Run exit 1 rather than the drift code with --diff-only-exit-code,
+// whether it comes from args that do not parse, the environment or the parsed config
+func TestEarlyExitCode(t *testing.T) {
+	t.Setenv("ECSAS_DIFF_ONLY_EXIT_CODE", "")
+	parseErr := func(args []string) error {
+		t.Helper()
+		_, err := parseArgs(args)
+		if err == nil {
+			t.Fatalf("parseArgs(%v) succeeded, want an error", args)
+		}
+		return err
+	}
+	verifyArgs := func(flags ...string) []string {
+		return append(append([]string{commandVerify}, testPositionalArgs()...), flags...)
+	}
+
+	invalid := verifyArgs("--bogus", "--diff-only-exit-code")
+	if got := earlyExitCode(invalid, nil, parseErr(invalid)); got != exitFailure {
+		t.Errorf("earlyExitCode() for an invalid flag with diff-only-exit-code = %d, want %d", got, exitFailure)
+	}
+	invalid = verifyArgs("--bogus")
+	if got := earlyExitCode(invalid, nil, parseErr(invalid)); got != exitValidation {
+		t.Errorf("earlyExitCode() for an invalid flag = %d, want %d", got, exitValidation)
+	}
+	invalid = verifyArgs("--bogus", "--diff-only-exit-code=false")
+	if got := earlyExitCode(invalid, nil, parseErr(invalid)); got != exitValidation {
+		t.Errorf("earlyExitCode() with diff-only-exit-code=false = %d, want %d", got, exitValidation)
+	}
+	if got := earlyExitCode(nil, &Config{DiffOnlyExitCode: true}, validationError(errors.New("no region found"))); got != exitFailure {
+		t.Errorf("earlyExitCode() for a validation error with diff-only-exit-code = %d, want %d", got, exitFailure)
+	}
+
+	t.Setenv("ECSAS_DIFF_ONLY_EXIT_CODE", "true")
+	invalid = verifyArgs("--bogus")
+	if got := earlyExitCode(invalid, nil, parseErr(invalid)); got != exitFailure {
+		t.Errorf("earlyExitCode() with ECSAS_DIFF_ONLY_EXIT_CODE = %d, want %d", got, exitFailure)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// exportDoc is the document --export prints.
+// Its values map onto the action inputs, so feeding it back in and applying is a no-op.
+type exportDoc struct {
+	ResourceID       string      `json:"resource_id"`
+	MinCapacity      int32       `json:"min_capacity"`
+	MaxCapacity      int32       `json:"max_capacity"`
+	ScaleOutCooldown *int32      `json:"scale_out_cooldown,omitempty"` // from the built-in scale-out policy, if present
+	ScaleInCooldown  *int32      `json:"scale_in_cooldown,omitempty"`  // from the built-in scale-in policy, if present
+	Policies         []PolicyDef `json:"policies"`                     // every other policy, in scaling-policies format
+}
+
+// Read the scalable target, its policies and their alarms back into this tool's input format
+func (r *runner) buildExport(ctx context.Context) (*exportDoc, error) {
+	target, err := describeScalableTarget(ctx, r.aas, r.resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scalable target: %w", err)
+	}
+	if target == nil {
+		return nil, fmt.Errorf("no scalable target registered for %s", r.resource.ID)
+	}
+
+	policies, err := describeScalingPolicies(ctx, r.aas, r.resource, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scaling policies: %w", err)
+	}
+
+	doc := &exportDoc{
+		ResourceID:  r.resource.ID,
+		MinCapacity: aws.ToInt32(target.MinCapacity),
+		MaxCapacity: aws.ToInt32(target.MaxCapacity),
+		Policies:    []PolicyDef{},
+	}
+	for _, sp := range policies {
+		// The built-in default policies are expressed through the cooldown inputs, not as custom policies
+		switch aws.ToString(sp.PolicyName) {
+		case r.scaleOutName:
+			if sp.StepScalingPolicyConfiguration != nil {
+				doc.ScaleOutCooldown = sp.StepScalingPolicyConfiguration.Cooldown
+			}
+			continue
+		case r.scaleInName:
+			if sp.StepScalingPolicyConfiguration != nil {
+				doc.ScaleInCooldown = sp.StepScalingPolicyConfiguration.Cooldown
+			}
+			continue
+		}
+
+		var alarm *cwTypes.MetricAlarm
+		if sp.PolicyType == aasTypes.PolicyTypeStepScaling && len(sp.Alarms) > 0 {
+			alarm, err = describeAlarm(ctx, r.cw, aws.ToString(sp.Alarms[0].AlarmName))
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe alarm for policy %s: %w", aws.ToString(sp.PolicyName), err)
+			}
+		}
+		doc.Policies = append(doc.Policies, policyDefFromScalingPolicy(sp, alarm, r.resource))
+	}
+	return doc, nil
+}
+
+// Convert an existing scaling policy (and the alarm driving it, if any) into a policy definition.
+// Alarm dimensions are only carried over when they differ from the resource's defaults.
+func policyDefFromScalingPolicy(sp aasTypes.ScalingPolicy, alarm *cwTypes.MetricAlarm, res resourceRef) PolicyDef {
+	p := PolicyDef{
+		PolicyName: aws.ToString(sp.PolicyName),
+		PolicyType: string(sp.PolicyType),
+	}
+
+	if step := sp.StepScalingPolicyConfiguration; step != nil {
+		p.AdjustmentType = string(step.AdjustmentType)
+		p.MinAdjustmentMagnitude = step.MinAdjustmentMagnitude
+		p.Cooldown = step.Cooldown
+		p.MetricAggregationType = string(step.MetricAggregationType)
+		for _, adj := range step.StepAdjustments {
+			p.StepAdjustments = append(p.StepAdjustments, StepAdj{
+				MetricIntervalLowerBound: adj.MetricIntervalLowerBound,
+				MetricIntervalUpperBound: adj.MetricIntervalUpperBound,
+				ScalingAdjustment:        aws.ToInt32(adj.ScalingAdjustment),
+			})
+		}
+		if alarm != nil {
+			p.MetricName = aws.ToString(alarm.MetricName)
+			p.MetricNamespace = aws.ToString(alarm.Namespace)
+			if stat := aws.ToString(alarm.ExtendedStatistic); stat != "" {
+				p.Statistic = stat
+			} else if alarm.Statistic != cwTypes.StatisticAverage {
+				p.Statistic = string(alarm.Statistic)
+			}
+			p.Unit = string(alarm.Unit)
+			period := alarm.Period
+			dims := alarm.Dimensions
+			// An anomaly detection alarm keeps its metric in the Metrics array
+			if ms, width, ok := anomalyAlarmMetric(alarm.Metrics, alarm.ThresholdMetricId); ok {
+				p.MetricName = aws.ToString(ms.Metric.MetricName)
+				p.MetricNamespace = aws.ToString(ms.Metric.Namespace)
+				p.Statistic = ""
+				if stat := aws.ToString(ms.Stat); stat != string(cwTypes.StatisticAverage) {
+					p.Statistic = stat
+				}
+				p.Unit = string(ms.Unit)
+				period = ms.Period
+				dims = ms.Metric.Dimensions
+				p.AnomalyDetection = &AnomalyDetection{}
+				if width != defaultAnomalyBandWidth {
+					p.AnomalyDetection.BandWidth = width
+				}
+			}
+			if period != nil && aws.ToInt32(period) != aws.ToInt32(p.Cooldown) {
+				p.Period = period
+			}
+			p.OKActions = alarm.OKActions
+			p.InsufficientDataActions = alarm.InsufficientDataActions
+			if alarm.ActionsEnabled != nil && !*alarm.ActionsEnabled {
+				p.ActionsEnabled = aws.Bool(false)
+			}
+			if alarmDimensionsString(dims) != alarmDimensionsString(res.alarmDimensions()) {
+				p.Dimensions = make(map[string]string, len(dims))
+				for _, dim := range dims {
+					p.Dimensions[aws.ToString(dim.Name)] = aws.ToString(dim.Value)
+				}
+			}
+			if strings.HasPrefix(string(alarm.ComparisonOperator), "LessThan") {
+				p.ScaleDirection = "in"
+			} else {
+				p.ScaleDirection = "out"
+			}
+		}
+	}
+
+	if ps := sp.PredictiveScalingPolicyConfiguration; ps != nil {
+		p.PredictiveScalingConfiguration = predictiveScalingConfigFrom(ps)
+	}
+
+	if tt := sp.TargetTrackingScalingPolicyConfiguration; tt != nil {
+		cfg := &TargetTrackingConfig{
+			TargetValue:      aws.ToFloat64(tt.TargetValue),
+			ScaleInCooldown:  tt.ScaleInCooldown,
+			ScaleOutCooldown: tt.ScaleOutCooldown,
+		}
+		// AWS reports an unset DisableScaleIn as false; only carry it over when it matters
+		if aws.ToBool(tt.DisableScaleIn) {
+			cfg.DisableScaleIn = aws.Bool(true)
+		}
+		if pre := tt.PredefinedMetricSpecification; pre != nil {
+			cfg.PredefinedMetricSpecification = string(pre.PredefinedMetricType)
+			cfg.ResourceLabel = aws.ToString(pre.ResourceLabel)
+		}
+		if cm := tt.CustomizedMetricSpecification; cm != nil {
+			spec := &CustomMetricSpec{
+				Namespace:  aws.ToString(cm.Namespace),
+				MetricName: aws.ToString(cm.MetricName),
+				Statistic:  string(cm.Statistic),
+				Unit:       aws.ToString(cm.Unit),
+			}
+			if len(cm.Dimensions) > 0 {
+				spec.Dimensions = make(map[string]string, len(cm.Dimensions))
+				for _, dim := range cm.Dimensions {
+					spec.Dimensions[aws.ToString(dim.Name)] = aws.ToString(dim.Value)
+				}
+			}
+			cfg.CustomMetricSpecification = spec
+		}
+		p.TargetTrackingConfiguration = cfg
+	}
+
+	return p
+}
+
+// Write the export document as indented JSON
+func printExport(w io.Writer, doc *exportDoc) error {
+	sort.SliceStable(doc.Policies, func(i, j int) bool {
+		return doc.Policies[i].PolicyName < doc.Policies[j].PolicyName
+	})
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
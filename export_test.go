@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// TestBuildExportRoundTrip tests that exported policies produce no diff when applied again
+func TestBuildExportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	existing := []aasTypes.ScalingPolicy{
+		{
+			PolicyName: aws.String("test-cluster-test-service-scale-out"),
+			PolicyType: aasTypes.PolicyTypeStepScaling,
+			StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{
+				AdjustmentType: aasTypes.AdjustmentTypeChangeInCapacity,
+				Cooldown:       aws.Int32(120),
+			},
+		},
+		{
+			PolicyName: aws.String("queue-step"),
+			PolicyType: aasTypes.PolicyTypeStepScaling,
+			StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{
+				AdjustmentType:        aasTypes.AdjustmentTypeChangeInCapacity,
+				Cooldown:              aws.Int32(60),
+				MetricAggregationType: aasTypes.MetricAggregationTypeAverage,
+				StepAdjustments: []aasTypes.StepAdjustment{
+					{MetricIntervalLowerBound: aws.Float64(0), MetricIntervalUpperBound: aws.Float64(10), ScalingAdjustment: aws.Int32(1)},
+					{MetricIntervalLowerBound: aws.Float64(10), ScalingAdjustment: aws.Int32(3)},
+				},
+			},
+			Alarms: []aasTypes.Alarm{{AlarmName: aws.String("queue-high")}},
+		},
+		{
+			PolicyName: aws.String("cpu-target"),
+			PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+			TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+				TargetValue:      aws.Float64(60),
+				DisableScaleIn:   aws.Bool(false),
+				ScaleOutCooldown: aws.Int32(30),
+				PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+					PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+				},
+			},
+		},
+		{
+			PolicyName: aws.String("queue-target"),
+			PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+			TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+				TargetValue:    aws.Float64(100),
+				DisableScaleIn: aws.Bool(true),
+				CustomizedMetricSpecification: &aasTypes.CustomizedMetricSpecification{
+					MetricName: aws.String("QueueDepth"),
+					Namespace:  aws.String("MyApp"),
+					Statistic:  aasTypes.MetricStatisticAverage,
+					Unit:       aws.String("Count"),
+					Dimensions: []aasTypes.MetricDimension{{Name: aws.String("QueueName"), Value: aws.String("jobs")}},
+				},
+			},
+		},
+	}
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(8)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{ScalingPolicies: existing},
+	}
+	mockCW := &mockCWClient{
+		describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+			MetricAlarms: []cwTypes.MetricAlarm{{
+				AlarmName:          aws.String("queue-high"),
+				MetricName:         aws.String("QueueDepth"),
+				Namespace:          aws.String("MyApp"),
+				ComparisonOperator: cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
+				Dimensions:         []cwTypes.Dimension{{Name: aws.String("QueueName"), Value: aws.String("jobs")}},
+			}},
+		},
+	}
+
+	r := newTestRunner(t, true, nil, mockAAS, mockCW)
+	doc, err := r.buildExport(ctx)
+	if err != nil {
+		t.Fatalf("buildExport() unexpected error: %v", err)
+	}
+	if doc.MinCapacity != 2 || doc.MaxCapacity != 8 {
+		t.Errorf("buildExport() capacity = %d/%d, want 2/8", doc.MinCapacity, doc.MaxCapacity)
+	}
+	if aws.ToInt32(doc.ScaleOutCooldown) != 120 || doc.ScaleInCooldown != nil {
+		t.Errorf("buildExport() cooldowns = %s/%s, want 120/<unset>", ptrString(doc.ScaleOutCooldown), ptrString(doc.ScaleInCooldown))
+	}
+	if len(doc.Policies) != 3 {
+		t.Fatalf("buildExport() returned %d policies, want 3 (built-in policies excluded)", len(doc.Policies))
+	}
+
+	// Serialize and parse back the way the scaling-policies input would be
+	var buf bytes.Buffer
+	if err := printExport(&buf, doc); err != nil {
+		t.Fatalf("printExport() unexpected error: %v", err)
+	}
+	var parsed exportDoc
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("export output is not valid JSON: %v", err)
+	}
+
+	byName := map[string]aasTypes.ScalingPolicy{}
+	for _, sp := range existing {
+		byName[aws.ToString(sp.PolicyName)] = sp
+	}
+	for _, p := range parsed.Policies {
+		input, err := buildPolicyInput(p, r.resource)
+		if err != nil {
+			t.Fatalf("buildPolicyInput(%s) unexpected error: %v", p.PolicyName, err)
+		}
+		sp := byName[p.PolicyName]
+		if diffs := diffScalingPolicy(&sp, input); len(diffs) != 0 {
+			t.Errorf("policy %s differs after round trip: %v", p.PolicyName, diffs)
+		}
+		if p.PolicyName == "queue-step" && (p.MetricName != "QueueDepth" || p.MetricNamespace != "MyApp" || p.ScaleDirection != "out") {
+			t.Errorf("policy %s alarm fields = %q/%q/%q, want QueueDepth/MyApp/out", p.PolicyName, p.MetricName, p.MetricNamespace, p.ScaleDirection)
+		}
+		if p.PolicyName == "queue-step" && p.Dimensions["QueueName"] != "jobs" {
+			t.Errorf("policy %s dimensions = %v, want QueueName=jobs", p.PolicyName, p.Dimensions)
+		}
+	}
+
+	// Exporting never mutates anything
+	if len(mockAAS.putScalingPolicyCalls) != 0 || len(mockAAS.registerScalableTargetCalls) != 0 || len(mockCW.putMetricAlarmCalls) != 0 {
+		t.Error("buildExport() made mutating API calls")
+	}
+}
+
+// TestBuildExportNoTarget tests that exporting an unconfigured service is an error
+func TestBuildExportNoTarget(t *testing.T) {
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+	}
+	r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+	if _, err := r.buildExport(context.Background()); err == nil {
+		t.Error("buildExport() expected error, got nil")
+	}
+}
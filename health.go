@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// ECS uses a minimum healthy percent of 100 when the deployment configuration leaves it unset
+const defaultMinHealthyPercent = 100
+
+// Tasks a deployment of the service must keep healthy: its minimum healthy percent of the running count, rounded up
+func minHealthyTasks(running, minHealthyPercent int32) int32 {
+	return int32((int64(running)*int64(minHealthyPercent) + 99) / 100)
+}
+
+// Before scale-in policies are applied, compare min-capacity with the tasks the service's deployment configuration
+// needs to keep healthy. Scaling in below that leaves deployments unable to honour it, so this warns, or with
+// --strict fails before anything is changed.
+func (r *runner) checkMinHealthyPercent(ctx context.Context) error {
+	if !r.cfg.CheckMinHealthyPercent {
+		return nil
+	}
+	if r.ecs == nil {
+		return errors.New("check-min-healthy-percent requires an ECS client")
+	}
+
+	svc, err := r.describeECSService(ctx)
+	if err != nil {
+		return fmt.Errorf("check-min-healthy-percent: %w", err)
+	}
+	minHealthyPercent := int32(defaultMinHealthyPercent)
+	if dc := svc.DeploymentConfiguration; dc != nil && dc.MinimumHealthyPercent != nil {
+		minHealthyPercent = *dc.MinimumHealthyPercent
+	}
+	needed := minHealthyTasks(svc.RunningCount, minHealthyPercent)
+	if r.cfg.MinCapacity >= needed {
+		r.log.Debug("min-capacity satisfies the deployment's minimum healthy percent",
+			"min_capacity", r.cfg.MinCapacity, "running_count", svc.RunningCount, "minimum_healthy_percent", minHealthyPercent)
+		return nil
+	}
+
+	msg := fmt.Sprintf("min-capacity %d is below the %d tasks %s needs to keep healthy during deployments (%d%% of %d running)",
+		r.cfg.MinCapacity, needed, r.resource.ID, minHealthyPercent, svc.RunningCount)
+	if r.cfg.Strict {
+		return errors.New(msg)
+	}
+	r.log.Warn(msg)
+	return nil
+}
+
+// Before the scalable target is registered, check the ECS service exists and is ACTIVE. Application Auto Scaling
+// accepts a target for any resource ID, so without this a misspelled cluster or service only shows up as a
+// service that never scales.
+func (r *runner) validateService(ctx context.Context) error {
+	if !r.cfg.ValidateService {
+		return nil
+	}
+	if r.ecs == nil {
+		return errors.New("validate-service requires an ECS client")
+	}
+
+	svc, err := r.describeECSService(ctx)
+	if err != nil {
+		return fmt.Errorf("validate-service: %w; check cluster-name and service-name", err)
+	}
+	if status := aws.ToString(svc.Status); status != "ACTIVE" {
+		return fmt.Errorf("validate-service: ECS service %s is %s, not ACTIVE", r.resource.ID, status)
+	}
+	r.log.Debug("ECS service is active")
+	return nil
+}
+
+// Describe the ECS service behind the resource ID (service/CLUSTER/SERVICE)
+func (r *runner) describeECSService(ctx context.Context) (*ecsTypes.Service, error) {
+	parts := strings.Split(r.resource.ID, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("cannot find the cluster and service in resource ID %s", r.resource.ID)
+	}
+	cluster, service := parts[1], parts[2]
+	out, err := r.ecs.DescribeServices(ctx, &ecs.DescribeServicesInput{
+		Cluster:  aws.String(cluster),
+		Services: []string{service},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ECS service %s: %w", r.resource.ID, err)
+	}
+	if len(out.Services) == 0 {
+		reason := "not found"
+		if len(out.Failures) > 0 {
+			reason = aws.ToString(out.Failures[0].Reason)
+		}
+		return nil, fmt.Errorf("failed to describe ECS service %s: %s", r.resource.ID, reason)
+	}
+	return &out.Services[0], nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// TestMinHealthyTasks tests rounding up of the tasks a deployment keeps healthy
+func TestMinHealthyTasks(t *testing.T) {
+	tests := []struct {
+		running, percent, want int32
+	}{
+		{4, 100, 4},
+		{4, 50, 2},
+		{5, 50, 3},
+		{3, 0, 0},
+		{0, 100, 0},
+	}
+	for _, tt := range tests {
+		if got := minHealthyTasks(tt.running, tt.percent); got != tt.want {
+			t.Errorf("minHealthyTasks(%d, %d) = %d, want %d", tt.running, tt.percent, got, tt.want)
+		}
+	}
+}
+
+// TestCheckMinHealthyPercent tests the warning, the --strict failure and the ECS request
+func TestCheckMinHealthyPercent(t *testing.T) {
+	service := func(running int32, percent *int32) *ecs.DescribeServicesOutput {
+		return &ecs.DescribeServicesOutput{Services: []ecsTypes.Service{{
+			RunningCount:            running,
+			DeploymentConfiguration: &ecsTypes.DeploymentConfiguration{MinimumHealthyPercent: percent},
+		}}}
+	}
+
+	tests := []struct {
+		name        string
+		output      *ecs.DescribeServicesOutput
+		err         error
+		minCapacity int32
+		strict      bool
+		wantErr     string
+	}{
+		{"enough tasks", service(4, aws.Int32(50)), nil, 2, true, ""},
+		{"below, warning only", service(4, aws.Int32(50)), nil, 1, false, ""},
+		{"below, strict", service(4, aws.Int32(50)), nil, 1, true, "min-capacity 1 is below the 2 tasks"},
+		{"unset percent defaults to 100", service(3, nil), nil, 2, true, "(100% of 3 running)"},
+		{"service missing", &ecs.DescribeServicesOutput{Failures: []ecsTypes.Failure{{Reason: aws.String("MISSING")}}}, nil, 1, false, "MISSING"},
+		{"api error", nil, errors.New("access denied"), 1, false, "access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockECS := &mockECSClient{describeServicesOutput: tt.output, describeServicesError: tt.err}
+			r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+			r.ecs = mockECS
+			r.cfg.CheckMinHealthyPercent = true
+			r.cfg.MinCapacity = tt.minCapacity
+			r.cfg.Strict = tt.strict
+
+			err := r.checkMinHealthyPercent(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkMinHealthyPercent() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkMinHealthyPercent() error = %v, want %q", err, tt.wantErr)
+			}
+
+			call := mockECS.describeServicesCalls[0]
+			if aws.ToString(call.Cluster) != "test-cluster" || len(call.Services) != 1 || call.Services[0] != "test-service" {
+				t.Errorf("DescribeServices(%s, %v), want test-cluster [test-service]", aws.ToString(call.Cluster), call.Services)
+			}
+		})
+	}
+
+	// Without the flag ECS is not called, so no ECS client is needed
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	if err := r.checkMinHealthyPercent(context.Background()); err != nil {
+		t.Errorf("checkMinHealthyPercent() without the flag unexpected error: %v", err)
+	}
+
+	// With --strict a failed check stops apply before anything is registered
+	mockAAS := &mockAASClient{}
+	r = newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+	r.ecs = &mockECSClient{describeServicesOutput: service(4, aws.Int32(100))}
+	r.cfg.CheckMinHealthyPercent = true
+	r.cfg.Strict = true
+	if err := r.apply(context.Background()); err == nil {
+		t.Error("apply() expected error, got nil")
+	}
+	if mockAAS.describeScalableTargetsCalls != 0 || len(mockAAS.calls) != 0 {
+		t.Errorf("apply() called Application Auto Scaling after a failed check: %v", mockAAS.calls)
+	}
+}
+
+// TestValidateService tests that --validate-service fails on a missing or inactive service before anything is registered
+func TestValidateService(t *testing.T) {
+	withStatus := func(status string) *ecs.DescribeServicesOutput {
+		return &ecs.DescribeServicesOutput{Services: []ecsTypes.Service{{Status: aws.String(status)}}}
+	}
+	missing := &ecs.DescribeServicesOutput{Failures: []ecsTypes.Failure{{
+		Arn:    aws.String("arn:aws:ecs:us-east-1:123456789012:service/test-cluster/test-service"),
+		Reason: aws.String("MISSING"),
+	}}}
+
+	tests := []struct {
+		name    string
+		output  *ecs.DescribeServicesOutput
+		err     error
+		wantErr string
+	}{
+		{"active", withStatus("ACTIVE"), nil, ""},
+		{"missing", missing, nil, "failed to describe ECS service service/test-cluster/test-service: MISSING; check cluster-name and service-name"},
+		{"draining", withStatus("DRAINING"), nil, "ECS service service/test-cluster/test-service is DRAINING, not ACTIVE"},
+		{"inactive", withStatus("INACTIVE"), nil, "is INACTIVE, not ACTIVE"},
+		{"cluster not found", nil, errors.New("ClusterNotFoundException: Cluster not found."), "Cluster not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{}
+			r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+			r.ecs = &mockECSClient{describeServicesOutput: tt.output, describeServicesError: tt.err}
+			r.cfg.ValidateService = true
+
+			err := r.validateService(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateService() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateService() error = %v, want %q", err, tt.wantErr)
+			}
+
+			// A failed check stops apply before the target is registered
+			if err := r.apply(context.Background()); err == nil {
+				t.Error("apply() expected error, got nil")
+			}
+			if mockAAS.describeScalableTargetsCalls != 0 || len(mockAAS.calls) != 0 {
+				t.Errorf("apply() called Application Auto Scaling after a failed check: %v", mockAAS.calls)
+			}
+		})
+	}
+
+	// Without the flag ECS is not called, so no ECS client is needed
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	if err := r.validateService(context.Background()); err != nil {
+		t.Errorf("validateService() without the flag unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Shortest accepted --interval; each reconcile reads every policy and alarm, so shorter risks throttling
+const minInterval = 30 * time.Second
+
+// Run reconcile now and then every interval until ctx is cancelled, e.g. by SIGINT or SIGTERM. A failed
+// reconcile is logged and tried again on the next tick, so transient API errors do not end the loop; an
+// invalid configuration never recovers, so it does. Cancellation ends the loop cleanly with nil.
+func reconcileLoop(ctx context.Context, interval time.Duration, reconcile func(context.Context) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	slog.Info("starting reconcile loop", "interval", interval)
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := reconcile(ctx)
+		switch {
+		case ctx.Err() != nil:
+			// The reconcile was cut short by the cancellation, not a real failure
+		case err != nil && exitCode(err) == exitValidation:
+			return err
+		case err != nil:
+			slog.Error("reconcile failed", append(awsErrorFields(err), "attempt", attempt, "next_in", interval)...)
+		default:
+			slog.Info("reconcile finished", "attempt", attempt, "duration", time.Since(start).Round(time.Millisecond), "next_in", interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("stopping reconcile loop", "attempts", attempt)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// TestReconcileLoop tests that the loop reconciles on every tick, survives failures, stops on an invalid
+// configuration and returns nil once cancelled
+func TestReconcileLoop(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error // returned by each attempt; the loop is cancelled after the last
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"two ticks", []error{nil, nil}, 2, false},
+		{"failure is retried", []error{errors.New("throttled"), nil}, 2, false},
+		{"invalid configuration stops", []error{validationError(errors.New("bad policy")), nil}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			attempts := 0
+			err := reconcileLoop(ctx, time.Millisecond, func(context.Context) error {
+				attempts++
+				if attempts == len(tt.errs) {
+					cancel()
+				}
+				return tt.errs[attempts-1]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("reconcileLoop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("reconcileLoop() made %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+// Cancels the run's context on the nth register, so an --interval loop stops after n reconciles
+type cancelAfterRegister struct {
+	*mockAASClient
+	n      int
+	cancel context.CancelFunc
+}
+
+func (m *cancelAfterRegister) RegisterScalableTarget(ctx context.Context, params *applicationautoscaling.RegisterScalableTargetInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.RegisterScalableTargetOutput, error) {
+	out, err := m.mockAASClient.RegisterScalableTarget(ctx, params, optFns...)
+	if len(m.registerScalableTargetCalls) == m.n {
+		m.cancel()
+	}
+	return out, err
+}
+
+// TestRunInterval tests that Run with an interval reconciles on each tick until its context is cancelled
+func TestRunInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TargetCPUOut:     75,
+		TargetCPUIn:      65,
+		TargetMemOut:     80,
+		TargetMemIn:      70,
+		AlarmsEnabled:    true,
+		Interval:         time.Millisecond, // below minInterval, which only parseArgs enforces
+	}
+	mockAAS := &cancelAfterRegister{
+		mockAASClient: &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+				ScalingPolicies: []aasTypes.ScalingPolicy{
+					{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+					{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:in")},
+				},
+			},
+		},
+		n:      2,
+		cancel: cancel,
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got := len(mockAAS.registerScalableTargetCalls); got != 2 {
+		t.Errorf("Run() registered the scalable target %d times, want 2 (one per tick)", got)
+	}
+}
@@ -1,20 +1,36 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"math"
 	"os"
+	"os/signal"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	aas "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
 	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
 	cw "github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/smithy-go"
 )
 
 // Define interfaces for AWS clients
@@ -25,6 +41,9 @@ type AASClient interface {
 	PutScalingPolicy(ctx context.Context, params *aas.PutScalingPolicyInput, optFns ...func(*aas.Options)) (*aas.PutScalingPolicyOutput, error)
 	DeleteScalingPolicy(ctx context.Context, params *aas.DeleteScalingPolicyInput, optFns ...func(*aas.Options)) (*aas.DeleteScalingPolicyOutput, error)
 	DeregisterScalableTarget(ctx context.Context, params *aas.DeregisterScalableTargetInput, optFns ...func(*aas.Options)) (*aas.DeregisterScalableTargetOutput, error)
+	DescribeScalingActivities(ctx context.Context, params *aas.DescribeScalingActivitiesInput, optFns ...func(*aas.Options)) (*aas.DescribeScalingActivitiesOutput, error)
+	TagResource(ctx context.Context, params *aas.TagResourceInput, optFns ...func(*aas.Options)) (*aas.TagResourceOutput, error)
+	ListTagsForResource(ctx context.Context, params *aas.ListTagsForResourceInput, optFns ...func(*aas.Options)) (*aas.ListTagsForResourceOutput, error)
 }
 
 type CWClient interface {
@@ -33,12 +52,71 @@ type CWClient interface {
 	PutMetricAlarm(ctx context.Context, params *cw.PutMetricAlarmInput, optFns ...func(*cw.Options)) (*cw.PutMetricAlarmOutput, error)
 }
 
-// Set up structured logging with slog
-func init() {
-	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+// Build the structured logger for the --log-format (text|json) and --log-level (debug|info|warn|error) flags
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "", "info":
+		lvl = slog.LevelInfo
+	case "warn", "warning":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return nil, fmt.Errorf("invalid log-level %q: must be one of debug, info, warn, error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log-format %q: must be text or json", format)
+	}
+}
+
+// LoggingOptions configures SetupLogging: where log lines go and the --log-format and --log-level values
+type LoggingOptions struct {
+	Writer io.Writer // os.Stderr when nil
+	Format string
+	Level  string
+}
+
+// SetupLogging builds the logger for opts and makes it the process default logger. Nothing in this package sets
+// the default logger on its own, so callers running Run from their own program keep theirs unless they call this.
+func SetupLogging(opts LoggingOptions) (*slog.Logger, error) {
+	w := opts.Writer
+	if w == nil {
+		w = os.Stderr
+	}
+	logger, err := newLogger(w, opts.Format, opts.Level)
+	if err != nil {
+		return nil, err
+	}
 	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// Log fields for an error: the error itself plus, for AWS API errors, the request ID and error code
+// AWS support asks for, the error category and, when access was denied, the missing IAM permission. Extra fields can be appended: slog.Error(msg, append(awsErrorFields(err), "key", v)...)
+func awsErrorFields(err error) []any {
+	fields := []any{"error", err}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.ServiceRequestID() != "" {
+		fields = append(fields, "request_id", respErr.ServiceRequestID())
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		fields = append(fields, "error_code", apiErr.ErrorCode(), "error_category", string(classifyAWSError(err)))
+	}
+	if permission, ok := missingPermission(err); ok {
+		fields = append(fields, "missing_permission", permission)
+	}
+	return fields
 }
 
 type StepAdj struct {
@@ -52,27 +130,51 @@ type CustomMetricSpec struct {
 	MetricName string            `json:"metric_name"`
 	Dimensions map[string]string `json:"dimensions,omitempty"`
 	Statistic  string            `json:"statistic"`
+	Unit       string            `json:"unit,omitempty"`
 }
 
 type TargetTrackingConfig struct {
 	TargetValue                   float64           `json:"target_value"`
 	PredefinedMetricSpecification string            `json:"predefined_metric_specification,omitempty"`
+	ResourceLabel                 string            `json:"resource_label,omitempty"` // required for ALBRequestCountPerTarget
 	CustomMetricSpecification     *CustomMetricSpec `json:"custom_metric_specification,omitempty"`
 	ScaleInCooldown               *int32            `json:"scale_in_cooldown,omitempty"`
 	ScaleOutCooldown              *int32            `json:"scale_out_cooldown,omitempty"`
+	DisableScaleIn                *bool             `json:"disable_scale_in,omitempty"` // scale out only, never scale in
 }
 
 type PolicyDef struct {
-	PolicyName                  string                `json:"policy_name"`
-	PolicyType                  string                `json:"policy_type"` // StepScaling or TargetTrackingScaling
-	MetricName                  string                `json:"metric_name,omitempty"`
-	MetricNamespace             string                `json:"metric_namespace,omitempty"`
-	AdjustmentType              string                `json:"adjustment_type,omitempty"`
-	Cooldown                    *int32                `json:"cooldown,omitempty"`
-	MetricAggregationType       string                `json:"metric_aggregation_type,omitempty"`
-	StepAdjustments             []StepAdj             `json:"step_adjustments,omitempty"`
-	TargetTrackingConfiguration *TargetTrackingConfig `json:"target_tracking_configuration,omitempty"`
-	ScaleDirection              string                `json:"scale_direction,omitempty"` // "in" or "out" (optional, explicit)
+	PolicyName                     string                   `json:"policy_name"`
+	PolicyType                     string                   `json:"policy_type"` // StepScaling, TargetTrackingScaling or PredictiveScaling
+	MetricName                     string                   `json:"metric_name,omitempty"`
+	MetricNamespace                string                   `json:"metric_namespace,omitempty"`
+	AdjustmentType                 string                   `json:"adjustment_type,omitempty"`
+	MinAdjustmentMagnitude         *int32                   `json:"min_adjustment_magnitude,omitempty"` // PercentChangeInCapacity only
+	Cooldown                       *int32                   `json:"cooldown,omitempty"`
+	Period                         *int32                   `json:"period,omitempty"` // alarm period in seconds: 10, 30 or a multiple of 60; defaults to cooldown
+	MetricAggregationType          string                   `json:"metric_aggregation_type,omitempty"`
+	StepAdjustments                []StepAdj                `json:"step_adjustments,omitempty"`
+	TargetTrackingConfiguration    *TargetTrackingConfig    `json:"target_tracking_configuration,omitempty"`
+	ScaleDirection                 string                   `json:"scale_direction,omitempty"`                  // "in" or "out" (optional, explicit)
+	Statistic                      string                   `json:"statistic,omitempty"`                        // alarm statistic, e.g. Average or p99; defaults to --alarm-statistic
+	Dimensions                     map[string]string        `json:"dimensions,omitempty"`                       // alarm dimensions, used verbatim; defaults to the scalable resource's
+	OKActions                      []string                 `json:"ok_actions,omitempty"`                       // alarm OK actions; defaults to --alarm-ok-actions
+	InsufficientDataActions        []string                 `json:"insufficient_data_actions,omitempty"`        // defaults to --alarm-insufficient-data-actions
+	ActionsEnabled                 *bool                    `json:"actions_enabled,omitempty"`                  // whether the alarm fires its actions; defaults to --alarms-enabled
+	Unit                           string                   `json:"unit,omitempty"`                             // alarm metric unit, e.g. Percent; unset matches any unit
+	ManageAlarm                    *bool                    `json:"manage_alarm,omitempty"`                     // whether this tool manages the policy's alarm; defaults to !--no-alarms
+	AnomalyDetection               *AnomalyDetection        `json:"anomaly_detection,omitempty"`                // alarm on the anomaly detection band instead of a static threshold
+	Bidirectional                  bool                     `json:"bidirectional,omitempty"`                    // expand into <name>-out and <name>-in step policies by step sign
+	PredictiveScalingConfiguration *PredictiveScalingConfig `json:"predictive_scaling_configuration,omitempty"` // PredictiveScaling only
+	Priority                       int                      `json:"priority,omitempty"`                         // apply order, lowest first, then by name; unset everywhere keeps input order
+}
+
+// The period of a custom policy's alarm: period when set, otherwise the cooldown
+func (p PolicyDef) alarmPeriod() int32 {
+	if p.Period != nil {
+		return *p.Period
+	}
+	return aws.ToInt32(p.Cooldown)
 }
 
 func getIntWithDefault(arg, name string, defaultValue int) (int, error) {
@@ -81,70 +183,160 @@ func getIntWithDefault(arg, name string, defaultValue int) (int, error) {
 	}
 	i, err := strconv.Atoi(arg)
 	if err != nil {
-		slog.Error("invalid input", "name", name, "value", arg, "error", err)
-		return 0, fmt.Errorf("invalid %s: %v", name, err)
+		// Args can end up in the wrong slot, so never echo the raw value
+		slog.Error("invalid input", "name", name, "value", redact(arg), "error", numErr(err))
+		return 0, fmt.Errorf("invalid %s: %v", name, numErr(err))
 	}
 	return i, nil
 }
 
+// getIntWithDefault for values AWS takes as int32 (capacities and cooldowns), rejecting any outside the int32 range
+// instead of letting the conversion wrap them, e.g. to a negative capacity
+func getInt32WithDefault(arg, name string, defaultValue int32) (int32, error) {
+	i, err := getIntWithDefault(arg, name, int(defaultValue))
+	if err != nil {
+		return 0, err
+	}
+	if i < math.MinInt32 || i > math.MaxInt32 {
+		slog.Error("invalid input", "name", name, "value", redact(arg), "error", "out of the int32 range")
+		return 0, fmt.Errorf("invalid %s: value out of range; must be between %d and %d", name, math.MinInt32, math.MaxInt32)
+	}
+	return int32(i), nil
+}
+
 func getFloatWithDefault(arg, name string, defaultValue float64) (float64, error) {
 	if arg == "" {
 		return defaultValue, nil
 	}
 	f, err := strconv.ParseFloat(arg, 64)
 	if err != nil {
-		slog.Error("invalid input", "name", name, "value", arg, "error", err)
-		return 0, fmt.Errorf("invalid %s: %v", name, err)
+		// Args can end up in the wrong slot, so never echo the raw value
+		slog.Error("invalid input", "name", name, "value", redact(arg), "error", numErr(err))
+		return 0, fmt.Errorf("invalid %s: %v", name, numErr(err))
 	}
 	return f, nil
 }
 
-// Check if scalable target exists and matches desired configuration
-func checkScalableTarget(ctx context.Context, client AASClient, resourceID string, minCap, maxCap int32) (bool, error) {
-	resp, err := client.DescribeScalableTargets(ctx, &aas.DescribeScalableTargetsInput{
-		ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-		ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-		ResourceIds:       []string{resourceID},
+// Describe the scalable target for a resource, following every page of results.
+// Returns nil when the target is not registered.
+func describeScalableTarget(ctx context.Context, client AASClient, res resourceRef) (*aasTypes.ScalableTarget, error) {
+	paginator := aas.NewDescribeScalableTargetsPaginator(client, &aas.DescribeScalableTargetsInput{
+		ServiceNamespace:  res.Namespace,
+		ScalableDimension: res.Dimension,
+		ResourceIds:       []string{res.ID},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(page.ScalableTargets) > 0 {
+			return &page.ScalableTargets[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// Describe all scaling policies for a resource, following every page of results.
+// When policyNames is empty every policy attached to the resource is returned.
+func describeScalingPolicies(ctx context.Context, client AASClient, res resourceRef, policyNames []string) ([]aasTypes.ScalingPolicy, error) {
+	paginator := aas.NewDescribeScalingPoliciesPaginator(client, &aas.DescribeScalingPoliciesInput{
+		ServiceNamespace:  res.Namespace,
+		ScalableDimension: res.Dimension,
+		ResourceId:        aws.String(res.ID),
+		PolicyNames:       policyNames,
 	})
+	var policies []aasTypes.ScalingPolicy
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, page.ScalingPolicies...)
+	}
+	return policies, nil
+}
+
+// Find a single scaling policy by name across all pages. Returns nil when it doesn't exist.
+func findScalingPolicy(ctx context.Context, client AASClient, res resourceRef, policyName string) (*aasTypes.ScalingPolicy, error) {
+	policies, err := describeScalingPolicies(ctx, client, res, []string{policyName})
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		if aws.ToString(policies[i].PolicyName) == policyName {
+			return &policies[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Check if scalable target exists and matches desired configuration, including its suspended state
+func checkScalableTarget(ctx context.Context, client AASClient, res resourceRef, minCap, maxCap int32, suspended *aasTypes.SuspendedState) (bool, error) {
+	target, err := describeScalableTarget(ctx, client, res)
 	if err != nil {
-		return false, fmt.Errorf("failed to describe scalable target: %v", err)
+		return false, fmt.Errorf("failed to describe scalable target: %w", err)
 	}
 
-	if len(resp.ScalableTargets) == 0 {
+	if target == nil {
 		return false, nil
 	}
 
-	target := resp.ScalableTargets[0]
-	return *target.MinCapacity == minCap && *target.MaxCapacity == maxCap, nil
+	return *target.MinCapacity == minCap && *target.MaxCapacity == maxCap && len(compareSuspendedState(target.SuspendedState, suspended)) == 0, nil
+}
+
+// The suspended state to register the target with, from the --suspend-* flags
+func (r *runner) suspendedState() *aasTypes.SuspendedState {
+	return &aasTypes.SuspendedState{
+		DynamicScalingInSuspended:  aws.Bool(r.cfg.SuspendScaleIn),
+		DynamicScalingOutSuspended: aws.Bool(r.cfg.SuspendScaleOut),
+		ScheduledScalingSuspended:  aws.Bool(r.cfg.SuspendScheduled),
+	}
+}
+
+// Compare a target's suspended state with the desired one; AWS reports a target that was never suspended
+// without a SuspendedState, which matches nothing suspended
+func compareSuspendedState(existing, desired *aasTypes.SuspendedState) []fieldDiff {
+	if existing == nil {
+		existing = &aasTypes.SuspendedState{}
+	}
+	if desired == nil {
+		desired = &aasTypes.SuspendedState{}
+	}
+	var diffs []fieldDiff
+	for _, f := range []struct {
+		field             string
+		existing, desired *bool
+	}{
+		{"DynamicScalingInSuspended", existing.DynamicScalingInSuspended, desired.DynamicScalingInSuspended},
+		{"DynamicScalingOutSuspended", existing.DynamicScalingOutSuspended, desired.DynamicScalingOutSuspended},
+		{"ScheduledScalingSuspended", existing.ScheduledScalingSuspended, desired.ScheduledScalingSuspended},
+	} {
+		if aws.ToBool(f.existing) != aws.ToBool(f.desired) {
+			diffs = append(diffs, fieldDiff{Field: f.field, Existing: fmt.Sprint(aws.ToBool(f.existing)), Desired: fmt.Sprint(aws.ToBool(f.desired))})
+		}
+	}
+	return diffs
 }
 
 // Check if scalable target exists (without checking capacity values)
-func scalableTargetExists(ctx context.Context, client AASClient, resourceID string) (bool, error) {
-	resp, err := client.DescribeScalableTargets(ctx, &aas.DescribeScalableTargetsInput{
-		ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-		ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-		ResourceIds:       []string{resourceID},
-	})
+func scalableTargetExists(ctx context.Context, client AASClient, res resourceRef) (bool, error) {
+	target, err := describeScalableTarget(ctx, client, res)
 	if err != nil {
-		return false, fmt.Errorf("failed to describe scalable target: %v", err)
+		return false, fmt.Errorf("failed to describe scalable target: %w", err)
 	}
 
-	return len(resp.ScalableTargets) > 0, nil
+	return target != nil, nil
 }
 
 // Check if scaling policy exists and matches desired configuration
-func checkScalingPolicy(ctx context.Context, client AASClient, resourceID, policyName string) (bool, error) {
-	resp, err := client.DescribeScalingPolicies(ctx, &aas.DescribeScalingPoliciesInput{
-		ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-		ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-		ResourceId:        aws.String(resourceID),
-		PolicyNames:       []string{policyName},
-	})
+func checkScalingPolicy(ctx context.Context, client AASClient, res resourceRef, policyName string) (bool, error) {
+	policy, err := findScalingPolicy(ctx, client, res, policyName)
 	if err != nil {
-		return false, fmt.Errorf("failed to describe scaling policy: %v", err)
+		return false, fmt.Errorf("failed to describe scaling policy: %w", err)
 	}
 
-	return len(resp.ScalingPolicies) > 0, nil
+	return policy != nil, nil
 }
 
 // Check if CloudWatch alarm exists
@@ -153,748 +345,2054 @@ func checkCloudWatchAlarm(ctx context.Context, client CWClient, alarmName string
 		AlarmNames: []string{alarmName},
 	})
 	if err != nil {
-		return false, fmt.Errorf("failed to describe alarm: %v", err)
+		return false, fmt.Errorf("failed to describe alarm: %w", err)
 	}
 
 	return len(resp.MetricAlarms) > 0, nil
 }
 
-// Compare existing scaling policy with desired configuration
-func compareScalingPolicy(ctx context.Context, client AASClient, resourceID, policyName string, desired *aas.PutScalingPolicyInput) (bool, error) {
-	resp, err := client.DescribeScalingPolicies(ctx, &aas.DescribeScalingPoliciesInput{
-		ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-		ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-		ResourceId:        aws.String(resourceID),
-		PolicyNames:       []string{policyName},
+// DescribeAlarms and DeleteAlarms take at most this many alarm names per call
+const maxAlarmNamesPerCall = 100
+
+// Return the alarms among alarmNames that exist, in their given order, describing them in batches of
+// maxAlarmNamesPerCall names. A failed batch does not stop the rest: the alarms found in the others are returned
+// along with the failures joined.
+func existingAlarmNames(ctx context.Context, client CWClient, alarmNames []string) ([]string, error) {
+	found := map[string]bool{}
+	var errs []error
+	for batch := range slices.Chunk(alarmNames, maxAlarmNamesPerCall) {
+		// Stop at cancellation rather than collecting one failure per remaining batch
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Join(append(errs, err)...)
+		}
+		input := &cw.DescribeAlarmsInput{AlarmNames: batch, MaxRecords: aws.Int32(maxAlarmNamesPerCall)}
+		for {
+			resp, err := client.DescribeAlarms(ctx, input)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to describe alarms: %w", err))
+				break
+			}
+			for _, alarm := range resp.MetricAlarms {
+				if name := aws.ToString(alarm.AlarmName); slices.Contains(batch, name) {
+					found[name] = true
+				}
+			}
+			if resp.NextToken == nil {
+				break
+			}
+			input.NextToken = resp.NextToken
+		}
+	}
+
+	var existing []string
+	for _, name := range alarmNames {
+		if found[name] {
+			existing = append(existing, name)
+		}
+	}
+	return existing, errors.Join(errs...)
+}
+
+// Delete alarmNames in batches of maxAlarmNamesPerCall, attempting every batch and returning their failures
+// joined. Each call goes through the SDK retryer, which backs off when CloudWatch throttles.
+func deleteAlarms(ctx context.Context, client CWClient, alarmNames []string) error {
+	var errs []error
+	for batch := range slices.Chunk(alarmNames, maxAlarmNamesPerCall) {
+		// Stop at cancellation rather than collecting one failure per remaining batch
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if _, err := client.DeleteAlarms(ctx, &cw.DeleteAlarmsInput{AlarmNames: batch}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Fetch a single metric alarm by name, returning nil if it does not exist
+func describeAlarm(ctx context.Context, client CWClient, alarmName string) (*cwTypes.MetricAlarm, error) {
+	resp, err := client.DescribeAlarms(ctx, &cw.DescribeAlarmsInput{
+		AlarmNames: []string{alarmName},
 	})
 	if err != nil {
-		return false, fmt.Errorf("failed to describe scaling policy: %v", err)
+		return nil, err
+	}
+	for _, alarm := range resp.MetricAlarms {
+		if aws.ToString(alarm.AlarmName) == alarmName {
+			return &alarm, nil
+		}
+	}
+	return nil, nil
+}
+
+// Check whether any metric alarm already triggers the given scaling policy
+func alarmExistsForPolicy(ctx context.Context, client CWClient, policyARN string) (bool, error) {
+	names, err := alarmsForPolicy(ctx, client, policyARN)
+	return len(names) > 0, err
+}
+
+// Names of the metric alarms whose actions trigger the policy
+func alarmsForPolicy(ctx context.Context, client CWClient, policyARN string) ([]string, error) {
+	input := &cw.DescribeAlarmsInput{
+		ActionPrefix: aws.String(policyARN),
+		AlarmTypes:   []cwTypes.AlarmType{cwTypes.AlarmTypeMetricAlarm},
+	}
+	var names []string
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := client.DescribeAlarms(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		// ActionPrefix is a prefix match, so confirm the exact ARN
+		for _, alarm := range resp.MetricAlarms {
+			if slices.Contains(alarm.AlarmActions, policyARN) {
+				names = append(names, aws.ToString(alarm.AlarmName))
+			}
+		}
+		if resp.NextToken == nil {
+			return names, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// Names of metric alarms whose actions trigger any scaling policy on the resource, including policies that no
+// longer exist. This finds the AWS-managed TargetTracking-* alarms, whose names cannot be predicted.
+func alarmsForResourcePolicies(ctx context.Context, client CWClient, res resourceRef) ([]string, error) {
+	// Policy ARNs end in :resource/<namespace>/<resource ID>:policyName/<name>
+	marker := fmt.Sprintf(":resource/%s/%s:policyName/", res.Namespace, res.ID)
+	input := &cw.DescribeAlarmsInput{AlarmTypes: []cwTypes.AlarmType{cwTypes.AlarmTypeMetricAlarm}}
+	var names []string
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := client.DescribeAlarms(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, alarm := range resp.MetricAlarms {
+			if slices.ContainsFunc(alarm.AlarmActions, func(action string) bool { return strings.Contains(action, marker) }) {
+				names = append(names, aws.ToString(alarm.AlarmName))
+			}
+		}
+		if resp.NextToken == nil {
+			return names, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// Compare an existing alarm with the desired configuration and return every differing field
+func compareAlarm(existing *cwTypes.MetricAlarm, desired *cw.PutMetricAlarmInput) []fieldDiff {
+	var diffs []fieldDiff
+	add := func(field, existing, desired string) {
+		diffs = append(diffs, fieldDiff{Field: field, Existing: existing, Desired: desired})
+	}
+
+	if aws.ToString(existing.Namespace) != aws.ToString(desired.Namespace) {
+		add("Namespace", ptrString(existing.Namespace), ptrString(desired.Namespace))
+	}
+	if aws.ToString(existing.MetricName) != aws.ToString(desired.MetricName) {
+		add("MetricName", ptrString(existing.MetricName), ptrString(desired.MetricName))
+	}
+	if aws.ToFloat64(existing.Threshold) != aws.ToFloat64(desired.Threshold) {
+		add("Threshold", ptrString(existing.Threshold), ptrString(desired.Threshold))
+	}
+	if aws.ToInt32(existing.Period) != aws.ToInt32(desired.Period) {
+		add("Period", ptrString(existing.Period), ptrString(desired.Period))
+	}
+	if aws.ToInt32(existing.EvaluationPeriods) != aws.ToInt32(desired.EvaluationPeriods) {
+		add("EvaluationPeriods", ptrString(existing.EvaluationPeriods), ptrString(desired.EvaluationPeriods))
+	}
+	if existing.ComparisonOperator != desired.ComparisonOperator {
+		add("ComparisonOperator", string(existing.ComparisonOperator), string(desired.ComparisonOperator))
+	}
+	if existing.Statistic != desired.Statistic {
+		add("Statistic", string(existing.Statistic), string(desired.Statistic))
+	}
+	if aws.ToString(existing.ExtendedStatistic) != aws.ToString(desired.ExtendedStatistic) {
+		add("ExtendedStatistic", ptrString(existing.ExtendedStatistic), ptrString(desired.ExtendedStatistic))
+	}
+	if existing.Unit != desired.Unit {
+		add("Unit", string(existing.Unit), string(desired.Unit))
+	}
+
+	// AWS treats an unset ActionsEnabled as true
+	if desired.ActionsEnabled != nil && aws.ToBool(desired.ActionsEnabled) != (existing.ActionsEnabled == nil || *existing.ActionsEnabled) {
+		add("ActionsEnabled", ptrString(existing.ActionsEnabled), ptrString(desired.ActionsEnabled))
+	}
+
+	if existingDims, desiredDims := alarmDimensionsString(existing.Dimensions), alarmDimensionsString(desired.Dimensions); existingDims != desiredDims {
+		add("Dimensions", existingDims, desiredDims)
+	}
+	if aws.ToString(existing.ThresholdMetricId) != aws.ToString(desired.ThresholdMetricId) {
+		add("ThresholdMetricId", ptrString(existing.ThresholdMetricId), ptrString(desired.ThresholdMetricId))
+	}
+	if existingMetrics, desiredMetrics := alarmMetricsString(existing.Metrics), alarmMetricsString(desired.Metrics); existingMetrics != desiredMetrics {
+		add("Metrics", existingMetrics, desiredMetrics)
+	}
+
+	// Compare actions as sets; AWS does not preserve their order
+	for _, actions := range []struct {
+		field             string
+		existing, desired []string
+	}{
+		{"AlarmActions", existing.AlarmActions, desired.AlarmActions},
+		{"OKActions", existing.OKActions, desired.OKActions},
+		{"InsufficientDataActions", existing.InsufficientDataActions, desired.InsufficientDataActions},
+	} {
+		existingActions := slices.Clone(actions.existing)
+		desiredActions := slices.Clone(actions.desired)
+		slices.Sort(existingActions)
+		slices.Sort(desiredActions)
+		if !slices.Equal(existingActions, desiredActions) {
+			add(actions.field, "["+strings.Join(existingActions, ",")+"]", "["+strings.Join(desiredActions, ",")+"]")
+		}
+	}
+
+	return diffs
+}
+
+// The ALARM actions of a created alarm: its scaling policy, then --extra-alarm-actions, without duplicates
+func (r *runner) alarmActions(policyARN string) []string {
+	actions := []string{policyARN}
+	for _, action := range r.cfg.ExtraAlarmActions {
+		if !slices.Contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// Set the alarm statistic; percentiles such as p99 go in ExtendedStatistic, everything else in Statistic
+func setAlarmStatistic(in *cw.PutMetricAlarmInput, stat string) {
+	if stat == "" {
+		stat = string(cwTypes.StatisticAverage)
+	}
+	if percentilePattern.MatchString(stat) {
+		in.ExtendedStatistic = aws.String(stat)
+		return
+	}
+	in.Statistic = cwTypes.Statistic(stat)
+}
+
+// fieldDiff describes a single field that differs between AWS and the desired configuration
+type fieldDiff struct {
+	Field    string
+	Existing string
+	Desired  string
+}
+
+func (d fieldDiff) String() string {
+	return fmt.Sprintf("%s: %s -> %s", d.Field, d.Existing, d.Desired)
+}
+
+// Format an optional value for diff output
+func ptrString[T any](p *T) string {
+	if p == nil {
+		return "<unset>"
+	}
+	return fmt.Sprint(*p)
+}
+
+// Format alarm dimensions as a sorted name=value list for diff output
+func alarmDimensionsString(dims []cwTypes.Dimension) string {
+	pairs := make([]string, 0, len(dims))
+	for _, dim := range dims {
+		pairs = append(pairs, aws.ToString(dim.Name)+"="+aws.ToString(dim.Value))
+	}
+	sort.Strings(pairs)
+	return "[" + strings.Join(pairs, ",") + "]"
+}
+
+// Convert a dimensions map into CloudWatch alarm dimensions, sorted by name so requests are stable
+func cwDimensions(m map[string]string) []cwTypes.Dimension {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	dims := make([]cwTypes.Dimension, 0, len(names))
+	for _, name := range names {
+		dims = append(dims, cwTypes.Dimension{Name: aws.String(name), Value: aws.String(m[name])})
+	}
+	return dims
+}
+
+// Convert a dimensions map into custom metric dimensions for a target tracking policy, sorted by name so
+// requests and diffs are stable
+func metricDimensions(m map[string]string) []aasTypes.MetricDimension {
+	if len(m) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	dims := make([]aasTypes.MetricDimension, 0, len(names))
+	for _, name := range names {
+		dims = append(dims, aasTypes.MetricDimension{Name: aws.String(name), Value: aws.String(m[name])})
+	}
+	return dims
+}
 
-	if len(resp.ScalingPolicies) == 0 {
-		return false, nil // Policy doesn't exist
+// Format metric dimensions as a stable `name=value,...` string for diff output
+func dimensionsString(dims []aasTypes.MetricDimension) string {
+	pairs := make([]string, 0, len(dims))
+	for _, dim := range dims {
+		pairs = append(pairs, aws.ToString(dim.Name)+"="+aws.ToString(dim.Value))
 	}
+	sort.Strings(pairs)
+	return "[" + strings.Join(pairs, ",") + "]"
+}
 
-	existing := resp.ScalingPolicies[0]
+// Compare an existing scaling policy with the desired configuration and return every differing field.
+// An empty result means the policy is up to date.
+func diffScalingPolicy(existing *aasTypes.ScalingPolicy, desired *aas.PutScalingPolicyInput) []fieldDiff {
+	var diffs []fieldDiff
+	add := func(field, existing, desired string) {
+		diffs = append(diffs, fieldDiff{Field: field, Existing: existing, Desired: desired})
+	}
 
-	// Compare policy type
+	// Compare policy type; the rest of the configuration is meaningless if it changed
 	if existing.PolicyType != desired.PolicyType {
-		return false, nil
+		add("PolicyType", string(existing.PolicyType), string(desired.PolicyType))
+		return diffs
 	}
 
 	// Compare based on policy type
 	switch desired.PolicyType {
 	case aasTypes.PolicyTypeStepScaling:
+		const prefix = "StepScalingPolicyConfiguration"
 		if existing.StepScalingPolicyConfiguration == nil || desired.StepScalingPolicyConfiguration == nil {
-			return false, nil
+			add(prefix, "<unset>", "<set>")
+			return diffs
 		}
 
 		existingStep := existing.StepScalingPolicyConfiguration
 		desiredStep := desired.StepScalingPolicyConfiguration
 
-		if existingStep.AdjustmentType != desiredStep.AdjustmentType ||
-			existingStep.MetricAggregationType != desiredStep.MetricAggregationType {
-			return false, nil
+		if existingStep.AdjustmentType != desiredStep.AdjustmentType {
+			add(prefix+".AdjustmentType", string(existingStep.AdjustmentType), string(desiredStep.AdjustmentType))
+		}
+		if existingStep.MetricAggregationType != desiredStep.MetricAggregationType {
+			add(prefix+".MetricAggregationType", string(existingStep.MetricAggregationType), string(desiredStep.MetricAggregationType))
 		}
 
 		// Compare cooldown (handle nil cases)
-		if (existingStep.Cooldown == nil) != (desiredStep.Cooldown == nil) {
-			return false, nil
+		if (existingStep.Cooldown == nil) != (desiredStep.Cooldown == nil) ||
+			(existingStep.Cooldown != nil && *existingStep.Cooldown != *desiredStep.Cooldown) {
+			add(prefix+".Cooldown", ptrString(existingStep.Cooldown), ptrString(desiredStep.Cooldown))
 		}
-		if existingStep.Cooldown != nil && desiredStep.Cooldown != nil && *existingStep.Cooldown != *desiredStep.Cooldown {
-			return false, nil
+		if aws.ToInt32(existingStep.MinAdjustmentMagnitude) != aws.ToInt32(desiredStep.MinAdjustmentMagnitude) {
+			add(prefix+".MinAdjustmentMagnitude", ptrString(existingStep.MinAdjustmentMagnitude), ptrString(desiredStep.MinAdjustmentMagnitude))
 		}
 
 		// Compare step adjustments
 		if len(existingStep.StepAdjustments) != len(desiredStep.StepAdjustments) {
-			return false, nil
+			add(prefix+".StepAdjustments", fmt.Sprintf("%d steps", len(existingStep.StepAdjustments)), fmt.Sprintf("%d steps", len(desiredStep.StepAdjustments)))
+			return diffs
 		}
 
 		for i, existingAdj := range existingStep.StepAdjustments {
 			desiredAdj := desiredStep.StepAdjustments[i]
+			stepPrefix := fmt.Sprintf("%s.StepAdjustments[%d]", prefix, i)
 
 			// Compare bounds (handle nil cases)
 			if (existingAdj.MetricIntervalLowerBound == nil) != (desiredAdj.MetricIntervalLowerBound == nil) ||
-				(existingAdj.MetricIntervalUpperBound == nil) != (desiredAdj.MetricIntervalUpperBound == nil) {
-				return false, nil
+				(existingAdj.MetricIntervalLowerBound != nil && *existingAdj.MetricIntervalLowerBound != *desiredAdj.MetricIntervalLowerBound) {
+				add(stepPrefix+".MetricIntervalLowerBound", ptrString(existingAdj.MetricIntervalLowerBound), ptrString(desiredAdj.MetricIntervalLowerBound))
 			}
 
-			if existingAdj.MetricIntervalLowerBound != nil && desiredAdj.MetricIntervalLowerBound != nil &&
-				*existingAdj.MetricIntervalLowerBound != *desiredAdj.MetricIntervalLowerBound {
-				return false, nil
+			if (existingAdj.MetricIntervalUpperBound == nil) != (desiredAdj.MetricIntervalUpperBound == nil) ||
+				(existingAdj.MetricIntervalUpperBound != nil && *existingAdj.MetricIntervalUpperBound != *desiredAdj.MetricIntervalUpperBound) {
+				add(stepPrefix+".MetricIntervalUpperBound", ptrString(existingAdj.MetricIntervalUpperBound), ptrString(desiredAdj.MetricIntervalUpperBound))
 			}
 
-			if existingAdj.MetricIntervalUpperBound != nil && desiredAdj.MetricIntervalUpperBound != nil &&
-				*existingAdj.MetricIntervalUpperBound != *desiredAdj.MetricIntervalUpperBound {
-				return false, nil
-			}
-
-			if *existingAdj.ScalingAdjustment != *desiredAdj.ScalingAdjustment {
-				return false, nil
+			if aws.ToInt32(existingAdj.ScalingAdjustment) != aws.ToInt32(desiredAdj.ScalingAdjustment) {
+				add(stepPrefix+".ScalingAdjustment", ptrString(existingAdj.ScalingAdjustment), ptrString(desiredAdj.ScalingAdjustment))
 			}
 		}
 
 	case aasTypes.PolicyTypeTargetTrackingScaling:
+		const prefix = "TargetTrackingScalingPolicyConfiguration"
 		if existing.TargetTrackingScalingPolicyConfiguration == nil || desired.TargetTrackingScalingPolicyConfiguration == nil {
-			return false, nil
+			add(prefix, "<unset>", "<set>")
+			return diffs
 		}
 
 		existingTT := existing.TargetTrackingScalingPolicyConfiguration
 		desiredTT := desired.TargetTrackingScalingPolicyConfiguration
 
-		if *existingTT.TargetValue != *desiredTT.TargetValue {
-			return false, nil
+		if aws.ToFloat64(existingTT.TargetValue) != aws.ToFloat64(desiredTT.TargetValue) {
+			add(prefix+".TargetValue", ptrString(existingTT.TargetValue), ptrString(desiredTT.TargetValue))
 		}
 
-		// Compare cooldowns (handle nil cases)
-		if (existingTT.ScaleInCooldown == nil) != (desiredTT.ScaleInCooldown == nil) ||
-			(existingTT.ScaleOutCooldown == nil) != (desiredTT.ScaleOutCooldown == nil) {
-			return false, nil
+		// AWS reports an unset DisableScaleIn as false
+		if aws.ToBool(existingTT.DisableScaleIn) != aws.ToBool(desiredTT.DisableScaleIn) {
+			add(prefix+".DisableScaleIn", fmt.Sprint(aws.ToBool(existingTT.DisableScaleIn)), fmt.Sprint(aws.ToBool(desiredTT.DisableScaleIn)))
 		}
 
-		if existingTT.ScaleInCooldown != nil && desiredTT.ScaleInCooldown != nil &&
-			*existingTT.ScaleInCooldown != *desiredTT.ScaleInCooldown {
-			return false, nil
+		// Compare cooldowns (handle nil cases)
+		if (existingTT.ScaleInCooldown == nil) != (desiredTT.ScaleInCooldown == nil) ||
+			(existingTT.ScaleInCooldown != nil && *existingTT.ScaleInCooldown != *desiredTT.ScaleInCooldown) {
+			add(prefix+".ScaleInCooldown", ptrString(existingTT.ScaleInCooldown), ptrString(desiredTT.ScaleInCooldown))
 		}
 
-		if existingTT.ScaleOutCooldown != nil && desiredTT.ScaleOutCooldown != nil &&
-			*existingTT.ScaleOutCooldown != *desiredTT.ScaleOutCooldown {
-			return false, nil
+		if (existingTT.ScaleOutCooldown == nil) != (desiredTT.ScaleOutCooldown == nil) ||
+			(existingTT.ScaleOutCooldown != nil && *existingTT.ScaleOutCooldown != *desiredTT.ScaleOutCooldown) {
+			add(prefix+".ScaleOutCooldown", ptrString(existingTT.ScaleOutCooldown), ptrString(desiredTT.ScaleOutCooldown))
 		}
 
 		// Compare metric specifications
 		if (existingTT.PredefinedMetricSpecification == nil) != (desiredTT.PredefinedMetricSpecification == nil) {
-			return false, nil
-		}
-
-		if existingTT.PredefinedMetricSpecification != nil && desiredTT.PredefinedMetricSpecification != nil {
-			if existingTT.PredefinedMetricSpecification.PredefinedMetricType != desiredTT.PredefinedMetricSpecification.PredefinedMetricType {
-				return false, nil
+			add(prefix+".PredefinedMetricSpecification", setString(existingTT.PredefinedMetricSpecification != nil), setString(desiredTT.PredefinedMetricSpecification != nil))
+		} else if existingTT.PredefinedMetricSpecification != nil {
+			existingType := existingTT.PredefinedMetricSpecification.PredefinedMetricType
+			desiredType := desiredTT.PredefinedMetricSpecification.PredefinedMetricType
+			if existingType != desiredType {
+				add(prefix+".PredefinedMetricSpecification.PredefinedMetricType", string(existingType), string(desiredType))
+			}
+			existingLabel := existingTT.PredefinedMetricSpecification.ResourceLabel
+			desiredLabel := desiredTT.PredefinedMetricSpecification.ResourceLabel
+			if aws.ToString(existingLabel) != aws.ToString(desiredLabel) {
+				add(prefix+".PredefinedMetricSpecification.ResourceLabel", ptrString(existingLabel), ptrString(desiredLabel))
 			}
 		}
 
 		if (existingTT.CustomizedMetricSpecification == nil) != (desiredTT.CustomizedMetricSpecification == nil) {
-			return false, nil
-		}
-
-		if existingTT.CustomizedMetricSpecification != nil && desiredTT.CustomizedMetricSpecification != nil {
+			add(prefix+".CustomizedMetricSpecification", setString(existingTT.CustomizedMetricSpecification != nil), setString(desiredTT.CustomizedMetricSpecification != nil))
+		} else if existingTT.CustomizedMetricSpecification != nil {
+			const customPrefix = prefix + ".CustomizedMetricSpecification"
 			existingCustom := existingTT.CustomizedMetricSpecification
 			desiredCustom := desiredTT.CustomizedMetricSpecification
 
-			if *existingCustom.MetricName != *desiredCustom.MetricName ||
-				*existingCustom.Namespace != *desiredCustom.Namespace ||
-				existingCustom.Statistic != desiredCustom.Statistic {
-				return false, nil
+			if aws.ToString(existingCustom.MetricName) != aws.ToString(desiredCustom.MetricName) {
+				add(customPrefix+".MetricName", ptrString(existingCustom.MetricName), ptrString(desiredCustom.MetricName))
 			}
-
-			// Compare dimensions
-			if len(existingCustom.Dimensions) != len(desiredCustom.Dimensions) {
-				return false, nil
+			if aws.ToString(existingCustom.Namespace) != aws.ToString(desiredCustom.Namespace) {
+				add(customPrefix+".Namespace", ptrString(existingCustom.Namespace), ptrString(desiredCustom.Namespace))
 			}
-
-			existingDims := make(map[string]string)
-			for _, dim := range existingCustom.Dimensions {
-				existingDims[*dim.Name] = *dim.Value
+			if existingCustom.Statistic != desiredCustom.Statistic {
+				add(customPrefix+".Statistic", string(existingCustom.Statistic), string(desiredCustom.Statistic))
+			}
+			if aws.ToString(existingCustom.Unit) != aws.ToString(desiredCustom.Unit) {
+				add(customPrefix+".Unit", ptrString(existingCustom.Unit), ptrString(desiredCustom.Unit))
 			}
 
-			for _, dim := range desiredCustom.Dimensions {
-				if existingDims[*dim.Name] != *dim.Value {
-					return false, nil
+			// Compare dimensions
+			dimensionsDiffer := len(existingCustom.Dimensions) != len(desiredCustom.Dimensions)
+			if !dimensionsDiffer {
+				existingDims := make(map[string]string)
+				for _, dim := range existingCustom.Dimensions {
+					existingDims[aws.ToString(dim.Name)] = aws.ToString(dim.Value)
 				}
+				for _, dim := range desiredCustom.Dimensions {
+					if value, ok := existingDims[aws.ToString(dim.Name)]; !ok || value != aws.ToString(dim.Value) {
+						dimensionsDiffer = true
+						break
+					}
+				}
+			}
+			if dimensionsDiffer {
+				add(customPrefix+".Dimensions", dimensionsString(existingCustom.Dimensions), dimensionsString(desiredCustom.Dimensions))
 			}
 		}
+
+	case aasTypes.PolicyTypePredictiveScaling:
+		diffPredictiveScaling(existing.PredictiveScalingPolicyConfiguration, desired.PredictiveScalingPolicyConfiguration, add)
 	}
 
-	return true, nil // Configuration matches
+	return diffs
 }
 
-// Helper function to deduplicate string slices
-func deduplicate(slice []string) []string {
-	seen := make(map[string]bool)
-	result := []string{}
-	for _, item := range slice {
-		if !seen[item] {
-			seen[item] = true
-			result = append(result, item)
-		}
+// Format whether an optional block is present for diff output
+func setString(set bool) string {
+	if set {
+		return "<set>"
 	}
-	return result
+	return "<unset>"
 }
 
-func main() {
-	// we expect 14 args after program name
-	if len(os.Args) != 17 {
-		slog.Error("invalid number of arguments", "expected", 16, "got", len(os.Args)-1)
-		os.Exit(1)
+// Field paths of a list of diffs, e.g. "StepScalingPolicyConfiguration.Cooldown"
+func diffFieldNames(diffs []fieldDiff) []string {
+	fields := make([]string, 0, len(diffs))
+	for _, d := range diffs {
+		fields = append(fields, d.Field)
 	}
+	return fields
+}
 
-	keyID := os.Args[1]
-	keySecret := os.Args[2]
-	region := os.Args[3]
-	cluster := os.Args[4]
-	service := os.Args[5]
-	enabled := os.Args[6] == "true"
-
-	minCap, err := getIntWithDefault(os.Args[7], "min-capacity", 1)
-	if err != nil {
-		os.Exit(1)
-	}
-	maxCap, err := getIntWithDefault(os.Args[8], "max-capacity", 10)
-	if err != nil {
-		os.Exit(1)
-	}
-	outCd, err := getIntWithDefault(os.Args[9], "scale-out-cooldown", 300)
+// Look up a scaling policy and return the field paths that differ from the desired configuration.
+// exists is false when the policy doesn't exist yet, in which case fields is empty.
+func scalingPolicyChanges(ctx context.Context, client AASClient, res resourceRef, policyName string, desired *aas.PutScalingPolicyInput) (fields []string, exists bool, err error) {
+	existing, err := findScalingPolicy(ctx, client, res, policyName)
 	if err != nil {
-		os.Exit(1)
-	}
-	inCd, err := getIntWithDefault(os.Args[10], "scale-in-cooldown", 300)
-	if err != nil {
-		os.Exit(1)
+		return nil, false, fmt.Errorf("failed to describe scaling policy: %w", err)
 	}
 
-	// Convert to int32 for AWS SDK
-	minCap32 := int32(minCap)
-	maxCap32 := int32(maxCap)
-	outCd32 := int32(outCd)
-	inCd32 := int32(inCd)
-
-	targetCPUOut, err := getFloatWithDefault(os.Args[11], "target-cpu-utilization-out", 75.0)
-	if err != nil {
-		os.Exit(1)
-	}
-	targetCPUIn, err := getFloatWithDefault(os.Args[12], "target-cpu-utilization-in", 65.0)
-	if err != nil {
-		os.Exit(1)
-	}
-	targetMemOut, err := getFloatWithDefault(os.Args[13], "target-memory-utilization-out", 80.0)
-	if err != nil {
-		os.Exit(1)
+	if existing == nil {
+		return nil, false, nil // Policy doesn't exist
 	}
-	targetMemIn, err := getFloatWithDefault(os.Args[14], "target-memory-utilization-in", 70.0)
-	if err != nil {
-		os.Exit(1)
-	}
-	defaultPoliciesRaw := os.Args[15]
-	policiesRaw := os.Args[16]
 
-	// AWS config
-	var cfg aws.Config
-	if keyID != "" && keySecret != "" {
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(region),
-			config.WithCredentialsProvider(
-				credentials.NewStaticCredentialsProvider(keyID, keySecret, ""),
-			),
-		)
-	} else {
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(region),
-		)
-	}
+	return diffFieldNames(diffScalingPolicy(existing, desired)), true, nil
+}
+
+// Compare existing scaling policy with desired configuration
+func compareScalingPolicy(ctx context.Context, client AASClient, res resourceRef, policyName string, desired *aas.PutScalingPolicyInput) (bool, error) {
+	fields, exists, err := scalingPolicyChanges(ctx, client, res, policyName, desired)
 	if err != nil {
-		slog.Error("loading AWS config", "error", err)
-		os.Exit(1)
+		return false, err
 	}
 
-	aasClient := aas.NewFromConfig(cfg)
-	cwClient := cw.NewFromConfig(cfg)
-	resourceID := fmt.Sprintf("service/%s/%s", cluster, service)
-
-	// Check if scalable target exists and matches desired configuration
-	if enabled {
-		exists, err := checkScalableTarget(context.TODO(), aasClient, resourceID, minCap32, maxCap32)
-		if err != nil {
-			slog.Error("failed to check scalable target", "error", err)
-			os.Exit(1)
-		}
-
-		if !exists {
-			slog.Info("registering scalable target", "resource", resourceID)
-			if _, err := aasClient.RegisterScalableTarget(context.TODO(), &aas.RegisterScalableTargetInput{
-				ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-				ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-				ResourceId:        aws.String(resourceID),
-				MinCapacity:       aws.Int32(minCap32),
-				MaxCapacity:       aws.Int32(maxCap32),
-			}); err != nil {
-				slog.Error("failed to register scalable target", "error", err)
-				os.Exit(1)
-			}
-		} else {
-			slog.Info("scalable target already exists with desired configuration", "resource", resourceID)
-		}
-	} else {
-		// cleanup: delete alarms, policies, then deregister
-		slog.Info("disabling auto-scaling", "resource", resourceID, "cluster", cluster, "service", service)
+	return exists && len(fields) == 0, nil // Configuration matches
+}
 
-		// First check if scalable target exists to determine if auto-scaling was ever enabled
-		exists, err := scalableTargetExists(context.TODO(), aasClient, resourceID)
-		if err != nil {
-			slog.Error("failed to check scalable target", "error", err)
-			os.Exit(1)
-		}
-		if !exists {
-			slog.Info("auto-scaling was not enabled for this service", "cluster", cluster, "service", service)
-			return
+// Build the PutScalingPolicy request for a policy definition
+func buildPolicyInput(p PolicyDef, res resourceRef) (*aas.PutScalingPolicyInput, error) {
+	switch p.PolicyType {
+	case "StepScaling":
+		// AWS rejects overlapping or gapped steps with a vague error, so catch them here
+		if err := validateStepAdjustments(p.StepAdjustments); err != nil {
+			return nil, err
 		}
 
-		// Parse custom policies to get all policy names
-		var policies []PolicyDef
-		if policiesRaw != "" {
-			slog.Info("parsing custom scaling policies for cleanup")
-			if err := json.Unmarshal([]byte(policiesRaw), &policies); err != nil {
-				slog.Error("invalid scaling-policies JSON during cleanup", "error", err)
-				os.Exit(1)
-			}
-		} else if defaultPoliciesRaw != "" {
-			slog.Info("parsing default scaling policies for cleanup")
-			if err := json.Unmarshal([]byte(defaultPoliciesRaw), &policies); err != nil {
-				slog.Error("invalid default-policies JSON during cleanup", "error", err)
-				os.Exit(1)
-			}
-		}
+		return &aas.PutScalingPolicyInput{
+			ServiceNamespace:  res.Namespace,
+			ScalableDimension: res.Dimension,
+			ResourceId:        aws.String(res.ID),
+			PolicyName:        aws.String(p.PolicyName),
+			PolicyType:        aasTypes.PolicyTypeStepScaling,
+			StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{
+				AdjustmentType:         aasTypes.AdjustmentType(p.AdjustmentType),
+				Cooldown:               p.Cooldown,
+				MetricAggregationType:  aasTypes.MetricAggregationType(p.MetricAggregationType),
+				MinAdjustmentMagnitude: p.MinAdjustmentMagnitude,
+				StepAdjustments:        stepAdjustments(p.StepAdjustments),
+			},
+		}, nil
 
-		// Collect all alarm names to delete
-		alarmNames := []string{
-			// Default alarms
-			fmt.Sprintf("%s-%s-cpu-high", cluster, service),
-			fmt.Sprintf("%s-%s-cpu-low", cluster, service),
-			fmt.Sprintf("%s-%s-mem-high", cluster, service),
-			fmt.Sprintf("%s-%s-mem-low", cluster, service),
+	case "TargetTrackingScaling":
+		cfgTT := &aasTypes.TargetTrackingScalingPolicyConfiguration{
+			TargetValue: aws.Float64(p.TargetTrackingConfiguration.TargetValue),
 		}
-
-		// Add custom policy alarms
-		for _, p := range policies {
-			if p.MetricName != "" && p.MetricNamespace != "" {
-				alarmName := fmt.Sprintf("%s-%s-%s", cluster, service, p.PolicyName)
-				alarmNames = append(alarmNames, alarmName)
+		if pre := p.TargetTrackingConfiguration.PredefinedMetricSpecification; pre != "" {
+			cfgTT.PredefinedMetricSpecification = &aasTypes.PredefinedMetricSpecification{
+				PredefinedMetricType: aasTypes.MetricType(pre),
 			}
-		}
-
-		// Check which alarms actually exist before deleting
-		existingAlarms := []string{}
-		for _, alarmName := range alarmNames {
-			exists, err := checkCloudWatchAlarm(context.TODO(), cwClient, alarmName)
-			if err != nil {
-				slog.Error("failed to check CloudWatch alarm", "alarm_name", alarmName, "error", err)
-				continue
+			// ALB request count is tracked per target group, identified by the resource label
+			if label := p.TargetTrackingConfiguration.ResourceLabel; label != "" {
+				cfgTT.PredefinedMetricSpecification.ResourceLabel = aws.String(label)
+			} else if aasTypes.MetricType(pre) == aasTypes.MetricTypeALBRequestCountPerTarget {
+				return nil, fmt.Errorf("resource_label is required for predefined metric %s", pre)
 			}
-			if exists {
-				existingAlarms = append(existingAlarms, alarmName)
+		} else if cm := p.TargetTrackingConfiguration.CustomMetricSpecification; cm != nil {
+			cfgTT.CustomizedMetricSpecification = &aasTypes.CustomizedMetricSpecification{
+				MetricName: aws.String(cm.MetricName),
+				Namespace:  aws.String(cm.Namespace),
+				Dimensions: metricDimensions(cm.Dimensions),
+				Statistic:  aasTypes.MetricStatistic(cm.Statistic),
 			}
-		}
-
-		// Delete only existing alarms
-		if len(existingAlarms) > 0 {
-			slog.Info("deleting CloudWatch alarms", "alarms", existingAlarms)
-			if _, err := cwClient.DeleteAlarms(context.TODO(), &cw.DeleteAlarmsInput{
-				AlarmNames: existingAlarms,
-			}); err != nil {
-				slog.Error("failed to delete alarms", "error", err)
-				os.Exit(1)
+			if cm.Unit != "" {
+				cfgTT.CustomizedMetricSpecification.Unit = aws.String(cm.Unit)
 			}
 		}
+		cfgTT.ScaleInCooldown = p.TargetTrackingConfiguration.ScaleInCooldown
+		cfgTT.ScaleOutCooldown = p.TargetTrackingConfiguration.ScaleOutCooldown
+		cfgTT.DisableScaleIn = p.TargetTrackingConfiguration.DisableScaleIn
+
+		return &aas.PutScalingPolicyInput{
+			ServiceNamespace:                         res.Namespace,
+			ScalableDimension:                        res.Dimension,
+			ResourceId:                               aws.String(res.ID),
+			PolicyName:                               aws.String(p.PolicyName),
+			PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
+			TargetTrackingScalingPolicyConfiguration: cfgTT,
+		}, nil
+
+	case "PredictiveScaling":
+		return &aas.PutScalingPolicyInput{
+			ServiceNamespace:                     res.Namespace,
+			ScalableDimension:                    res.Dimension,
+			ResourceId:                           aws.String(res.ID),
+			PolicyName:                           aws.String(p.PolicyName),
+			PolicyType:                           aasTypes.PolicyTypePredictiveScaling,
+			PredictiveScalingPolicyConfiguration: predictiveScalingPolicyConfig(p.PredictiveScalingConfiguration),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("policy %s: %w", p.PolicyName, policyTypeError(p.PolicyType))
+	}
+}
 
-		// Collect all policy names to delete
-		policyNames := []string{
-			// Default policies
-			fmt.Sprintf("%s-%s-scale-out", cluster, service),
-			fmt.Sprintf("%s-%s-scale-in", cluster, service),
-		}
+// Build the PutScalingPolicy request for one of the default CPU/memory step-scaling policies, aggregating the
+// metric with --default-aggregation-type (Maximum when empty)
+func defaultStepPolicyInput(res resourceRef, name string, steps []StepAdj, cooldown int32, aggregation string) *aas.PutScalingPolicyInput {
+	return &aas.PutScalingPolicyInput{
+		ServiceNamespace:  res.Namespace,
+		ScalableDimension: res.Dimension,
+		ResourceId:        aws.String(res.ID),
+		PolicyName:        aws.String(name),
+		PolicyType:        aasTypes.PolicyTypeStepScaling,
+		StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{
+			AdjustmentType:        aasTypes.AdjustmentTypeChangeInCapacity,
+			Cooldown:              aws.Int32(cooldown),
+			MetricAggregationType: aasTypes.MetricAggregationType(cmp.Or(aggregation, string(aasTypes.MetricAggregationTypeMaximum))),
+			StepAdjustments:       stepAdjustments(steps),
+		},
+	}
+}
 
-		// Add custom policy names
-		for _, p := range policies {
-			policyNames = append(policyNames, p.PolicyName)
+// Default policy types selected with --default-policy-type
+const (
+	defaultPolicyTypeStep           = "step"
+	defaultPolicyTypeTargetTracking = "target-tracking"
+)
+
+// Build the blended CPU/memory target-tracking pair: AWS scales on whichever metric is further above its target.
+// Used for --blended and --default-policy-type=target-tracking. AWS creates and manages their alarms, so they only
+// need the scale-out thresholds as targets and the configured cooldowns.
+func buildBlendedPolicies(names *resourceNamer, cfg *Config) ([]PolicyDef, error) {
+	var policies []PolicyDef
+	for _, d := range []struct {
+		suffix string
+		metric aasTypes.MetricType
+		target float64
+	}{
+		{"cpu-target", aasTypes.MetricTypeECSServiceAverageCPUUtilization, cfg.TargetCPUOut},
+		{"mem-target", aasTypes.MetricTypeECSServiceAverageMemoryUtilization, cfg.TargetMemOut},
+	} {
+		name, err := names.name(d.suffix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build policy name: %w", err)
 		}
+		policies = append(policies, PolicyDef{
+			PolicyName: name,
+			PolicyType: string(aasTypes.PolicyTypeTargetTrackingScaling),
+			TargetTrackingConfiguration: &TargetTrackingConfig{
+				TargetValue:                   d.target,
+				PredefinedMetricSpecification: string(d.metric),
+				ScaleOutCooldown:              aws.Int32(cfg.ScaleOutCooldown),
+				ScaleInCooldown:               aws.Int32(cfg.ScaleInCooldown),
+			},
+		})
+	}
+	return policies, nil
+}
 
-		// Deduplicate policy names to avoid attempting to delete the same policy twice
-		policyNames = deduplicate(policyNames)
+// Tag limits shared by Application Auto Scaling and CloudWatch
+const (
+	maxTags           = 50
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
 
-		// Check and delete only existing scaling policies
-		existingPolicies := []string{}
-		for _, name := range policyNames {
-			exists, err := checkScalingPolicy(context.TODO(), aasClient, resourceID, name)
-			if err != nil {
-				slog.Error("failed to check scaling policy", "policy_name", name, "error", err)
-				continue
-			}
-			if exists {
-				existingPolicies = append(existingPolicies, name)
-			}
+// Parse a `key=value,key2=value2` tag list and validate it against the AWS tag limits
+func parseTags(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	tags := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", pair)
 		}
-
-		// Delete existing policies
-		for _, name := range existingPolicies {
-			slog.Info("deleting scaling policy", "policy_name", name)
-			if _, err := aasClient.DeleteScalingPolicy(context.TODO(), &aas.DeleteScalingPolicyInput{
-				ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-				ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-				ResourceId:        aws.String(resourceID),
-				PolicyName:        aws.String(name),
-			}); err != nil {
-				slog.Error("failed to delete scaling policy", "policy_name", name, "error", err)
-				os.Exit(1)
-			}
+		if utf8.RuneCountInString(key) > maxTagKeyLength {
+			return nil, fmt.Errorf("invalid tag %q: key exceeds %d characters", key, maxTagKeyLength)
 		}
-
-		// Deregister the scalable target
-		slog.Info("deregistering scalable target", "resource", resourceID)
-		if _, err := aasClient.DeregisterScalableTarget(context.TODO(), &aas.DeregisterScalableTargetInput{
-			ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-			ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-			ResourceId:        aws.String(resourceID),
-		}); err != nil {
-			slog.Error("failed to deregister scalable target", "error", err)
-			os.Exit(1)
+		if utf8.RuneCountInString(value) > maxTagValueLength {
+			return nil, fmt.Errorf("invalid tag %q: value exceeds %d characters", key, maxTagValueLength)
 		}
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			return nil, fmt.Errorf("invalid tag %q: the aws: prefix is reserved", key)
+		}
+		if _, dup := tags[key]; dup {
+			return nil, fmt.Errorf("invalid tag %q: duplicate key", key)
+		}
+		tags[key] = value
+	}
+	if len(tags) > maxTags {
+		return nil, fmt.Errorf("too many tags: %d (max %d)", len(tags), maxTags)
+	}
+	return tags, nil
+}
 
-		slog.Info("auto-scaling disabled and cleaned up", "cluster", cluster, "service", service)
-		return
+// Convert a tag map into CloudWatch tags, sorted by key for stable API calls
+func cloudWatchTags(tags map[string]string) []cwTypes.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]cwTypes.Tag, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, cwTypes.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return result
+}
+
+// defaultNameTemplate reproduces the historical `{cluster}-{service}-{suffix}` naming
+const defaultNameTemplate = "{{.Cluster}}-{{.Service}}-{{.Suffix}}"
+
+// prefixNameTemplate is used when only --name-prefix is set
+const prefixNameTemplate = "{{.Prefix}}-{{.Suffix}}"
+
+// Longest generated name; CloudWatch alarm names allow 255 characters and scaling policy names 256
+const maxResourceNameLength = 255
+
+// nameData is the data available to --name-template
+type nameData struct {
+	Cluster string
+	Service string
+	Prefix  string
+	Suffix  string
+}
+
+// resourceNamer builds the names of generated scaling policies and alarms
+type resourceNamer struct {
+	tmpl    *template.Template
+	cluster string
+	service string
+	prefix  string
+	env     string // --env, appended as -<env> to every rendered name
+}
+
+// Create a namer from the --name-prefix, --name-template and --env inputs.
+// An explicit template wins over the prefix; with neither set the default naming is kept.
+func newResourceNamer(cluster, service, prefix, tmplText, env string) (*resourceNamer, error) {
+	if tmplText == "" {
+		tmplText = defaultNameTemplate
+		if prefix != "" {
+			tmplText = prefixNameTemplate
+		}
+	}
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(tmplText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name-template: %v", err)
+	}
+	n := &resourceNamer{tmpl: tmpl, cluster: cluster, service: service, prefix: prefix, env: env}
+
+	// Render once up front so a broken template fails before any AWS call is made
+	if _, err := n.name("scale-out"); err != nil {
+		return nil, err
 	}
+	return n, nil
+}
 
-	// (2) parse custom policies if provided
+// Render the resource name for the given suffix (e.g. "scale-out", "cpu-high" or a custom policy name)
+func (n *resourceNamer) name(suffix string) (string, error) {
+	var sb strings.Builder
+	if err := n.tmpl.Execute(&sb, nameData{
+		Cluster: n.cluster,
+		Service: n.service,
+		Prefix:  n.prefix,
+		Suffix:  suffix,
+	}); err != nil {
+		return "", fmt.Errorf("failed to render name-template: %v", err)
+	}
+	name := strings.TrimSpace(sb.String())
+	if name == "" {
+		return "", fmt.Errorf("name-template rendered an empty name for suffix %q", suffix)
+	}
+	if n.env != "" {
+		name += "-" + n.env
+	}
+	if n := utf8.RuneCountInString(name); n > maxResourceNameLength {
+		return "", fmt.Errorf("generated name %q is %d characters, over the %d AWS allows; shorten name-prefix, name-template or env", name, n, maxResourceNameLength)
+	}
+	return name, nil
+}
+
+// Parse the scaling-policies JSON, falling back to default-policies when it is empty
+func parsePolicies(policiesRaw, defaultPoliciesRaw string) ([]PolicyDef, error) {
 	var policies []PolicyDef
+	input, raw := "scaling-policies", policiesRaw
 	if policiesRaw != "" {
-		slog.Info("parsing custom scaling policies")
 		if err := json.Unmarshal([]byte(policiesRaw), &policies); err != nil {
-			slog.Error("invalid scaling-policies JSON", "error", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("invalid scaling-policies JSON: %v", err)
 		}
 	} else if defaultPoliciesRaw != "" {
-		slog.Info("parsing default scaling policies")
+		input, raw = "default-policies", defaultPoliciesRaw
 		if err := json.Unmarshal([]byte(defaultPoliciesRaw), &policies); err != nil {
-			slog.Error("invalid default-policies JSON", "error", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("invalid default-policies JSON: %v", err)
 		}
 	}
 
-	// For each policy, compare with existing configuration and update only if needed
-	for _, p := range policies {
-		slog.Info("processing policy", "policy_name", p.PolicyName)
-
-		var policyInput *aas.PutScalingPolicyInput
-
-		switch p.PolicyType {
-		case "StepScaling":
-			// build step adjustments
-			var sa []aasTypes.StepAdjustment
-			for _, adj := range p.StepAdjustments {
-				sa = append(sa, aasTypes.StepAdjustment{
-					MetricIntervalLowerBound: adj.MetricIntervalLowerBound,
-					MetricIntervalUpperBound: adj.MetricIntervalUpperBound,
-					ScalingAdjustment:        aws.Int32(adj.ScalingAdjustment),
-				})
-			}
-			policyInput = &aas.PutScalingPolicyInput{
-				ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-				ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-				ResourceId:        aws.String(resourceID),
-				PolicyName:        aws.String(p.PolicyName),
-				PolicyType:        aasTypes.PolicyTypeStepScaling,
-				StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{
-					AdjustmentType:        aasTypes.AdjustmentType(p.AdjustmentType),
-					Cooldown:              p.Cooldown,
-					MetricAggregationType: aasTypes.MetricAggregationType(p.MetricAggregationType),
-					StepAdjustments:       sa,
-				},
-			}
-
-		case "TargetTrackingScaling":
-			cfgTT := &aasTypes.TargetTrackingScalingPolicyConfiguration{
-				TargetValue: aws.Float64(p.TargetTrackingConfiguration.TargetValue),
-			}
-			if pre := p.TargetTrackingConfiguration.PredefinedMetricSpecification; pre != "" {
-				cfgTT.PredefinedMetricSpecification = &aasTypes.PredefinedMetricSpecification{
-					PredefinedMetricType: aasTypes.MetricType(pre),
-				}
-			} else if cm := p.TargetTrackingConfiguration.CustomMetricSpecification; cm != nil {
-				var dims []aasTypes.MetricDimension
-				for k, v := range cm.Dimensions {
-					dims = append(dims, aasTypes.MetricDimension{Name: aws.String(k), Value: aws.String(v)})
-				}
-				cfgTT.CustomizedMetricSpecification = &aasTypes.CustomizedMetricSpecification{
-					MetricName: aws.String(cm.MetricName),
-					Namespace:  aws.String(cm.Namespace),
-					Dimensions: dims,
-					Statistic:  aasTypes.MetricStatistic(cm.Statistic),
-				}
+	// Reject missing fields and typos up front instead of failing halfway through an apply,
+	// and report every problem at once rather than one per run
+	var errs []error
+	required := checkRequiredFields([]byte(raw))
+	for i, p := range policies {
+		prefix := fmt.Sprintf("policies[%d]: ", i)
+		if p.PolicyName != "" {
+			prefix += "policy " + p.PolicyName + ": "
+		}
+		for _, err := range required[i] {
+			errs = append(errs, fmt.Errorf("%s%w", prefix, err))
+		}
+		if err := validatePolicy(p); err != nil {
+			errs = append(errs, fmt.Errorf("policies[%d]: %w", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid %s: %d problem(s):\n%w", input, len(errs), errors.Join(errs...))
+	}
+	sortPolicies(policies)
+	expanded, err := expandBidirectional(policies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", input, err)
+	}
+	return expanded, nil
+}
+
+// Order policies for applying by priority, lowest first, then by name. Without any priority set the input order
+// is kept.
+func sortPolicies(policies []PolicyDef) {
+	if !slices.ContainsFunc(policies, func(p PolicyDef) bool { return p.Priority != 0 }) {
+		return
+	}
+	slices.SortStableFunc(policies, func(a, b PolicyDef) int {
+		return cmp.Or(cmp.Compare(a.Priority, b.Priority), strings.Compare(a.PolicyName, b.PolicyName))
+	})
+}
+
+// Names of every alarm this tool may have created: the default alarms plus custom policy alarms, skipping
+// any it does not manage
+func (r *runner) cleanupAlarmNames() ([]string, error) {
+	alarmNames := []string{}
+	if !r.cfg.NoAlarms {
+		for _, suffix := range []string{"cpu-high", "cpu-low", "mem-high", "mem-low"} {
+			alarmName, err := r.names.name(suffix)
+			if err != nil {
+				return nil, err
 			}
-			cfgTT.ScaleInCooldown = p.TargetTrackingConfiguration.ScaleInCooldown
-			cfgTT.ScaleOutCooldown = p.TargetTrackingConfiguration.ScaleOutCooldown
+			alarmNames = append(alarmNames, alarmName)
+		}
+	}
 
-			policyInput = &aas.PutScalingPolicyInput{
-				ServiceNamespace:                         aasTypes.ServiceNamespaceEcs,
-				ScalableDimension:                        aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-				ResourceId:                               aws.String(resourceID),
-				PolicyName:                               aws.String(p.PolicyName),
-				PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
-				TargetTrackingScalingPolicyConfiguration: cfgTT,
+	for _, p := range r.policies {
+		if p.MetricName != "" && p.MetricNamespace != "" && r.managesAlarm(p) {
+			alarmName, err := r.names.name(p.PolicyName)
+			if err != nil {
+				return nil, err
 			}
+			alarmNames = append(alarmNames, alarmName)
+		}
+	}
+	return alarmNames, nil
+}
+
+// Whether this tool creates and deletes the alarm for a custom policy: its manage_alarm, otherwise not with --no-alarms
+func (r *runner) managesAlarm(p PolicyDef) bool {
+	if p.ManageAlarm != nil {
+		return *p.ManageAlarm
+	}
+	return !r.cfg.NoAlarms
+}
+
+// Whether this tool manages the alarm for the named policy, which need not be one of the custom policies
+func (r *runner) managesAlarmFor(policyName string) bool {
+	for _, p := range r.policies {
+		if p.PolicyName == policyName {
+			return r.managesAlarm(p)
+		}
+	}
+	return !r.cfg.NoAlarms
+}
+
+// Whether every alarm on the resource is this tool's to delete, so alarms it cannot name may be swept up too.
+// Alarms managed elsewhere also point at the resource's policies, so the sweep would catch them.
+func (r *runner) managesAllAlarms() bool {
+	return !r.cfg.NoAlarms && !slices.ContainsFunc(r.policies, func(p PolicyDef) bool { return !r.managesAlarm(p) })
+}
+
+// Names of every scaling policy this tool may have created: the default policies plus custom policies
+func cleanupPolicyNames(scaleOutName, scaleInName string, policies []PolicyDef) []string {
+	policyNames := []string{scaleOutName, scaleInName}
+	for _, p := range policies {
+		policyNames = append(policyNames, p.PolicyName)
+	}
+
+	// Deduplicate policy names to avoid attempting to delete the same policy twice
+	return deduplicate(policyNames)
+}
+
+// Helper function to deduplicate string slices
+func deduplicate(slice []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, item := range slice {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Clients bundles the AWS API clients a run talks to
+type Clients struct {
+	AAS AASClient
+	CW  CWClient
+	ECS ECSClient // only needed for --all-services-in-cluster and --check-min-healthy-percent
+	SNS SNSClient // only needed for --audit-topic-arn
+}
+
+// runner carries the resolved state for a single run
+type runner struct {
+	cfg          *Config
+	aas          AASClient
+	uncountedAAS AASClient // clients.AAS without the counting wrapper, for the provenance tag (see tagProvenance)
+	cw           CWClient
+	ecs          ECSClient // nil unless the caller provided one
+	sns          SNSClient // nil unless the caller provided one
+	out          io.Writer
+	in           io.Reader // answers to the disable confirmation prompt
+	interactive  bool      // whether in is a terminal the prompt can be answered on
+	resource     resourceRef
+	names        *resourceNamer
+	scaleOutName string
+	scaleInName  string
+	policies     []PolicyDef
+	alarmTags    []cwTypes.Tag
+	metrics      *runMetrics
+	log          *slog.Logger // the default logger with this service's attributes, for every line about it
+	configHash   string       // configHash of cfg, recorded in alarm descriptions
+	provenance   string       // appended to alarm descriptions and, with --provenance-tag, tagged on the target
+
+	arnRetryDelay time.Duration // between lookups of a scaling policy described without its ARN
+}
+
+// Options for loading the AWS config: the region, plus static keys (with a session token for temporary
+// credentials) or a named profile when given.
+// Otherwise credentials come from the default chain (environment, shared config, IAM role).
+func awsConfigOptions(cfg *Config) []func(*config.LoadOptions) error {
+	opts := []func(*config.LoadOptions) error{config.WithRegion(cfg.Region)}
+	if cfg.RegionFromInstanceMetadata {
+		// No fixed region: the SDK looks at AWS_REGION, AWS_DEFAULT_REGION and the profile, then instance metadata
+		opts = []func(*config.LoadOptions) error{config.WithEC2IMDSRegion()}
+	}
+	switch {
+	case cfg.KeyID != "" && cfg.KeySecret != "":
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.KeyID, cfg.KeySecret, cfg.SessionToken),
+		))
+	case cfg.Profile != "":
+		opts = append(opts, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	if cfg.SharedConfigFile != "" {
+		opts = append(opts, config.WithSharedConfigFiles([]string{cfg.SharedConfigFile}))
+	}
+	if cfg.SharedCredentialsFile != "" {
+		opts = append(opts, config.WithSharedCredentialsFiles([]string{cfg.SharedCredentialsFile}))
+	}
+	if cfg.UseFIPSEndpoint {
+		opts = append(opts, config.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled))
+	}
+	if cfg.UseDualStackEndpoint {
+		opts = append(opts, config.WithUseDualStackEndpoint(aws.DualStackEndpointStateEnabled))
+	}
+	return opts
+}
+
+// Load the AWS config for cfg. With --region-from-instance-metadata the resolved region is checked like a given
+// one and written back to cfg.Region, which everything after this reads.
+func loadAWSConfig(ctx context.Context, cfg *Config) (aws.Config, error) {
+	noRegion := errors.New("no region found: set aws-region, AWS_REGION or a region in the shared config, or run on EC2 or ECS with instance metadata available")
+	awsCfg, err := config.LoadDefaultConfig(ctx, awsConfigOptions(cfg)...)
+	if err != nil {
+		// The instance metadata lookup is the last resort, so its failure means no region was found anywhere
+		var opErr *smithy.OperationError
+		if cfg.RegionFromInstanceMetadata && errors.As(err, &opErr) && opErr.Service() == "ec2imds" && opErr.Operation() == "GetRegion" {
+			return aws.Config{}, validationError(fmt.Errorf("%w (%v)", noRegion, opErr.Err))
+		}
+		return aws.Config{}, err
+	}
+	if cfg.RegionFromInstanceMetadata {
+		if awsCfg.Region == "" {
+			return aws.Config{}, validationError(noRegion)
+		}
+		if err := validateRegion(awsCfg.Region, cfg.AllowAnyRegion); err != nil {
+			return aws.Config{}, validationError(fmt.Errorf("resolved region: %w", err))
+		}
+		if err := validateEndpointVariants(awsCfg.Region, cfg.UseFIPSEndpoint, cfg.UseDualStackEndpoint); err != nil {
+			return aws.Config{}, validationError(err)
+		}
+		cfg.Region = awsCfg.Region
+		slog.Info("resolved region", "region", cfg.Region)
+	}
+	return awsCfg, nil
+}
+
+// Build the AWS API clients, pointing them all at endpointURL (e.g. LocalStack) when it is set.
+// Only the endpoint changes; credentials and region still come from awsCfg.
+func newClients(awsCfg aws.Config, endpointURL string) Clients {
+	var aasOpts []func(*aas.Options)
+	var cwOpts []func(*cw.Options)
+	var ecsOpts []func(*ecs.Options)
+	if endpointURL != "" {
+		aasOpts = append(aasOpts, func(o *aas.Options) { o.BaseEndpoint = aws.String(endpointURL) })
+		cwOpts = append(cwOpts, func(o *cw.Options) { o.BaseEndpoint = aws.String(endpointURL) })
+		ecsOpts = append(ecsOpts, func(o *ecs.Options) { o.BaseEndpoint = aws.String(endpointURL) })
+	}
+	return Clients{
+		AAS: aas.NewFromConfig(awsCfg, aasOpts...),
+		CW:  cw.NewFromConfig(awsCfg, cwOpts...),
+		ECS: ecs.NewFromConfig(awsCfg, ecsOpts...),
+		SNS: newSNSClient(awsCfg, endpointURL),
+	}
+}
+
+// Resolve names and policies for a run without touching AWS
+func newRunner(cfg *Config, clients Clients, out io.Writer) (*runner, error) {
+	resource, err := cfg.resource()
+	if err != nil {
+		return nil, err
+	}
+	log := serviceLogger(cfg, resource)
+	log.Debug("resolved scalable target", "namespace", resource.Namespace, "dimension", resource.Dimension)
+
+	prefix := cfg.NamePrefix
+	if resource.Namespace == aasTypes.ServiceNamespaceDynamodb && prefix == "" {
+		// DynamoDB has no cluster/service, so name resources after the table, index and capacity instead
+		prefix = dynamoDBNamePrefix(resource)
+	}
+	names, err := newResourceNamer(cfg.Cluster, cfg.Service, prefix, cfg.NameTemplate, cfg.Env)
+	if err != nil {
+		return nil, fmt.Errorf("invalid naming configuration: %w", err)
+	}
+	if cfg.PrefixCleanup || cfg.PurgeUnmanaged {
+		if _, err := names.fixedPrefix(); err != nil {
+			return nil, fmt.Errorf("invalid naming configuration: %w", err)
+		}
+	}
+	scaleOutName, err := names.name("scale-out")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build policy name: %w", err)
+	}
+	scaleInName, err := names.name("scale-in")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build policy name: %w", err)
+	}
+
+	// Parse custom policies if provided; cleanup needs them too to find every policy name
+	if cfg.PoliciesRaw != "" {
+		log.Info("parsing custom scaling policies")
+	} else if cfg.DefaultPoliciesRaw != "" {
+		log.Info("parsing default scaling policies")
+	}
+	policies, err := parsePolicies(cfg.PoliciesRaw, cfg.DefaultPoliciesRaw)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range policies {
+		if err := validatePolicyCooldowns(p, cfg.MaxCooldown); err != nil {
+			return nil, err
+		}
+		if cfg.Enabled {
+			if err := validateExactCapacityRange(p, cfg.MinCapacity, cfg.MaxCapacity); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if (len(policies) == 0 && cfg.Enabled && !cfg.TargetOnly || cfg.Blended) && resource.Namespace != aasTypes.ServiceNamespaceEcs {
+		return nil, fmt.Errorf("the built-in CPU/memory policies only apply to ECS services; provide scaling-policies for %s", resource.ID)
+	}
+	if resource.Namespace == aasTypes.ServiceNamespaceEcs && (cfg.Blended || len(policies) == 0 && cfg.DefaultPolicyType == defaultPolicyTypeTargetTracking) {
+		// The CPU/memory pair goes through the same path as custom policies, so plan, verify and cleanup cover it
+		blended, err := buildBlendedPolicies(names, cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range policies {
+			if p.PolicyName == blended[0].PolicyName || p.PolicyName == blended[1].PolicyName {
+				return nil, fmt.Errorf("policy %s clashes with a blended CPU/memory policy name", p.PolicyName)
+			}
+		}
+		policies = append(policies, blended...)
+	}
+	if cfg.SQSQueue != "" {
+		sqs, err := buildSQSPolicy(names, cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range policies {
+			if p.PolicyName == sqs.PolicyName {
+				return nil, fmt.Errorf("policy %s clashes with the sqs-queue policy name", p.PolicyName)
+			}
+		}
+		policies = append(policies, sqs)
+	}
+	if cfg.LoadBalancerARN != "" {
+		label, err := albResourceLabel(cfg.LoadBalancerARN, cfg.TargetGroupARN)
+		if err != nil {
+			return nil, err
+		}
+		if err := setALBResourceLabel(policies, label); err != nil {
+			return nil, err
+		}
+	}
+	// Only now that --load-balancer-arn has filled in the missing labels can they be checked
+	for _, p := range policies {
+		if err := validateResourceLabel(p); err != nil {
+			return nil, err
+		}
+	}
+
+	var alarmTags []cwTypes.Tag
+	if cfg.TagAlarms {
+		alarmTags = cloudWatchTags(cfg.Tags)
+	}
+
+	if cfg.AuditTopicARN != "" && clients.SNS == nil {
+		return nil, errors.New("audit-topic-arn requires an SNS client")
+	}
+
+	// Every call goes through counting wrappers so --metrics-file can report them
+	metrics := newRunMetrics()
+	hash := configHash(cfg)
+	var ecsClient ECSClient
+	if clients.ECS != nil {
+		ecsClient = countingECSClient{ECSClient: clients.ECS, metrics: metrics}
+	}
+	return &runner{
+		cfg:          cfg,
+		aas:          countingAASClient{AASClient: clients.AAS, metrics: metrics},
+		uncountedAAS: clients.AAS,
+		cw:           countingCWClient{CWClient: clients.CW, metrics: metrics},
+		ecs:          ecsClient,
+		sns:          clients.SNS,
+		out:          out,
+		in:           os.Stdin,
+		interactive:  stdinIsTerminal(),
+		resource:     resource,
+		names:        names,
+		scaleOutName: scaleOutName,
+		scaleInName:  scaleInName,
+		policies:     policies,
+		alarmTags:    alarmTags,
+		metrics:      metrics,
+		log:          log,
+		configHash:   hash,
+		provenance:   provenance(hash, time.Now()),
+
+		arnRetryDelay: policyARNRetryDelay,
+	}, nil
+}
+
+// The default logger with the service's attributes attached, so lines about different services can be told apart
+// when several are processed in one run. Cluster and service are left out for resources that have none.
+func serviceLogger(cfg *Config, resource resourceRef) *slog.Logger {
+	attrs := []any{"resource_id", resource.ID}
+	if cfg.Cluster != "" {
+		attrs = append(attrs, "cluster", cfg.Cluster)
+	}
+	if cfg.Service != "" {
+		attrs = append(attrs, "service", cfg.Service)
+	}
+	return slog.Default().With(attrs...)
+}
+
+// Run applies (or, when disabled, removes) auto-scaling for the configured service.
+// Plan, verify and export output is written to out. Errors are returned rather than exiting so callers decide how to fail.
+// With --interval it reconciles again every interval until ctx is cancelled (see reconcileLoop).
+func Run(ctx context.Context, cfg *Config, clients Clients, out io.Writer) error {
+	if cfg.Interval > 0 {
+		return reconcileLoop(ctx, cfg.Interval, func(ctx context.Context) error {
+			return runOnce(ctx, cfg, clients, out)
+		})
+	}
+	return runOnce(ctx, cfg, clients, out)
+}
+
+// A single run of Run
+func runOnce(ctx context.Context, cfg *Config, clients Clients, out io.Writer) error {
+	r, err := newRunner(cfg, clients, out)
+	if err != nil {
+		return validationError(err)
+	}
+
+	start := time.Now()
+	err = withExitCode(explainAccessDenied(r.run(ctx), r.resource))
+	if cfg.AuditTopicARN != "" {
+		r.publishAudit(ctx, err)
+	}
+	if cfg.SummaryFile != "" {
+		r.writeSummary(err)
+	}
+	if cfg.MetricsFile != "" {
+		if metricsErr := r.metrics.writeFile(cfg.MetricsFile, cfg, r.resource, time.Since(start), err); metricsErr != nil {
+			return withExitCode(errors.Join(err, metricsErr))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return r.reportChanges()
+}
+
+// Log whether an enable or disable run changed anything. With --detailed-exit-code a run that did returns
+// errChanged, so pipelines can tell it apart from a no-op.
+func (r *runner) reportChanges() error {
+	if command := r.cfg.command(); command != commandEnable && command != commandDisable {
+		return nil
+	}
+	r.log.Info(r.changeSummary(), "changes", r.metrics.changes)
+	if r.metrics.changes == 0 {
+		return nil
+	}
+	if r.cfg.DetailedExitCode {
+		return &ExitError{Code: exitChanged, Err: errChanged}
+	}
+	return nil
+}
+
+// "no changes" or "applied N changes", for the closing log line and the audit event
+func (r *runner) changeSummary() string {
+	if r.metrics.changes == 0 {
+		return "no changes"
+	}
+	return fmt.Sprintf("applied %d changes", r.metrics.changes)
+}
+
+// Dispatch to selftest, export, describe, verify, plan, policy removal, cleanup or apply
+func (r *runner) run(ctx context.Context) error {
+	switch r.cfg.command() {
+	case commandSelftest:
+		return r.runSelftest(ctx)
+	case commandExport:
+		return r.runExport(ctx)
+	case commandDescribe:
+		return r.runDescribe(ctx)
+	case commandVerify:
+		return r.verify(ctx)
+	case commandPlan:
+		return r.runPlan(ctx)
+	case commandDisable:
+		return r.runDisable(ctx)
+	default:
+		return r.runEnable(ctx)
+	}
+}
+
+// Print the existing auto-scaling configuration as policy JSON
+func (r *runner) runExport(ctx context.Context) error {
+	doc, err := r.buildExport(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to export configuration: %w", err)
+	}
+	return printExport(r.out, doc)
+}
+
+// Print what enabling or disabling would change
+func (r *runner) runPlan(ctx context.Context) error {
+	items, err := r.buildPlan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to build plan: %w", err)
+	}
+	printPlan(r.out, items)
+	return nil
+}
+
+// Register the scalable target and apply the policies and alarms, purging any others with --purge-unmanaged,
+// recording the provenance tag with --provenance-tag and then printing recent activity with --show-activities, or
+// just remove the --remove-policy ones
+func (r *runner) runEnable(ctx context.Context) error {
+	if len(r.cfg.RemovePolicies) > 0 {
+		return r.removePolicies(ctx, r.cfg.RemovePolicies)
+	}
+	if err := r.apply(ctx); err != nil {
+		return err
+	}
+	if r.cfg.PurgeUnmanaged {
+		if err := r.purgeUnmanaged(ctx); err != nil {
+			return fmt.Errorf("failed to purge unmanaged policies: %w", err)
+		}
+	}
+	if err := r.tagProvenance(ctx); err != nil {
+		return err
+	}
+	if r.cfg.Wait {
+		if err := r.waitUntilVisible(ctx); err != nil {
+			return err
+		}
+	}
+	if r.cfg.ShowActivities > 0 {
+		return r.showActivities(ctx)
+	}
+	return nil
+}
+
+// Delete everything auto-scaling created for the resource, once confirmed
+func (r *runner) runDisable(ctx context.Context) error {
+	if err := r.confirmCleanup(ctx); err != nil {
+		return err
+	}
+	return r.cleanup(ctx)
+}
+
+// Delete alarms and policies, then deregister the scalable target unless --keep-target
+func (r *runner) cleanup(ctx context.Context) error {
+	r.log.Info("disabling auto-scaling")
+
+	// First check if scalable target exists to determine if auto-scaling was ever enabled
+	exists, err := scalableTargetExists(ctx, r.aas, r.resource)
+	if err != nil {
+		return fmt.Errorf("failed to check scalable target: %w", err)
+	}
+	if !exists {
+		r.log.Info("auto-scaling was not enabled for this service")
+		return nil
+	}
+
+	// Collect all alarm names to delete
+	alarmNames, err := r.cleanupAlarmNames()
+	if err != nil {
+		return fmt.Errorf("failed to build alarm name: %w", err)
+	}
+
+	// Attempt every deletion and collect failures, so one stuck resource doesn't block the rest. A failed lookup
+	// counts too: whatever it would have found is left behind, so the target must stay.
+	var errs []error
+
+	// Check which alarms actually exist before deleting
+	existingAlarms, err := existingAlarmNames(ctx, r.cw, alarmNames)
+	if err != nil {
+		r.log.Error("failed to check CloudWatch alarms", append(awsErrorFields(err), "alarms", alarmNames)...)
+		errs = append(errs, fmt.Errorf("failed to check alarms: %w", err))
+	}
+
+	// Also sweep up alarms still pointing at this resource's policies, e.g. ones AWS left behind for a deleted
+	// target-tracking policy
+	if r.managesAllAlarms() {
+		orphaned, err := alarmsForResourcePolicies(ctx, r.cw, r.resource)
+		if err != nil {
+			r.log.Error("failed to list alarms for scaling policies", awsErrorFields(err)...)
+			errs = append(errs, fmt.Errorf("failed to list alarms for scaling policies: %w", err))
+		}
+		existingAlarms = deduplicate(append(existingAlarms, orphaned...))
+	}
+
+	// --prefix-cleanup also deletes alarms named like ours that were renamed or created out of band
+	if r.cfg.PrefixCleanup {
+		prefixed, err := r.prefixAlarms(ctx)
+		if err != nil {
+			r.log.Error("failed to list alarms by name prefix", awsErrorFields(err)...)
+			errs = append(errs, fmt.Errorf("failed to list alarms by name prefix: %w", err))
+		}
+		existingAlarms = deduplicate(append(existingAlarms, prefixed...))
+	}
+
+	// Delete only existing alarms
+	if len(existingAlarms) > 0 {
+		r.log.Info("deleting CloudWatch alarms", "alarms", existingAlarms)
+		if err := deleteAlarms(ctx, r.cw, existingAlarms); err != nil {
+			r.log.Error("failed to delete alarms", append(awsErrorFields(err), "alarms", existingAlarms)...)
+			errs = append(errs, fmt.Errorf("failed to delete alarms: %w", err))
+		}
+	}
+
+	// Collect all policy names to delete
+	policyNames := cleanupPolicyNames(r.scaleOutName, r.scaleInName, r.policies)
 
-		default:
-			slog.Error("unknown policy_type", "policy_type", p.PolicyType)
-			os.Exit(1)
+	// Check and delete only existing scaling policies
+	existingPolicies := []string{}
+	for _, name := range policyNames {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		exists, err := checkScalingPolicy(ctx, r.aas, r.resource, name)
+		if err != nil {
+			r.log.Error("failed to check scaling policy", append(awsErrorFields(err), "policy_name", name)...)
+			errs = append(errs, fmt.Errorf("failed to check scaling policy %s: %w", name, err))
+			continue
+		}
+		if exists {
+			existingPolicies = append(existingPolicies, name)
+		}
+	}
+
+	// Delete existing policies
+	for _, name := range existingPolicies {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		r.log.Info("deleting scaling policy", "policy_name", name)
+		if _, err := r.aas.DeleteScalingPolicy(ctx, &aas.DeleteScalingPolicyInput{
+			ServiceNamespace:  r.resource.Namespace,
+			ScalableDimension: r.resource.Dimension,
+			ResourceId:        aws.String(r.resource.ID),
+			PolicyName:        aws.String(name),
+		}); err != nil {
+			r.log.Error("failed to delete scaling policy", append(awsErrorFields(err), "policy_name", name)...)
+			errs = append(errs, fmt.Errorf("failed to delete scaling policy %s: %w", name, err))
+		}
+	}
+
+	// Keep the scalable target while anything attached to it may be left, so a re-run can finish the job
+	if len(errs) > 0 {
+		r.log.Warn("skipping scalable target deregistration after failed lookups or deletions", "failures", len(errs))
+		return fmt.Errorf("cleanup incomplete: %w", errors.Join(errs...))
+	}
+
+	if r.cfg.KeepTarget {
+		r.log.Info("scaling policies and alarms removed; scalable target retained")
+		return nil
+	}
+
+	// Deregister the scalable target
+	r.log.Info("deregistering scalable target")
+	if _, err := r.aas.DeregisterScalableTarget(ctx, &aas.DeregisterScalableTargetInput{
+		ServiceNamespace:  r.resource.Namespace,
+		ScalableDimension: r.resource.Dimension,
+		ResourceId:        aws.String(r.resource.ID),
+	}); err != nil {
+		return fmt.Errorf("failed to deregister scalable target: %w", err)
+	}
+	if r.cfg.Wait {
+		if err := r.waitUntilDeregistered(ctx); err != nil {
+			return err
+		}
+	}
+
+	r.log.Info("auto-scaling disabled and cleaned up")
+	return nil
+}
+
+// Register the scalable target, then apply custom policies or the built-in defaults
+func (r *runner) apply(ctx context.Context) error {
+	if err := r.validateService(ctx); err != nil {
+		return err
+	}
+	if err := r.checkMinHealthyPercent(ctx); err != nil {
+		return err
+	}
+
+	// Check if scalable target exists and matches desired configuration
+	exists, err := checkScalableTarget(ctx, r.aas, r.resource, r.cfg.MinCapacity, r.cfg.MaxCapacity, r.suspendedState())
+	if err != nil {
+		return fmt.Errorf("failed to check scalable target: %w", err)
+	}
+
+	var targetARN string
+	if !exists {
+		r.log.Info("registering scalable target")
+		out, err := r.aas.RegisterScalableTarget(ctx, &aas.RegisterScalableTargetInput{
+			ServiceNamespace:  r.resource.Namespace,
+			ScalableDimension: r.resource.Dimension,
+			ResourceId:        aws.String(r.resource.ID),
+			MinCapacity:       aws.Int32(r.cfg.MinCapacity),
+			MaxCapacity:       aws.Int32(r.cfg.MaxCapacity),
+			SuspendedState:    r.suspendedState(),
+			Tags:              r.scalableTargetTags(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register scalable target: %w", err)
+		}
+		targetARN = aws.ToString(out.ScalableTargetARN)
+	} else {
+		r.log.Info("scalable target already exists with desired configuration")
+	}
+	// Registering an existing target ignores its Tags, so they are compared and applied separately
+	if err := r.applyTargetTags(ctx, targetARN); err != nil {
+		return err
+	}
+
+	if r.cfg.TargetOnly {
+		r.log.Info("target-only: scalable target configured, leaving scaling policies and alarms alone")
+		return nil
+	}
+	if len(r.policies) > 0 {
+		if err := r.applyCustomPolicies(ctx); err != nil {
+			return err
+		}
+		r.log.Info("custom scaling policies applied")
+		return nil
+	}
+
+	if err := r.applyDefaultPolicies(ctx); err != nil {
+		return err
+	}
+	r.log.Info("default CPU and memory auto-scaling & alarms configured")
+	return nil
+}
+
+// Tag the scalable target with any --tags it lacks or has with another value. Tags added outside this tool are
+// left alone. targetARN may be empty, in which case the target is looked up.
+func (r *runner) applyTargetTags(ctx context.Context, targetARN string) error {
+	if len(r.cfg.Tags) == 0 {
+		return nil
+	}
+	current, targetARN, err := r.targetTags(ctx, targetARN)
+	if err != nil {
+		return err
+	}
+	if targetARN == "" {
+		return fmt.Errorf("scalable target %s not found to tag", r.resource.ID)
+	}
+	missing := tagDrift(current, r.cfg.Tags)
+	if len(missing) == 0 {
+		r.log.Debug("scalable target tags up to date")
+		return nil
+	}
+	r.log.Info("tagging scalable target", "tag_keys", slices.Sorted(maps.Keys(missing)))
+	if _, err := r.aas.TagResource(ctx, &aas.TagResourceInput{ResourceARN: aws.String(targetARN), Tags: missing}); err != nil {
+		return fmt.Errorf("failed to tag scalable target: %w", err)
+	}
+	return nil
+}
+
+// The scalable target's current tags and its ARN. targetARN may be empty, in which case the target is looked up; a
+// target that does not exist yet has no tags.
+func (r *runner) targetTags(ctx context.Context, targetARN string) (map[string]string, string, error) {
+	if targetARN == "" {
+		target, err := describeScalableTarget(ctx, r.aas, r.resource)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to describe scalable target: %w", err)
+		}
+		if target == nil {
+			return nil, "", nil
+		}
+		targetARN = aws.ToString(target.ScalableTargetARN)
+	}
+	resp, err := r.aas.ListTagsForResource(ctx, &aas.ListTagsForResourceInput{ResourceARN: aws.String(targetARN)})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list scalable target tags: %w", err)
+	}
+	return resp.Tags, targetARN, nil
+}
+
+// The desired tags that current lacks or has with another value
+func tagDrift(current, desired map[string]string) map[string]string {
+	drift := map[string]string{}
+	for key, value := range desired {
+		if existing, ok := current[key]; !ok || existing != value {
+			drift[key] = value
+		}
+	}
+	return drift
+}
+
+// For each custom policy, compare with existing configuration and update only if needed
+func (r *runner) applyCustomPolicies(ctx context.Context) error {
+	for _, p := range r.policies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.log.Info("processing policy", "policy_name", p.PolicyName)
+
+		policyInput, err := buildPolicyInput(p, r.resource)
+		if err != nil {
+			return fmt.Errorf("policy %s: %w", p.PolicyName, err)
 		}
 
 		// Check if policy needs to be updated
-		policyMatches, err := compareScalingPolicy(context.TODO(), aasClient, resourceID, p.PolicyName, policyInput)
+		changedFields, policyExists, err := scalingPolicyChanges(ctx, r.aas, r.resource, p.PolicyName, policyInput)
 		if err != nil {
-			slog.Error("failed to compare scaling policy", "policy_name", p.PolicyName, "error", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to compare scaling policy %s: %w", p.PolicyName, err)
 		}
 
-		policyExists := true
-		if !policyMatches {
-			// Check if policy exists at all
-			exists, err := checkScalingPolicy(context.TODO(), aasClient, resourceID, p.PolicyName)
-			if err != nil {
-				slog.Error("failed to check scaling policy existence", "policy_name", p.PolicyName, "error", err)
-				os.Exit(1)
+		// The ARN from a put saves describing the policy again for its alarm
+		var policyARN string
+		if policyExists && len(changedFields) > 0 && r.cfg.ForceRecreate {
+			r.log.Info("recreating drifted scaling policy", "policy_name", p.PolicyName, "changed_fields", changedFields)
+			if policyARN, err = r.recreatePolicy(ctx, policyInput); err != nil {
+				return err
 			}
-			policyExists = exists
-
+			// recreatePolicy deleted the old alarms, so the alarm step below finds none attached to the new ARN
+			// and creates the alarm against it
+		} else if !policyExists || len(changedFields) > 0 {
 			if policyExists {
-				slog.Info("updating scaling policy configuration", "policy_name", p.PolicyName)
+				r.log.Info("updating scaling policy configuration", "policy_name", p.PolicyName, "changed_fields", changedFields)
 			} else {
-				slog.Info("creating new scaling policy", "policy_name", p.PolicyName)
+				r.log.Info("creating new scaling policy", "policy_name", p.PolicyName)
 			}
-			_, err = aasClient.PutScalingPolicy(context.TODO(), policyInput)
+			out, err := r.aas.PutScalingPolicy(ctx, policyInput)
 			if err != nil {
-				slog.Error("failed to put scaling policy", "policy_name", p.PolicyName, "error", err)
-				os.Exit(1)
+				return fmt.Errorf("failed to put scaling policy %s: %w", p.PolicyName, err)
 			}
+			policyARN = aws.ToString(out.PolicyARN)
+			r.metrics.policy(changeAction(policyExists), 1)
 		} else {
-			slog.Info("scaling policy is up to date", "policy_name", p.PolicyName)
-		}
-
-		// Only create alarms for NEW policies to prevent "Multiple alarms attached" warnings
-		// If policy already existed, we leave existing alarms completely alone
-		if p.PolicyType == "StepScaling" && p.MetricName != "" && p.MetricNamespace != "" && !policyExists {
-			slog.Info("creating CloudWatch alarm for new scaling policy", "policy_name", p.PolicyName)
-
-			// Fetch policy ARN (needed for alarm configuration)
-			polDesc, err := aasClient.DescribeScalingPolicies(context.TODO(), &aas.DescribeScalingPoliciesInput{
-				ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-				ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-				ResourceId:        aws.String(resourceID),
-				PolicyNames:       []string{p.PolicyName},
-			})
-			if err != nil || len(polDesc.ScalingPolicies) == 0 {
-				slog.Error("failed to describe scaling policy for alarm", "policy_name", p.PolicyName, "error", err)
-				os.Exit(1)
-			}
-			policyARN := *polDesc.ScalingPolicies[0].PolicyARN
-			alarmName := fmt.Sprintf("%s-%s-%s", cluster, service, p.PolicyName)
-
-			// Determine threshold and comparison operator based on scaling direction
-			var threshold float64
-			var compOp cwTypes.ComparisonOperator
-			if p.ScaleDirection == "in" {
-				threshold = targetCPUIn
-				compOp = cwTypes.ComparisonOperatorLessThanOrEqualToThreshold
-			} else if p.ScaleDirection == "out" {
-				threshold = targetCPUOut
-				compOp = cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold
-			} else {
-				threshold = targetCPUOut
-				compOp = cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold
-			}
-
-			alarmInput := &cw.PutMetricAlarmInput{
-				AlarmName:          aws.String(alarmName),
-				AlarmDescription:   aws.String(fmt.Sprintf("Scale based on %s", p.MetricName)),
-				Namespace:          aws.String(p.MetricNamespace),
-				MetricName:         aws.String(p.MetricName),
-				Statistic:          cwTypes.StatisticAverage,
-				Period:             aws.Int32(*p.Cooldown),
-				EvaluationPeriods:  aws.Int32(2),
-				Threshold:          aws.Float64(threshold),
-				ComparisonOperator: compOp,
-				Dimensions: []cwTypes.Dimension{
-					{Name: aws.String("ClusterName"), Value: aws.String(cluster)},
-					{Name: aws.String("ServiceName"), Value: aws.String(service)},
-				},
-				AlarmActions: []string{policyARN},
-			}
-
-			// Check if alarm already exists - if it does, leave it alone
-			var alarmExists bool
-			alarmExists, err = checkCloudWatchAlarm(context.TODO(), cwClient, alarmName)
-			if err != nil {
-				slog.Error("failed to check CloudWatch alarm existence", "alarm_name", alarmName, "error", err)
-				os.Exit(1)
+			r.log.Info("scaling policy is up to date", "policy_name", p.PolicyName)
+		}
+
+		if p.PolicyType == "StepScaling" && p.MetricName != "" && p.MetricNamespace != "" && r.managesAlarm(p) {
+			if err := r.applyCustomPolicyAlarm(ctx, p, policyARN); err != nil {
+				return err
 			}
+		}
+	}
+	return nil
+}
 
-			if !alarmExists {
-				slog.Info("creating CloudWatch alarm for new policy", "alarm_name", alarmName)
-				_, err = cwClient.PutMetricAlarm(context.TODO(), alarmInput)
-				if err != nil {
-					slog.Error("failed to put metric alarm", "alarm_name", alarmName, "error", err)
-					os.Exit(1)
-				}
-			} else {
-				slog.Info("CloudWatch alarm already exists, leaving unchanged", "alarm_name", alarmName)
+// Delete a drifted policy together with the alarms attached to it, then put it again and return its new ARN.
+// Target tracking alarms are owned by AWS but can be left behind when a policy is updated in place.
+func (r *runner) recreatePolicy(ctx context.Context, desired *aas.PutScalingPolicyInput) (string, error) {
+	policyName := aws.ToString(desired.PolicyName)
+	existing, err := findScalingPolicy(ctx, r.aas, r.resource, policyName)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe scaling policy %s: %w", policyName, err)
+	}
+
+	if existing != nil {
+		var alarmNames []string
+		for _, alarm := range existing.Alarms {
+			alarmNames = append(alarmNames, aws.ToString(alarm.AlarmName))
+		}
+		if len(alarmNames) > 0 && r.managesAlarmFor(policyName) {
+			r.log.Info("deleting CloudWatch alarms", "policy_name", policyName, "alarms", alarmNames)
+			if err := deleteAlarms(ctx, r.cw, alarmNames); err != nil {
+				return "", fmt.Errorf("failed to delete alarms for scaling policy %s: %w", policyName, err)
 			}
-		} else if p.PolicyType == "StepScaling" && p.MetricName != "" && p.MetricNamespace != "" {
-			slog.Info("scaling policy already exists, leaving existing alarms unchanged", "policy_name", p.PolicyName)
+		}
+
+		r.log.Info("deleting scaling policy", "policy_name", policyName)
+		if _, err := r.aas.DeleteScalingPolicy(ctx, &aas.DeleteScalingPolicyInput{
+			ServiceNamespace:  r.resource.Namespace,
+			ScalableDimension: r.resource.Dimension,
+			ResourceId:        aws.String(r.resource.ID),
+			PolicyName:        aws.String(policyName),
+		}); err != nil {
+			return "", fmt.Errorf("failed to delete scaling policy %s: %w", policyName, err)
 		}
 	}
-	if len(policies) > 0 {
-		slog.Info("custom scaling policies applied")
-		return
+
+	r.log.Info("creating new scaling policy", "policy_name", policyName)
+	out, err := r.aas.PutScalingPolicy(ctx, desired)
+	if err != nil {
+		return "", fmt.Errorf("failed to put scaling policy %s: %w", policyName, err)
 	}
+	r.metrics.policy("created", 1)
+	return aws.ToString(out.PolicyARN), nil
+}
 
-	// (3b) default CPU step-scaling + alarms
-	slog.Info("applying default CPU step-scaling policies")
-	// a) step policies
-	for _, info := range []struct {
-		name   string
-		adjust int32
-		cd     int32
-	}{
-		{fmt.Sprintf("%s-%s-scale-out", cluster, service), 1, outCd32},
-		{fmt.Sprintf("%s-%s-scale-in", cluster, service), -1, inCd32},
-	} {
-		policyInput := &aas.PutScalingPolicyInput{
-			ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-			ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-			ResourceId:        aws.String(resourceID),
-			PolicyName:        aws.String(info.name),
-			PolicyType:        aasTypes.PolicyTypeStepScaling,
-			StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{
-				AdjustmentType:        aasTypes.AdjustmentTypeChangeInCapacity,
-				Cooldown:              aws.Int32(info.cd),
-				MetricAggregationType: aasTypes.MetricAggregationTypeMaximum,
-				StepAdjustments:       []aasTypes.StepAdjustment{{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: aws.Int32(info.adjust)}},
-			},
+// Lookups of a scaling policy whose described ARN is still empty, and the default delay between them
+const (
+	policyARNAttempts   = 3
+	policyARNRetryDelay = time.Second
+)
+
+// The ARN of a scaling policy: known, from a PutScalingPolicy response, or else looked up. Put responses carry
+// the ARN, so the lookup is only for policies that were already up to date. A policy can briefly be described
+// without its ARN, so the lookup is retried a few times before giving up.
+func (r *runner) scalingPolicyARN(ctx context.Context, policyName, known string) (string, error) {
+	if known != "" {
+		return known, nil
+	}
+	for attempt := 1; ; attempt++ {
+		existing, err := findScalingPolicy(ctx, r.aas, r.resource, policyName)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return "", fmt.Errorf("%s not found", policyName)
+		}
+		if policyARN := aws.ToString(existing.PolicyARN); policyARN != "" {
+			return policyARN, nil
+		}
+		if attempt == policyARNAttempts {
+			return "", fmt.Errorf("%s: policy ARN not yet available, retry", policyName)
 		}
+		r.log.Debug("scaling policy ARN not yet available", "policy_name", policyName, "attempt", attempt)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(r.arnRetryDelay):
+		}
+	}
+}
 
-		// Check if policy needs to be updated
-		policyMatches, err := compareScalingPolicy(context.TODO(), aasClient, resourceID, info.name, policyInput)
+// Build the desired alarm for a custom step policy
+func (r *runner) customAlarmInput(p PolicyDef, policyARN string) (*cw.PutMetricAlarmInput, error) {
+	alarmName, err := r.names.name(p.PolicyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build alarm name for policy %s: %w", p.PolicyName, err)
+	}
+
+	// Determine threshold and comparison operator based on scaling direction
+	var threshold float64
+	var compOp cwTypes.ComparisonOperator
+	if p.ScaleDirection == "in" {
+		threshold = r.cfg.TargetCPUIn
+		compOp = cwTypes.ComparisonOperatorLessThanOrEqualToThreshold
+	} else if p.ScaleDirection == "out" {
+		threshold = r.cfg.TargetCPUOut
+		compOp = cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold
+	} else {
+		threshold = r.cfg.TargetCPUOut
+		compOp = cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold
+	}
+
+	alarmInput := &cw.PutMetricAlarmInput{
+		AlarmName:               aws.String(alarmName),
+		AlarmDescription:        aws.String(withProvenance(fmt.Sprintf("Scale based on %s", p.MetricName), r.provenance)),
+		Namespace:               aws.String(p.MetricNamespace),
+		MetricName:              aws.String(p.MetricName),
+		Period:                  aws.Int32(p.alarmPeriod()),
+		EvaluationPeriods:       aws.Int32(2),
+		Threshold:               aws.Float64(threshold),
+		ComparisonOperator:      compOp,
+		Dimensions:              r.resource.alarmDimensions(),
+		AlarmActions:            r.alarmActions(policyARN),
+		Tags:                    r.alarmTags,
+		OKActions:               r.cfg.AlarmOKActions,
+		InsufficientDataActions: r.cfg.AlarmInsufficientDataActions,
+		ActionsEnabled:          aws.Bool(r.cfg.AlarmsEnabled),
+	}
+	if len(p.Dimensions) > 0 {
+		alarmInput.Dimensions = cwDimensions(p.Dimensions)
+	}
+	if p.OKActions != nil {
+		alarmInput.OKActions = p.OKActions
+	}
+	if p.InsufficientDataActions != nil {
+		alarmInput.InsufficientDataActions = p.InsufficientDataActions
+	}
+	if p.ActionsEnabled != nil {
+		alarmInput.ActionsEnabled = aws.Bool(*p.ActionsEnabled)
+	}
+	if p.Unit != "" {
+		alarmInput.Unit = cwTypes.StandardUnit(p.Unit)
+	}
+
+	statistic := p.Statistic
+	if statistic == "" {
+		statistic = r.cfg.AlarmStatistic
+	}
+	setAlarmStatistic(alarmInput, statistic)
+	if p.AnomalyDetection != nil {
+		setAnomalyDetection(alarmInput, p.AnomalyDetection)
+	}
+	return alarmInput, nil
+}
+
+// Create or reconcile the CloudWatch alarm for a custom step policy. policyARN is the ARN from putting the policy
+// in this run, or empty to look it up.
+func (r *runner) applyCustomPolicyAlarm(ctx context.Context, p PolicyDef, policyARN string) error {
+	policyARN, err := r.scalingPolicyARN(ctx, p.PolicyName, policyARN)
+	if err != nil {
+		return fmt.Errorf("failed to describe scaling policy %s for alarm: %w", p.PolicyName, err)
+	}
+	alarmInput, err := r.customAlarmInput(p, policyARN)
+	if err != nil {
+		return err
+	}
+
+	// An alarm already driving this policy (ours or one managed by hand) is left alone, so the policy
+	// never ends up with multiple alarms attached. --reconcile-alarms manages our named alarm instead.
+	if !r.cfg.ReconcileAlarms {
+		attached, err := alarmExistsForPolicy(ctx, r.cw, policyARN)
 		if err != nil {
-			slog.Error("failed to compare scaling policy", "policy_name", info.name, "error", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to check alarms for scaling policy %s: %w", p.PolicyName, err)
+		}
+		if attached {
+			r.log.Info("CloudWatch alarm already attached to scaling policy, leaving unchanged", "policy_name", p.PolicyName)
+			return nil
 		}
+	}
+	return r.ensureAlarm(ctx, alarmInput)
+}
 
-		if !policyMatches {
-			slog.Info("updating default scaling policy", "policy_name", info.name)
-			if _, err := aasClient.PutScalingPolicy(context.TODO(), policyInput); err != nil {
-				slog.Error("failed to put scaling policy", "policy_name", info.name, "error", err)
-				os.Exit(1)
-			}
-		} else {
-			slog.Info("default scaling policy is up to date", "policy_name", info.name)
+// Create an alarm if it is missing. An existing alarm is left alone unless --reconcile-alarms is set,
+// in which case it is re-put when it has drifted from the desired configuration.
+func (r *runner) ensureAlarm(ctx context.Context, desired *cw.PutMetricAlarmInput) error {
+	alarmName := aws.ToString(desired.AlarmName)
+	existing, err := describeAlarm(ctx, r.cw, alarmName)
+	if err != nil {
+		return fmt.Errorf("failed to check CloudWatch alarm existence for %s: %w", alarmName, err)
+	}
+
+	if existing == nil {
+		r.log.Info("creating CloudWatch alarm", "alarm_name", alarmName)
+		if _, err := r.cw.PutMetricAlarm(ctx, desired); err != nil {
+			return fmt.Errorf("failed to put metric alarm %s: %w", alarmName, err)
 		}
+		r.metrics.alarm("created", 1)
+		return nil
 	}
 
-	// b) describe to fetch ARNs
-	upPol, err := aasClient.DescribeScalingPolicies(context.TODO(), &aas.DescribeScalingPoliciesInput{
-		ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-		ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-		ResourceId:        aws.String(resourceID),
-		PolicyNames:       []string{fmt.Sprintf("%s-%s-scale-out", cluster, service)},
-	})
-	if err != nil || len(upPol.ScalingPolicies) == 0 {
-		slog.Error("failed to describe up-policy", "error", err)
-		os.Exit(1)
-	}
-	downPol, err := aasClient.DescribeScalingPolicies(context.TODO(), &aas.DescribeScalingPoliciesInput{
-		ServiceNamespace:  aasTypes.ServiceNamespaceEcs,
-		ScalableDimension: aasTypes.ScalableDimension("ecs:service:DesiredCount"),
-		ResourceId:        aws.String(resourceID),
-		PolicyNames:       []string{fmt.Sprintf("%s-%s-scale-in", cluster, service)},
-	})
-	if err != nil || len(downPol.ScalingPolicies) == 0 {
-		slog.Error("failed to describe down-policy", "error", err)
-		os.Exit(1)
+	if stored, ok := provenanceHash(aws.ToString(existing.AlarmDescription)); ok && stored != r.configHash {
+		r.log.Info("configuration changed since the alarm was last applied", "alarm_name", alarmName, "applied_config_hash", stored, "config_hash", r.configHash)
+	}
+
+	if !r.cfg.ReconcileAlarms {
+		r.log.Info("CloudWatch alarm already exists, leaving unchanged", "alarm_name", alarmName)
+		return nil
+	}
+
+	diffs := compareAlarm(existing, desired)
+	if len(diffs) == 0 {
+		r.log.Info("CloudWatch alarm is up to date", "alarm_name", alarmName)
+		return nil
+	}
+	r.log.Info("updating CloudWatch alarm configuration", "alarm_name", alarmName, "changed_fields", diffFieldNames(diffs))
+	if _, err := r.cw.PutMetricAlarm(ctx, desired); err != nil {
+		return fmt.Errorf("failed to put metric alarm %s: %w", alarmName, err)
+	}
+	r.metrics.alarm("updated", 1)
+	return nil
+}
+
+// Evaluation periods of the default alarms unless --default-evaluation-periods is set
+const defaultEvaluationPeriods = 2
+
+// Metrics of the default alarms unless --default-metric-namespace, --cpu-metric-name or --mem-metric-name is set
+const (
+	defaultMetricNamespace = "AWS/ECS"
+	defaultCPUMetricName   = "CPUUtilization"
+	defaultMemMetricName   = "MemoryUtilization"
+)
+
+// Build the desired alarms for the default CPU/memory step policies
+func (r *runner) defaultAlarmInputs(scaleOutARN, scaleInARN string) ([]*cw.PutMetricAlarmInput, error) {
+	// --scale-out-operator and --scale-in-operator override the inclusive comparisons
+	outOp, inOp := cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold, cwTypes.ComparisonOperatorLessThanOrEqualToThreshold
+	if r.cfg.ScaleOutOperator != "" {
+		outOp = cwTypes.ComparisonOperator(r.cfg.ScaleOutOperator)
+	}
+	if r.cfg.ScaleInOperator != "" {
+		inOp = cwTypes.ComparisonOperator(r.cfg.ScaleInOperator)
+	}
+	namespace, cpuMetric, memMetric := defaultMetricNamespace, defaultCPUMetricName, defaultMemMetricName
+	if r.cfg.DefaultMetricNamespace != "" {
+		namespace = r.cfg.DefaultMetricNamespace
+	}
+	if r.cfg.CPUMetricName != "" {
+		cpuMetric = r.cfg.CPUMetricName
+	}
+	if r.cfg.MemMetricName != "" {
+		memMetric = r.cfg.MemMetricName
 	}
 
-	// c) CloudWatch alarms
 	alarms := []struct {
-		name, desc string
-		comp       cwTypes.ComparisonOperator
-		period     int32
-		arn        string
-		metric     string
-		threshold  float64
+		suffix, desc string
+		comp         cwTypes.ComparisonOperator
+		period       int32
+		arn          string
+		metric       string
+		threshold    float64
 	}{
 		{
-			name:      fmt.Sprintf("%s-%s-cpu-high", cluster, service),
+			suffix:    "cpu-high",
 			desc:      "Scale out on high CPU",
-			comp:      cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
-			period:    outCd32,
-			arn:       *upPol.ScalingPolicies[0].PolicyARN,
-			metric:    "CPUUtilization",
-			threshold: targetCPUOut,
+			comp:      outOp,
+			period:    r.cfg.ScaleOutCooldown,
+			arn:       scaleOutARN,
+			metric:    cpuMetric,
+			threshold: r.cfg.TargetCPUOut,
 		},
 		{
-			name:      fmt.Sprintf("%s-%s-cpu-low", cluster, service),
+			suffix:    "cpu-low",
 			desc:      "Scale in on low CPU",
-			comp:      cwTypes.ComparisonOperatorLessThanOrEqualToThreshold,
-			period:    inCd32,
-			arn:       *downPol.ScalingPolicies[0].PolicyARN,
-			metric:    "CPUUtilization",
-			threshold: targetCPUIn,
+			comp:      inOp,
+			period:    r.cfg.ScaleInCooldown,
+			arn:       scaleInARN,
+			metric:    cpuMetric,
+			threshold: r.cfg.TargetCPUIn,
 		},
 		{
-			name:      fmt.Sprintf("%s-%s-mem-high", cluster, service),
+			suffix:    "mem-high",
 			desc:      "Scale out on high memory",
-			comp:      cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
-			period:    outCd32,
-			arn:       *upPol.ScalingPolicies[0].PolicyARN,
-			metric:    "MemoryUtilization",
-			threshold: targetMemOut,
+			comp:      outOp,
+			period:    r.cfg.ScaleOutCooldown,
+			arn:       scaleOutARN,
+			metric:    memMetric,
+			threshold: r.cfg.TargetMemOut,
 		},
 		{
-			name:      fmt.Sprintf("%s-%s-mem-low", cluster, service),
+			suffix:    "mem-low",
 			desc:      "Scale in on low memory",
-			comp:      cwTypes.ComparisonOperatorLessThanOrEqualToThreshold,
-			period:    inCd32,
-			arn:       *downPol.ScalingPolicies[0].PolicyARN,
-			metric:    "MemoryUtilization",
-			threshold: targetMemIn,
+			comp:      inOp,
+			period:    r.cfg.ScaleInCooldown,
+			arn:       scaleInARN,
+			metric:    memMetric,
+			threshold: r.cfg.TargetMemIn,
 		},
 	}
 
-	// Only create alarms if they don't already exist
-	slog.Info("configuring CloudWatch alarms for default policies")
+	// --default-evaluation-periods and --default-alarm-period override the built-in 2 periods of one cooldown each
+	evaluationPeriods := int32(defaultEvaluationPeriods)
+	if r.cfg.DefaultEvaluationPeriods > 0 {
+		evaluationPeriods = r.cfg.DefaultEvaluationPeriods
+	}
+
+	inputs := make([]*cw.PutMetricAlarmInput, 0, len(alarms))
 	for _, a := range alarms {
+		if r.cfg.DefaultAlarmPeriod > 0 {
+			a.period = r.cfg.DefaultAlarmPeriod
+		}
+		alarmName, err := r.names.name(a.suffix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build alarm name: %w", err)
+		}
 		alarmInput := &cw.PutMetricAlarmInput{
-			AlarmName:          aws.String(a.name),
-			AlarmDescription:   aws.String(a.desc),
-			Namespace:          aws.String("AWS/ECS"),
-			MetricName:         aws.String(a.metric),
-			Statistic:          cwTypes.StatisticAverage,
-			Period:             aws.Int32(a.period),
-			EvaluationPeriods:  aws.Int32(2),
-			Threshold:          aws.Float64(a.threshold),
-			ComparisonOperator: a.comp,
-			Dimensions: []cwTypes.Dimension{
-				{Name: aws.String("ClusterName"), Value: aws.String(cluster)},
-				{Name: aws.String("ServiceName"), Value: aws.String(service)},
-			},
-			AlarmActions: []string{a.arn},
+			AlarmName:               aws.String(alarmName),
+			AlarmDescription:        aws.String(withProvenance(a.desc, r.provenance)),
+			Namespace:               aws.String(namespace),
+			MetricName:              aws.String(a.metric),
+			Period:                  aws.Int32(a.period),
+			EvaluationPeriods:       aws.Int32(evaluationPeriods),
+			Threshold:               aws.Float64(a.threshold),
+			ComparisonOperator:      a.comp,
+			Dimensions:              append(r.resource.alarmDimensions(), cwDimensions(r.cfg.ExtraAlarmDimensions)...),
+			AlarmActions:            r.alarmActions(a.arn),
+			Tags:                    r.alarmTags,
+			OKActions:               r.cfg.AlarmOKActions,
+			InsufficientDataActions: r.cfg.AlarmInsufficientDataActions,
+			ActionsEnabled:          aws.Bool(r.cfg.AlarmsEnabled),
 		}
+		setAlarmStatistic(alarmInput, r.cfg.AlarmStatistic)
+		inputs = append(inputs, alarmInput)
+	}
+	return inputs, nil
+}
 
-		// Check if alarm already exists - if it does, leave it alone
-		var alarmExists bool
-		alarmExists, err = checkCloudWatchAlarm(context.TODO(), cwClient, a.name)
+// Apply the default CPU/memory step-scaling policies and their alarms
+func (r *runner) applyDefaultPolicies(ctx context.Context) error {
+	r.log.Info("applying default CPU step-scaling policies")
+	// a) step policies, keeping the ARNs of those put so they need not be described again
+	arns := map[string]string{}
+	for _, info := range []struct {
+		name  string
+		steps []StepAdj
+		cd    int32
+	}{
+		{r.scaleOutName, r.scaleOutSteps(), r.cfg.ScaleOutCooldown},
+		{r.scaleInName, r.scaleInSteps(), r.cfg.ScaleInCooldown},
+	} {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		policyInput := defaultStepPolicyInput(r.resource, info.name, info.steps, info.cd, r.cfg.DefaultAggregationType)
+
+		// Check if policy needs to be updated
+		changedFields, policyExists, err := scalingPolicyChanges(ctx, r.aas, r.resource, info.name, policyInput)
 		if err != nil {
-			slog.Error("failed to check CloudWatch alarm existence", "alarm_name", a.name, "error", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to compare scaling policy %s: %w", info.name, err)
 		}
 
-		if !alarmExists {
-			slog.Info("creating CloudWatch alarm for default policy", "alarm_name", a.name)
-			_, err = cwClient.PutMetricAlarm(context.TODO(), alarmInput)
+		if policyExists && len(changedFields) > 0 && r.cfg.ForceRecreate {
+			r.log.Info("recreating drifted default scaling policy", "policy_name", info.name, "changed_fields", changedFields)
+			if arns[info.name], err = r.recreatePolicy(ctx, policyInput); err != nil {
+				return err
+			}
+		} else if !policyExists || len(changedFields) > 0 {
+			r.log.Info("updating default scaling policy", "policy_name", info.name, "changed_fields", changedFields)
+			out, err := r.aas.PutScalingPolicy(ctx, policyInput)
 			if err != nil {
-				slog.Error("failed to put metric alarm", "alarm_name", a.name, "error", err)
-				os.Exit(1)
+				return fmt.Errorf("failed to put scaling policy %s: %w", info.name, err)
 			}
+			arns[info.name] = aws.ToString(out.PolicyARN)
+			r.metrics.policy(changeAction(policyExists), 1)
 		} else {
-			slog.Info("CloudWatch alarm already exists, leaving unchanged", "alarm_name", a.name)
+			r.log.Info("default scaling policy is up to date", "policy_name", info.name)
+		}
+	}
+
+	if r.cfg.NoAlarms {
+		r.log.Info("leaving CloudWatch alarms for default policies unmanaged", "no_alarms", true)
+		return nil
+	}
+
+	// b) ARNs of the policies that were not put, from describe
+	upARN, err := r.scalingPolicyARN(ctx, r.scaleOutName, arns[r.scaleOutName])
+	if err != nil {
+		return fmt.Errorf("failed to describe up-policy: %w", err)
+	}
+	downARN, err := r.scalingPolicyARN(ctx, r.scaleInName, arns[r.scaleInName])
+	if err != nil {
+		return fmt.Errorf("failed to describe down-policy: %w", err)
+	}
+
+	// c) CloudWatch alarms
+	alarms, err := r.defaultAlarmInputs(upARN, downARN)
+	if err != nil {
+		return err
+	}
+
+	// Only create alarms if they don't already exist (or reconcile them when asked to)
+	r.log.Info("configuring CloudWatch alarms for default policies")
+	for _, alarmInput := range alarms {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
+		if err := r.ensureAlarm(ctx, alarmInput); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	cfg, err := parseArgs(os.Args[1:])
+	if err != nil {
+		slog.Error("invalid arguments", "error", err)
+		os.Exit(earlyExitCode(os.Args[1:], nil, err))
+	}
+	if cfg.Usage != "" {
+		fmt.Print(cfg.Usage)
+		return
+	}
+	if cfg.Version {
+		fmt.Println(currentBuildInfo())
+		return
 	}
 
-	slog.Info("default CPU and memory auto-scaling & alarms configured")
+	// Set up structured logging with slog
+	if _, err := SetupLogging(LoggingOptions{Writer: os.Stderr, Format: cfg.LogFormat, Level: cfg.LogLevel}); err != nil {
+		slog.Error("invalid logging configuration", "error", err)
+		os.Exit(earlyExitCode(nil, cfg, validationError(err)))
+	}
+	slog.Debug("parsed configuration", "config", cfg)
+
+	// SIGINT and SIGTERM cancel in-flight calls and end an --interval loop cleanly. Once cancelled the default
+	// handling is restored, so a second signal still kills a run that does not stop.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// AWS config
+	awsCfg, err := loadAWSConfig(ctx, cfg)
+	if err != nil {
+		slog.Error("loading AWS config", awsErrorFields(err)...)
+		os.Exit(earlyExitCode(nil, cfg, err))
+	}
+
+	clients := newClients(awsCfg, cfg.EndpointURL)
+	run := Run
+	if cfg.AllServicesInCluster {
+		run = RunCluster
+	}
+	if err := run(ctx, cfg, clients, os.Stdout); err != nil {
+		// Each kind of failure gets its own exit code so pipelines can tell them apart
+		code := exitCode(err)
+		if cfg.DiffOnlyExitCode {
+			code = diffOnlyExitCode(err)
+		}
+		if errors.Is(err, errChanged) {
+			os.Exit(code)
+		}
+		if errors.Is(err, errDrift) {
+			slog.Error("verification failed", append(awsErrorFields(err), "exit_code", code)...)
+		} else {
+			slog.Error("ecs-autoscaler failed", append(awsErrorFields(err), "exit_code", code)...)
+		}
+		os.Exit(code)
+	}
 }
@@ -4,74 +4,288 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
 	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 )
 
+// testResource returns the ECS service resource for a resource ID such as service/test-cluster/test-service
+func testResource(id string) resourceRef {
+	return resourceRef{
+		Namespace: aasTypes.ServiceNamespaceEcs,
+		ID:        id,
+		Dimension: aasTypes.ScalableDimensionECSServiceDesiredCount,
+	}
+}
+
 // Mock AWS clients for testing
 type mockAASClient struct {
 	describeScalableTargetsOutput *applicationautoscaling.DescribeScalableTargetsOutput
 	describeScalableTargetsError  error
+	describeScalableTargetsSeq    []*applicationautoscaling.DescribeScalableTargetsOutput // one per call, last repeats; overrides describeScalableTargetsOutput
+	describeScalableTargetsCalls  int
 	describeScalingPoliciesOutput *applicationautoscaling.DescribeScalingPoliciesOutput
+	describeScalingPoliciesPages  []*applicationautoscaling.DescribeScalingPoliciesOutput
+	describeScalingPoliciesSeq    []*applicationautoscaling.DescribeScalingPoliciesOutput // one per call, last repeats; overrides describeScalingPoliciesOutput
 	describeScalingPoliciesError  error
+	describeScalingPoliciesCalls  int
+	putScalingPolicyARNs          map[string]string // PolicyARN returned by PutScalingPolicy, per policy name
 	deleteScalingPolicyError      error
+	deleteScalingPolicyErrors     map[string]error // per policy name, overrides deleteScalingPolicyError
 	deregisterScalableTargetError error
 	registerScalableTargetError   error
 	putScalingPolicyError         error
+	describeActivitiesOutput      *applicationautoscaling.DescribeScalingActivitiesOutput
+	describeActivitiesError       error
+	describeActivitiesCalls       []*applicationautoscaling.DescribeScalingActivitiesInput
+	listTagsOutput                map[string]string // tags ListTagsForResource returns for any ARN
+	listTagsError                 error
+	tagResourceError              error
+
+	// Recorded mutating calls; calls lists every mutating method name in order
+	calls                         []string
+	registerScalableTargetCalls   []*applicationautoscaling.RegisterScalableTargetInput
+	putScalingPolicyCalls         []*applicationautoscaling.PutScalingPolicyInput
+	deleteScalingPolicyCalls      []*applicationautoscaling.DeleteScalingPolicyInput
+	deregisterScalableTargetCalls []*applicationautoscaling.DeregisterScalableTargetInput
+	tagResourceCalls              []*applicationautoscaling.TagResourceInput
 }
 
 func (m *mockAASClient) DescribeScalableTargets(ctx context.Context, params *applicationautoscaling.DescribeScalableTargetsInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DescribeScalableTargetsOutput, error) {
+	m.describeScalableTargetsCalls++
+	if n := len(m.describeScalableTargetsSeq); n > 0 {
+		return m.describeScalableTargetsSeq[min(m.describeScalableTargetsCalls, n)-1], m.describeScalableTargetsError
+	}
 	return m.describeScalableTargetsOutput, m.describeScalableTargetsError
 }
 
 func (m *mockAASClient) DescribeScalingPolicies(ctx context.Context, params *applicationautoscaling.DescribeScalingPoliciesInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DescribeScalingPoliciesOutput, error) {
+	m.describeScalingPoliciesCalls++
+	if len(m.describeScalingPoliciesPages) > 0 {
+		// Serve pages in order, using the page index as the NextToken
+		idx := 0
+		if params.NextToken != nil {
+			idx, _ = strconv.Atoi(*params.NextToken)
+		}
+		page := *m.describeScalingPoliciesPages[idx]
+		if idx+1 < len(m.describeScalingPoliciesPages) {
+			page.NextToken = aws.String(strconv.Itoa(idx + 1))
+		}
+		return &page, m.describeScalingPoliciesError
+	}
+	if n := len(m.describeScalingPoliciesSeq); n > 0 {
+		return m.describeScalingPoliciesSeq[min(m.describeScalingPoliciesCalls, n)-1], m.describeScalingPoliciesError
+	}
 	return m.describeScalingPoliciesOutput, m.describeScalingPoliciesError
 }
 
 func (m *mockAASClient) RegisterScalableTarget(ctx context.Context, params *applicationautoscaling.RegisterScalableTargetInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.RegisterScalableTargetOutput, error) {
-	return &applicationautoscaling.RegisterScalableTargetOutput{}, m.registerScalableTargetError
+	m.registerScalableTargetCalls = append(m.registerScalableTargetCalls, params)
+	m.calls = append(m.calls, "RegisterScalableTarget")
+	return &applicationautoscaling.RegisterScalableTargetOutput{ScalableTargetARN: aws.String("arn:scalable-target")}, m.registerScalableTargetError
 }
 
 func (m *mockAASClient) PutScalingPolicy(ctx context.Context, params *applicationautoscaling.PutScalingPolicyInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.PutScalingPolicyOutput, error) {
+	m.putScalingPolicyCalls = append(m.putScalingPolicyCalls, params)
+	m.calls = append(m.calls, "PutScalingPolicy")
+	if arn, ok := m.putScalingPolicyARNs[aws.ToString(params.PolicyName)]; ok {
+		return &applicationautoscaling.PutScalingPolicyOutput{PolicyARN: aws.String(arn)}, m.putScalingPolicyError
+	}
 	return &applicationautoscaling.PutScalingPolicyOutput{}, m.putScalingPolicyError
 }
 
 func (m *mockAASClient) DeleteScalingPolicy(ctx context.Context, params *applicationautoscaling.DeleteScalingPolicyInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DeleteScalingPolicyOutput, error) {
+	m.deleteScalingPolicyCalls = append(m.deleteScalingPolicyCalls, params)
+	m.calls = append(m.calls, "DeleteScalingPolicy")
+	if err, ok := m.deleteScalingPolicyErrors[aws.ToString(params.PolicyName)]; ok {
+		return nil, err
+	}
 	return &applicationautoscaling.DeleteScalingPolicyOutput{}, m.deleteScalingPolicyError
 }
 
+func (m *mockAASClient) DescribeScalingActivities(ctx context.Context, params *applicationautoscaling.DescribeScalingActivitiesInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DescribeScalingActivitiesOutput, error) {
+	m.describeActivitiesCalls = append(m.describeActivitiesCalls, params)
+	if m.describeActivitiesOutput == nil {
+		return &applicationautoscaling.DescribeScalingActivitiesOutput{}, m.describeActivitiesError
+	}
+	return m.describeActivitiesOutput, m.describeActivitiesError
+}
+
+func (m *mockAASClient) TagResource(ctx context.Context, params *applicationautoscaling.TagResourceInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.TagResourceOutput, error) {
+	m.tagResourceCalls = append(m.tagResourceCalls, params)
+	m.calls = append(m.calls, "TagResource")
+	return &applicationautoscaling.TagResourceOutput{}, m.tagResourceError
+}
+
+func (m *mockAASClient) ListTagsForResource(ctx context.Context, params *applicationautoscaling.ListTagsForResourceInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.ListTagsForResourceOutput, error) {
+	return &applicationautoscaling.ListTagsForResourceOutput{Tags: m.listTagsOutput}, m.listTagsError
+}
+
 func (m *mockAASClient) DeregisterScalableTarget(ctx context.Context, params *applicationautoscaling.DeregisterScalableTargetInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DeregisterScalableTargetOutput, error) {
+	m.deregisterScalableTargetCalls = append(m.deregisterScalableTargetCalls, params)
+	m.calls = append(m.calls, "DeregisterScalableTarget")
 	return &applicationautoscaling.DeregisterScalableTargetOutput{}, m.deregisterScalableTargetError
 }
 
 type mockCWClient struct {
 	describeAlarmsOutput *cloudwatch.DescribeAlarmsOutput
 	describeAlarmsError  error
+	describeAlarmsErrors []error // one per call, nil for success; overrides describeAlarmsError while it lasts
 	deleteAlarmsError    error
 	putMetricAlarmError  error
+
+	// Recorded mutating calls
+	deleteAlarmsCalls   []*cloudwatch.DeleteAlarmsInput
+	putMetricAlarmCalls []*cloudwatch.PutMetricAlarmInput
+
+	// Recorded reads
+	describeAlarmsCalls []*cloudwatch.DescribeAlarmsInput
 }
 
 func (m *mockCWClient) DescribeAlarms(ctx context.Context, params *cloudwatch.DescribeAlarmsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	m.describeAlarmsCalls = append(m.describeAlarmsCalls, params)
+	if n := len(m.describeAlarmsCalls); n <= len(m.describeAlarmsErrors) {
+		if err := m.describeAlarmsErrors[n-1]; err != nil {
+			return nil, err
+		}
+		return m.describeAlarmsOutput, nil
+	}
 	return m.describeAlarmsOutput, m.describeAlarmsError
 }
 
 func (m *mockCWClient) DeleteAlarms(ctx context.Context, params *cloudwatch.DeleteAlarmsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DeleteAlarmsOutput, error) {
+	m.deleteAlarmsCalls = append(m.deleteAlarmsCalls, params)
 	return &cloudwatch.DeleteAlarmsOutput{}, m.deleteAlarmsError
 }
 
 func (m *mockCWClient) PutMetricAlarm(ctx context.Context, params *cloudwatch.PutMetricAlarmInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	m.putMetricAlarmCalls = append(m.putMetricAlarmCalls, params)
 	return &cloudwatch.PutMetricAlarmOutput{}, m.putMetricAlarmError
 }
 
+// cancelOn cancels a run's context right after the first call to operation, like a SIGINT arriving mid-run, and
+// counts the calls made after that. The mocks ignore ctx, so a late call is a loop that did not stop between
+// iterations.
+type cancelOn struct {
+	operation string
+	cancel    context.CancelFunc
+	cancelled bool
+	late      []string
+}
+
+// Wrap the clients so each call goes through the canceller; calls not overridden here are passed through uncounted
+func (c *cancelOn) clients(aasClient AASClient, cwClient CWClient) Clients {
+	return Clients{AAS: cancellingAASClient{AASClient: aasClient, c: c}, CW: cancellingCWClient{CWClient: cwClient, c: c}}
+}
+
+func (c *cancelOn) call(operation string) {
+	switch {
+	case c.cancelled:
+		c.late = append(c.late, operation)
+	case operation == c.operation:
+		c.cancelled = true
+		c.cancel()
+	}
+}
+
+type cancellingAASClient struct {
+	AASClient
+	c *cancelOn
+}
+
+func (m cancellingAASClient) DescribeScalableTargets(ctx context.Context, params *applicationautoscaling.DescribeScalableTargetsInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DescribeScalableTargetsOutput, error) {
+	m.c.call("DescribeScalableTargets")
+	return m.AASClient.DescribeScalableTargets(ctx, params, optFns...)
+}
+
+func (m cancellingAASClient) DescribeScalingPolicies(ctx context.Context, params *applicationautoscaling.DescribeScalingPoliciesInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DescribeScalingPoliciesOutput, error) {
+	m.c.call("DescribeScalingPolicies")
+	return m.AASClient.DescribeScalingPolicies(ctx, params, optFns...)
+}
+
+func (m cancellingAASClient) RegisterScalableTarget(ctx context.Context, params *applicationautoscaling.RegisterScalableTargetInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.RegisterScalableTargetOutput, error) {
+	m.c.call("RegisterScalableTarget")
+	return m.AASClient.RegisterScalableTarget(ctx, params, optFns...)
+}
+
+func (m cancellingAASClient) PutScalingPolicy(ctx context.Context, params *applicationautoscaling.PutScalingPolicyInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.PutScalingPolicyOutput, error) {
+	m.c.call("PutScalingPolicy")
+	return m.AASClient.PutScalingPolicy(ctx, params, optFns...)
+}
+
+func (m cancellingAASClient) DeleteScalingPolicy(ctx context.Context, params *applicationautoscaling.DeleteScalingPolicyInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DeleteScalingPolicyOutput, error) {
+	m.c.call("DeleteScalingPolicy")
+	return m.AASClient.DeleteScalingPolicy(ctx, params, optFns...)
+}
+
+func (m cancellingAASClient) DeregisterScalableTarget(ctx context.Context, params *applicationautoscaling.DeregisterScalableTargetInput, optFns ...func(*applicationautoscaling.Options)) (*applicationautoscaling.DeregisterScalableTargetOutput, error) {
+	m.c.call("DeregisterScalableTarget")
+	return m.AASClient.DeregisterScalableTarget(ctx, params, optFns...)
+}
+
+type cancellingCWClient struct {
+	CWClient
+	c *cancelOn
+}
+
+func (m cancellingCWClient) DescribeAlarms(ctx context.Context, params *cloudwatch.DescribeAlarmsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	m.c.call("DescribeAlarms")
+	return m.CWClient.DescribeAlarms(ctx, params, optFns...)
+}
+
+func (m cancellingCWClient) DeleteAlarms(ctx context.Context, params *cloudwatch.DeleteAlarmsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DeleteAlarmsOutput, error) {
+	m.c.call("DeleteAlarms")
+	return m.CWClient.DeleteAlarms(ctx, params, optFns...)
+}
+
+func (m cancellingCWClient) PutMetricAlarm(ctx context.Context, params *cloudwatch.PutMetricAlarmInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	m.c.call("PutMetricAlarm")
+	return m.CWClient.PutMetricAlarm(ctx, params, optFns...)
+}
+
+// checkGoroutineLeaks fails the test if it ends with more goroutines than it started with, allowing a moment for
+// those already stopping to exit
+func checkGoroutineLeaks(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if after := runtime.NumGoroutine(); after > before {
+			buf := make([]byte, 1<<16)
+			t.Errorf("%d goroutines leaked:\n%s", after-before, buf[:runtime.Stack(buf, true)])
+		}
+	})
+}
+
 // TestGetIntWithDefault_Valid ensures getIntWithDefault returns the correct integer for a valid string.
 func TestGetIntWithDefault_Valid(t *testing.T) {
 	got, err := getIntWithDefault("123", "test", 1)
@@ -96,6 +310,42 @@ func TestGetIntWithDefault_Empty(t *testing.T) {
 	}
 }
 
+// TestGetInt32WithDefault tests that values outside the int32 range are rejected instead of wrapping
+func TestGetInt32WithDefault(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    int32
+		wantErr bool
+	}{
+		{"valid", "123", 123, false},
+		{"empty", "", 42, false},
+		{"max int32", strconv.Itoa(math.MaxInt32), math.MaxInt32, false},
+		{"above max int32", strconv.Itoa(math.MaxInt32 + 1), 0, true},
+		{"would wrap negative", "4294967295", 0, true},
+		{"below min int32", strconv.Itoa(math.MinInt32 - 1), 0, true},
+		{"invalid", "ten", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getInt32WithDefault(tt.arg, "min-capacity", 42)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getInt32WithDefault(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getInt32WithDefault(%q) = %d, want %d", tt.arg, got, tt.want)
+			}
+		})
+	}
+
+	// Through parseArgs, a pasted huge capacity fails rather than reaching AWS as a negative number
+	args := testPositionalArgs()
+	args[6] = "2147483648"
+	if _, err := parseArgs(args); err == nil || !strings.Contains(err.Error(), "invalid min-capacity: value out of range") {
+		t.Errorf("parseArgs() error = %v, want min-capacity out of range", err)
+	}
+}
+
 // TestGetIntWithDefault_Invalid ensures getIntWithDefault handles invalid input correctly.
 func TestGetIntWithDefault_Invalid(t *testing.T) {
 	// Create a buffer to capture log output
@@ -234,6 +484,55 @@ func TestUnmarshalStepScalingPolicyWithMetric(t *testing.T) {
 	}
 }
 
+// TestStepScalingMinAdjustmentMagnitude tests that min_adjustment_magnitude is parsed, applied and compared
+func TestStepScalingMinAdjustmentMagnitude(t *testing.T) {
+	policies, err := parsePolicies(`[
+      {
+        "policy_name": "percent-step",
+        "policy_type": "StepScaling",
+        "adjustment_type": "PercentChangeInCapacity",
+        "min_adjustment_magnitude": 2,
+        "cooldown": 60,
+        "step_adjustments": [
+          {"MetricIntervalLowerBound": 0, "ScalingAdjustment": 20}
+        ]
+      }
+    ]`, "")
+	if err != nil {
+		t.Fatalf("parsePolicies() unexpected error: %v", err)
+	}
+	if got := aws.ToInt32(policies[0].MinAdjustmentMagnitude); got != 2 {
+		t.Fatalf("MinAdjustmentMagnitude: got %d, want 2", got)
+	}
+
+	mockAAS := &mockAASClient{describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{}}
+	r := newTestRunner(t, true, policies, mockAAS, &mockCWClient{})
+	if err := r.applyCustomPolicies(context.Background()); err != nil {
+		t.Fatalf("applyCustomPolicies() unexpected error: %v", err)
+	}
+	if len(mockAAS.putScalingPolicyCalls) != 1 {
+		t.Fatalf("PutScalingPolicy called %d times, want 1", len(mockAAS.putScalingPolicyCalls))
+	}
+	put := mockAAS.putScalingPolicyCalls[0]
+	if got := aws.ToInt32(put.StepScalingPolicyConfiguration.MinAdjustmentMagnitude); got != 2 {
+		t.Errorf("PutScalingPolicy MinAdjustmentMagnitude = %d, want 2", got)
+	}
+
+	// A policy created without it has drifted
+	existing := &aasTypes.ScalingPolicy{
+		PolicyType: aasTypes.PolicyTypeStepScaling,
+		StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{
+			AdjustmentType:  aasTypes.AdjustmentTypePercentChangeInCapacity,
+			Cooldown:        aws.Int32(60),
+			StepAdjustments: put.StepScalingPolicyConfiguration.StepAdjustments,
+		},
+	}
+	want := []string{"StepScalingPolicyConfiguration.MinAdjustmentMagnitude"}
+	if got := diffFieldNames(diffScalingPolicy(existing, put)); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffScalingPolicy() fields = %v, want %v", got, want)
+	}
+}
+
 // TestUnmarshalTargetTrackingPolicy tests JSON unmarshalling of a TargetTrackingScaling policy.
 func TestUnmarshalTargetTrackingPolicy(t *testing.T) {
 	jsonStr := `[
@@ -290,7 +589,8 @@ func TestUnmarshalTargetTrackingPolicyWithCustomMetric(t *testing.T) {
               "Dimension1": "Value1",
               "Dimension2": "Value2"
             },
-            "statistic": "Average"
+            "statistic": "Average",
+            "unit": "Percent"
           },
           "scale_in_cooldown": 200,
           "scale_out_cooldown": 200
@@ -322,6 +622,9 @@ func TestUnmarshalTargetTrackingPolicyWithCustomMetric(t *testing.T) {
 	if cfg.CustomMetricSpecification.Statistic != "Average" {
 		t.Errorf("Statistic: got %q, want %q", cfg.CustomMetricSpecification.Statistic, "Average")
 	}
+	if cfg.CustomMetricSpecification.Unit != "Percent" {
+		t.Errorf("Unit: got %q, want %q", cfg.CustomMetricSpecification.Unit, "Percent")
+	}
 	if len(cfg.CustomMetricSpecification.Dimensions) != 2 {
 		t.Errorf("Dimensions: got %d, want %d", len(cfg.CustomMetricSpecification.Dimensions), 2)
 	}
@@ -358,13 +661,14 @@ func TestCheckScalableTarget(t *testing.T) {
 
 	// Test cases
 	tests := []struct {
-		name     string
-		resource string
-		minCap   int32
-		maxCap   int32
-		mock     *mockAASClient
-		want     bool
-		wantErr  bool
+		name      string
+		resource  string
+		minCap    int32
+		maxCap    int32
+		suspended *aasTypes.SuspendedState
+		mock      *mockAASClient
+		want      bool
+		wantErr   bool
 	}{
 		{
 			name:     "valid target",
@@ -384,6 +688,45 @@ func TestCheckScalableTarget(t *testing.T) {
 			want:    true,
 			wantErr: false,
 		},
+		{
+			name:      "suspended state matches",
+			resource:  "service/test-cluster/test-service",
+			minCap:    1,
+			maxCap:    10,
+			suspended: &aasTypes.SuspendedState{DynamicScalingInSuspended: aws.Bool(false), DynamicScalingOutSuspended: aws.Bool(false)},
+			mock: &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+					ScalableTargets: []aasTypes.ScalableTarget{
+						{
+							MinCapacity: aws.Int32(1),
+							MaxCapacity: aws.Int32(10),
+						},
+					},
+				},
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:      "suspended state differs",
+			resource:  "service/test-cluster/test-service",
+			minCap:    1,
+			maxCap:    10,
+			suspended: &aasTypes.SuspendedState{DynamicScalingInSuspended: aws.Bool(true)},
+			mock: &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+					ScalableTargets: []aasTypes.ScalableTarget{
+						{
+							MinCapacity:    aws.Int32(1),
+							MaxCapacity:    aws.Int32(10),
+							SuspendedState: &aasTypes.SuspendedState{DynamicScalingInSuspended: aws.Bool(false)},
+						},
+					},
+				},
+			},
+			want:    false,
+			wantErr: false,
+		},
 		{
 			name:     "invalid target",
 			resource: "service/invalid-cluster/invalid-service",
@@ -412,7 +755,7 @@ func TestCheckScalableTarget(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := checkScalableTarget(ctx, tt.mock, tt.resource, tt.minCap, tt.maxCap)
+			got, err := checkScalableTarget(ctx, tt.mock, testResource(tt.resource), tt.minCap, tt.maxCap, tt.suspended)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("checkScalableTarget() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -480,7 +823,7 @@ func TestCheckScalingPolicy(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := checkScalingPolicy(ctx, tt.mock, tt.resource, tt.policyName)
+			got, err := checkScalingPolicy(ctx, tt.mock, testResource(tt.resource), tt.policyName)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("checkScalingPolicy() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -556,6 +899,177 @@ func TestCheckCloudWatchAlarm(t *testing.T) {
 	}
 }
 
+// TestSortPolicies tests that policies apply by priority, lowest first, then by name, and keep their input order
+// when no priority is set
+func TestSortPolicies(t *testing.T) {
+	names := func(policies []PolicyDef) []string {
+		var out []string
+		for _, p := range policies {
+			out = append(out, p.PolicyName)
+		}
+		return out
+	}
+	tests := []struct {
+		name     string
+		policies []PolicyDef
+		want     []string
+	}{
+		{"no priorities", []PolicyDef{{PolicyName: "mem"}, {PolicyName: "cpu"}, {PolicyName: "queue"}}, []string{"mem", "cpu", "queue"}},
+		{"priorities", []PolicyDef{{PolicyName: "mem", Priority: 2}, {PolicyName: "cpu", Priority: 1}, {PolicyName: "queue", Priority: 3}}, []string{"cpu", "mem", "queue"}},
+		{"ties by name", []PolicyDef{{PolicyName: "mem", Priority: 1}, {PolicyName: "cpu", Priority: 1}, {PolicyName: "alb"}}, []string{"alb", "cpu", "mem"}},
+		{"negative first", []PolicyDef{{PolicyName: "cpu"}, {PolicyName: "queue", Priority: -1}}, []string{"queue", "cpu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortPolicies(tt.policies)
+			if got := names(tt.policies); !slices.Equal(got, tt.want) {
+				t.Errorf("sortPolicies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// parsePolicies sorts before expanding bidirectional policies, so the halves stay together, and the policies
+	// are put in that order
+	raw := `[
+		{"policy_name": "queue", "policy_type": "TargetTrackingScaling", "priority": 2, "target_tracking_configuration": {"target_value": 50, "predefined_metric_specification": "ECSServiceAverageCPUUtilization"}},
+		{"policy_name": "cpu", "policy_type": "StepScaling", "priority": 1, "bidirectional": true, "metric_name": "CPUUtilization", "metric_namespace": "AWS/ECS", "adjustment_type": "ChangeInCapacity",
+			"step_adjustments": [{"MetricIntervalLowerBound": 0, "ScalingAdjustment": 1}, {"MetricIntervalUpperBound": 0, "ScalingAdjustment": -1}]}
+	]`
+	policies, err := parsePolicies(raw, "")
+	if err != nil {
+		t.Fatalf("parsePolicies() unexpected error: %v", err)
+	}
+	if got, want := names(policies), []string{"cpu-out", "cpu-in", "queue"}; !slices.Equal(got, want) {
+		t.Errorf("parsePolicies() order = %v, want %v", got, want)
+	}
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		putScalingPolicyARNs:          map[string]string{"cpu-out": "arn:cpu-out", "cpu-in": "arn:cpu-in", "queue": "arn:queue"},
+	}
+	r := newTestRunner(t, true, policies, mockAAS, &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}})
+	if err := r.apply(context.Background()); err != nil {
+		t.Fatalf("apply() unexpected error: %v", err)
+	}
+	var put []string
+	for _, in := range mockAAS.putScalingPolicyCalls {
+		put = append(put, aws.ToString(in.PolicyName))
+	}
+	if want := []string{"cpu-out", "cpu-in", "queue"}; !slices.Equal(put, want) {
+		t.Errorf("PutScalingPolicy order = %v, want %v", put, want)
+	}
+}
+
+// TestScalingPolicyARN tests that a policy described without its ARN is looked up again, and that one whose ARN
+// never appears fails the default-policy path with a clean error rather than a panic or an alarm without actions
+func TestScalingPolicyARN(t *testing.T) {
+	ctx := context.Background()
+	withoutARN := &applicationautoscaling.DescribeScalingPoliciesOutput{ScalingPolicies: []aasTypes.ScalingPolicy{
+		{PolicyName: aws.String("test-cluster-test-service-scale-out")},
+		{PolicyName: aws.String("test-cluster-test-service-scale-in")},
+	}}
+	withARN := &applicationautoscaling.DescribeScalingPoliciesOutput{ScalingPolicies: []aasTypes.ScalingPolicy{
+		{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+	}}
+
+	mockAAS := &mockAASClient{describeScalingPoliciesSeq: []*applicationautoscaling.DescribeScalingPoliciesOutput{withoutARN, withARN}}
+	r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+	r.arnRetryDelay = time.Millisecond
+	got, err := r.scalingPolicyARN(ctx, "test-cluster-test-service-scale-out", "")
+	if err != nil || got != "arn:out" {
+		t.Errorf("scalingPolicyARN() = %q, %v, want arn:out once it is available", got, err)
+	}
+	if mockAAS.describeScalingPoliciesCalls != 2 {
+		t.Errorf("DescribeScalingPolicies called %d times, want 2", mockAAS.describeScalingPoliciesCalls)
+	}
+	if got, err := r.scalingPolicyARN(ctx, "test-cluster-test-service-scale-out", "arn:put"); err != nil || got != "arn:put" || mockAAS.describeScalingPoliciesCalls != 2 {
+		t.Errorf("scalingPolicyARN() with a known ARN = %q, %v, want arn:put without a lookup", got, err)
+	}
+
+	mockAAS = &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: withoutARN,
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	r = newTestRunner(t, true, nil, mockAAS, mockCW)
+	r.arnRetryDelay = time.Millisecond
+	err = r.applyDefaultPolicies(ctx)
+	if err == nil || !strings.Contains(err.Error(), "policy ARN not yet available, retry") {
+		t.Fatalf("applyDefaultPolicies() error = %v, want the ARN to be not yet available", err)
+	}
+	if len(mockCW.putMetricAlarmCalls) != 0 {
+		t.Errorf("PutMetricAlarm called %d times without a policy ARN, want 0", len(mockCW.putMetricAlarmCalls))
+	}
+}
+
+// TestAlarmBatches tests that alarm names are described and deleted at most 100 per call, and that a failed
+// batch does not stop the rest
+func TestAlarmBatches(t *testing.T) {
+	ctx := context.Background()
+	names := make([]string, 150)
+	var alarms []cwTypes.MetricAlarm
+	for i := range names {
+		names[i] = fmt.Sprintf("alarm-%03d", i)
+		if i%2 == 0 {
+			alarms = append(alarms, cwTypes.MetricAlarm{AlarmName: aws.String(names[i])})
+		}
+	}
+
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{MetricAlarms: alarms}}
+	existing, err := existingAlarmNames(ctx, mockCW, names)
+	if err != nil {
+		t.Fatalf("existingAlarmNames() unexpected error: %v", err)
+	}
+	if len(existing) != 75 || existing[0] != "alarm-000" || existing[74] != "alarm-148" {
+		t.Errorf("existingAlarmNames() = %d alarms from %v, want the 75 even ones in order", len(existing), existing[:1])
+	}
+	if len(mockCW.describeAlarmsCalls) != 2 || len(mockCW.describeAlarmsCalls[0].AlarmNames) != 100 || len(mockCW.describeAlarmsCalls[1].AlarmNames) != 50 {
+		t.Errorf("DescribeAlarms called %d times, want twice with 100 and 50 names", len(mockCW.describeAlarmsCalls))
+	}
+
+	// A failed batch keeps the alarms found in the others and returns its failure
+	mockCW = &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{MetricAlarms: alarms}, describeAlarmsErrors: []error{errors.New("throttled")}}
+	existing, err = existingAlarmNames(ctx, mockCW, names)
+	if err == nil || !strings.Contains(err.Error(), "throttled") {
+		t.Errorf("existingAlarmNames() error = %v, want the throttled batch", err)
+	}
+	if len(existing) != 25 || existing[0] != "alarm-100" || existing[24] != "alarm-148" {
+		t.Errorf("existingAlarmNames() after a failed batch = %d alarms, want the 25 even ones of the second batch", len(existing))
+	}
+	// A cancelled context stops before describing any batch
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	mockCW = &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{MetricAlarms: alarms}}
+	if existing, err := existingAlarmNames(cancelCtx, mockCW, names); !errors.Is(err, context.Canceled) || existing != nil {
+		t.Errorf("existingAlarmNames() with a cancelled context = %v, %v, want context.Canceled", existing, err)
+	}
+	if len(mockCW.describeAlarmsCalls) != 0 {
+		t.Errorf("DescribeAlarms called %d times after cancellation, want 0", len(mockCW.describeAlarmsCalls))
+	}
+
+	if err := deleteAlarms(ctx, mockCW, names); err != nil {
+		t.Fatalf("deleteAlarms() unexpected error: %v", err)
+	}
+	if len(mockCW.deleteAlarmsCalls) != 2 {
+		t.Fatalf("DeleteAlarms called %d times, want 2", len(mockCW.deleteAlarmsCalls))
+	}
+	if got := mockCW.deleteAlarmsCalls[0].AlarmNames; len(got) != 100 || got[0] != "alarm-000" || got[99] != "alarm-099" {
+		t.Errorf("first DeleteAlarms batch = %d names, want alarm-000 through alarm-099", len(got))
+	}
+	if got := mockCW.deleteAlarmsCalls[1].AlarmNames; len(got) != 50 || got[0] != "alarm-100" || got[49] != "alarm-149" {
+		t.Errorf("second DeleteAlarms batch = %d names, want alarm-100 through alarm-149", len(got))
+	}
+
+	// Every batch is attempted and the failures are returned together
+	mockCW = &mockCWClient{deleteAlarmsError: errors.New("throttled")}
+	if err := deleteAlarms(ctx, mockCW, names); err == nil || strings.Count(err.Error(), "throttled") != 2 {
+		t.Errorf("deleteAlarms() error = %v, want both batch failures", err)
+	}
+	if len(mockCW.deleteAlarmsCalls) != 2 {
+		t.Errorf("DeleteAlarms called %d times after a failure, want 2", len(mockCW.deleteAlarmsCalls))
+	}
+}
+
 // TestThresholdParsing tests parsing of both CPU and memory thresholds
 func TestThresholdParsing(t *testing.T) {
 	tests := []struct {
@@ -718,7 +1232,7 @@ func TestPolicyAndAlarmUpdates(t *testing.T) {
 			}
 
 			// Verify policy exists and should be updated
-			exists, err := checkScalingPolicy(ctx, mockAAS, "service/test-cluster/test-service", tt.policy.PolicyName)
+			exists, err := checkScalingPolicy(ctx, mockAAS, testResource("service/test-cluster/test-service"), tt.policy.PolicyName)
 			if err != nil {
 				t.Errorf("checkScalingPolicy() error = %v", err)
 				return
@@ -1107,7 +1621,7 @@ func TestScalableTargetExists(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := scalableTargetExists(ctx, tt.mock, tt.resource)
+			got, err := scalableTargetExists(ctx, tt.mock, testResource(tt.resource))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("scalableTargetExists() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1175,3 +1689,2919 @@ func TestDeduplicate(t *testing.T) {
 		})
 	}
 }
+
+// TestResourceNamer tests name generation for policies and alarms
+func TestResourceNamer(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		template string
+		env      string
+		suffix   string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:   "default naming",
+			suffix: "scale-out",
+			want:   "test-cluster-test-service-scale-out",
+		},
+		{
+			name:   "default naming for custom policy alarm",
+			suffix: "custom-scale-out",
+			want:   "test-cluster-test-service-custom-scale-out",
+		},
+		{
+			name:   "name prefix",
+			prefix: "svc",
+			suffix: "cpu-high",
+			want:   "svc-cpu-high",
+		},
+		{
+			name:     "name template",
+			template: "{{.Service}}.{{.Suffix}}",
+			suffix:   "mem-low",
+			want:     "test-service.mem-low",
+		},
+		{
+			name:     "template wins over prefix",
+			prefix:   "svc",
+			template: "{{.Prefix}}/{{.Cluster}}/{{.Suffix}}",
+			suffix:   "scale-in",
+			want:     "svc/test-cluster/scale-in",
+		},
+		{
+			name:     "invalid template syntax",
+			template: "{{.Cluster",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown template field",
+			template: "{{.Region}}-{{.Suffix}}",
+			wantErr:  true,
+		},
+		{
+			name:     "template renders empty name",
+			template: "{{if false}}x{{end}}",
+			wantErr:  true,
+		},
+		{
+			name:   "env",
+			env:    "staging",
+			suffix: "cpu-high",
+			want:   "test-cluster-test-service-cpu-high-staging",
+		},
+		{
+			name:     "env after a template",
+			template: "{{.Service}}.{{.Suffix}}",
+			env:      "prod",
+			suffix:   "scale-out",
+			want:     "test-service.scale-out-prod",
+		},
+		{
+			name:    "name too long",
+			prefix:  strings.Repeat("p", 240),
+			env:     "staging",
+			wantErr: true,
+		},
+		{
+			name:   "length counts characters, not bytes",
+			prefix: strings.Repeat("é", 200),
+			suffix: "scale-out",
+			want:   strings.Repeat("é", 200) + "-scale-out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := newResourceNamer("test-cluster", "test-service", tt.prefix, tt.template, tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newResourceNamer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := n.name(tt.suffix)
+			if err != nil {
+				t.Fatalf("name() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("name() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseTags tests parsing and validation of the --tags input
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			raw:  "",
+			want: nil,
+		},
+		{
+			name: "multiple tags with whitespace",
+			raw:  "team=platform, cost-center=1234 ,empty=",
+			want: map[string]string{"team": "platform", "cost-center": "1234", "empty": ""},
+		},
+		{
+			name:    "missing equals",
+			raw:     "team",
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			raw:     "=value",
+			wantErr: true,
+		},
+		{
+			name:    "key too long",
+			raw:     strings.Repeat("k", 129) + "=v",
+			wantErr: true,
+		},
+		{
+			name:    "value too long",
+			raw:     "k=" + strings.Repeat("v", 257),
+			wantErr: true,
+		},
+		{
+			name: "limits count characters, not bytes",
+			raw:  strings.Repeat("é", 128) + "=" + strings.Repeat("ü", 256),
+			want: map[string]string{strings.Repeat("é", 128): strings.Repeat("ü", 256)},
+		},
+		{
+			name:    "reserved prefix",
+			raw:     "aws:owner=me",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate key",
+			raw:     "a=1,a=2",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTags(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// More than 50 tags is rejected
+	var pairs []string
+	for i := 0; i < 51; i++ {
+		pairs = append(pairs, fmt.Sprintf("k%d=v", i))
+	}
+	if _, err := parseTags(strings.Join(pairs, ",")); err == nil {
+		t.Error("parseTags() expected error for 51 tags, got nil")
+	}
+}
+
+// TestApplyTargetTags tests that enabling tags an existing scalable target with the tags it lacks or has with
+// another value, since registering an existing target ignores its tags, and that plan reports the drift
+func TestApplyTargetTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing map[string]string
+		want     map[string]string // tags sent to TagResource; nil for no call
+	}{
+		{"untagged", nil, map[string]string{"team": "platform", "env": "prod"}},
+		{"changed value", map[string]string{"team": "payments", "env": "prod", "owner": "someone"}, map[string]string{"team": "platform"}},
+		{"up to date", map[string]string{"team": "platform", "env": "prod"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+					ScalableTargets: []aasTypes.ScalableTarget{{
+						ScalableTargetARN: aws.String("arn:scalable-target"),
+						MinCapacity:       aws.Int32(2),
+						MaxCapacity:       aws.Int32(10),
+					}},
+				},
+				listTagsOutput: tt.existing,
+			}
+			r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+			r.cfg.Tags = map[string]string{"team": "platform", "env": "prod"}
+			r.cfg.TargetOnly = true
+
+			if err := r.apply(context.Background()); err != nil {
+				t.Fatalf("apply() unexpected error: %v", err)
+			}
+			var got map[string]string
+			if len(mockAAS.tagResourceCalls) > 0 {
+				if len(mockAAS.tagResourceCalls) != 1 || aws.ToString(mockAAS.tagResourceCalls[0].ResourceARN) != "arn:scalable-target" {
+					t.Fatalf("TagResource calls = %+v, want one for arn:scalable-target", mockAAS.tagResourceCalls)
+				}
+				got = mockAAS.tagResourceCalls[0].Tags
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TagResource tags = %v, want %v", got, tt.want)
+			}
+
+			items, err := r.buildPlan(context.Background())
+			if err != nil {
+				t.Fatalf("buildPlan() unexpected error: %v", err)
+			}
+			if gotUpdate := items[0].Action == planUpdate; gotUpdate != (tt.want != nil) {
+				t.Errorf("buildPlan() scalable target action = %v, diffs %+v", items[0].Action, items[0].Diffs)
+			}
+		})
+	}
+}
+
+// TestCloudWatchTags tests conversion of tags to sorted CloudWatch tags
+func TestCloudWatchTags(t *testing.T) {
+	if got := cloudWatchTags(nil); got != nil {
+		t.Errorf("cloudWatchTags(nil) = %v, want nil", got)
+	}
+
+	got := cloudWatchTags(map[string]string{"team": "platform", "env": "prod"})
+	want := []cwTypes.Tag{
+		{Key: aws.String("env"), Value: aws.String("prod")},
+		{Key: aws.String("team"), Value: aws.String("platform")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cloudWatchTags() = %v, want %v", got, want)
+	}
+}
+
+// TestScalingPolicyPagination verifies policies returned on a later page are found
+func TestScalingPolicyPagination(t *testing.T) {
+	ctx := context.Background()
+	resourceID := testResource("service/test-cluster/test-service")
+
+	mock := &mockAASClient{
+		describeScalingPoliciesPages: []*applicationautoscaling.DescribeScalingPoliciesOutput{
+			{
+				ScalingPolicies: []aasTypes.ScalingPolicy{
+					{PolicyName: aws.String("first-page-policy")},
+				},
+			},
+			{
+				ScalingPolicies: []aasTypes.ScalingPolicy{
+					{
+						PolicyName: aws.String("second-page-policy"),
+						PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+						TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+							TargetValue: aws.Float64(60),
+							PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+								PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	exists, err := checkScalingPolicy(ctx, mock, resourceID, "second-page-policy")
+	if err != nil {
+		t.Fatalf("checkScalingPolicy() unexpected error: %v", err)
+	}
+	if !exists {
+		t.Error("checkScalingPolicy() did not find policy on second page")
+	}
+
+	exists, err = checkScalingPolicy(ctx, mock, resourceID, "missing-policy")
+	if err != nil {
+		t.Fatalf("checkScalingPolicy() unexpected error: %v", err)
+	}
+	if exists {
+		t.Error("checkScalingPolicy() found a policy that is on neither page")
+	}
+
+	matches, err := compareScalingPolicy(ctx, mock, resourceID, "second-page-policy", &applicationautoscaling.PutScalingPolicyInput{
+		PolicyName: aws.String("second-page-policy"),
+		PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+		TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+			TargetValue: aws.Float64(60),
+			PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+				PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("compareScalingPolicy() unexpected error: %v", err)
+	}
+	if !matches {
+		t.Error("compareScalingPolicy() did not match policy on second page")
+	}
+}
+
+// TestUnmarshalTargetTrackingDisableScaleIn tests JSON unmarshalling of disable_scale_in.
+func TestUnmarshalTargetTrackingDisableScaleIn(t *testing.T) {
+	jsonStr := `[
+      {
+        "policy_name": "queue-tt",
+        "policy_type": "TargetTrackingScaling",
+        "target_tracking_configuration": {
+          "target_value": 100,
+          "predefined_metric_specification": "ECSServiceAverageCPUUtilization",
+          "disable_scale_in": true
+        }
+      },
+      {
+        "policy_name": "cpu-tt",
+        "policy_type": "TargetTrackingScaling",
+        "target_tracking_configuration": {
+          "target_value": 60,
+          "predefined_metric_specification": "ECSServiceAverageCPUUtilization"
+        }
+      }
+    ]`
+
+	var policies []PolicyDef
+	if err := json.Unmarshal([]byte(jsonStr), &policies); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if got := policies[0].TargetTrackingConfiguration.DisableScaleIn; got == nil || !*got {
+		t.Errorf("DisableScaleIn: got %v, want true", got)
+	}
+	if got := policies[1].TargetTrackingConfiguration.DisableScaleIn; got != nil {
+		t.Errorf("DisableScaleIn: got %v, want nil", *got)
+	}
+}
+
+// TestCompareScalingPolicyDisableScaleIn tests drift detection on DisableScaleIn
+func TestCompareScalingPolicyDisableScaleIn(t *testing.T) {
+	ctx := context.Background()
+
+	ttConfig := func(disableScaleIn *bool) *aasTypes.TargetTrackingScalingPolicyConfiguration {
+		return &aasTypes.TargetTrackingScalingPolicyConfiguration{
+			TargetValue:    aws.Float64(75),
+			DisableScaleIn: disableScaleIn,
+			PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+				PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing *bool
+		desired  *bool
+		want     bool
+	}{
+		{name: "both unset", existing: nil, desired: nil, want: true},
+		{name: "existing false, desired unset", existing: aws.Bool(false), desired: nil, want: true},
+		{name: "both true", existing: aws.Bool(true), desired: aws.Bool(true), want: true},
+		{name: "enable disable_scale_in", existing: aws.Bool(false), desired: aws.Bool(true), want: false},
+		{name: "remove disable_scale_in", existing: aws.Bool(true), desired: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockAASClient{
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+					ScalingPolicies: []aasTypes.ScalingPolicy{
+						{
+							PolicyName:                               aws.String("tt"),
+							PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
+							TargetTrackingScalingPolicyConfiguration: ttConfig(tt.existing),
+						},
+					},
+				},
+			}
+			got, err := compareScalingPolicy(ctx, mock, testResource("service/test-cluster/test-service"), "tt", &applicationautoscaling.PutScalingPolicyInput{
+				PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: ttConfig(tt.desired),
+			})
+			if err != nil {
+				t.Fatalf("compareScalingPolicy() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("compareScalingPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDimensionsSorted tests that dimension maps become metric and alarm dimensions sorted by name, whatever the
+// map iteration order
+func TestDimensionsSorted(t *testing.T) {
+	dims := map[string]string{"TargetGroup": "tg", "ClusterName": "c", "ServiceName": "s", "AZ": "a", "LoadBalancer": "lb"}
+	want := []string{"AZ", "ClusterName", "LoadBalancer", "ServiceName", "TargetGroup"}
+	res := resourceRef{Namespace: aasTypes.ServiceNamespaceEcs, ID: "service/c/s", Dimension: aasTypes.ScalableDimensionECSServiceDesiredCount}
+
+	// Map order is randomised per iteration, so repeat to catch unsorted output
+	for range 20 {
+		input, err := buildPolicyInput(PolicyDef{
+			PolicyName: "custom-target",
+			PolicyType: "TargetTrackingScaling",
+			TargetTrackingConfiguration: &TargetTrackingConfig{
+				TargetValue:               50,
+				CustomMetricSpecification: &CustomMetricSpec{MetricName: "Latency", Namespace: "App", Statistic: "Average", Dimensions: dims},
+			},
+		}, res)
+		if err != nil {
+			t.Fatalf("buildPolicyInput() unexpected error: %v", err)
+		}
+		var got []string
+		for _, dim := range input.TargetTrackingScalingPolicyConfiguration.CustomizedMetricSpecification.Dimensions {
+			got = append(got, aws.ToString(dim.Name))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("custom metric dimensions = %v, want %v", got, want)
+		}
+
+		got = nil
+		for _, dim := range cwDimensions(dims) {
+			got = append(got, aws.ToString(dim.Name))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("cwDimensions() = %v, want %v", got, want)
+		}
+	}
+	if metricDimensions(nil) != nil {
+		t.Error("metricDimensions(nil) should be nil")
+	}
+}
+
+// TestCompareScalingPolicyCustomMetric tests drift detection for custom metric target tracking policies
+func TestCompareScalingPolicyCustomMetric(t *testing.T) {
+	ctx := context.Background()
+
+	ttConfig := func(disableScaleIn *bool, unit *string) *aasTypes.TargetTrackingScalingPolicyConfiguration {
+		return &aasTypes.TargetTrackingScalingPolicyConfiguration{
+			TargetValue:    aws.Float64(60),
+			DisableScaleIn: disableScaleIn,
+			CustomizedMetricSpecification: &aasTypes.CustomizedMetricSpecification{
+				MetricName: aws.String("QueueDepth"),
+				Namespace:  aws.String("MyApp"),
+				Statistic:  aasTypes.MetricStatisticAverage,
+				Unit:       unit,
+				Dimensions: []aasTypes.MetricDimension{
+					{Name: aws.String("QueueName"), Value: aws.String("jobs")},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing *aasTypes.TargetTrackingScalingPolicyConfiguration
+		desired  *aasTypes.TargetTrackingScalingPolicyConfiguration
+		want     bool
+	}{
+		{
+			name:     "identical",
+			existing: ttConfig(aws.Bool(false), aws.String("Count")),
+			desired:  ttConfig(aws.Bool(false), aws.String("Count")),
+			want:     true,
+		},
+		{
+			name:     "only DisableScaleIn differs",
+			existing: ttConfig(aws.Bool(false), nil),
+			desired:  ttConfig(aws.Bool(true), nil),
+			want:     false,
+		},
+		{
+			name:     "only unit differs",
+			existing: ttConfig(nil, aws.String("Count")),
+			desired:  ttConfig(nil, aws.String("Percent")),
+			want:     false,
+		},
+		{
+			name:     "unit removed",
+			existing: ttConfig(nil, aws.String("Count")),
+			desired:  ttConfig(nil, nil),
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockAASClient{
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+					ScalingPolicies: []aasTypes.ScalingPolicy{
+						{
+							PolicyName:                               aws.String("custom-tt"),
+							PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
+							TargetTrackingScalingPolicyConfiguration: tt.existing,
+						},
+					},
+				},
+			}
+			got, err := compareScalingPolicy(ctx, mock, testResource("service/test-cluster/test-service"), "custom-tt", &applicationautoscaling.PutScalingPolicyInput{
+				PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: tt.desired,
+			})
+			if err != nil {
+				t.Fatalf("compareScalingPolicy() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("compareScalingPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDiffScalingPolicyFields asserts the exact field paths reported for mismatches
+func TestDiffScalingPolicyFields(t *testing.T) {
+	stepPolicy := func(cooldown int32, aggregation aasTypes.MetricAggregationType, adjustments ...aasTypes.StepAdjustment) *aasTypes.StepScalingPolicyConfiguration {
+		return &aasTypes.StepScalingPolicyConfiguration{
+			AdjustmentType:        aasTypes.AdjustmentTypeChangeInCapacity,
+			Cooldown:              aws.Int32(cooldown),
+			MetricAggregationType: aggregation,
+			StepAdjustments:       adjustments,
+		}
+	}
+	step := func(lower float64, adjust int32) aasTypes.StepAdjustment {
+		return aasTypes.StepAdjustment{MetricIntervalLowerBound: aws.Float64(lower), ScalingAdjustment: aws.Int32(adjust)}
+	}
+	ttPolicy := func(target float64, scaleIn *int32, metric aasTypes.MetricType) *aasTypes.TargetTrackingScalingPolicyConfiguration {
+		return &aasTypes.TargetTrackingScalingPolicyConfiguration{
+			TargetValue:     aws.Float64(target),
+			ScaleInCooldown: scaleIn,
+			PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+				PredefinedMetricType: metric,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing *aasTypes.ScalingPolicy
+		desired  *applicationautoscaling.PutScalingPolicyInput
+		want     []string
+	}{
+		{
+			name: "step scaling matches",
+			existing: &aasTypes.ScalingPolicy{
+				PolicyType:                     aasTypes.PolicyTypeStepScaling,
+				StepScalingPolicyConfiguration: stepPolicy(300, aasTypes.MetricAggregationTypeMaximum, step(0, 1)),
+			},
+			desired: &applicationautoscaling.PutScalingPolicyInput{
+				PolicyType:                     aasTypes.PolicyTypeStepScaling,
+				StepScalingPolicyConfiguration: stepPolicy(300, aasTypes.MetricAggregationTypeMaximum, step(0, 1)),
+			},
+			want: []string{},
+		},
+		{
+			name: "step scaling cooldown and adjustment",
+			existing: &aasTypes.ScalingPolicy{
+				PolicyType:                     aasTypes.PolicyTypeStepScaling,
+				StepScalingPolicyConfiguration: stepPolicy(300, aasTypes.MetricAggregationTypeMaximum, step(0, 1)),
+			},
+			desired: &applicationautoscaling.PutScalingPolicyInput{
+				PolicyType:                     aasTypes.PolicyTypeStepScaling,
+				StepScalingPolicyConfiguration: stepPolicy(120, aasTypes.MetricAggregationTypeMaximum, step(0, 2)),
+			},
+			want: []string{
+				"StepScalingPolicyConfiguration.Cooldown",
+				"StepScalingPolicyConfiguration.StepAdjustments[0].ScalingAdjustment",
+			},
+		},
+		{
+			name: "step scaling aggregation and step count",
+			existing: &aasTypes.ScalingPolicy{
+				PolicyType:                     aasTypes.PolicyTypeStepScaling,
+				StepScalingPolicyConfiguration: stepPolicy(300, aasTypes.MetricAggregationTypeMaximum, step(0, 1)),
+			},
+			desired: &applicationautoscaling.PutScalingPolicyInput{
+				PolicyType:                     aasTypes.PolicyTypeStepScaling,
+				StepScalingPolicyConfiguration: stepPolicy(300, aasTypes.MetricAggregationTypeAverage, step(0, 1), step(10, 2)),
+			},
+			want: []string{
+				"StepScalingPolicyConfiguration.MetricAggregationType",
+				"StepScalingPolicyConfiguration.StepAdjustments",
+			},
+		},
+		{
+			name: "target tracking value, cooldown and metric",
+			existing: &aasTypes.ScalingPolicy{
+				PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: ttPolicy(60, nil, aasTypes.MetricTypeECSServiceAverageCPUUtilization),
+			},
+			desired: &applicationautoscaling.PutScalingPolicyInput{
+				PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: ttPolicy(75, aws.Int32(120), aasTypes.MetricTypeECSServiceAverageMemoryUtilization),
+			},
+			want: []string{
+				"TargetTrackingScalingPolicyConfiguration.TargetValue",
+				"TargetTrackingScalingPolicyConfiguration.ScaleInCooldown",
+				"TargetTrackingScalingPolicyConfiguration.PredefinedMetricSpecification.PredefinedMetricType",
+			},
+		},
+		{
+			name: "target tracking resource label",
+			existing: &aasTypes.ScalingPolicy{
+				PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+					TargetValue: aws.Float64(1000),
+					PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+						PredefinedMetricType: aasTypes.MetricTypeALBRequestCountPerTarget,
+						ResourceLabel:        aws.String("app/my-alb/1/targetgroup/old/2"),
+					},
+				},
+			},
+			desired: &applicationautoscaling.PutScalingPolicyInput{
+				PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+					TargetValue: aws.Float64(1000),
+					PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+						PredefinedMetricType: aasTypes.MetricTypeALBRequestCountPerTarget,
+						ResourceLabel:        aws.String("app/my-alb/1/targetgroup/new/3"),
+					},
+				},
+			},
+			want: []string{"TargetTrackingScalingPolicyConfiguration.PredefinedMetricSpecification.ResourceLabel"},
+		},
+		{
+			name: "policy type change",
+			existing: &aasTypes.ScalingPolicy{
+				PolicyType:                     aasTypes.PolicyTypeStepScaling,
+				StepScalingPolicyConfiguration: stepPolicy(300, aasTypes.MetricAggregationTypeMaximum, step(0, 1)),
+			},
+			desired: &applicationautoscaling.PutScalingPolicyInput{
+				PolicyType:                               aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: ttPolicy(75, nil, aasTypes.MetricTypeECSServiceAverageCPUUtilization),
+			},
+			want: []string{"PolicyType"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffFieldNames(diffScalingPolicy(tt.existing, tt.desired))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffScalingPolicy() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScalingPolicyChanges tests the lookup + diff used before PutScalingPolicy
+func TestScalingPolicyChanges(t *testing.T) {
+	ctx := context.Background()
+	desired := &applicationautoscaling.PutScalingPolicyInput{
+		PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+		TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+			TargetValue: aws.Float64(75),
+			PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+				PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+			},
+		},
+	}
+
+	// Missing policy
+	mock := &mockAASClient{describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{}}
+	fields, exists, err := scalingPolicyChanges(ctx, mock, testResource("service/test-cluster/test-service"), "tt", desired)
+	if err != nil || exists || len(fields) != 0 {
+		t.Errorf("scalingPolicyChanges() missing policy = (%v, %v, %v), want ([], false, nil)", fields, exists, err)
+	}
+
+	// Drifted policy
+	mock.describeScalingPoliciesOutput = &applicationautoscaling.DescribeScalingPoliciesOutput{
+		ScalingPolicies: []aasTypes.ScalingPolicy{
+			{
+				PolicyName: aws.String("tt"),
+				PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+					TargetValue: aws.Float64(60),
+					PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+						PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+					},
+				},
+			},
+		},
+	}
+	fields, exists, err = scalingPolicyChanges(ctx, mock, testResource("service/test-cluster/test-service"), "tt", desired)
+	if err != nil {
+		t.Fatalf("scalingPolicyChanges() unexpected error: %v", err)
+	}
+	if !exists || !reflect.DeepEqual(fields, []string{"TargetTrackingScalingPolicyConfiguration.TargetValue"}) {
+		t.Errorf("scalingPolicyChanges() = (%v, %v), want ([TargetTrackingScalingPolicyConfiguration.TargetValue], true)", fields, exists)
+	}
+}
+
+// TestServiceLogger tests that every line a runner logs carries the service it is about
+func TestServiceLogger(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{
+				{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+				{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:in")},
+			},
+		},
+	}
+	r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}})
+	if err := r.apply(context.Background()); err != nil {
+		t.Fatalf("apply() unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("apply() logged %d lines, want several", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "resource_id=service/test-cluster/test-service cluster=test-cluster service=test-service") {
+			t.Errorf("log line %q, want the resource_id, cluster and service attributes", line)
+		}
+	}
+
+	// Resources without a cluster or service only carry their ID
+	logs.Reset()
+	res := resourceRef{ID: "table/orders", Namespace: aasTypes.ServiceNamespaceDynamodb}
+	serviceLogger(&Config{}, res).Info("line")
+	if got := logs.String(); !strings.Contains(got, "resource_id=table/orders") || strings.Contains(got, "service=") {
+		t.Errorf("DynamoDB log line = %q, want only resource_id", got)
+	}
+}
+
+// TestNewLogger tests log format selection and level filtering
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		level       string
+		wantErr     bool
+		wantJSON    bool
+		wantDebug   bool
+		wantInfo    bool
+		wantWarning bool
+	}{
+		{name: "defaults", format: "", level: "", wantInfo: true, wantWarning: true},
+		{name: "text info", format: "text", level: "info", wantInfo: true, wantWarning: true},
+		{name: "json debug", format: "json", level: "debug", wantJSON: true, wantDebug: true, wantInfo: true, wantWarning: true},
+		{name: "json warn", format: "JSON", level: "warn", wantJSON: true, wantWarning: true},
+		{name: "text error", format: "text", level: "error"},
+		{name: "invalid format", format: "xml", level: "info", wantErr: true},
+		{name: "invalid level", format: "text", level: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := newLogger(&buf, tt.format, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newLogger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			logger.Debug("debug line")
+			logger.Info("info line")
+			logger.Warn("warn line")
+			logger.Error("error line")
+			output := buf.String()
+
+			if got := strings.Contains(output, "debug line"); got != tt.wantDebug {
+				t.Errorf("debug line logged = %v, want %v", got, tt.wantDebug)
+			}
+			if got := strings.Contains(output, "info line"); got != tt.wantInfo {
+				t.Errorf("info line logged = %v, want %v", got, tt.wantInfo)
+			}
+			if got := strings.Contains(output, "warn line"); got != tt.wantWarning {
+				t.Errorf("warn line logged = %v, want %v", got, tt.wantWarning)
+			}
+			if !strings.Contains(output, "error line") {
+				t.Error("error line was not logged")
+			}
+
+			firstLine := strings.SplitN(output, "\n", 2)[0]
+			if tt.wantJSON {
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(firstLine), &entry); err != nil {
+					t.Errorf("json log line is not valid JSON: %q", firstLine)
+				}
+			} else if strings.HasPrefix(firstLine, "{") {
+				t.Errorf("text log line looks like JSON: %q", firstLine)
+			}
+		})
+	}
+}
+
+// Whether the process default logger was still the standard library's when the tests started, after every
+// package initializer had run
+var defaultLoggerUntouched bool
+
+func TestMain(m *testing.M) {
+	// slog.SetDefault with a handler of its own redirects the log package, so an untouched log package means the
+	// default logger was never set
+	defaultLoggerUntouched = log.Writer() == os.Stderr && log.Flags() == log.LstdFlags
+	os.Exit(m.Run())
+}
+
+// TestSetupLogging tests that loading the package leaves the process default logger alone, and that SetupLogging
+// replaces it only when called
+func TestSetupLogging(t *testing.T) {
+	if !defaultLoggerUntouched {
+		t.Error("the default logger was changed before any test ran, want it left to the caller")
+	}
+
+	defer slog.SetDefault(slog.Default())
+	var buf bytes.Buffer
+	logger, err := SetupLogging(LoggingOptions{Writer: &buf, Format: "json", Level: "debug"})
+	if err != nil {
+		t.Fatalf("SetupLogging() unexpected error: %v", err)
+	}
+	if slog.Default() != logger {
+		t.Error("SetupLogging() did not make its logger the default")
+	}
+	slog.Debug("debug line")
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil || entry["msg"] != "debug line" {
+		t.Errorf("default logger wrote %q, want a JSON debug line", buf.String())
+	}
+
+	before := slog.Default()
+	if _, err := SetupLogging(LoggingOptions{Format: "xml"}); err == nil {
+		t.Error("SetupLogging() with an invalid format succeeded, want an error")
+	}
+	if slog.Default() != before {
+		t.Error("a failed SetupLogging() changed the default logger")
+	}
+}
+
+// TestQuietLogging tests that --quiet suppresses info lines but keeps errors, even with --log-level=debug
+func TestQuietLogging(t *testing.T) {
+	cfg, err := parseArgs(append(testPositionalArgs(), "--quiet", "--log-level=debug"))
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		t.Fatalf("newLogger() unexpected error: %v", err)
+	}
+	logger.Debug("debug line")
+	logger.Info("info line")
+	logger.Error("error line")
+
+	output := buf.String()
+	if strings.Contains(output, "debug line") || strings.Contains(output, "info line") {
+		t.Errorf("quiet output contains debug or info lines: %q", output)
+	}
+	if !strings.Contains(output, "error line") {
+		t.Errorf("quiet output is missing the error line: %q", output)
+	}
+}
+
+// TestAwsErrorFields tests extracting the request ID and error code from wrapped AWS errors
+func TestAwsErrorFields(t *testing.T) {
+	apiErr := &smithy.OperationError{
+		ServiceID:     "Application Auto Scaling",
+		OperationName: "PutScalingPolicy",
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: 400}},
+				Err:      &smithy.GenericAPIError{Code: "ValidationException", Message: "invalid step adjustments"},
+			},
+			RequestID: "4f1b2c3d-request",
+		},
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want map[string]any
+	}{
+		{
+			name: "wrapped API error",
+			err:  fmt.Errorf("failed to put scaling policy cpu: %w", apiErr),
+			want: map[string]any{"request_id": "4f1b2c3d-request", "error_code": "ValidationException", "error_category": "validation"},
+		},
+		{
+			name: "access denied",
+			err: &smithy.OperationError{
+				ServiceID:     "CloudWatch",
+				OperationName: "PutMetricAlarm",
+				Err:           &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized"},
+			},
+			want: map[string]any{"error_code": "AccessDenied", "error_category": "access-denied", "missing_permission": "cloudwatch:PutMetricAlarm"},
+		},
+		{
+			name: "plain error",
+			err:  errors.New("policy not found"),
+			want: map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := awsErrorFields(tt.err)
+			if len(fields) < 2 || fields[0] != "error" || fields[1] != tt.err {
+				t.Fatalf("awsErrorFields() = %v, want the error first", fields)
+			}
+			got := map[string]any{}
+			for i := 2; i+1 < len(fields); i += 2 {
+				got[fields[i].(string)] = fields[i+1]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("awsErrorFields() extra fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewClientsEndpoint tests that --endpoint-url only changes where the clients send requests
+func TestNewClientsEndpoint(t *testing.T) {
+	awsCfg := aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("key", "secret", "")}
+
+	for _, endpoint := range []string{"", "http://localhost:4566"} {
+		clients := newClients(awsCfg, endpoint)
+		aasOpts := clients.AAS.(*applicationautoscaling.Client).Options()
+		cwOpts := clients.CW.(*cloudwatch.Client).Options()
+		if got := aws.ToString(aasOpts.BaseEndpoint); got != endpoint {
+			t.Errorf("newClients(%q) AAS endpoint = %q", endpoint, got)
+		}
+		if got := aws.ToString(cwOpts.BaseEndpoint); got != endpoint {
+			t.Errorf("newClients(%q) CloudWatch endpoint = %q", endpoint, got)
+		}
+		if creds, err := aasOpts.Credentials.Retrieve(context.Background()); err != nil || creds.AccessKeyID != "key" || cwOpts.Region != "us-east-1" {
+			t.Errorf("newClients(%q) changed credentials or region", endpoint)
+		}
+	}
+}
+
+// TestAWSConfigOptions tests that --profile selects the shared config profile and static keys take its place
+func TestAWSConfigOptions(t *testing.T) {
+	load := func(cfg *Config) config.LoadOptions {
+		var opts config.LoadOptions
+		for _, opt := range awsConfigOptions(cfg) {
+			if err := opt(&opts); err != nil {
+				t.Fatalf("awsConfigOptions() option unexpected error: %v", err)
+			}
+		}
+		return opts
+	}
+
+	opts := load(&Config{Region: "us-east-1", Profile: "staging"})
+	if opts.SharedConfigProfile != "staging" || opts.Credentials != nil || opts.Region != "us-east-1" {
+		t.Errorf("awsConfigOptions() with profile = profile %q, credentials %v, region %q, want staging, nil, us-east-1",
+			opts.SharedConfigProfile, opts.Credentials, opts.Region)
+	}
+
+	opts = load(&Config{Region: "us-east-1", KeyID: "key", KeySecret: "secret"})
+	if opts.SharedConfigProfile != "" || opts.Credentials == nil {
+		t.Errorf("awsConfigOptions() with static keys = profile %q, credentials %v, want static credentials only",
+			opts.SharedConfigProfile, opts.Credentials)
+	}
+
+	// A session token reaches the static provider with the keys, for temporary credentials
+	opts = load(&Config{Region: "us-east-1", KeyID: "key", KeySecret: "secret", SessionToken: "token"})
+	creds, err := opts.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Credentials.Retrieve() unexpected error: %v", err)
+	}
+	if creds.AccessKeyID != "key" || creds.SecretAccessKey != "secret" || creds.SessionToken != "token" {
+		t.Errorf("awsConfigOptions() credentials = %s/%s/%s, want key/secret/token", creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken)
+	}
+
+	opts = load(&Config{Region: "us-east-1"})
+	if opts.SharedConfigProfile != "" || opts.Credentials != nil || opts.SharedConfigFiles != nil || opts.SharedCredentialsFiles != nil {
+		t.Errorf("awsConfigOptions() without credentials set options, want the default chain")
+	}
+
+	opts = load(&Config{Region: "us-east-1", Profile: "staging", SharedConfigFile: "/etc/aws/config", SharedCredentialsFile: "/etc/aws/credentials"})
+	if !slices.Equal(opts.SharedConfigFiles, []string{"/etc/aws/config"}) || !slices.Equal(opts.SharedCredentialsFiles, []string{"/etc/aws/credentials"}) || opts.SharedConfigProfile != "staging" {
+		t.Errorf("awsConfigOptions() with shared files = config %v, credentials %v, profile %q, want the given files and staging",
+			opts.SharedConfigFiles, opts.SharedCredentialsFiles, opts.SharedConfigProfile)
+	}
+
+	if opts.UseFIPSEndpoint != aws.FIPSEndpointStateUnset || opts.UseDualStackEndpoint != aws.DualStackEndpointStateUnset {
+		t.Errorf("awsConfigOptions() without endpoint flags = FIPS %v, dual-stack %v, want both unset", opts.UseFIPSEndpoint, opts.UseDualStackEndpoint)
+	}
+	opts = load(&Config{Region: "us-gov-west-1", UseFIPSEndpoint: true, UseDualStackEndpoint: true})
+	if opts.UseFIPSEndpoint != aws.FIPSEndpointStateEnabled || opts.UseDualStackEndpoint != aws.DualStackEndpointStateEnabled {
+		t.Errorf("awsConfigOptions() with endpoint flags = FIPS %v, dual-stack %v, want both enabled", opts.UseFIPSEndpoint, opts.UseDualStackEndpoint)
+	}
+}
+
+// TestLoadAWSConfigRegion tests that with --region-from-instance-metadata an empty aws-region is resolved from the
+// environment and written back to the config, and that failing to find one is a clear validation error
+func TestLoadAWSConfigRegion(t *testing.T) {
+	// Keep the host's shared config and instance metadata out of it
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	ctx := context.Background()
+
+	t.Setenv("AWS_REGION", "eu-west-1")
+	cfg := &Config{RegionFromInstanceMetadata: true}
+	awsCfg, err := loadAWSConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("loadAWSConfig() unexpected error: %v", err)
+	}
+	if awsCfg.Region != "eu-west-1" || cfg.Region != "eu-west-1" {
+		t.Errorf("loadAWSConfig() region = %q, config region %q, want eu-west-1 for both", awsCfg.Region, cfg.Region)
+	}
+
+	t.Setenv("AWS_REGION", "")
+	_, err = loadAWSConfig(ctx, &Config{RegionFromInstanceMetadata: true})
+	if err == nil || !strings.Contains(err.Error(), "no region found") {
+		t.Fatalf("loadAWSConfig() without a region error = %v, want no region found", err)
+	}
+	if code := exitCode(err); code != exitValidation {
+		t.Errorf("exitCode() = %d, want %d", code, exitValidation)
+	}
+
+	// A given region is still used as is, even with AWS_REGION set
+	t.Setenv("AWS_REGION", "eu-west-1")
+	if awsCfg, err := loadAWSConfig(ctx, &Config{Region: "us-east-1"}); err != nil || awsCfg.Region != "us-east-1" {
+		t.Errorf("loadAWSConfig() with aws-region = %q, %v, want us-east-1", awsCfg.Region, err)
+	}
+
+	args := testPositionalArgs()
+	args[2] = ""
+	if _, err := parseArgs(args); err == nil || !strings.Contains(err.Error(), "aws-region is required") {
+		t.Errorf("parseArgs() with an empty region error = %v, want aws-region is required", err)
+	}
+	if cfg, err := parseArgs(append(args, "--region-from-instance-metadata")); err != nil || cfg.Region != "" {
+		t.Errorf("parseArgs() with region-from-instance-metadata = %v, want an empty region and no error", err)
+	}
+	if _, err := parseArgs(append(testPositionalArgs(), "--region-from-instance-metadata")); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("parseArgs() with both error = %v, want mutually exclusive", err)
+	}
+}
+
+// TestNewClientsEndpointIntegration sends real requests to a fake endpoint.
+// Set ECSAS_INTEGRATION=1 to run it.
+func TestNewClientsEndpointIntegration(t *testing.T) {
+	if os.Getenv("ECSAS_INTEGRATION") == "" {
+		t.Skip("set ECSAS_INTEGRATION=1 to run integration tests")
+	}
+
+	var mu sync.Mutex
+	var targets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		targets = append(targets, r.Header.Get("X-Amz-Target")+r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	awsCfg := aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("key", "secret", "")}
+	clients := newClients(awsCfg, server.URL)
+	ctx := context.Background()
+
+	if _, err := describeScalableTarget(ctx, clients.AAS, testResource("service/test-cluster/test-service")); err != nil {
+		t.Fatalf("describeScalableTarget() against fake endpoint unexpected error: %v", err)
+	}
+	// The CloudWatch response body is not valid for its protocol; only the request matters here
+	_, _ = clients.CW.DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(targets) < 2 {
+		t.Fatalf("fake endpoint received %d requests (%v), want at least 2", len(targets), targets)
+	}
+	if !strings.Contains(targets[0], "DescribeScalableTargets") {
+		t.Errorf("first request = %q, want DescribeScalableTargets", targets[0])
+	}
+}
+
+// TestRun tests that Run applies the default policies and reports failures as errors instead of exiting
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TargetCPUOut:     75,
+		TargetCPUIn:      65,
+		TargetMemOut:     80,
+		TargetMemIn:      70,
+		TagAlarms:        true,
+		AlarmsEnabled:    true,
+	}
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{
+				{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+				{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:in")},
+			},
+		},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(mockAAS.registerScalableTargetCalls) != 1 {
+		t.Errorf("Run() registered %d scalable targets, want 1", len(mockAAS.registerScalableTargetCalls))
+	}
+	if len(mockCW.putMetricAlarmCalls) != 4 {
+		t.Errorf("Run() created %d alarms, want 4", len(mockCW.putMetricAlarmCalls))
+	}
+
+	// API failures surface as errors
+	failing := &mockAASClient{describeScalableTargetsError: fmt.Errorf("access denied")}
+	err := Run(ctx, cfg, Clients{AAS: failing, CW: mockCW}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("Run() error = %v, want wrapped access denied", err)
+	}
+
+	// Invalid policy definitions surface as errors
+	bad := *cfg
+	bad.PoliciesRaw = `[{"policy_name":"p","policy_type":"Bogus"}]`
+	err = Run(ctx, &bad, Clients{AAS: mockAAS, CW: mockCW}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "unknown policy_type") {
+		t.Errorf("Run() error = %v, want unknown policy_type", err)
+	}
+}
+
+// TestRunNoChanges tests that a run where every compare matches makes no changes, and that --detailed-exit-code
+// reports one that does with errChanged
+func TestRunNoChanges(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      2,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TagAlarms:        true,
+		AlarmsEnabled:    true,
+		DetailedExitCode: true,
+		PoliciesRaw:      `[{"policy_name":"cpu-target","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"target_value":75,"predefined_metric_specification":"ECSServiceAverageCPUUtilization"}}]`,
+	}
+	upToDate := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{{
+				PolicyName: aws.String("cpu-target"),
+				PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+					TargetValue: aws.Float64(75),
+					PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+						PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+					},
+				},
+			}},
+		},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	if err := Run(ctx, cfg, Clients{AAS: upToDate, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() error = %v, want nil when nothing changed", err)
+	}
+	if len(upToDate.calls) != 0 {
+		t.Errorf("Run() made mutating calls %v, want none", upToDate.calls)
+	}
+
+	// A new target and policy are changes
+	empty := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	err := Run(ctx, cfg, Clients{AAS: empty, CW: mockCW}, io.Discard)
+	if !errors.Is(err, errChanged) || exitCode(err) != exitChanged {
+		t.Errorf("Run() error = %v (exit %d), want errChanged (exit %d)", err, exitCode(err), exitChanged)
+	}
+
+	// Without --detailed-exit-code changes are still a success
+	cfg.DetailedExitCode = false
+	if err := Run(ctx, cfg, Clients{AAS: empty, CW: mockCW}, io.Discard); err != nil {
+		t.Errorf("Run() error = %v, want nil", err)
+	}
+}
+
+// TestRunCancellation tests that cancelling the context stops a run before its next policy, alarm or deletion, with
+// the cancellation as the error, and that a reconcile loop cancelled mid-run returns promptly without leaking
+// goroutines
+func TestRunCancellation(t *testing.T) {
+	existingPolicies := &applicationautoscaling.DescribeScalingPoliciesOutput{
+		ScalingPolicies: []aasTypes.ScalingPolicy{
+			{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+			{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:in")},
+		},
+	}
+	tests := []struct {
+		name      string
+		enabled   bool
+		policies  string
+		operation string
+		wantAAS   []string // mutating calls made before stopping
+		wantAlarm int      // alarms put before stopping
+	}{
+		{
+			name:      "custom policies",
+			enabled:   true,
+			policies:  `[{"policy_name":"a","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"target_value":50,"predefined_metric_specification":"ECSServiceAverageCPUUtilization"}},{"policy_name":"b","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"target_value":60,"predefined_metric_specification":"ECSServiceAverageMemoryUtilization"}}]`,
+			operation: "PutScalingPolicy",
+			wantAAS:   []string{"RegisterScalableTarget", "PutScalingPolicy"},
+		},
+		{
+			name:      "default policies",
+			enabled:   true,
+			operation: "PutScalingPolicy",
+			wantAAS:   []string{"RegisterScalableTarget", "PutScalingPolicy"},
+		},
+		{
+			name:      "default alarms",
+			enabled:   true,
+			operation: "PutMetricAlarm",
+			wantAAS:   []string{"RegisterScalableTarget", "PutScalingPolicy", "PutScalingPolicy"},
+			wantAlarm: 1,
+		},
+		{
+			name:      "cleanup",
+			operation: "DeleteScalingPolicy",
+			wantAAS:   []string{"DeleteScalingPolicy"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+				describeScalingPoliciesOutput: existingPolicies,
+			}
+			if !tt.enabled {
+				mockAAS.describeScalableTargetsOutput.ScalableTargets = []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}}
+			}
+			mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+			r := newTestRunner(t, tt.enabled, nil, mockAAS, mockCW)
+			r.cfg.Yes = true
+			r.cfg.PoliciesRaw = tt.policies
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c := &cancelOn{operation: tt.operation, cancel: cancel}
+			err := Run(ctx, r.cfg, c.clients(mockAAS, mockCW), io.Discard)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Run() error = %v, want context.Canceled", err)
+			}
+			if len(c.late) > 0 {
+				t.Errorf("Run() called %v after the context was cancelled, want nothing", c.late)
+			}
+			if !slices.Equal(mockAAS.calls, tt.wantAAS) || len(mockCW.putMetricAlarmCalls) != tt.wantAlarm {
+				t.Errorf("Run() made calls %v and put %d alarms, want %v and %d", mockAAS.calls, len(mockCW.putMetricAlarmCalls), tt.wantAAS, tt.wantAlarm)
+			}
+		})
+	}
+
+	t.Run("reconcile loop", func(t *testing.T) {
+		checkGoroutineLeaks(t)
+		mockAAS := &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+			describeScalingPoliciesOutput: existingPolicies,
+		}
+		mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+		r := newTestRunner(t, true, nil, mockAAS, mockCW)
+		r.cfg.Interval = time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c := &cancelOn{operation: "PutMetricAlarm", cancel: cancel}
+		done := make(chan error, 1)
+		go func() { done <- Run(ctx, r.cfg, c.clients(mockAAS, mockCW), io.Discard) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Run() error = %v, want nil once the loop is cancelled", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run() did not return after the context was cancelled")
+		}
+		if len(c.late) > 0 {
+			t.Errorf("Run() called %v after the context was cancelled, want nothing", c.late)
+		}
+	})
+}
+
+// TestRunSuspendedState tests that the --suspend-* flags re-register an otherwise up-to-date target with its
+// suspended state, and that plan reports the difference
+func TestRunSuspendedState(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      2,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TagAlarms:        true,
+		AlarmsEnabled:    true,
+		SuspendScaleIn:   true,
+		SuspendScheduled: true,
+		PoliciesRaw:      `[{"policy_name":"cpu-target","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"target_value":75,"predefined_metric_specification":"ECSServiceAverageCPUUtilization"}}]`,
+	}
+	newAAS := func() *mockAASClient {
+		return &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+				ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+			},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+				ScalingPolicies: []aasTypes.ScalingPolicy{{
+					PolicyName: aws.String("cpu-target"),
+					PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+					TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+						TargetValue: aws.Float64(75),
+						PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+							PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+						},
+					},
+				}},
+			},
+		}
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	mockAAS := newAAS()
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(mockAAS.registerScalableTargetCalls) != 1 {
+		t.Fatalf("Run() made %d RegisterScalableTarget calls, want 1", len(mockAAS.registerScalableTargetCalls))
+	}
+	got := mockAAS.registerScalableTargetCalls[0].SuspendedState
+	if got == nil || !aws.ToBool(got.DynamicScalingInSuspended) || aws.ToBool(got.DynamicScalingOutSuspended) || !aws.ToBool(got.ScheduledScalingSuspended) {
+		t.Errorf("RegisterScalableTarget SuspendedState = %+v, want scale-in and scheduled suspended", got)
+	}
+
+	var out bytes.Buffer
+	cfg.Plan = true
+	if err := Run(ctx, cfg, Clients{AAS: newAAS(), CW: mockCW}, &out); err != nil {
+		t.Fatalf("Run() plan unexpected error: %v", err)
+	}
+	for _, want := range []string{"DynamicScalingInSuspended: false -> true", "ScheduledScalingSuspended: false -> true"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("plan output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "DynamicScalingOutSuspended") {
+		t.Errorf("plan output reports unchanged DynamicScalingOutSuspended:\n%s", out.String())
+	}
+}
+
+// TestRunDefaultTargetTracking tests that --default-policy-type=target-tracking creates CPU and memory
+// target-tracking policies with the configured targets and cooldowns, and no alarms of its own
+func TestRunDefaultTargetTracking(t *testing.T) {
+	cfg := &Config{
+		Cluster:           "test-cluster",
+		Service:           "test-service",
+		Enabled:           true,
+		MinCapacity:       1,
+		MaxCapacity:       10,
+		ScaleOutCooldown:  60,
+		ScaleInCooldown:   300,
+		TargetCPUOut:      70,
+		TargetMemOut:      80,
+		DefaultPolicyType: defaultPolicyTypeTargetTracking,
+	}
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	if err := Run(context.Background(), cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	want := map[string]struct {
+		metric aasTypes.MetricType
+		target float64
+	}{
+		"test-cluster-test-service-cpu-target": {aasTypes.MetricTypeECSServiceAverageCPUUtilization, 70},
+		"test-cluster-test-service-mem-target": {aasTypes.MetricTypeECSServiceAverageMemoryUtilization, 80},
+	}
+	if len(mockAAS.putScalingPolicyCalls) != len(want) {
+		t.Fatalf("PutScalingPolicy called %d times, want %d", len(mockAAS.putScalingPolicyCalls), len(want))
+	}
+	for _, put := range mockAAS.putScalingPolicyCalls {
+		w, ok := want[aws.ToString(put.PolicyName)]
+		tt := put.TargetTrackingScalingPolicyConfiguration
+		if !ok || put.PolicyType != aasTypes.PolicyTypeTargetTrackingScaling || tt == nil {
+			t.Fatalf("unexpected policy %s of type %s", aws.ToString(put.PolicyName), put.PolicyType)
+		}
+		if tt.PredefinedMetricSpecification.PredefinedMetricType != w.metric || aws.ToFloat64(tt.TargetValue) != w.target {
+			t.Errorf("%s = %s/%v, want %s/%v", aws.ToString(put.PolicyName), tt.PredefinedMetricSpecification.PredefinedMetricType, aws.ToFloat64(tt.TargetValue), w.metric, w.target)
+		}
+		if aws.ToInt32(tt.ScaleOutCooldown) != 60 || aws.ToInt32(tt.ScaleInCooldown) != 300 {
+			t.Errorf("%s cooldowns = %s/%s, want 60/300", aws.ToString(put.PolicyName), ptrString(tt.ScaleOutCooldown), ptrString(tt.ScaleInCooldown))
+		}
+	}
+	if len(mockCW.putMetricAlarmCalls) != 0 {
+		t.Errorf("PutMetricAlarm called %d times, want 0 (AWS manages target-tracking alarms)", len(mockCW.putMetricAlarmCalls))
+	}
+}
+
+// TestRunBlended tests that --blended adds the CPU/memory target-tracking pair alongside custom policies,
+// that disabling removes the pair too, and that a custom policy cannot take one of their names
+func TestRunBlended(t *testing.T) {
+	custom := `[{"policy_name":"queue-target","policy_type":"TargetTrackingScaling",` +
+		`"target_tracking_configuration":{"target_value":100,"predefined_metric_specification":"ALBRequestCountPerTarget","resource_label":"app/a/1/targetgroup/t/2"}}]`
+	newConfig := func(enabled bool, policiesRaw string) *Config {
+		return &Config{
+			Cluster:          "test-cluster",
+			Service:          "test-service",
+			Enabled:          enabled,
+			MinCapacity:      1,
+			MaxCapacity:      10,
+			ScaleOutCooldown: 60,
+			ScaleInCooldown:  300,
+			TargetCPUOut:     70,
+			TargetMemOut:     80,
+			PoliciesRaw:      policiesRaw,
+			Blended:          true,
+		}
+	}
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	if err := Run(context.Background(), newConfig(true, custom), Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	var put []string
+	for _, call := range mockAAS.putScalingPolicyCalls {
+		put = append(put, aws.ToString(call.PolicyName))
+	}
+	if want := []string{"queue-target", "test-cluster-test-service-cpu-target", "test-cluster-test-service-mem-target"}; !reflect.DeepEqual(put, want) {
+		t.Errorf("PutScalingPolicy names = %v, want %v", put, want)
+	}
+
+	// Disabling removes the blended pair as well
+	r, err := newRunner(newConfig(false, custom), Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard)
+	if err != nil {
+		t.Fatalf("newRunner() unexpected error: %v", err)
+	}
+	cleanup := cleanupPolicyNames(r.scaleOutName, r.scaleInName, r.policies)
+	for _, name := range []string{"test-cluster-test-service-cpu-target", "test-cluster-test-service-mem-target"} {
+		if !slices.Contains(cleanup, name) {
+			t.Errorf("cleanupPolicyNames() = %v, missing %s", cleanup, name)
+		}
+	}
+
+	clash := `[{"policy_name":"test-cluster-test-service-cpu-target","policy_type":"TargetTrackingScaling",` +
+		`"target_tracking_configuration":{"target_value":50,"predefined_metric_specification":"ECSServiceAverageCPUUtilization"}}]`
+	if _, err := newRunner(newConfig(true, clash), Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard); err == nil {
+		t.Error("newRunner() with a clashing policy name expected error, got nil")
+	}
+}
+
+// TestBuildPolicyInputResourceLabel tests the resource label on ALB request count target tracking
+func TestBuildPolicyInputResourceLabel(t *testing.T) {
+	const label = "app/my-alb/1234567890abcdef/targetgroup/my-tg/1234567890abcdef"
+
+	tests := []struct {
+		name      string
+		metric    string
+		label     string
+		wantLabel *string
+		wantErr   bool
+	}{
+		{"ALB request count with label", "ALBRequestCountPerTarget", label, aws.String(label), false},
+		{"ALB request count without label", "ALBRequestCountPerTarget", "", nil, true},
+		{"CPU without label", "ECSServiceAverageCPUUtilization", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := buildPolicyInput(PolicyDef{
+				PolicyName: "requests",
+				PolicyType: "TargetTrackingScaling",
+				TargetTrackingConfiguration: &TargetTrackingConfig{
+					TargetValue:                   1000,
+					PredefinedMetricSpecification: tt.metric,
+					ResourceLabel:                 tt.label,
+				},
+			}, testResource("service/test-cluster/test-service"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildPolicyInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := input.TargetTrackingScalingPolicyConfiguration.PredefinedMetricSpecification.ResourceLabel
+			if ptrString(got) != ptrString(tt.wantLabel) {
+				t.Errorf("ResourceLabel = %s, want %s", ptrString(got), ptrString(tt.wantLabel))
+			}
+		})
+	}
+}
+
+// TestCompareAlarm tests drift detection on CloudWatch alarm configuration
+func TestCompareAlarm(t *testing.T) {
+	existing := &cwTypes.MetricAlarm{
+		AlarmName:          aws.String("test-alarm"),
+		Threshold:          aws.Float64(75),
+		Period:             aws.Int32(300),
+		EvaluationPeriods:  aws.Int32(2),
+		ComparisonOperator: cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
+		Statistic:          cwTypes.StatisticAverage,
+		AlarmActions:       []string{"arn:b", "arn:a"},
+	}
+	desired := func() *cloudwatch.PutMetricAlarmInput {
+		return &cloudwatch.PutMetricAlarmInput{
+			AlarmName:          aws.String("test-alarm"),
+			Threshold:          aws.Float64(75),
+			Period:             aws.Int32(300),
+			EvaluationPeriods:  aws.Int32(2),
+			ComparisonOperator: cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
+			Statistic:          cwTypes.StatisticAverage,
+			AlarmActions:       []string{"arn:a", "arn:b"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*cloudwatch.PutMetricAlarmInput)
+		want   []string
+	}{
+		{"identical", func(*cloudwatch.PutMetricAlarmInput) {}, []string{}},
+		{"threshold", func(in *cloudwatch.PutMetricAlarmInput) { in.Threshold = aws.Float64(85) }, []string{"Threshold"}},
+		{"metric", func(in *cloudwatch.PutMetricAlarmInput) {
+			in.Namespace = aws.String("ECS/ContainerInsights")
+			in.MetricName = aws.String("CpuUtilized")
+		}, []string{"Namespace", "MetricName"}},
+		{"period and evaluation periods", func(in *cloudwatch.PutMetricAlarmInput) {
+			in.Period = aws.Int32(60)
+			in.EvaluationPeriods = aws.Int32(3)
+		}, []string{"Period", "EvaluationPeriods"}},
+		{"operator and statistic", func(in *cloudwatch.PutMetricAlarmInput) {
+			in.ComparisonOperator = cwTypes.ComparisonOperatorLessThanOrEqualToThreshold
+			in.Statistic = cwTypes.StatisticMaximum
+		}, []string{"ComparisonOperator", "Statistic"}},
+		{"actions", func(in *cloudwatch.PutMetricAlarmInput) { in.AlarmActions = []string{"arn:c"} }, []string{"AlarmActions"}},
+		{"ok and insufficient data actions", func(in *cloudwatch.PutMetricAlarmInput) {
+			in.OKActions = []string{"arn:ok"}
+			in.InsufficientDataActions = []string{"arn:data"}
+		}, []string{"OKActions", "InsufficientDataActions"}},
+		{"dimensions", func(in *cloudwatch.PutMetricAlarmInput) {
+			in.Dimensions = []cwTypes.Dimension{{Name: aws.String("QueueName"), Value: aws.String("jobs")}}
+		}, []string{"Dimensions"}},
+		{"actions enabled unset on the alarm", func(in *cloudwatch.PutMetricAlarmInput) { in.ActionsEnabled = aws.Bool(true) }, []string{}},
+		{"actions disabled", func(in *cloudwatch.PutMetricAlarmInput) { in.ActionsEnabled = aws.Bool(false) }, []string{"ActionsEnabled"}},
+		{"unit", func(in *cloudwatch.PutMetricAlarmInput) { in.Unit = cwTypes.StandardUnitPercent }, []string{"Unit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := desired()
+			tt.modify(in)
+			got := diffFieldNames(compareAlarm(existing, in))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compareAlarm() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReconcileAlarms tests that a drifted alarm is re-put only with --reconcile-alarms
+func TestReconcileAlarms(t *testing.T) {
+	ctx := context.Background()
+
+	for _, reconcile := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reconcile=%v", reconcile), func(t *testing.T) {
+			mockAAS := &mockAASClient{
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+					ScalingPolicies: []aasTypes.ScalingPolicy{
+						{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+					},
+				},
+			}
+			mockCW := &mockCWClient{
+				describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+					MetricAlarms: []cwTypes.MetricAlarm{{
+						AlarmName:          aws.String("test-cluster-test-service-cpu-high"),
+						Threshold:          aws.Float64(75),
+						Period:             aws.Int32(300),
+						EvaluationPeriods:  aws.Int32(2),
+						ComparisonOperator: cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
+						Statistic:          cwTypes.StatisticAverage,
+						AlarmActions:       []string{"arn:out"},
+					}},
+				},
+			}
+
+			r := newTestRunner(t, true, nil, mockAAS, mockCW)
+			r.cfg.TargetCPUOut = 85
+			r.cfg.ReconcileAlarms = reconcile
+
+			alarms, err := r.defaultAlarmInputs("arn:out", "arn:in")
+			if err != nil {
+				t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+			}
+			if err := r.ensureAlarm(ctx, alarms[0]); err != nil {
+				t.Fatalf("ensureAlarm() unexpected error: %v", err)
+			}
+
+			wantCalls := 0
+			if reconcile {
+				wantCalls = 1
+			}
+			if len(mockCW.putMetricAlarmCalls) != wantCalls {
+				t.Fatalf("PutMetricAlarm called %d times, want %d", len(mockCW.putMetricAlarmCalls), wantCalls)
+			}
+			if reconcile && aws.ToFloat64(mockCW.putMetricAlarmCalls[0].Threshold) != 85 {
+				t.Errorf("PutMetricAlarm threshold = %v, want 85", aws.ToFloat64(mockCW.putMetricAlarmCalls[0].Threshold))
+			}
+		})
+	}
+}
+
+// TestDefaultAlarmPeriods tests that --default-evaluation-periods and --default-alarm-period reach all four
+// default alarms, and that the built-in values are kept when they are unset
+func TestDefaultAlarmPeriods(t *testing.T) {
+	tests := []struct {
+		name              string
+		evaluationPeriods int32
+		period            int32
+		wantEvaluations   int32
+		wantPeriods       map[string]int32
+	}{
+		{"built-in", 0, 0, 2, map[string]int32{"cpu-high": 120, "cpu-low": 600, "mem-high": 120, "mem-low": 600}},
+		{"overridden", 5, 60, 5, map[string]int32{"cpu-high": 60, "cpu-low": 60, "mem-high": 60, "mem-low": 60}},
+		{"evaluation periods only", 3, 0, 3, map[string]int32{"cpu-high": 120, "cpu-low": 600, "mem-high": 120, "mem-low": 600}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+			r.cfg.ScaleOutCooldown = 120
+			r.cfg.ScaleInCooldown = 600
+			r.cfg.DefaultEvaluationPeriods = tt.evaluationPeriods
+			r.cfg.DefaultAlarmPeriod = tt.period
+
+			inputs, err := r.defaultAlarmInputs("arn:out", "arn:in")
+			if err != nil {
+				t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+			}
+			if len(inputs) != len(tt.wantPeriods) {
+				t.Fatalf("defaultAlarmInputs() returned %d alarms, want %d", len(inputs), len(tt.wantPeriods))
+			}
+			for _, in := range inputs {
+				suffix := strings.TrimPrefix(aws.ToString(in.AlarmName), "test-cluster-test-service-")
+				if got := aws.ToInt32(in.EvaluationPeriods); got != tt.wantEvaluations {
+					t.Errorf("%s EvaluationPeriods = %d, want %d", suffix, got, tt.wantEvaluations)
+				}
+				if got := aws.ToInt32(in.Period); got != tt.wantPeriods[suffix] {
+					t.Errorf("%s Period = %d, want %d", suffix, got, tt.wantPeriods[suffix])
+				}
+			}
+		})
+	}
+}
+
+// TestDefaultAlarmMetrics tests that --default-metric-namespace, --cpu-metric-name and --mem-metric-name reach the
+// four default alarms, that AWS/ECS CPUUtilization and MemoryUtilization are kept when they are unset, and that a
+// custom namespace allows high-resolution alarm periods
+func TestDefaultAlarmMetrics(t *testing.T) {
+	tests := []struct {
+		name, namespace, cpu, mem string
+		wantNamespace             string
+		wantMetrics               map[string]string
+	}{
+		{"built-in", "", "", "", "AWS/ECS", map[string]string{"cpu-high": "CPUUtilization", "cpu-low": "CPUUtilization", "mem-high": "MemoryUtilization", "mem-low": "MemoryUtilization"}},
+		{"overridden", "ECS/ContainerInsights", "CpuUtilized", "MemoryUtilized", "ECS/ContainerInsights", map[string]string{"cpu-high": "CpuUtilized", "cpu-low": "CpuUtilized", "mem-high": "MemoryUtilized", "mem-low": "MemoryUtilized"}},
+		{"namespace only", "Custom/Exporter", "", "", "Custom/Exporter", map[string]string{"cpu-high": "CPUUtilization", "cpu-low": "CPUUtilization", "mem-high": "MemoryUtilization", "mem-low": "MemoryUtilization"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+			r.cfg.DefaultMetricNamespace = tt.namespace
+			r.cfg.CPUMetricName = tt.cpu
+			r.cfg.MemMetricName = tt.mem
+
+			inputs, err := r.defaultAlarmInputs("arn:out", "arn:in")
+			if err != nil {
+				t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+			}
+			if len(inputs) != len(tt.wantMetrics) {
+				t.Fatalf("defaultAlarmInputs() returned %d alarms, want %d", len(inputs), len(tt.wantMetrics))
+			}
+			for _, in := range inputs {
+				suffix := strings.TrimPrefix(aws.ToString(in.AlarmName), "test-cluster-test-service-")
+				if got := aws.ToString(in.Namespace); got != tt.wantNamespace {
+					t.Errorf("%s Namespace = %s, want %s", suffix, got, tt.wantNamespace)
+				}
+				if got := aws.ToString(in.MetricName); got != tt.wantMetrics[suffix] {
+					t.Errorf("%s MetricName = %s, want %s", suffix, got, tt.wantMetrics[suffix])
+				}
+			}
+		})
+	}
+
+	cfg, err := parseArgs(append(testPositionalArgs(), "--default-metric-namespace=Custom/Exporter", "--cpu-metric-name=cpu", "--mem-metric-name=mem", "--default-alarm-period=10"))
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+	if cfg.DefaultMetricNamespace != "Custom/Exporter" || cfg.CPUMetricName != "cpu" || cfg.MemMetricName != "mem" || cfg.DefaultAlarmPeriod != 10 {
+		t.Errorf("parseArgs() = %s %s/%s every %ds, want Custom/Exporter cpu/mem every 10s", cfg.DefaultMetricNamespace, cfg.CPUMetricName, cfg.MemMetricName, cfg.DefaultAlarmPeriod)
+	}
+	cfg, err = parseArgs(testPositionalArgs())
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+	if cfg.DefaultMetricNamespace != "AWS/ECS" || cfg.CPUMetricName != "CPUUtilization" || cfg.MemMetricName != "MemoryUtilization" {
+		t.Errorf("parseArgs() defaults = %s %s/%s, want AWS/ECS CPUUtilization/MemoryUtilization", cfg.DefaultMetricNamespace, cfg.CPUMetricName, cfg.MemMetricName)
+	}
+}
+
+// TestAlarmStateActions tests that OK and insufficient-data actions reach default and custom alarms
+func TestAlarmStateActions(t *testing.T) {
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	r.cfg.AlarmOKActions = []string{"arn:ok"}
+	r.cfg.AlarmInsufficientDataActions = []string{"arn:data"}
+
+	defaults, err := r.defaultAlarmInputs("arn:out", "arn:in")
+	if err != nil {
+		t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+	}
+	for _, in := range defaults {
+		if !reflect.DeepEqual(in.OKActions, []string{"arn:ok"}) || !reflect.DeepEqual(in.InsufficientDataActions, []string{"arn:data"}) {
+			t.Errorf("%s actions = %v/%v, want [arn:ok]/[arn:data]", aws.ToString(in.AlarmName), in.OKActions, in.InsufficientDataActions)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		okActions    []string
+		dataActions  []string
+		wantOK       []string
+		wantDataActs []string
+	}{
+		{"flags", nil, nil, []string{"arn:ok"}, []string{"arn:data"}},
+		{"policy overrides", []string{"arn:team-ok"}, []string{}, []string{"arn:team-ok"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := r.customAlarmInput(PolicyDef{
+				PolicyName:              "latency",
+				PolicyType:              "StepScaling",
+				MetricName:              "TargetResponseTime",
+				MetricNamespace:         "AWS/ApplicationELB",
+				Cooldown:                aws.Int32(60),
+				OKActions:               tt.okActions,
+				InsufficientDataActions: tt.dataActions,
+			}, "arn:latency")
+			if err != nil {
+				t.Fatalf("customAlarmInput() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(in.OKActions, tt.wantOK) || !reflect.DeepEqual(in.InsufficientDataActions, tt.wantDataActs) {
+				t.Errorf("custom alarm actions = %v/%v, want %v/%v", in.OKActions, in.InsufficientDataActions, tt.wantOK, tt.wantDataActs)
+			}
+		})
+	}
+}
+
+// TestExtraAlarmActions tests that --extra-alarm-actions follow the scaling policy in the ALARM actions of default
+// and custom alarms, without duplicates, and that the policy leaves room for at most four of them
+func TestExtraAlarmActions(t *testing.T) {
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	r.cfg.ExtraAlarmActions = []string{"arn:lambda", "arn:out", "arn:sns", "arn:lambda"}
+
+	defaults, err := r.defaultAlarmInputs("arn:out", "arn:in")
+	if err != nil {
+		t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+	}
+	for _, in := range defaults {
+		want := []string{"arn:in", "arn:lambda", "arn:out", "arn:sns"}
+		if strings.Contains(aws.ToString(in.AlarmName), "high") {
+			want = []string{"arn:out", "arn:lambda", "arn:sns"}
+		}
+		if !reflect.DeepEqual(in.AlarmActions, want) {
+			t.Errorf("%s actions = %v, want %v", aws.ToString(in.AlarmName), in.AlarmActions, want)
+		}
+	}
+	in, err := r.customAlarmInput(PolicyDef{
+		PolicyName:      "latency",
+		PolicyType:      "StepScaling",
+		MetricName:      "TargetResponseTime",
+		MetricNamespace: "AWS/ApplicationELB",
+		Cooldown:        aws.Int32(60),
+	}, "arn:latency")
+	if err != nil {
+		t.Fatalf("customAlarmInput() unexpected error: %v", err)
+	}
+	if want := []string{"arn:latency", "arn:lambda", "arn:out", "arn:sns"}; !reflect.DeepEqual(in.AlarmActions, want) {
+		t.Errorf("custom alarm actions = %v, want %v", in.AlarmActions, want)
+	}
+
+	cfg, err := parseArgs(append(testPositionalArgs(), "--extra-alarm-actions= arn:a, ,arn:b"))
+	if err != nil || !reflect.DeepEqual(cfg.ExtraAlarmActions, []string{"arn:a", "arn:b"}) {
+		t.Errorf("parseArgs() extra alarm actions = %v, %v, want [arn:a arn:b]", cfg, err)
+	}
+	for _, tt := range []struct{ raw, wantErr string }{
+		{"arn:a,arn:b,arn:c,arn:d,arn:e", "max 4 besides the scaling policy"},
+		{"my-topic", `invalid action "my-topic"`},
+	} {
+		if _, err := parseArgs(append(testPositionalArgs(), "--extra-alarm-actions="+tt.raw)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseArgs(%q) error = %v, want %q", tt.raw, err, tt.wantErr)
+		}
+	}
+}
+
+// TestExtraAlarmDimensions tests that --extra-alarm-dimensions are merged into every default alarm's dimensions
+func TestExtraAlarmDimensions(t *testing.T) {
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	r.cfg.ExtraAlarmDimensions = map[string]string{"CapacityProviderName": "spot", "DeploymentStage": "blue"}
+
+	alarms, err := r.defaultAlarmInputs("arn:out", "arn:in")
+	if err != nil {
+		t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+	}
+	want := "[CapacityProviderName=spot,ClusterName=test-cluster,DeploymentStage=blue,ServiceName=test-service]"
+	for _, in := range alarms {
+		if got := alarmDimensionsString(in.Dimensions); got != want {
+			t.Errorf("%s dimensions = %s, want %s", aws.ToString(in.AlarmName), got, want)
+		}
+	}
+
+	// The resource's own dimensions are untouched for the next alarm
+	if got := alarmDimensionsString(r.resource.alarmDimensions()); got != "[ClusterName=test-cluster,ServiceName=test-service]" {
+		t.Errorf("resource dimensions = %s, want ClusterName and ServiceName only", got)
+	}
+}
+
+// TestDefaultAlarmOperators tests that --scale-out-operator and --scale-in-operator reach the default alarms
+func TestDefaultAlarmOperators(t *testing.T) {
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	want := map[string]cwTypes.ComparisonOperator{
+		"test-cluster-test-service-cpu-high": cwTypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
+		"test-cluster-test-service-cpu-low":  cwTypes.ComparisonOperatorLessThanOrEqualToThreshold,
+	}
+	check := func() {
+		t.Helper()
+		alarms, err := r.defaultAlarmInputs("arn:out", "arn:in")
+		if err != nil {
+			t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+		}
+		for _, in := range alarms {
+			name := strings.Replace(aws.ToString(in.AlarmName), "-mem-", "-cpu-", 1) // memory alarms match their CPU twin
+			if in.ComparisonOperator != want[name] {
+				t.Errorf("%s operator = %s, want %s", aws.ToString(in.AlarmName), in.ComparisonOperator, want[name])
+			}
+		}
+	}
+	check()
+
+	r.cfg.ScaleOutOperator = "GreaterThanThreshold"
+	r.cfg.ScaleInOperator = "LessThanThreshold"
+	want["test-cluster-test-service-cpu-high"] = cwTypes.ComparisonOperatorGreaterThanThreshold
+	want["test-cluster-test-service-cpu-low"] = cwTypes.ComparisonOperatorLessThanThreshold
+	check()
+
+	cfg, err := parseArgs(append(testPositionalArgs(), "--scale-out-operator=GreaterThanThreshold"))
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+	if cfg.ScaleOutOperator != "GreaterThanThreshold" || cfg.ScaleInOperator != "LessThanOrEqualToThreshold" {
+		t.Errorf("parseArgs() operators = %s/%s, want GreaterThanThreshold and the LessThanOrEqualToThreshold default", cfg.ScaleOutOperator, cfg.ScaleInOperator)
+	}
+	if _, err := parseArgs(append(testPositionalArgs(), "--scale-in-operator=GreaterThanThreshold")); err == nil || !strings.Contains(err.Error(), "invalid scale-in-operator") {
+		t.Errorf("parseArgs() error = %v, want invalid scale-in-operator", err)
+	}
+}
+
+// TestParseExtraAlarmDimensions tests parsing and validation of --extra-alarm-dimensions
+func TestParseExtraAlarmDimensions(t *testing.T) {
+	cfg, err := parseArgs(append(testPositionalArgs(), "--extra-alarm-dimensions= CapacityProviderName=spot ,Stage=blue"))
+	if err != nil {
+		t.Fatalf("parseArgs() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.ExtraAlarmDimensions, map[string]string{"CapacityProviderName": "spot", "Stage": "blue"}) {
+		t.Errorf("parseArgs() extra dimensions = %v, want CapacityProviderName=spot Stage=blue", cfg.ExtraAlarmDimensions)
+	}
+
+	var tooMany []string
+	for i := range maxAlarmDimensions - 1 {
+		tooMany = append(tooMany, fmt.Sprintf("d%d=v", i))
+	}
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{"missing value", "Stage=", "expected name=value"},
+		{"missing separator", "Stage", "expected name=value"},
+		{"duplicate", "Stage=a,Stage=b", "duplicate name"},
+		{"overrides the service", "ServiceName=other", "ServiceName is already set"},
+		{"over the CloudWatch limit", strings.Join(tooMany, ","), "31 dimensions (max 30)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseArgs(append(testPositionalArgs(), "--extra-alarm-dimensions="+tt.raw))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseArgs() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestAlarmActionsEnabled tests that --alarms-enabled=false and actions_enabled reach created alarms
+func TestAlarmActionsEnabled(t *testing.T) {
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	r := newTestRunner(t, true, nil, &mockAASClient{}, mockCW)
+	r.cfg.AlarmsEnabled = false
+
+	alarms, err := r.defaultAlarmInputs("arn:out", "arn:in")
+	if err != nil {
+		t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+	}
+	for _, alarmInput := range alarms {
+		if err := r.ensureAlarm(context.Background(), alarmInput); err != nil {
+			t.Fatalf("ensureAlarm() unexpected error: %v", err)
+		}
+	}
+	if len(mockCW.putMetricAlarmCalls) == 0 {
+		t.Fatal("PutMetricAlarm not called")
+	}
+	for _, call := range mockCW.putMetricAlarmCalls {
+		if call.ActionsEnabled == nil || *call.ActionsEnabled {
+			t.Errorf("%s ActionsEnabled = %s, want false", aws.ToString(call.AlarmName), ptrString(call.ActionsEnabled))
+		}
+	}
+
+	tests := []struct {
+		name           string
+		actionsEnabled *bool
+		want           bool
+	}{
+		{"flag", nil, false},
+		{"policy override", aws.Bool(true), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := r.customAlarmInput(PolicyDef{
+				PolicyName:      "latency",
+				PolicyType:      "StepScaling",
+				MetricName:      "TargetResponseTime",
+				MetricNamespace: "AWS/ApplicationELB",
+				Cooldown:        aws.Int32(60),
+				ActionsEnabled:  tt.actionsEnabled,
+			}, "arn:latency")
+			if err != nil {
+				t.Fatalf("customAlarmInput() unexpected error: %v", err)
+			}
+			if in.ActionsEnabled == nil || *in.ActionsEnabled != tt.want {
+				t.Errorf("custom alarm ActionsEnabled = %s, want %v", ptrString(in.ActionsEnabled), tt.want)
+			}
+		})
+	}
+}
+
+// TestAlarmExistsForPolicy tests that an alarm already driving a policy is detected and not duplicated
+func TestAlarmExistsForPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		actions   []string
+		wantFound bool
+	}{
+		{"no alarms", nil, false},
+		{"alarm references policy", []string{"arn:sns", "arn:policy/backlog"}, true},
+		{"only a prefix match", []string{"arn:policy/backlog-2"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+					ScalingPolicies: []aasTypes.ScalingPolicy{{PolicyName: aws.String("backlog"), PolicyARN: aws.String("arn:policy/backlog")}},
+				},
+			}
+			mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+			if tt.actions != nil {
+				// A hand-made alarm under a different name than the one the action would create
+				mockCW.describeAlarmsOutput.MetricAlarms = []cwTypes.MetricAlarm{{AlarmName: aws.String("manual-backlog"), AlarmActions: tt.actions}}
+			}
+
+			found, err := alarmExistsForPolicy(ctx, mockCW, "arn:policy/backlog")
+			if err != nil {
+				t.Fatalf("alarmExistsForPolicy() unexpected error: %v", err)
+			}
+			if found != tt.wantFound {
+				t.Errorf("alarmExistsForPolicy() = %v, want %v", found, tt.wantFound)
+			}
+
+			r := newTestRunner(t, true, nil, mockAAS, mockCW)
+			err = r.applyCustomPolicyAlarm(ctx, PolicyDef{
+				PolicyName:      "backlog",
+				PolicyType:      "StepScaling",
+				MetricName:      "ApproximateNumberOfMessagesVisible",
+				MetricNamespace: "AWS/SQS",
+				Cooldown:        aws.Int32(60),
+			}, "")
+			if err != nil {
+				t.Fatalf("applyCustomPolicyAlarm() unexpected error: %v", err)
+			}
+			wantCalls := 1
+			if tt.wantFound {
+				wantCalls = 0
+			}
+			if len(mockCW.putMetricAlarmCalls) != wantCalls {
+				t.Errorf("PutMetricAlarm called %d times, want %d", len(mockCW.putMetricAlarmCalls), wantCalls)
+			}
+		})
+	}
+
+	// A cancelled context stops before listing alarms
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	if _, err := alarmsForPolicy(cancelCtx, mockCW, "arn:policy/backlog"); !errors.Is(err, context.Canceled) {
+		t.Errorf("alarmsForPolicy() error = %v, want context.Canceled", err)
+	}
+	if len(mockCW.describeAlarmsCalls) != 0 {
+		t.Errorf("DescribeAlarms called %d times after cancellation, want 0", len(mockCW.describeAlarmsCalls))
+	}
+}
+
+// TestAlarmStatistic tests standard and percentile statistics on default and custom alarms
+func TestAlarmStatistic(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfgStatistic    string
+		policyStatistic string
+		wantStatistic   cwTypes.Statistic
+		wantExtended    string
+	}{
+		{"default", "", "", cwTypes.StatisticAverage, ""},
+		{"standard flag", "Maximum", "", cwTypes.StatisticMaximum, ""},
+		{"percentile flag", "p99", "", "", "p99"},
+		{"policy overrides flag", "Maximum", "p90", "", "p90"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+			r.cfg.AlarmStatistic = tt.cfgStatistic
+
+			custom, err := r.customAlarmInput(PolicyDef{
+				PolicyName:      "latency",
+				PolicyType:      "StepScaling",
+				MetricName:      "TargetResponseTime",
+				MetricNamespace: "AWS/ApplicationELB",
+				Cooldown:        aws.Int32(60),
+				Statistic:       tt.policyStatistic,
+			}, "arn:latency")
+			if err != nil {
+				t.Fatalf("customAlarmInput() unexpected error: %v", err)
+			}
+			if custom.Statistic != tt.wantStatistic || aws.ToString(custom.ExtendedStatistic) != tt.wantExtended {
+				t.Errorf("custom alarm statistic = %q/%q, want %q/%q", custom.Statistic, aws.ToString(custom.ExtendedStatistic), tt.wantStatistic, tt.wantExtended)
+			}
+
+			if tt.policyStatistic != "" {
+				return
+			}
+			defaults, err := r.defaultAlarmInputs("arn:out", "arn:in")
+			if err != nil {
+				t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+			}
+			for _, in := range defaults {
+				if in.Statistic != tt.wantStatistic || aws.ToString(in.ExtendedStatistic) != tt.wantExtended {
+					t.Errorf("%s statistic = %q/%q, want %q/%q", aws.ToString(in.AlarmName), in.Statistic, aws.ToString(in.ExtendedStatistic), tt.wantStatistic, tt.wantExtended)
+				}
+			}
+		})
+	}
+}
+
+// TestAlarmUnit tests that a step policy's unit is read from JSON and forwarded to its alarm
+func TestAlarmUnit(t *testing.T) {
+	policies, err := parsePolicies(`[{"policy_name":"latency","policy_type":"StepScaling","metric_name":"TargetResponseTime","metric_namespace":"AWS/ApplicationELB","cooldown":60,"unit":"Seconds","adjustment_type":"ChangeInCapacity","step_adjustments":[{"MetricIntervalLowerBound":0,"ScalingAdjustment":1}]}]`, "")
+	if err != nil {
+		t.Fatalf("parsePolicies() unexpected error: %v", err)
+	}
+	if policies[0].Unit != "Seconds" {
+		t.Fatalf("Unit = %q, want Seconds", policies[0].Unit)
+	}
+
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	in, err := r.customAlarmInput(policies[0], "arn:latency")
+	if err != nil {
+		t.Fatalf("customAlarmInput() unexpected error: %v", err)
+	}
+	if in.Unit != cwTypes.StandardUnitSeconds {
+		t.Errorf("custom alarm Unit = %q, want Seconds", in.Unit)
+	}
+
+	policies[0].Unit = ""
+	in, err = r.customAlarmInput(policies[0], "arn:latency")
+	if err != nil {
+		t.Fatalf("customAlarmInput() unexpected error: %v", err)
+	}
+	if in.Unit != "" {
+		t.Errorf("custom alarm Unit = %q, want unset", in.Unit)
+	}
+}
+
+// TestAlarmDimensions tests that custom dimensions replace the resource's defaults verbatim
+func TestAlarmDimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		dimensions map[string]string
+		want       string
+	}{
+		{"resource defaults", nil, "[ClusterName=test-cluster,ServiceName=test-service]"},
+		{"custom dimensions", map[string]string{"QueueName": "jobs", "Region": "eu"}, "[QueueName=jobs,Region=eu]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+			in, err := r.customAlarmInput(PolicyDef{
+				PolicyName:      "backlog",
+				PolicyType:      "StepScaling",
+				MetricName:      "ApproximateNumberOfMessagesVisible",
+				MetricNamespace: "AWS/SQS",
+				Cooldown:        aws.Int32(60),
+				Dimensions:      tt.dimensions,
+			}, "arn:backlog")
+			if err != nil {
+				t.Fatalf("customAlarmInput() unexpected error: %v", err)
+			}
+			if got := alarmDimensionsString(in.Dimensions); got != tt.want {
+				t.Errorf("alarm dimensions = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCleanupPartialFailure tests that cleanup attempts every deletion and keeps the target when one fails
+func TestCleanupPartialFailure(t *testing.T) {
+	ctx := context.Background()
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{
+				{PolicyName: aws.String("test-cluster-test-service-scale-out")},
+				{PolicyName: aws.String("test-cluster-test-service-scale-in")},
+			},
+		},
+		deleteScalingPolicyErrors: map[string]error{
+			"test-cluster-test-service-scale-out": fmt.Errorf("throttled"),
+		},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	r := newTestRunner(t, false, nil, mockAAS, mockCW)
+	err := r.cleanup(ctx)
+	if err == nil || !strings.Contains(err.Error(), "throttled") {
+		t.Fatalf("cleanup() error = %v, want wrapped throttled", err)
+	}
+
+	var attempted []string
+	for _, call := range mockAAS.deleteScalingPolicyCalls {
+		attempted = append(attempted, aws.ToString(call.PolicyName))
+	}
+	want := []string{"test-cluster-test-service-scale-out", "test-cluster-test-service-scale-in"}
+	if !reflect.DeepEqual(attempted, want) {
+		t.Errorf("DeleteScalingPolicy attempted %v, want %v", attempted, want)
+	}
+	if len(mockAAS.deregisterScalableTargetCalls) != 0 {
+		t.Error("cleanup() deregistered the scalable target after a failed deletion")
+	}
+
+	// Once every deletion succeeds the target is deregistered
+	mockAAS.deleteScalingPolicyErrors = nil
+	if err := r.cleanup(ctx); err != nil {
+		t.Fatalf("cleanup() unexpected error: %v", err)
+	}
+	if len(mockAAS.deregisterScalableTargetCalls) != 1 {
+		t.Errorf("DeregisterScalableTarget called %d times, want 1", len(mockAAS.deregisterScalableTargetCalls))
+	}
+}
+
+// TestCleanupFailedLookups tests that a failed alarm or policy lookup fails cleanup and keeps the target, since
+// whatever it would have found is still attached
+func TestCleanupFailedLookups(t *testing.T) {
+	tests := []struct {
+		name          string
+		alarmsErr     error
+		policiesErr   error
+		prefixCleanup bool
+		wantErr       string
+	}{
+		{"alarms", errors.New("throttled"), nil, false, "failed to check alarms"},
+		{"prefix alarms", errors.New("throttled"), nil, true, "failed to list alarms by name prefix"},
+		{"policies", nil, errors.New("throttled"), false, "failed to check scaling policy test-cluster-test-service-scale-out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+					ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+				},
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+				describeScalingPoliciesError:  tt.policiesErr,
+			}
+			mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}, describeAlarmsError: tt.alarmsErr}
+			r := newTestRunner(t, false, nil, mockAAS, mockCW)
+			r.cfg.PrefixCleanup = tt.prefixCleanup
+
+			err := r.cleanup(context.Background())
+			if err == nil || !strings.Contains(err.Error(), "cleanup incomplete") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("cleanup() error = %v, want an incomplete cleanup with %q", err, tt.wantErr)
+			}
+			if len(mockAAS.deregisterScalableTargetCalls) != 0 {
+				t.Error("cleanup() deregistered the scalable target after a failed lookup")
+			}
+		})
+	}
+}
+
+// TestCleanupKeepTarget tests that --keep-target deletes the policies and alarms but never deregisters the target
+func TestCleanupKeepTarget(t *testing.T) {
+	ctx := context.Background()
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{
+				{PolicyName: aws.String("test-cluster-test-service-scale-out")},
+				{PolicyName: aws.String("test-cluster-test-service-scale-in")},
+			},
+		},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+		MetricAlarms: []cwTypes.MetricAlarm{{AlarmName: aws.String("test-cluster-test-service-cpu-high")}},
+	}}
+
+	r := newTestRunner(t, false, nil, mockAAS, mockCW)
+	r.cfg.KeepTarget = true
+	items, err := r.buildPlan(ctx)
+	if err != nil {
+		t.Fatalf("buildPlan() unexpected error: %v", err)
+	}
+	if last := items[len(items)-1]; last.Kind != "scalable-target" || last.Action != planNoChange {
+		t.Errorf("buildPlan() target item = %+v, want it kept", last)
+	}
+
+	if err := r.cleanup(ctx); err != nil {
+		t.Fatalf("cleanup() unexpected error: %v", err)
+	}
+	if len(mockAAS.deleteScalingPolicyCalls) != 2 || len(mockCW.deleteAlarmsCalls) != 1 {
+		t.Errorf("cleanup() deleted %d policies and made %d alarm deletions, want 2 and 1", len(mockAAS.deleteScalingPolicyCalls), len(mockCW.deleteAlarmsCalls))
+	}
+	if len(mockAAS.deregisterScalableTargetCalls) != 0 {
+		t.Errorf("DeregisterScalableTarget called %d times with keep-target, want 0", len(mockAAS.deregisterScalableTargetCalls))
+	}
+}
+
+// TestCleanupOrphanedAlarms tests that cleanup also deletes alarms it cannot name, such as AWS-managed
+// target-tracking alarms, when their actions reference a policy on the same resource
+func TestCleanupOrphanedAlarms(t *testing.T) {
+	const policyARN = "arn:aws:autoscaling:us-east-1:123456789012:scalingPolicy:6d8972f3-5bb4-4d2b-9f7e-4d3c1a2b3c4d:" +
+		"resource/ecs/service/test-cluster/test-service:policyName/deleted-target"
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	mockCW := &mockCWClient{
+		describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+			MetricAlarms: []cwTypes.MetricAlarm{
+				{
+					AlarmName:    aws.String("TargetTracking-service/test-cluster/test-service-AlarmHigh-0a1b2c3d"),
+					AlarmActions: []string{policyARN},
+				},
+				{
+					// Same service name in another cluster must be left alone
+					AlarmName:    aws.String("TargetTracking-service/other-cluster/test-service-AlarmHigh-9f8e7d6c"),
+					AlarmActions: []string{strings.Replace(policyARN, "test-cluster", "other-cluster", 1)},
+				},
+			},
+		},
+	}
+
+	r := newTestRunner(t, false, nil, mockAAS, mockCW)
+	if err := r.cleanup(context.Background()); err != nil {
+		t.Fatalf("cleanup() unexpected error: %v", err)
+	}
+	if len(mockCW.deleteAlarmsCalls) != 1 {
+		t.Fatalf("DeleteAlarms called %d times, want 1", len(mockCW.deleteAlarmsCalls))
+	}
+	deleted := mockCW.deleteAlarmsCalls[0].AlarmNames
+	if !slices.Contains(deleted, "TargetTracking-service/test-cluster/test-service-AlarmHigh-0a1b2c3d") {
+		t.Errorf("DeleteAlarms names = %v, want the orphaned target-tracking alarm", deleted)
+	}
+	if slices.Contains(deleted, "TargetTracking-service/other-cluster/test-service-AlarmHigh-9f8e7d6c") {
+		t.Errorf("DeleteAlarms names = %v, deleted another resource's alarm", deleted)
+	}
+
+	// The plan for the disable path lists it too
+	items, err := r.buildCleanupPlan(context.Background(), true)
+	if err != nil {
+		t.Fatalf("buildCleanupPlan() unexpected error: %v", err)
+	}
+	if !slices.ContainsFunc(items, func(item planItem) bool {
+		return item.Name == "TargetTracking-service/test-cluster/test-service-AlarmHigh-0a1b2c3d" && item.Action == planDelete
+	}) {
+		t.Errorf("buildCleanupPlan() = %v, want the orphaned alarm deleted", items)
+	}
+
+	// A cancelled context stops before listing alarms
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := len(mockCW.describeAlarmsCalls)
+	if _, err := alarmsForResourcePolicies(ctx, mockCW, r.resource); !errors.Is(err, context.Canceled) {
+		t.Errorf("alarmsForResourcePolicies() error = %v, want context.Canceled", err)
+	}
+	if len(mockCW.describeAlarmsCalls) != calls {
+		t.Errorf("DescribeAlarms called after cancellation")
+	}
+}
+
+// TestNoAlarms tests that --no-alarms manages policies and the scalable target without creating or deleting
+// any alarm, and that a policy's manage_alarm overrides it
+func TestNoAlarms(t *testing.T) {
+	ctx := context.Background()
+	newAAS := func() *mockAASClient {
+		return &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+				ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+			},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+				ScalingPolicies: []aasTypes.ScalingPolicy{
+					{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+					{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:in")},
+					{PolicyName: aws.String("queue-depth"), PolicyARN: aws.String("arn:queue-depth")},
+				},
+			},
+		}
+	}
+	policy := PolicyDef{
+		PolicyName:      "queue-depth",
+		PolicyType:      "StepScaling",
+		MetricName:      "ApproximateNumberOfMessagesVisible",
+		MetricNamespace: "AWS/SQS",
+		AdjustmentType:  "ChangeInCapacity",
+		Cooldown:        aws.Int32(60),
+		StepAdjustments: []StepAdj{{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 1}},
+		ScaleDirection:  "out",
+	}
+
+	// Default policies are still put, but their alarms are not
+	mockAAS, mockCW := newAAS(), &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	r := newTestRunner(t, true, nil, mockAAS, mockCW)
+	r.cfg.NoAlarms = true
+	if err := r.apply(ctx); err != nil {
+		t.Fatalf("apply() unexpected error: %v", err)
+	}
+	if len(mockAAS.registerScalableTargetCalls) != 1 || len(mockAAS.putScalingPolicyCalls) != 2 {
+		t.Errorf("apply() registered %d targets and put %d policies, want 1 and 2",
+			len(mockAAS.registerScalableTargetCalls), len(mockAAS.putScalingPolicyCalls))
+	}
+	if len(mockCW.putMetricAlarmCalls) != 0 {
+		t.Errorf("PutMetricAlarm called %d times with no-alarms, want 0", len(mockCW.putMetricAlarmCalls))
+	}
+	items, err := r.buildPlan(ctx)
+	if err != nil {
+		t.Fatalf("buildPlan() unexpected error: %v", err)
+	}
+	if slices.ContainsFunc(items, func(item planItem) bool { return item.Kind == "alarm" }) {
+		t.Errorf("buildPlan() = %v, want no alarms with no-alarms", items)
+	}
+
+	// Step policies with metric info get no alarm unless manage_alarm asks for one
+	for _, tt := range []struct {
+		name        string
+		manageAlarm *bool
+		wantCalls   int
+	}{
+		{"no-alarms", nil, 0},
+		{"manage_alarm overrides no-alarms", aws.Bool(true), 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+			p := policy
+			p.ManageAlarm = tt.manageAlarm
+			r := newTestRunner(t, true, []PolicyDef{p}, newAAS(), mockCW)
+			r.cfg.NoAlarms = true
+			if err := r.applyCustomPolicies(ctx); err != nil {
+				t.Fatalf("applyCustomPolicies() unexpected error: %v", err)
+			}
+			if len(mockCW.putMetricAlarmCalls) != tt.wantCalls {
+				t.Errorf("PutMetricAlarm called %d times, want %d", len(mockCW.putMetricAlarmCalls), tt.wantCalls)
+			}
+		})
+	}
+
+	// manage_alarm false opts a single policy out without --no-alarms
+	mockCW = &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	p := policy
+	p.ManageAlarm = aws.Bool(false)
+	r = newTestRunner(t, true, []PolicyDef{p}, newAAS(), mockCW)
+	if err := r.applyCustomPolicies(ctx); err != nil {
+		t.Fatalf("applyCustomPolicies() unexpected error: %v", err)
+	}
+	if len(mockCW.putMetricAlarmCalls) != 0 {
+		t.Errorf("PutMetricAlarm called %d times with manage_alarm false, want 0", len(mockCW.putMetricAlarmCalls))
+	}
+
+	// Cleanup removes the policies and target but leaves every alarm, including ones pointing at the policies
+	mockAAS = newAAS()
+	mockCW = &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+		MetricAlarms: []cwTypes.MetricAlarm{{
+			AlarmName:    aws.String("terraform-queue-depth"),
+			AlarmActions: []string{"arn:aws:autoscaling:us-east-1:123456789012:scalingPolicy:x:resource/ecs/service/test-cluster/test-service:policyName/queue-depth"},
+		}},
+	}}
+	r = newTestRunner(t, false, []PolicyDef{policy}, mockAAS, mockCW)
+	r.cfg.NoAlarms = true
+	if err := r.cleanup(ctx); err != nil {
+		t.Fatalf("cleanup() unexpected error: %v", err)
+	}
+	if len(mockCW.deleteAlarmsCalls) != 0 {
+		t.Errorf("DeleteAlarms called %d times with no-alarms, want 0", len(mockCW.deleteAlarmsCalls))
+	}
+	if len(mockAAS.deleteScalingPolicyCalls) == 0 || len(mockAAS.deregisterScalableTargetCalls) != 1 {
+		t.Errorf("cleanup() deleted %d policies and deregistered %d targets, want policies and the target removed",
+			len(mockAAS.deleteScalingPolicyCalls), len(mockAAS.deregisterScalableTargetCalls))
+	}
+	items, err = r.buildCleanupPlan(ctx, true)
+	if err != nil {
+		t.Fatalf("buildCleanupPlan() unexpected error: %v", err)
+	}
+	if slices.ContainsFunc(items, func(item planItem) bool { return item.Kind == "alarm" }) {
+		t.Errorf("buildCleanupPlan() = %v, want no alarms with no-alarms", items)
+	}
+}
+
+// TestForceRecreate tests that a drifted policy is deleted with its alarms and then put again
+func TestForceRecreate(t *testing.T) {
+	ctx := context.Background()
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{{
+				PolicyName: aws.String("cpu-target"),
+				PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+					TargetValue: aws.Float64(60),
+					PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+						PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageMemoryUtilization,
+					},
+				},
+				Alarms: []aasTypes.Alarm{
+					{AlarmName: aws.String("TargetTracking-service/test-cluster/test-service-AlarmHigh-1")},
+					{AlarmName: aws.String("TargetTracking-service/test-cluster/test-service-AlarmLow-1")},
+				},
+			}},
+		},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	policies := []PolicyDef{{
+		PolicyName: "cpu-target",
+		PolicyType: "TargetTrackingScaling",
+		TargetTrackingConfiguration: &TargetTrackingConfig{
+			TargetValue:                   60,
+			PredefinedMetricSpecification: "ECSServiceAverageCPUUtilization",
+		},
+	}}
+
+	for _, force := range []bool{false, true} {
+		t.Run(fmt.Sprintf("force-recreate=%v", force), func(t *testing.T) {
+			mockAAS.calls = nil
+			mockCW.deleteAlarmsCalls = nil
+			r := newTestRunner(t, true, policies, mockAAS, mockCW)
+			r.cfg.ForceRecreate = force
+
+			if err := r.applyCustomPolicies(ctx); err != nil {
+				t.Fatalf("applyCustomPolicies() unexpected error: %v", err)
+			}
+
+			want := []string{"PutScalingPolicy"}
+			if force {
+				want = []string{"DeleteScalingPolicy", "PutScalingPolicy"}
+			}
+			if !reflect.DeepEqual(mockAAS.calls, want) {
+				t.Errorf("calls = %v, want %v", mockAAS.calls, want)
+			}
+
+			if !force {
+				if len(mockCW.deleteAlarmsCalls) != 0 {
+					t.Error("DeleteAlarms called without force-recreate")
+				}
+				return
+			}
+			if len(mockCW.deleteAlarmsCalls) != 1 || len(mockCW.deleteAlarmsCalls[0].AlarmNames) != 2 {
+				t.Errorf("DeleteAlarms calls = %+v, want one call with both target tracking alarms", mockCW.deleteAlarmsCalls)
+			}
+		})
+	}
+}
+
+// TestPolicyARNFromPut tests that new policies take their alarm's ARN from the PutScalingPolicy response instead
+// of describing the policy again, and that a response without one falls back to describe
+func TestPolicyARNFromPut(t *testing.T) {
+	ctx := context.Background()
+	stepPolicy := PolicyDef{
+		PolicyName:      "backlog",
+		PolicyType:      "StepScaling",
+		MetricName:      "ApproximateNumberOfMessagesVisible",
+		MetricNamespace: "AWS/SQS",
+		AdjustmentType:  "ChangeInCapacity",
+		Cooldown:        aws.Int32(60),
+		ScaleDirection:  "out",
+		StepAdjustments: []StepAdj{{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 1}},
+	}
+	alarmActions := func(m *mockCWClient) []string {
+		var actions []string
+		for _, in := range m.putMetricAlarmCalls {
+			actions = append(actions, in.AlarmActions...)
+		}
+		return actions
+	}
+
+	// Custom policy: the only describe is the comparison before the put
+	mockAAS := &mockAASClient{
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		putScalingPolicyARNs:          map[string]string{"backlog": "arn:backlog"},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	r := newTestRunner(t, true, []PolicyDef{stepPolicy}, mockAAS, mockCW)
+	if err := r.applyCustomPolicies(ctx); err != nil {
+		t.Fatalf("applyCustomPolicies() unexpected error: %v", err)
+	}
+	if mockAAS.describeScalingPoliciesCalls != 1 {
+		t.Errorf("DescribeScalingPolicies called %d times, want 1", mockAAS.describeScalingPoliciesCalls)
+	}
+	if got := alarmActions(mockCW); !slices.Equal(got, []string{"arn:backlog"}) {
+		t.Errorf("alarm actions = %v, want the ARN from the put", got)
+	}
+
+	// Default policies: one comparison each, no describe for the alarms
+	mockAAS = &mockAASClient{
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		putScalingPolicyARNs: map[string]string{
+			"test-cluster-test-service-scale-out": "arn:out",
+			"test-cluster-test-service-scale-in":  "arn:in",
+		},
+	}
+	mockCW = &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	r = newTestRunner(t, true, nil, mockAAS, mockCW)
+	if err := r.applyDefaultPolicies(ctx); err != nil {
+		t.Fatalf("applyDefaultPolicies() unexpected error: %v", err)
+	}
+	if mockAAS.describeScalingPoliciesCalls != 2 {
+		t.Errorf("DescribeScalingPolicies called %d times, want 2", mockAAS.describeScalingPoliciesCalls)
+	}
+	if got := alarmActions(mockCW); !slices.Contains(got, "arn:out") || !slices.Contains(got, "arn:in") {
+		t.Errorf("alarm actions = %v, want the ARNs from the puts", got)
+	}
+
+	// Without an ARN in the response the policy is described for it
+	mockAAS = &mockAASClient{describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+		ScalingPolicies: []aasTypes.ScalingPolicy{{PolicyName: aws.String("backlog"), PolicyARN: aws.String("arn:described"), PolicyType: aasTypes.PolicyTypeTargetTrackingScaling}},
+	}}
+	mockCW = &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	r = newTestRunner(t, true, []PolicyDef{stepPolicy}, mockAAS, mockCW)
+	if err := r.applyCustomPolicies(ctx); err != nil {
+		t.Fatalf("applyCustomPolicies() unexpected error: %v", err)
+	}
+	if mockAAS.describeScalingPoliciesCalls != 2 {
+		t.Errorf("DescribeScalingPolicies called %d times, want 2", mockAAS.describeScalingPoliciesCalls)
+	}
+	if got := alarmActions(mockCW); !slices.Equal(got, []string{"arn:described"}) {
+		t.Errorf("alarm actions = %v, want the described ARN", got)
+	}
+}
+
+// TestRunEnv tests that --env is appended to the generated policy and alarm names when enabling, and that
+// disabling deletes the same suffixed names
+func TestRunEnv(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TargetCPUOut:     75,
+		TargetCPUIn:      65,
+		TargetMemOut:     80,
+		TargetMemIn:      70,
+		AlarmsEnabled:    true,
+		Env:              "staging",
+	}
+	policies := []aasTypes.ScalingPolicy{
+		{PolicyName: aws.String("test-cluster-test-service-scale-out-staging"), PolicyARN: aws.String("arn:out")},
+		{PolicyName: aws.String("test-cluster-test-service-scale-in-staging"), PolicyARN: aws.String("arn:in")},
+	}
+	wantAlarms := []string{
+		"test-cluster-test-service-cpu-high-staging",
+		"test-cluster-test-service-cpu-low-staging",
+		"test-cluster-test-service-mem-high-staging",
+		"test-cluster-test-service-mem-low-staging",
+	}
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		putScalingPolicyARNs:          map[string]string{"test-cluster-test-service-scale-out-staging": "arn:out", "test-cluster-test-service-scale-in-staging": "arn:in"},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	var put []string
+	for _, in := range mockAAS.putScalingPolicyCalls {
+		put = append(put, aws.ToString(in.PolicyName))
+	}
+	if !slices.Equal(put, []string{"test-cluster-test-service-scale-out-staging", "test-cluster-test-service-scale-in-staging"}) {
+		t.Errorf("put policies %v, want the -staging names", put)
+	}
+	var alarms []string
+	for _, in := range mockCW.putMetricAlarmCalls {
+		alarms = append(alarms, aws.ToString(in.AlarmName))
+	}
+	if !slices.Equal(alarms, wantAlarms) {
+		t.Errorf("put alarms %v, want %v", alarms, wantAlarms)
+	}
+
+	// Disabling finds and deletes the same names
+	cfg.Enabled, cfg.Yes = false, true
+	var existingAlarms []cwTypes.MetricAlarm
+	for _, name := range wantAlarms {
+		existingAlarms = append(existingAlarms, cwTypes.MetricAlarm{AlarmName: aws.String(name)})
+	}
+	mockAAS = &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{ScalableTargets: []aasTypes.ScalableTarget{{}}},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{ScalingPolicies: policies},
+	}
+	mockCW = &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{MetricAlarms: existingAlarms}}
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	var deleted []string
+	for _, in := range mockAAS.deleteScalingPolicyCalls {
+		deleted = append(deleted, aws.ToString(in.PolicyName))
+	}
+	if !slices.Contains(deleted, "test-cluster-test-service-scale-out-staging") || !slices.Contains(deleted, "test-cluster-test-service-scale-in-staging") {
+		t.Errorf("deleted policies %v, want the -staging names", deleted)
+	}
+	var deletedAlarms []string
+	for _, in := range mockCW.deleteAlarmsCalls {
+		deletedAlarms = append(deletedAlarms, in.AlarmNames...)
+	}
+	for _, name := range wantAlarms {
+		if !slices.Contains(deletedAlarms, name) {
+			t.Errorf("deleted alarms %v, want %s among them", deletedAlarms, name)
+		}
+	}
+}
+
+// TestRunTargetOnly tests that --target-only registers the scalable target and puts no policies or alarms, and
+// that plan shows only the target
+func TestRunTargetOnly(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      2,
+		MaxCapacity:      8,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TargetCPUOut:     75,
+		TargetCPUIn:      65,
+		TargetMemOut:     80,
+		TargetMemIn:      70,
+		AlarmsEnabled:    true,
+		TargetOnly:       true,
+	}
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got := len(mockAAS.registerScalableTargetCalls); got != 1 {
+		t.Fatalf("RegisterScalableTarget called %d times, want 1", got)
+	}
+	if in := mockAAS.registerScalableTargetCalls[0]; aws.ToInt32(in.MinCapacity) != 2 || aws.ToInt32(in.MaxCapacity) != 8 {
+		t.Errorf("registered capacity %d-%d, want 2-8", aws.ToInt32(in.MinCapacity), aws.ToInt32(in.MaxCapacity))
+	}
+	if len(mockAAS.putScalingPolicyCalls) != 0 || len(mockCW.putMetricAlarmCalls) != 0 {
+		t.Errorf("put %d policies and %d alarms, want none", len(mockAAS.putScalingPolicyCalls), len(mockCW.putMetricAlarmCalls))
+	}
+
+	r := newTestRunner(t, true, nil, mockAAS, mockCW)
+	r.cfg.TargetOnly = true
+	items, err := r.buildPlan(ctx)
+	if err != nil {
+		t.Fatalf("buildPlan() unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].Kind != "scalable-target" {
+		t.Errorf("buildPlan() = %+v, want only the scalable target", items)
+	}
+
+	if _, err := parseArgs(append(testPositionalArgs(), "--target-only", "--purge-unmanaged")); err == nil || !strings.Contains(err.Error(), "target-only cannot be combined") {
+		t.Errorf("parseArgs() error = %v, want target-only and purge-unmanaged rejected", err)
+	}
+}
+
+// TestDefaultAggregationType tests that --default-aggregation-type reaches both default step policies, that a
+// policy applied with the old Maximum is updated to it, and that values outside the enum are rejected
+func TestDefaultAggregationType(t *testing.T) {
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	if got := defaultStepPolicyInput(r.resource, r.scaleOutName, r.scaleOutSteps(), 300, "").StepScalingPolicyConfiguration.MetricAggregationType; got != aasTypes.MetricAggregationTypeMaximum {
+		t.Errorf("MetricAggregationType without an override = %s, want Maximum", got)
+	}
+
+	var existing []aasTypes.ScalingPolicy
+	for _, p := range []struct {
+		name, arn string
+		steps     []StepAdj
+	}{
+		{r.scaleOutName, "arn:out", r.scaleOutSteps()},
+		{r.scaleInName, "arn:in", r.scaleInSteps()},
+	} {
+		in := defaultStepPolicyInput(r.resource, p.name, p.steps, 300, string(aasTypes.MetricAggregationTypeMaximum))
+		existing = append(existing, aasTypes.ScalingPolicy{
+			PolicyName:                     in.PolicyName,
+			PolicyARN:                      aws.String(p.arn),
+			PolicyType:                     in.PolicyType,
+			StepScalingPolicyConfiguration: in.StepScalingPolicyConfiguration,
+		})
+	}
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{ScalingPolicies: existing},
+	}
+	r.cfg.DefaultAggregationType = string(aasTypes.MetricAggregationTypeAverage)
+
+	desired := defaultStepPolicyInput(r.resource, r.scaleOutName, r.scaleOutSteps(), 300, r.cfg.DefaultAggregationType)
+	fields, exists, err := scalingPolicyChanges(context.Background(), mockAAS, r.resource, r.scaleOutName, desired)
+	if err != nil || !exists || !slices.Equal(fields, []string{"StepScalingPolicyConfiguration.MetricAggregationType"}) {
+		t.Errorf("scalingPolicyChanges() = %v, %v, %v, want only MetricAggregationType changed", fields, exists, err)
+	}
+
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	if err := Run(context.Background(), r.cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(mockAAS.putScalingPolicyCalls) != 2 {
+		t.Fatalf("Run() put %d scaling policies, want both default policies updated", len(mockAAS.putScalingPolicyCalls))
+	}
+	for _, in := range mockAAS.putScalingPolicyCalls {
+		if got := in.StepScalingPolicyConfiguration.MetricAggregationType; got != aasTypes.MetricAggregationTypeAverage {
+			t.Errorf("%s MetricAggregationType = %s, want Average", aws.ToString(in.PolicyName), got)
+		}
+	}
+
+	cfg, err := parseArgs(append(testPositionalArgs(), "--default-aggregation-type=Minimum"))
+	if err != nil || cfg.DefaultAggregationType != "Minimum" {
+		t.Errorf("parseArgs() default aggregation type = %v, want Minimum", err)
+	}
+	// An empty value, as action.yml passes for an unset input, keeps the default
+	cfg, err = parseArgs(append(testPositionalArgs(), "--default-aggregation-type="))
+	if err != nil || cfg.DefaultAggregationType != "Maximum" {
+		t.Errorf("parseArgs() empty default aggregation type = %v, %v, want Maximum", cfg, err)
+	}
+	for _, tt := range []struct{ value, wantErr string }{
+		{"average", "did you mean Average?"},
+		{"Sum", "must be one of Average, Minimum, Maximum"},
+		{" ", `invalid default-aggregation-type " "`},
+	} {
+		if _, err := parseArgs(append(testPositionalArgs(), "--default-aggregation-type="+tt.value)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseArgs(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+// TestRunDefaultCooldownChange tests that changing --scale-out-cooldown updates the default scale-out policy and,
+// with --reconcile-alarms, the period of the alarms that trigger it, leaving the scale-in side alone
+func TestRunDefaultCooldownChange(t *testing.T) {
+	ctx := context.Background()
+	newConfig := func(scaleOutCooldown int32) *Config {
+		return &Config{
+			Cluster:          "test-cluster",
+			Service:          "test-service",
+			Enabled:          true,
+			MinCapacity:      1,
+			MaxCapacity:      10,
+			ScaleOutCooldown: scaleOutCooldown,
+			ScaleInCooldown:  300,
+			TargetCPUOut:     75,
+			TargetCPUIn:      65,
+			TargetMemOut:     80,
+			TargetMemIn:      70,
+			AlarmsEnabled:    true,
+			ReconcileAlarms:  true,
+		}
+	}
+
+	// AWS holds what a run with the old 300s cooldown applied
+	old, err := newRunner(newConfig(300), Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard)
+	if err != nil {
+		t.Fatalf("newRunner() unexpected error: %v", err)
+	}
+	var policies []aasTypes.ScalingPolicy
+	for _, p := range []struct {
+		name, arn string
+		steps     []StepAdj
+		cooldown  int32
+	}{
+		{old.scaleOutName, "arn:out", old.scaleOutSteps(), 300},
+		{old.scaleInName, "arn:in", old.scaleInSteps(), 300},
+	} {
+		in := defaultStepPolicyInput(old.resource, p.name, p.steps, p.cooldown, "")
+		policies = append(policies, aasTypes.ScalingPolicy{
+			PolicyName:                     in.PolicyName,
+			PolicyARN:                      aws.String(p.arn),
+			PolicyType:                     in.PolicyType,
+			StepScalingPolicyConfiguration: in.StepScalingPolicyConfiguration,
+		})
+	}
+	oldAlarms, err := old.defaultAlarmInputs("arn:out", "arn:in")
+	if err != nil {
+		t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+	}
+	var alarms []cwTypes.MetricAlarm
+	for _, in := range oldAlarms {
+		alarms = append(alarms, cwTypes.MetricAlarm{
+			AlarmName:          in.AlarmName,
+			AlarmDescription:   in.AlarmDescription,
+			Namespace:          in.Namespace,
+			MetricName:         in.MetricName,
+			Statistic:          in.Statistic,
+			Period:             in.Period,
+			EvaluationPeriods:  in.EvaluationPeriods,
+			Threshold:          in.Threshold,
+			ComparisonOperator: in.ComparisonOperator,
+			Dimensions:         in.Dimensions,
+			AlarmActions:       in.AlarmActions,
+			ActionsEnabled:     in.ActionsEnabled,
+		})
+	}
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{ScalingPolicies: policies},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{MetricAlarms: alarms}}
+	if err := Run(ctx, newConfig(120), Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	if len(mockAAS.putScalingPolicyCalls) != 1 {
+		t.Fatalf("PutScalingPolicy called %d times, want 1 (the scale-out policy)", len(mockAAS.putScalingPolicyCalls))
+	}
+	if in := mockAAS.putScalingPolicyCalls[0]; aws.ToString(in.PolicyName) != old.scaleOutName || aws.ToInt32(in.StepScalingPolicyConfiguration.Cooldown) != 120 {
+		t.Errorf("put %s with cooldown %d, want %s with 120", aws.ToString(in.PolicyName), aws.ToInt32(in.StepScalingPolicyConfiguration.Cooldown), old.scaleOutName)
+	}
+	periods := map[string]int32{}
+	for _, in := range mockCW.putMetricAlarmCalls {
+		periods[aws.ToString(in.AlarmName)] = aws.ToInt32(in.Period)
+	}
+	if want := map[string]int32{"test-cluster-test-service-cpu-high": 120, "test-cluster-test-service-mem-high": 120}; !reflect.DeepEqual(periods, want) {
+		t.Errorf("updated alarm periods %v, want %v", periods, want)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	aas "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	cw "github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+)
+
+// runMetrics counts what a single run did, for --metrics-file
+type runMetrics struct {
+	policies map[string]int // by action: created, updated, deleted
+	alarms   map[string]int // by action: created, updated, deleted
+	apiCalls map[string]int // by API operation
+	changes  int            // successful mutating calls, counting each deleted alarm
+}
+
+func newRunMetrics() *runMetrics {
+	return &runMetrics{policies: map[string]int{}, alarms: map[string]int{}, apiCalls: map[string]int{}}
+}
+
+func (m *runMetrics) policy(action string, n int) {
+	m.policies[action] += n
+}
+
+func (m *runMetrics) alarm(action string, n int) {
+	m.alarms[action] += n
+}
+
+func (m *runMetrics) call(operation string) {
+	m.apiCalls[operation]++
+}
+
+// Count a successful mutating call towards the run's changes
+func (m *runMetrics) mutated(err error, n int) {
+	if err == nil {
+		m.changes += n
+	}
+}
+
+// Metrics action for a put: updated if the resource already existed, created otherwise
+func changeAction(existed bool) string {
+	if existed {
+		return "updated"
+	}
+	return "created"
+}
+
+// Write the metrics in the Prometheus text exposition format read by node_exporter's textfile collector
+func (m *runMetrics) write(w io.Writer, cfg *Config, res resourceRef, duration time.Duration, runErr error) error {
+	labels := fmt.Sprintf(`cluster="%s",service="%s",resource="%s"`,
+		escapeLabelValue(cfg.Cluster), escapeLabelValue(cfg.Service), escapeLabelValue(res.ID))
+	success := 1
+	if runErr != nil {
+		success = 0
+	}
+
+	var b strings.Builder
+	header := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	byLabel := func(name, label string, counts map[string]int, keys []string) {
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s{%s,%s=\"%s\"} %d\n", name, labels, label, escapeLabelValue(key), counts[key])
+		}
+	}
+	actions := []string{"created", "updated", "deleted"}
+
+	header("ecs_autoscaler_scaling_policies_changed", "Scaling policies changed by the last run, by action.")
+	byLabel("ecs_autoscaler_scaling_policies_changed", "action", m.policies, actions)
+	header("ecs_autoscaler_alarms_changed", "CloudWatch alarms changed by the last run, by action.")
+	byLabel("ecs_autoscaler_alarms_changed", "action", m.alarms, actions)
+	header("ecs_autoscaler_api_calls", "AWS API calls made by the last run, by operation.")
+	operations := make([]string, 0, len(m.apiCalls))
+	for op := range m.apiCalls {
+		operations = append(operations, op)
+	}
+	slices.Sort(operations)
+	byLabel("ecs_autoscaler_api_calls", "operation", m.apiCalls, operations)
+	header("ecs_autoscaler_run_duration_seconds", "Duration of the last run.")
+	fmt.Fprintf(&b, "ecs_autoscaler_run_duration_seconds{%s} %g\n", labels, duration.Seconds())
+	header("ecs_autoscaler_run_success", "Whether the last run succeeded (1) or failed (0).")
+	fmt.Fprintf(&b, "ecs_autoscaler_run_success{%s} %d\n", labels, success)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Write the metrics file atomically, so the textfile collector never reads a half-written file
+func (m *runMetrics) writeFile(path string, cfg *Config, res resourceRef, duration time.Duration, runErr error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := m.write(tmp, cfg, res, duration, runErr); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
+
+// Escape a Prometheus label value
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// countingAASClient records every Application Auto Scaling call, successful deletion and change
+type countingAASClient struct {
+	AASClient
+	metrics *runMetrics
+}
+
+func (c countingAASClient) DescribeScalableTargets(ctx context.Context, params *aas.DescribeScalableTargetsInput, optFns ...func(*aas.Options)) (*aas.DescribeScalableTargetsOutput, error) {
+	c.metrics.call("DescribeScalableTargets")
+	return c.AASClient.DescribeScalableTargets(ctx, params, optFns...)
+}
+
+func (c countingAASClient) DescribeScalingPolicies(ctx context.Context, params *aas.DescribeScalingPoliciesInput, optFns ...func(*aas.Options)) (*aas.DescribeScalingPoliciesOutput, error) {
+	c.metrics.call("DescribeScalingPolicies")
+	return c.AASClient.DescribeScalingPolicies(ctx, params, optFns...)
+}
+
+func (c countingAASClient) RegisterScalableTarget(ctx context.Context, params *aas.RegisterScalableTargetInput, optFns ...func(*aas.Options)) (*aas.RegisterScalableTargetOutput, error) {
+	c.metrics.call("RegisterScalableTarget")
+	out, err := c.AASClient.RegisterScalableTarget(ctx, params, optFns...)
+	c.metrics.mutated(err, 1)
+	return out, err
+}
+
+func (c countingAASClient) PutScalingPolicy(ctx context.Context, params *aas.PutScalingPolicyInput, optFns ...func(*aas.Options)) (*aas.PutScalingPolicyOutput, error) {
+	c.metrics.call("PutScalingPolicy")
+	out, err := c.AASClient.PutScalingPolicy(ctx, params, optFns...)
+	c.metrics.mutated(err, 1)
+	return out, err
+}
+
+func (c countingAASClient) DeleteScalingPolicy(ctx context.Context, params *aas.DeleteScalingPolicyInput, optFns ...func(*aas.Options)) (*aas.DeleteScalingPolicyOutput, error) {
+	c.metrics.call("DeleteScalingPolicy")
+	out, err := c.AASClient.DeleteScalingPolicy(ctx, params, optFns...)
+	if err == nil {
+		c.metrics.policy("deleted", 1)
+	}
+	c.metrics.mutated(err, 1)
+	return out, err
+}
+
+func (c countingAASClient) DeregisterScalableTarget(ctx context.Context, params *aas.DeregisterScalableTargetInput, optFns ...func(*aas.Options)) (*aas.DeregisterScalableTargetOutput, error) {
+	c.metrics.call("DeregisterScalableTarget")
+	out, err := c.AASClient.DeregisterScalableTarget(ctx, params, optFns...)
+	c.metrics.mutated(err, 1)
+	return out, err
+}
+
+func (c countingAASClient) DescribeScalingActivities(ctx context.Context, params *aas.DescribeScalingActivitiesInput, optFns ...func(*aas.Options)) (*aas.DescribeScalingActivitiesOutput, error) {
+	c.metrics.call("DescribeScalingActivities")
+	return c.AASClient.DescribeScalingActivities(ctx, params, optFns...)
+}
+
+func (c countingAASClient) TagResource(ctx context.Context, params *aas.TagResourceInput, optFns ...func(*aas.Options)) (*aas.TagResourceOutput, error) {
+	c.metrics.call("TagResource")
+	out, err := c.AASClient.TagResource(ctx, params, optFns...)
+	c.metrics.mutated(err, 1)
+	return out, err
+}
+
+func (c countingAASClient) ListTagsForResource(ctx context.Context, params *aas.ListTagsForResourceInput, optFns ...func(*aas.Options)) (*aas.ListTagsForResourceOutput, error) {
+	c.metrics.call("ListTagsForResource")
+	return c.AASClient.ListTagsForResource(ctx, params, optFns...)
+}
+
+// countingCWClient records every CloudWatch call, successful deletion and change
+type countingCWClient struct {
+	CWClient
+	metrics *runMetrics
+}
+
+func (c countingCWClient) DescribeAlarms(ctx context.Context, params *cw.DescribeAlarmsInput, optFns ...func(*cw.Options)) (*cw.DescribeAlarmsOutput, error) {
+	c.metrics.call("DescribeAlarms")
+	return c.CWClient.DescribeAlarms(ctx, params, optFns...)
+}
+
+func (c countingCWClient) DeleteAlarms(ctx context.Context, params *cw.DeleteAlarmsInput, optFns ...func(*cw.Options)) (*cw.DeleteAlarmsOutput, error) {
+	c.metrics.call("DeleteAlarms")
+	out, err := c.CWClient.DeleteAlarms(ctx, params, optFns...)
+	if err == nil {
+		c.metrics.alarm("deleted", len(params.AlarmNames))
+	}
+	c.metrics.mutated(err, len(params.AlarmNames))
+	return out, err
+}
+
+func (c countingCWClient) PutMetricAlarm(ctx context.Context, params *cw.PutMetricAlarmInput, optFns ...func(*cw.Options)) (*cw.PutMetricAlarmOutput, error) {
+	c.metrics.call("PutMetricAlarm")
+	out, err := c.CWClient.PutMetricAlarm(ctx, params, optFns...)
+	c.metrics.mutated(err, 1)
+	return out, err
+}
+
+// countingECSClient records every ECS call
+type countingECSClient struct {
+	ECSClient
+	metrics *runMetrics
+}
+
+func (c countingECSClient) ListServices(ctx context.Context, params *ecs.ListServicesInput, optFns ...func(*ecs.Options)) (*ecs.ListServicesOutput, error) {
+	c.metrics.call("ListServices")
+	return c.ECSClient.ListServices(ctx, params, optFns...)
+}
+
+func (c countingECSClient) DescribeServices(ctx context.Context, params *ecs.DescribeServicesInput, optFns ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error) {
+	c.metrics.call("DescribeServices")
+	return c.ECSClient.DescribeServices(ctx, params, optFns...)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// promLine matches a comment or a sample line of the Prometheus text format
+var promLine = regexp.MustCompile(`^(# (HELP|TYPE) [a-z_]+ .+|[a-z_]+\{([a-z_]+="(\\.|[^"\\])*",?)*\} [0-9.e+-]+)$`)
+
+// TestRunMetricsFile tests that a run writes a parseable textfile-collector file with the expected metrics
+func TestRunMetricsFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		registerErr error
+		want        []string
+	}{
+		{"apply", nil, []string{
+			`ecs_autoscaler_scaling_policies_changed{cluster="test-cluster",service="test-service",resource="service/test-cluster/test-service",action="updated"} 2`,
+			`ecs_autoscaler_alarms_changed{cluster="test-cluster",service="test-service",resource="service/test-cluster/test-service",action="created"} 4`,
+			`ecs_autoscaler_api_calls{cluster="test-cluster",service="test-service",resource="service/test-cluster/test-service",operation="PutScalingPolicy"} 2`,
+			`ecs_autoscaler_api_calls{cluster="test-cluster",service="test-service",resource="service/test-cluster/test-service",operation="RegisterScalableTarget"} 1`,
+			`ecs_autoscaler_run_success{cluster="test-cluster",service="test-service",resource="service/test-cluster/test-service"} 1`,
+		}},
+		{"failed run", errors.New("access denied"), []string{
+			`ecs_autoscaler_scaling_policies_changed{cluster="test-cluster",service="test-service",resource="service/test-cluster/test-service",action="updated"} 0`,
+			`ecs_autoscaler_run_success{cluster="test-cluster",service="test-service",resource="service/test-cluster/test-service"} 0`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+					ScalingPolicies: []aasTypes.ScalingPolicy{
+						{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+						{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:in")},
+					},
+				},
+				registerScalableTargetError: tt.registerErr,
+			}
+			mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+			r := newTestRunner(t, true, nil, mockAAS, mockCW)
+			r.cfg.MetricsFile = filepath.Join(t.TempDir(), "ecs-autoscaler.prom")
+
+			err := Run(context.Background(), r.cfg, Clients{AAS: mockAAS, CW: mockCW}, nil)
+			if (err != nil) != (tt.registerErr != nil) {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.registerErr != nil)
+			}
+
+			data, err := os.ReadFile(r.cfg.MetricsFile)
+			if err != nil {
+				t.Fatalf("metrics file not written: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			for _, line := range lines {
+				if !promLine.MatchString(line) {
+					t.Errorf("metrics line is not in Prometheus text format: %q", line)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want+"\n") {
+					t.Errorf("metrics file missing %q:\n%s", want, data)
+				}
+			}
+			if !strings.Contains(string(data), "ecs_autoscaler_run_duration_seconds{") {
+				t.Errorf("metrics file missing run duration:\n%s", data)
+			}
+		})
+	}
+}
+
+// TestRunMetricsChanges tests that only successful mutating calls count as changes, one per deleted alarm
+func TestRunMetricsChanges(t *testing.T) {
+	ctx := context.Background()
+	m := newRunMetrics()
+	aasClient := countingAASClient{AASClient: &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		registerScalableTargetError:   errors.New("throttled"),
+	}, metrics: m}
+	cwClient := countingCWClient{CWClient: &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}, metrics: m}
+
+	aasClient.DescribeScalableTargets(ctx, &applicationautoscaling.DescribeScalableTargetsInput{})
+	aasClient.RegisterScalableTarget(ctx, &applicationautoscaling.RegisterScalableTargetInput{})
+	aasClient.PutScalingPolicy(ctx, &applicationautoscaling.PutScalingPolicyInput{})
+	cwClient.DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{})
+	cwClient.DeleteAlarms(ctx, &cloudwatch.DeleteAlarmsInput{AlarmNames: []string{"a", "b", "c"}})
+	if m.changes != 4 {
+		t.Errorf("changes = %d, want 4 (one policy put and three alarms deleted)", m.changes)
+	}
+}
+
+// TestEscapeLabelValue tests escaping of Prometheus label values
+func TestEscapeLabelValue(t *testing.T) {
+	if got, want := escapeLabelValue("a\"b\\c\nd"), `a\"b\\c\nd`; got != want {
+		t.Errorf("escapeLabelValue() = %q, want %q", got, want)
+	}
+}
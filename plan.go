@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aas "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	cw "github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// errDrift is returned by --verify when AWS does not match the desired configuration
+var errDrift = errors.New("drift detected")
+
+// planAction is what an apply would do to a single resource
+type planAction string
+
+const (
+	planCreate   planAction = "create"
+	planUpdate   planAction = "update"
+	planDelete   planAction = "delete"
+	planNoChange planAction = "no-change"
+)
+
+// planItem is one resource in a plan along with the fields that would change
+type planItem struct {
+	Kind   string // "scalable-target", "scaling-policy" or "alarm"
+	Name   string
+	Action planAction
+	Diffs  []fieldDiff
+}
+
+// Compute what an apply (or cleanup when disabled) would change, without mutating anything
+func (r *runner) buildPlan(ctx context.Context) ([]planItem, error) {
+	target, err := describeScalableTarget(ctx, r.aas, r.resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scalable target: %w", err)
+	}
+
+	if !r.cfg.Enabled {
+		return r.buildCleanupPlan(ctx, target != nil)
+	}
+
+	items, err := r.buildEnablePlan(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if r.cfg.PurgeUnmanaged && target != nil {
+		stale, err := r.stalePolicies(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range stale {
+			items = append(items, planItem{Kind: "scaling-policy", Name: name, Action: planDelete})
+		}
+	}
+	return items, nil
+}
+
+// Plan registering the target and applying the desired policies and alarms against the existing target, if any
+func (r *runner) buildEnablePlan(ctx context.Context, target *aasTypes.ScalableTarget) ([]planItem, error) {
+	var items []planItem
+
+	// Scalable target capacities
+	targetItem := planItem{Kind: "scalable-target", Name: r.resource.ID, Action: planNoChange}
+	if target == nil {
+		targetItem.Action = planCreate
+	} else {
+		if existing := *target.MinCapacity; existing != r.cfg.MinCapacity {
+			targetItem.Diffs = append(targetItem.Diffs, fieldDiff{Field: "MinCapacity", Existing: fmt.Sprint(existing), Desired: fmt.Sprint(r.cfg.MinCapacity)})
+		}
+		if existing := *target.MaxCapacity; existing != r.cfg.MaxCapacity {
+			targetItem.Diffs = append(targetItem.Diffs, fieldDiff{Field: "MaxCapacity", Existing: fmt.Sprint(existing), Desired: fmt.Sprint(r.cfg.MaxCapacity)})
+		}
+		targetItem.Diffs = append(targetItem.Diffs, compareSuspendedState(target.SuspendedState, r.suspendedState())...)
+		if len(r.cfg.Tags) > 0 {
+			current, _, err := r.targetTags(ctx, aws.ToString(target.ScalableTargetARN))
+			if err != nil {
+				return nil, err
+			}
+			missing := tagDrift(current, r.cfg.Tags)
+			for _, key := range slices.Sorted(maps.Keys(missing)) {
+				targetItem.Diffs = append(targetItem.Diffs, fieldDiff{Field: "Tags." + key, Existing: current[key], Desired: missing[key]})
+			}
+		}
+		if len(targetItem.Diffs) > 0 {
+			targetItem.Action = planUpdate
+		}
+	}
+	items = append(items, targetItem)
+	if r.cfg.TargetOnly {
+		return items, nil
+	}
+
+	// Custom policies, with alarms for step policies that carry metric info and have none attached yet
+	if len(r.policies) > 0 {
+		for _, p := range r.policies {
+			policyInput, err := buildPolicyInput(p, r.resource)
+			if err != nil {
+				return nil, err
+			}
+			policyItem, err := planPolicy(ctx, r.aas, r.resource, p.PolicyName, policyInput)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, policyItem)
+
+			if p.PolicyType == "StepScaling" && p.MetricName != "" && p.MetricNamespace != "" && r.managesAlarm(p) {
+				policyARN, err := r.policyARN(ctx, p.PolicyName)
+				if err != nil {
+					return nil, err
+				}
+				alarmInput, err := r.customAlarmInput(p, policyARN)
+				if err != nil {
+					return nil, err
+				}
+				if policyARN != "" && !r.cfg.ReconcileAlarms {
+					// Any alarm already driving an existing policy is left untouched
+					attached, err := alarmExistsForPolicy(ctx, r.cw, policyARN)
+					if err != nil {
+						return nil, err
+					}
+					if attached {
+						items = append(items, planItem{Kind: "alarm", Name: aws.ToString(alarmInput.AlarmName), Action: planNoChange})
+						continue
+					}
+				}
+				alarmItem, err := r.planAlarm(ctx, alarmInput)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, alarmItem)
+			}
+		}
+		return items, nil
+	}
+
+	// Default CPU/memory step policies and alarms
+	for _, info := range []struct {
+		name  string
+		steps []StepAdj
+		cd    int32
+	}{
+		{r.scaleOutName, r.scaleOutSteps(), r.cfg.ScaleOutCooldown},
+		{r.scaleInName, r.scaleInSteps(), r.cfg.ScaleInCooldown},
+	} {
+		policyItem, err := planPolicy(ctx, r.aas, r.resource, info.name, defaultStepPolicyInput(r.resource, info.name, info.steps, info.cd, r.cfg.DefaultAggregationType))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, policyItem)
+	}
+	if r.cfg.NoAlarms {
+		return items, nil
+	}
+	scaleOutARN, err := r.policyARN(ctx, r.scaleOutName)
+	if err != nil {
+		return nil, err
+	}
+	scaleInARN, err := r.policyARN(ctx, r.scaleInName)
+	if err != nil {
+		return nil, err
+	}
+	alarms, err := r.defaultAlarmInputs(scaleOutARN, scaleInARN)
+	if err != nil {
+		return nil, err
+	}
+	for _, alarmInput := range alarms {
+		alarmItem, err := r.planAlarm(ctx, alarmInput)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, alarmItem)
+	}
+	return items, nil
+}
+
+// Plan the deletions the disable path would perform
+func (r *runner) buildCleanupPlan(ctx context.Context, targetExists bool) ([]planItem, error) {
+	if !targetExists {
+		return nil, nil
+	}
+
+	var items []planItem
+	alarmNames, err := r.cleanupAlarmNames()
+	if err != nil {
+		return nil, err
+	}
+	existingAlarms, err := existingAlarmNames(ctx, r.cw, alarmNames)
+	if err != nil {
+		return nil, err
+	}
+	if r.managesAllAlarms() {
+		orphaned, err := alarmsForResourcePolicies(ctx, r.cw, r.resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list alarms for scaling policies: %w", err)
+		}
+		existingAlarms = append(existingAlarms, orphaned...)
+	}
+	if r.cfg.PrefixCleanup {
+		prefixed, err := r.prefixAlarms(ctx)
+		if err != nil {
+			return nil, err
+		}
+		existingAlarms = append(existingAlarms, prefixed...)
+	}
+	for _, alarmName := range deduplicate(existingAlarms) {
+		items = append(items, planItem{Kind: "alarm", Name: alarmName, Action: planDelete})
+	}
+	for _, name := range cleanupPolicyNames(r.scaleOutName, r.scaleInName, r.policies) {
+		exists, err := checkScalingPolicy(ctx, r.aas, r.resource, name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			items = append(items, planItem{Kind: "scaling-policy", Name: name, Action: planDelete})
+		}
+	}
+	if r.cfg.KeepTarget {
+		items = append(items, planItem{Kind: "scalable-target", Name: r.resource.ID, Action: planNoChange})
+	} else {
+		items = append(items, planItem{Kind: "scalable-target", Name: r.resource.ID, Action: planDelete})
+	}
+	return items, nil
+}
+
+// Plan a single scaling policy against its existing configuration
+func planPolicy(ctx context.Context, client AASClient, res resourceRef, policyName string, desired *aas.PutScalingPolicyInput) (planItem, error) {
+	item := planItem{Kind: "scaling-policy", Name: policyName, Action: planNoChange}
+	existing, err := findScalingPolicy(ctx, client, res, policyName)
+	if err != nil {
+		return item, fmt.Errorf("failed to describe scaling policy: %w", err)
+	}
+	if existing == nil {
+		item.Action = planCreate
+		return item, nil
+	}
+	if item.Diffs = diffScalingPolicy(existing, desired); len(item.Diffs) > 0 {
+		item.Action = planUpdate
+	}
+	return item, nil
+}
+
+// ARN of an existing scaling policy, or "" if it has not been created yet
+func (r *runner) policyARN(ctx context.Context, policyName string) (string, error) {
+	policy, err := findScalingPolicy(ctx, r.aas, r.resource, policyName)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe scaling policy: %w", err)
+	}
+	if policy == nil {
+		return "", nil
+	}
+	return aws.ToString(policy.PolicyARN), nil
+}
+
+// Plan a single alarm; existing alarms are only updated with --reconcile-alarms
+func (r *runner) planAlarm(ctx context.Context, desired *cw.PutMetricAlarmInput) (planItem, error) {
+	item := planItem{Kind: "alarm", Name: aws.ToString(desired.AlarmName), Action: planNoChange}
+	existing, err := describeAlarm(ctx, r.cw, item.Name)
+	if err != nil {
+		return item, err
+	}
+	if existing == nil {
+		item.Action = planCreate
+		return item, nil
+	}
+	if !r.cfg.ReconcileAlarms {
+		return item, nil
+	}
+	if item.Diffs = compareAlarm(existing, desired); len(item.Diffs) > 0 {
+		item.Action = planUpdate
+	}
+	return item, nil
+}
+
+// Print the plan in a human-readable form, followed by a summary line
+func printPlan(w io.Writer, items []planItem) {
+	counts := map[planAction]int{}
+	for _, item := range items {
+		counts[item.Action]++
+		fmt.Fprintf(w, "%-9s %-15s %s\n", item.Action, item.Kind, item.Name)
+		for _, d := range item.Diffs {
+			fmt.Fprintf(w, "          ~ %s\n", d)
+		}
+	}
+	fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+		counts[planCreate], counts[planUpdate], counts[planDelete], counts[planNoChange])
+}
+
+// Compare AWS against the desired configuration, print only what drifted, and return errDrift if anything did
+func (r *runner) verify(ctx context.Context) error {
+	items, err := r.buildPlan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to build plan: %w", err)
+	}
+
+	var drifted []planItem
+	for _, item := range items {
+		if item.Action != planNoChange {
+			drifted = append(drifted, item)
+		}
+	}
+	if len(drifted) == 0 {
+		fmt.Fprintln(r.out, "No drift detected.")
+		return nil
+	}
+	printPlan(r.out, drifted)
+	return fmt.Errorf("%w: %d resources differ from the desired configuration", errDrift, len(drifted))
+}
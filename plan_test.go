@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// newTestRunner returns a runner for test-cluster/test-service with default naming
+func newTestRunner(t *testing.T, enabled bool, policies []PolicyDef, aasClient AASClient, cwClient CWClient) *runner {
+	t.Helper()
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          enabled,
+		MinCapacity:      2,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		TargetCPUOut:     75,
+		TargetCPUIn:      65,
+		TargetMemOut:     80,
+		TargetMemIn:      70,
+		TagAlarms:        true,
+		AlarmsEnabled:    true,
+	}
+	r, err := newRunner(cfg, Clients{AAS: aasClient, CW: cwClient}, io.Discard)
+	if err != nil {
+		t.Fatalf("newRunner() unexpected error: %v", err)
+	}
+	r.policies = policies
+	return r
+}
+
+// TestBuildPlanCustomPolicies tests planning of target capacity changes, policy updates and new policies with alarms
+func TestBuildPlanCustomPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{
+				{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)},
+			},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{
+				{
+					PolicyName: aws.String("cpu-target"),
+					PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+					TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+						TargetValue: aws.Float64(60),
+						PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+							PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+						},
+					},
+				},
+			},
+		},
+	}
+	mockCW := &mockCWClient{
+		describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{},
+	}
+
+	policies := []PolicyDef{
+		{
+			PolicyName: "cpu-target",
+			PolicyType: "TargetTrackingScaling",
+			TargetTrackingConfiguration: &TargetTrackingConfig{
+				TargetValue:                   75,
+				PredefinedMetricSpecification: "ECSServiceAverageCPUUtilization",
+			},
+		},
+		{
+			PolicyName:            "custom-step",
+			PolicyType:            "StepScaling",
+			MetricName:            "CPUUtilization",
+			MetricNamespace:       "AWS/ECS",
+			AdjustmentType:        "ChangeInCapacity",
+			Cooldown:              aws.Int32(300),
+			MetricAggregationType: "Maximum",
+			StepAdjustments:       []StepAdj{{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 1}},
+		},
+	}
+
+	items, err := newTestRunner(t, true, policies, mockAAS, mockCW).buildPlan(ctx)
+	if err != nil {
+		t.Fatalf("buildPlan() unexpected error: %v", err)
+	}
+
+	want := []struct {
+		kind   string
+		name   string
+		action planAction
+		diffs  int
+	}{
+		{"scalable-target", "service/test-cluster/test-service", planUpdate, 1},
+		{"scaling-policy", "cpu-target", planUpdate, 1},
+		{"scaling-policy", "custom-step", planCreate, 0},
+		{"alarm", "test-cluster-test-service-custom-step", planCreate, 0},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("buildPlan() returned %d items, want %d: %+v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		got := items[i]
+		if got.Kind != w.kind || got.Name != w.name || got.Action != w.action || len(got.Diffs) != w.diffs {
+			t.Errorf("item %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if items[0].Diffs[0].Field != "MinCapacity" {
+		t.Errorf("target diff field = %q, want MinCapacity", items[0].Diffs[0].Field)
+	}
+
+	// A plan must never mutate anything
+	if len(mockAAS.registerScalableTargetCalls) != 0 || len(mockAAS.putScalingPolicyCalls) != 0 || len(mockCW.putMetricAlarmCalls) != 0 {
+		t.Error("buildPlan() made mutating API calls")
+	}
+
+	var buf bytes.Buffer
+	printPlan(&buf, items)
+	output := buf.String()
+	for _, expected := range []string{
+		"~ MinCapacity: 1 -> 2",
+		"~ TargetTrackingScalingPolicyConfiguration.TargetValue: 60 -> 75",
+		"Plan: 2 to create, 2 to update, 0 to delete, 0 unchanged.",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("printPlan() output missing %q:\n%s", expected, output)
+		}
+	}
+}
+
+// TestBuildPlanDefaultPolicies tests planning of the default step policies and alarms
+func TestBuildPlanDefaultPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	mockCW := &mockCWClient{
+		describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{},
+	}
+
+	items, err := newTestRunner(t, true, nil, mockAAS, mockCW).buildPlan(ctx)
+	if err != nil {
+		t.Fatalf("buildPlan() unexpected error: %v", err)
+	}
+	if len(items) != 7 {
+		t.Fatalf("buildPlan() returned %d items, want 7", len(items))
+	}
+	for _, item := range items {
+		if item.Action != planCreate {
+			t.Errorf("item %s %s action = %s, want create", item.Kind, item.Name, item.Action)
+		}
+	}
+}
+
+// TestBuildPlanCleanup tests planning of the disable path
+func TestBuildPlanCleanup(t *testing.T) {
+	ctx := context.Background()
+
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{
+				{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)},
+			},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{
+				{PolicyName: aws.String("test-cluster-test-service-scale-out")},
+			},
+		},
+	}
+	mockCW := &mockCWClient{
+		describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+			MetricAlarms: []cwTypes.MetricAlarm{{AlarmName: aws.String("test-cluster-test-service-cpu-high")}},
+		},
+	}
+
+	items, err := newTestRunner(t, false, nil, mockAAS, mockCW).buildPlan(ctx)
+	if err != nil {
+		t.Fatalf("buildPlan() unexpected error: %v", err)
+	}
+
+	deletes := map[string]bool{}
+	for _, item := range items {
+		if item.Action != planDelete {
+			t.Errorf("item %s %s action = %s, want delete", item.Kind, item.Name, item.Action)
+		}
+		deletes[item.Name] = true
+	}
+	for _, name := range []string{"test-cluster-test-service-scale-out", "service/test-cluster/test-service"} {
+		if !deletes[name] {
+			t.Errorf("plan does not delete %s", name)
+		}
+	}
+	if deletes["test-cluster-test-service-scale-in"] {
+		t.Error("plan deletes a scaling policy that does not exist")
+	}
+	if len(mockAAS.deleteScalingPolicyCalls) != 0 || len(mockAAS.deregisterScalableTargetCalls) != 0 || len(mockCW.deleteAlarmsCalls) != 0 {
+		t.Error("buildPlan() made mutating API calls")
+	}
+
+	// Nothing to delete when auto-scaling was never enabled
+	mockAAS.describeScalableTargetsOutput = &applicationautoscaling.DescribeScalableTargetsOutput{}
+	items, err = newTestRunner(t, false, nil, mockAAS, mockCW).buildPlan(ctx)
+	if err != nil {
+		t.Fatalf("buildPlan() unexpected error: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("buildPlan() returned %d items for an unconfigured service, want 0", len(items))
+	}
+}
+
+// TestVerify tests that verify reports drift as errDrift and succeeds when everything matches
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+
+	policies := []PolicyDef{{
+		PolicyName: "cpu-target",
+		PolicyType: "TargetTrackingScaling",
+		TargetTrackingConfiguration: &TargetTrackingConfig{
+			TargetValue:                   75,
+			PredefinedMetricSpecification: "ECSServiceAverageCPUUtilization",
+		},
+	}}
+	existingPolicy := func(target float64) *applicationautoscaling.DescribeScalingPoliciesOutput {
+		return &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{{
+				PolicyName: aws.String("cpu-target"),
+				PolicyType: aasTypes.PolicyTypeTargetTrackingScaling,
+				TargetTrackingScalingPolicyConfiguration: &aasTypes.TargetTrackingScalingPolicyConfiguration{
+					TargetValue: aws.Float64(target),
+					PredefinedMetricSpecification: &aasTypes.PredefinedMetricSpecification{
+						PredefinedMetricType: aasTypes.MetricTypeECSServiceAverageCPUUtilization,
+					},
+				},
+			}},
+		}
+	}
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: existingPolicy(75),
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	var buf bytes.Buffer
+	r := newTestRunner(t, true, policies, mockAAS, mockCW)
+	r.out = &buf
+	if err := r.verify(ctx); err != nil {
+		t.Fatalf("verify() unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No drift detected.") {
+		t.Errorf("verify() output = %q, want no drift", buf.String())
+	}
+
+	buf.Reset()
+	mockAAS.describeScalingPoliciesOutput = existingPolicy(60)
+	err := r.verify(ctx)
+	if !errors.Is(err, errDrift) {
+		t.Fatalf("verify() error = %v, want errDrift", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "~ TargetTrackingScalingPolicyConfiguration.TargetValue: 60 -> 75") {
+		t.Errorf("verify() output missing drift report:\n%s", output)
+	}
+	if strings.Contains(output, "scalable-target") {
+		t.Errorf("verify() output lists unchanged resources:\n%s", output)
+	}
+	if len(mockAAS.putScalingPolicyCalls) != 0 || len(mockAAS.registerScalableTargetCalls) != 0 {
+		t.Error("verify() made mutating API calls")
+	}
+}
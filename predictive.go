@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// Longest scheduling_buffer_time AWS accepts, in seconds
+const maxSchedulingBufferTime = 3600
+
+// PredictiveScalingConfig is a PredictiveScaling policy's predictive_scaling_configuration. AWS defaults mode to
+// ForecastOnly and max_capacity_breach_behavior to HonorMaxCapacity.
+type PredictiveScalingConfig struct {
+	MetricSpecifications      []PredictiveMetricSpec `json:"metric_specifications"`
+	Mode                      string                 `json:"mode,omitempty"`                         // ForecastOnly or ForecastAndScale
+	SchedulingBufferTime      *int32                 `json:"scheduling_buffer_time,omitempty"`       // seconds capacity is launched ahead of the forecast
+	MaxCapacityBreachBehavior string                 `json:"max_capacity_breach_behavior,omitempty"` // HonorMaxCapacity or IncreaseMaxCapacity
+	MaxCapacityBuffer         *int32                 `json:"max_capacity_buffer,omitempty"`          // percent above the forecast, IncreaseMaxCapacity only
+}
+
+// PredictiveMetricSpec is one metric specification: either a predefined metric pair, or a predefined load metric
+// together with a predefined scaling metric
+type PredictiveMetricSpec struct {
+	TargetValue             float64 `json:"target_value"`
+	PredefinedMetricPair    string  `json:"predefined_metric_pair,omitempty"` // e.g. ECSServiceCPUUtilization
+	PredefinedLoadMetric    string  `json:"predefined_load_metric,omitempty"`
+	PredefinedScalingMetric string  `json:"predefined_scaling_metric,omitempty"`
+	ResourceLabel           string  `json:"resource_label,omitempty"` // for the ALB request count metrics
+}
+
+// Check a predictive policy's configuration: each metric specification needs a positive target and its metric
+// pair, given either as predefined_metric_pair or as both predefined_load_metric and predefined_scaling_metric
+func validatePredictiveScaling(p PolicyDef) error {
+	ps := p.PredictiveScalingConfiguration
+	if p.PolicyType != string(aasTypes.PolicyTypePredictiveScaling) {
+		if ps != nil {
+			return fmt.Errorf("policy %s: predictive_scaling_configuration requires policy_type PredictiveScaling", p.PolicyName)
+		}
+		return nil
+	}
+	if ps == nil {
+		return nil // reported by checkRequiredFields
+	}
+
+	for i, spec := range ps.MetricSpecifications {
+		prefix := fmt.Sprintf("policy %s: metric_specifications[%d]", p.PolicyName, i)
+		if spec.TargetValue <= 0 {
+			return fmt.Errorf("%s: target_value must be positive, got %v", prefix, spec.TargetValue)
+		}
+		pair, load, scaling := spec.PredefinedMetricPair != "", spec.PredefinedLoadMetric != "", spec.PredefinedScalingMetric != ""
+		switch {
+		case pair && (load || scaling):
+			return fmt.Errorf("%s: predefined_metric_pair is mutually exclusive with predefined_load_metric and predefined_scaling_metric", prefix)
+		case !pair && !(load && scaling):
+			return fmt.Errorf("%s: needs a metric pair: predefined_metric_pair, or both predefined_load_metric and predefined_scaling_metric", prefix)
+		}
+	}
+	if err := validateEnum(p.PolicyName, "mode", ps.Mode, enumStrings(aasTypes.PredictiveScalingMode("").Values())); err != nil {
+		return err
+	}
+	if err := validateEnum(p.PolicyName, "max_capacity_breach_behavior", ps.MaxCapacityBreachBehavior, enumStrings(aasTypes.PredictiveScalingMaxCapacityBreachBehavior("").Values())); err != nil {
+		return err
+	}
+	if t := ps.SchedulingBufferTime; t != nil && (*t < 0 || *t > maxSchedulingBufferTime) {
+		return fmt.Errorf("policy %s: scheduling_buffer_time must be between 0 and %d seconds, got %d", p.PolicyName, maxSchedulingBufferTime, *t)
+	}
+	if b := ps.MaxCapacityBuffer; b != nil {
+		if aasTypes.PredictiveScalingMaxCapacityBreachBehavior(ps.MaxCapacityBreachBehavior) != aasTypes.PredictiveScalingMaxCapacityBreachBehaviorIncreaseMaxCapacity {
+			return fmt.Errorf("policy %s: max_capacity_buffer requires max_capacity_breach_behavior IncreaseMaxCapacity", p.PolicyName)
+		}
+		if *b < 0 || *b > 100 {
+			return fmt.Errorf("policy %s: max_capacity_buffer must be between 0 and 100, got %d", p.PolicyName, *b)
+		}
+	}
+	return nil
+}
+
+// Build the PutScalingPolicy predictive configuration from a policy definition
+func predictiveScalingPolicyConfig(ps *PredictiveScalingConfig) *aasTypes.PredictiveScalingPolicyConfiguration {
+	label := func(spec PredictiveMetricSpec) *string {
+		if spec.ResourceLabel == "" {
+			return nil
+		}
+		return aws.String(spec.ResourceLabel)
+	}
+	cfg := &aasTypes.PredictiveScalingPolicyConfiguration{
+		Mode:                      aasTypes.PredictiveScalingMode(ps.Mode),
+		SchedulingBufferTime:      ps.SchedulingBufferTime,
+		MaxCapacityBreachBehavior: aasTypes.PredictiveScalingMaxCapacityBreachBehavior(ps.MaxCapacityBreachBehavior),
+		MaxCapacityBuffer:         ps.MaxCapacityBuffer,
+	}
+	for _, spec := range ps.MetricSpecifications {
+		ms := aasTypes.PredictiveScalingMetricSpecification{TargetValue: aws.Float64(spec.TargetValue)}
+		if spec.PredefinedMetricPair != "" {
+			ms.PredefinedMetricPairSpecification = &aasTypes.PredictiveScalingPredefinedMetricPairSpecification{
+				PredefinedMetricType: aws.String(spec.PredefinedMetricPair),
+				ResourceLabel:        label(spec),
+			}
+		} else {
+			ms.PredefinedLoadMetricSpecification = &aasTypes.PredictiveScalingPredefinedLoadMetricSpecification{
+				PredefinedMetricType: aws.String(spec.PredefinedLoadMetric),
+				ResourceLabel:        label(spec),
+			}
+			ms.PredefinedScalingMetricSpecification = &aasTypes.PredictiveScalingPredefinedScalingMetricSpecification{
+				PredefinedMetricType: aws.String(spec.PredefinedScalingMetric),
+				ResourceLabel:        label(spec),
+			}
+		}
+		cfg.MetricSpecifications = append(cfg.MetricSpecifications, ms)
+	}
+	return cfg
+}
+
+// Convert an existing predictive configuration back into a policy definition's, for --export
+func predictiveScalingConfigFrom(cfg *aasTypes.PredictiveScalingPolicyConfiguration) *PredictiveScalingConfig {
+	ps := &PredictiveScalingConfig{
+		Mode:                      string(cfg.Mode),
+		SchedulingBufferTime:      cfg.SchedulingBufferTime,
+		MaxCapacityBreachBehavior: string(cfg.MaxCapacityBreachBehavior),
+		MaxCapacityBuffer:         cfg.MaxCapacityBuffer,
+	}
+	for _, ms := range cfg.MetricSpecifications {
+		spec := PredictiveMetricSpec{TargetValue: aws.ToFloat64(ms.TargetValue)}
+		if pair := ms.PredefinedMetricPairSpecification; pair != nil {
+			spec.PredefinedMetricPair = aws.ToString(pair.PredefinedMetricType)
+			spec.ResourceLabel = aws.ToString(pair.ResourceLabel)
+		}
+		if load := ms.PredefinedLoadMetricSpecification; load != nil {
+			spec.PredefinedLoadMetric = aws.ToString(load.PredefinedMetricType)
+			spec.ResourceLabel = aws.ToString(load.ResourceLabel)
+		}
+		if scaling := ms.PredefinedScalingMetricSpecification; scaling != nil {
+			spec.PredefinedScalingMetric = aws.ToString(scaling.PredefinedMetricType)
+		}
+		ps.MetricSpecifications = append(ps.MetricSpecifications, spec)
+	}
+	return ps
+}
+
+// Compare predictive configurations field by field, for diffScalingPolicy. Unset mode and breach behavior
+// compare as the AWS defaults, since AWS reports them filled in.
+func diffPredictiveScaling(existing, desired *aasTypes.PredictiveScalingPolicyConfiguration, add func(field, existing, desired string)) {
+	const prefix = "PredictiveScalingPolicyConfiguration"
+	if existing == nil || desired == nil {
+		add(prefix, setString(existing != nil), setString(desired != nil))
+		return
+	}
+
+	orDefault := func(v, def string) string {
+		if v == "" {
+			return def
+		}
+		return v
+	}
+	if e, d := orDefault(string(existing.Mode), string(aasTypes.PredictiveScalingModeForecastOnly)), orDefault(string(desired.Mode), string(aasTypes.PredictiveScalingModeForecastOnly)); e != d {
+		add(prefix+".Mode", e, d)
+	}
+	if aws.ToInt32(existing.SchedulingBufferTime) != aws.ToInt32(desired.SchedulingBufferTime) {
+		add(prefix+".SchedulingBufferTime", ptrString(existing.SchedulingBufferTime), ptrString(desired.SchedulingBufferTime))
+	}
+	breach := string(aasTypes.PredictiveScalingMaxCapacityBreachBehaviorHonorMaxCapacity)
+	if e, d := orDefault(string(existing.MaxCapacityBreachBehavior), breach), orDefault(string(desired.MaxCapacityBreachBehavior), breach); e != d {
+		add(prefix+".MaxCapacityBreachBehavior", e, d)
+	}
+	if aws.ToInt32(existing.MaxCapacityBuffer) != aws.ToInt32(desired.MaxCapacityBuffer) {
+		add(prefix+".MaxCapacityBuffer", ptrString(existing.MaxCapacityBuffer), ptrString(desired.MaxCapacityBuffer))
+	}
+
+	if len(existing.MetricSpecifications) != len(desired.MetricSpecifications) {
+		add(prefix+".MetricSpecifications", fmt.Sprintf("%d specifications", len(existing.MetricSpecifications)), fmt.Sprintf("%d specifications", len(desired.MetricSpecifications)))
+		return
+	}
+	for i, e := range existing.MetricSpecifications {
+		d := desired.MetricSpecifications[i]
+		specPrefix := fmt.Sprintf("%s.MetricSpecifications[%d]", prefix, i)
+		if aws.ToFloat64(e.TargetValue) != aws.ToFloat64(d.TargetValue) {
+			add(specPrefix+".TargetValue", ptrString(e.TargetValue), ptrString(d.TargetValue))
+		}
+		if e, d := predictiveMetricString(e), predictiveMetricString(d); e != d {
+			add(specPrefix+".Metrics", e, d)
+		}
+	}
+}
+
+// The predefined metrics of a specification in one comparable string, e.g. "pair=ECSServiceCPUUtilization"
+func predictiveMetricString(ms aasTypes.PredictiveScalingMetricSpecification) string {
+	s := ""
+	if pair := ms.PredefinedMetricPairSpecification; pair != nil {
+		s += fmt.Sprintf("pair=%s%s", aws.ToString(pair.PredefinedMetricType), labelSuffix(pair.ResourceLabel))
+	}
+	if load := ms.PredefinedLoadMetricSpecification; load != nil {
+		s += fmt.Sprintf("load=%s%s", aws.ToString(load.PredefinedMetricType), labelSuffix(load.ResourceLabel))
+	}
+	if scaling := ms.PredefinedScalingMetricSpecification; scaling != nil {
+		s += fmt.Sprintf(" scaling=%s%s", aws.ToString(scaling.PredefinedMetricType), labelSuffix(scaling.ResourceLabel))
+	}
+	if ms.CustomizedLoadMetricSpecification != nil || ms.CustomizedScalingMetricSpecification != nil || ms.CustomizedCapacityMetricSpecification != nil {
+		s += " customized"
+	}
+	return s
+}
+
+// A resource label appended to a metric type, when there is one
+func labelSuffix(label *string) string {
+	if aws.ToString(label) == "" {
+		return ""
+	}
+	return "(" + aws.ToString(label) + ")"
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+const testPredictivePolicy = `[{
+	"policy_name": "cpu-forecast",
+	"policy_type": "PredictiveScaling",
+	"predictive_scaling_configuration": {
+		"mode": "ForecastAndScale",
+		"scheduling_buffer_time": 300,
+		"metric_specifications": [
+			{"target_value": 40, "predefined_metric_pair": "ECSServiceCPUUtilization"},
+			{"target_value": 1000, "predefined_load_metric": "ALBTargetGroupRequestCount", "predefined_scaling_metric": "ECSServiceAverageCPUUtilization", "resource_label": "app/my-alb/1/targetgroup/my-tg/2"}
+		]
+	}
+}]`
+
+// TestValidatePredictiveScaling tests that each metric specification needs its metric pair and the optional
+// settings are range checked
+func TestValidatePredictiveScaling(t *testing.T) {
+	policy := func(ps PredictiveScalingConfig) PolicyDef {
+		return PolicyDef{PolicyName: "forecast", PolicyType: "PredictiveScaling", PredictiveScalingConfiguration: &ps}
+	}
+	pair := PredictiveMetricSpec{TargetValue: 40, PredefinedMetricPair: "ECSServiceCPUUtilization"}
+
+	tests := []struct {
+		name    string
+		policy  PolicyDef
+		wantErr string
+	}{
+		{"metric pair", policy(PredictiveScalingConfig{MetricSpecifications: []PredictiveMetricSpec{pair}}), ""},
+		{"load and scaling metrics", policy(PredictiveScalingConfig{MetricSpecifications: []PredictiveMetricSpec{{TargetValue: 40, PredefinedLoadMetric: "ECSServiceTotalCPUUtilization", PredefinedScalingMetric: "ECSServiceAverageCPUUtilization"}}}), ""},
+		{"load metric alone", policy(PredictiveScalingConfig{MetricSpecifications: []PredictiveMetricSpec{{TargetValue: 40, PredefinedLoadMetric: "ECSServiceTotalCPUUtilization"}}}), "metric_specifications[0]: needs a metric pair"},
+		{"pair and load metric", policy(PredictiveScalingConfig{MetricSpecifications: []PredictiveMetricSpec{{TargetValue: 40, PredefinedMetricPair: "ECSServiceCPUUtilization", PredefinedLoadMetric: "ECSServiceTotalCPUUtilization"}}}), "mutually exclusive"},
+		{"zero target", policy(PredictiveScalingConfig{MetricSpecifications: []PredictiveMetricSpec{{PredefinedMetricPair: "ECSServiceCPUUtilization"}}}), "target_value must be positive"},
+		{"invalid mode", policy(PredictiveScalingConfig{MetricSpecifications: []PredictiveMetricSpec{pair}, Mode: "Forecast"}), `invalid mode "Forecast"`},
+		{"buffer time too long", policy(PredictiveScalingConfig{MetricSpecifications: []PredictiveMetricSpec{pair}, SchedulingBufferTime: aws.Int32(7200)}), "scheduling_buffer_time must be between 0 and 3600"},
+		{"capacity buffer without increase", policy(PredictiveScalingConfig{MetricSpecifications: []PredictiveMetricSpec{pair}, MaxCapacityBuffer: aws.Int32(10)}), "requires max_capacity_breach_behavior IncreaseMaxCapacity"},
+		{"configuration on a step policy", PolicyDef{PolicyName: "forecast", PolicyType: "StepScaling", PredictiveScalingConfiguration: &PredictiveScalingConfig{}}, "requires policy_type PredictiveScaling"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePolicy(tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePolicy() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The configuration and at least one metric specification are required
+	for raw, want := range map[string]string{
+		`[{"policy_name": "p", "policy_type": "PredictiveScaling"}]`:                                                               "predictive_scaling_configuration is required for PredictiveScaling",
+		`[{"policy_name": "p", "policy_type": "PredictiveScaling", "predictive_scaling_configuration": {"mode": "ForecastOnly"}}]`: "predictive_scaling_configuration.metric_specifications is required",
+	} {
+		if _, err := parsePolicies(raw, ""); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parsePolicies() error = %v, want %q", err, want)
+		}
+	}
+}
+
+// TestPredictiveScalingPolicy tests that a predictive policy reaches PutScalingPolicy, compares equal to what AWS
+// reports back with its defaults filled in, reports changed fields, and exports back to the same definition
+func TestPredictiveScalingPolicy(t *testing.T) {
+	policies, err := parsePolicies(testPredictivePolicy, "")
+	if err != nil {
+		t.Fatalf("parsePolicies() unexpected error: %v", err)
+	}
+	res := testResource("service/test-cluster/test-service")
+	in, err := buildPolicyInput(policies[0], res)
+	if err != nil {
+		t.Fatalf("buildPolicyInput() unexpected error: %v", err)
+	}
+	ps := in.PredictiveScalingPolicyConfiguration
+	if in.PolicyType != aasTypes.PolicyTypePredictiveScaling || ps == nil || ps.Mode != aasTypes.PredictiveScalingModeForecastAndScale || aws.ToInt32(ps.SchedulingBufferTime) != 300 || len(ps.MetricSpecifications) != 2 {
+		t.Fatalf("buildPolicyInput() = %+v, want the predictive configuration", in)
+	}
+	if got := aws.ToString(ps.MetricSpecifications[1].PredefinedScalingMetricSpecification.PredefinedMetricType); got != "ECSServiceAverageCPUUtilization" {
+		t.Errorf("scaling metric = %s, want ECSServiceAverageCPUUtilization", got)
+	}
+
+	// AWS reports the unset breach behavior as its default, which is not a change
+	reported := *ps
+	reported.MaxCapacityBreachBehavior = aasTypes.PredictiveScalingMaxCapacityBreachBehaviorHonorMaxCapacity
+	existing := &aasTypes.ScalingPolicy{PolicyName: in.PolicyName, PolicyType: aasTypes.PolicyTypePredictiveScaling, PredictiveScalingPolicyConfiguration: &reported}
+	if diffs := diffScalingPolicy(existing, in); len(diffs) != 0 {
+		t.Errorf("diffScalingPolicy() = %v, want no diffs", diffs)
+	}
+
+	changed := *ps
+	changed.Mode = aasTypes.PredictiveScalingModeForecastOnly
+	changed.MetricSpecifications = []aasTypes.PredictiveScalingMetricSpecification{ps.MetricSpecifications[0], ps.MetricSpecifications[1]}
+	changed.MetricSpecifications[0].TargetValue = aws.Float64(50)
+	existing.PredictiveScalingPolicyConfiguration = &changed
+	got := diffFieldNames(diffScalingPolicy(existing, in))
+	want := []string{"PredictiveScalingPolicyConfiguration.Mode", "PredictiveScalingPolicyConfiguration.MetricSpecifications[0].TargetValue"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("diffScalingPolicy() fields = %v, want %v", got, want)
+	}
+
+	// Export writes the same definition back
+	exported := policyDefFromScalingPolicy(aasTypes.ScalingPolicy{PolicyName: in.PolicyName, PolicyType: in.PolicyType, PredictiveScalingPolicyConfiguration: ps}, nil, res)
+	back, err := buildPolicyInput(exported, res)
+	if err != nil {
+		t.Fatalf("buildPolicyInput() of the export unexpected error: %v", err)
+	}
+	existing.PredictiveScalingPolicyConfiguration = ps
+	if diffs := diffScalingPolicy(existing, back); len(diffs) != 0 {
+		t.Errorf("diffScalingPolicy() of the export = %v, want no diffs", diffs)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cw "github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// Stand-in suffix used to find where the name template puts the suffix
+const prefixMarker = "\x00suffix\x00"
+
+// The fixed start of every generated name, e.g. cluster-service- with the default template, for --prefix-cleanup
+// and --purge-unmanaged. Fails for templates that render nothing before {{.Suffix}}, since every name would match.
+func (n *resourceNamer) fixedPrefix() (string, error) {
+	var sb strings.Builder
+	if err := n.tmpl.Execute(&sb, nameData{Cluster: n.cluster, Service: n.service, Prefix: n.prefix, Suffix: prefixMarker}); err != nil {
+		return "", fmt.Errorf("failed to render name-template: %v", err)
+	}
+	prefix, _, found := strings.Cut(strings.TrimLeft(sb.String(), " \t\r\n"), prefixMarker)
+	if !found || prefix == "" {
+		return "", errors.New("prefix-cleanup and purge-unmanaged need a name-template that renders a fixed prefix before {{.Suffix}}")
+	}
+	return prefix, nil
+}
+
+// Whether name looks like one this namer generates: it starts with the fixed prefix and, with --env, ends in -<env>
+func (n *resourceNamer) generated(name, prefix string) bool {
+	return strings.HasPrefix(name, prefix) && (n.env == "" || strings.HasSuffix(name, "-"+n.env))
+}
+
+// Find the metric alarms named like this tool's, for --prefix-cleanup: those starting with the name prefix and,
+// with --env, ending in -<env>. Alarms whose actions scale another resource belong to a service whose names share
+// the prefix (e.g. api-worker for api) and are left alone.
+func (r *runner) prefixAlarms(ctx context.Context) ([]string, error) {
+	prefix, err := r.names.fixedPrefix()
+	if err != nil {
+		return nil, err
+	}
+	// Policy ARNs end in :resource/<namespace>/<resource ID>:policyName/<name>
+	marker := fmt.Sprintf(":resource/%s/%s:policyName/", r.resource.Namespace, r.resource.ID)
+	otherResource := func(action string) bool {
+		return strings.Contains(action, ":scalingPolicy:") && !strings.Contains(action, marker)
+	}
+
+	input := &cw.DescribeAlarmsInput{
+		AlarmNamePrefix: aws.String(prefix),
+		AlarmTypes:      []cwTypes.AlarmType{cwTypes.AlarmTypeMetricAlarm},
+	}
+	var names []string
+	for {
+		resp, err := r.cw.DescribeAlarms(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list alarms with prefix %s: %w", prefix, err)
+		}
+		for _, alarm := range resp.MetricAlarms {
+			name := aws.ToString(alarm.AlarmName)
+			if !r.names.generated(name, prefix) {
+				continue
+			}
+			if slices.ContainsFunc(alarm.AlarmActions, otherResource) {
+				r.log.Debug("skipping alarm that scales another resource", "alarm_name", name)
+				continue
+			}
+			names = append(names, name)
+		}
+		if resp.NextToken == nil {
+			return names, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// TestFixedPrefix tests finding the fixed start of generated names, and rejecting templates without one
+func TestFixedPrefix(t *testing.T) {
+	tests := []struct {
+		name, prefix, template, env string
+		want, wantErr               string
+	}{
+		{"default", "", "", "", "test-cluster-test-service-", ""},
+		{"name prefix", "team", "", "", "team-", ""},
+		{"env", "", "", "staging", "test-cluster-test-service-", ""},
+		{"template", "", "as-{{.Service}}/{{.Suffix}}", "", "as-test-service/", ""},
+		{"suffix first", "", "{{.Suffix}}-{{.Service}}", "", "", "fixed prefix before {{.Suffix}}"},
+		{"no suffix", "", "{{.Cluster}}-{{.Service}}", "", "", "fixed prefix before {{.Suffix}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := newResourceNamer("test-cluster", "test-service", tt.prefix, tt.template, tt.env)
+			if err != nil {
+				t.Fatalf("newResourceNamer() unexpected error: %v", err)
+			}
+			got, err := n.fixedPrefix()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("fixedPrefix() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("fixedPrefix() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunPrefixCleanup tests that disable with --prefix-cleanup deletes an unpredicted alarm matching the name
+// prefix, but not alarms of another service sharing the prefix or unrelated alarms, and that without the flag the
+// unpredicted alarm is kept
+func TestRunPrefixCleanup(t *testing.T) {
+	ctx := context.Background()
+	alarms := &cloudwatch.DescribeAlarmsOutput{MetricAlarms: []cwTypes.MetricAlarm{
+		{AlarmName: aws.String("test-cluster-test-service-cpu-high")},
+		{AlarmName: aws.String("test-cluster-test-service-legacy-latency")},
+		{AlarmName: aws.String("test-cluster-test-service-worker-cpu-high"), AlarmActions: []string{
+			"arn:aws:autoscaling:us-east-1:123456789012:scalingPolicy:1:resource/ecs/service/test-cluster/test-service-worker:policyName/scale-out",
+		}},
+		{AlarmName: aws.String("unrelated-alarm")},
+	}}
+	newCfg := func(prefixCleanup bool) *Config {
+		return &Config{
+			Cluster:          "test-cluster",
+			Service:          "test-service",
+			Enabled:          false,
+			Yes:              true,
+			MinCapacity:      1,
+			MaxCapacity:      10,
+			ScaleOutCooldown: 300,
+			ScaleInCooldown:  300,
+			AlarmsEnabled:    true,
+			PrefixCleanup:    prefixCleanup,
+		}
+	}
+	deletedAlarms := func(prefixCleanup bool) []string {
+		mockAAS := &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+				ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+			},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		}
+		mockCW := &mockCWClient{describeAlarmsOutput: alarms}
+		if err := Run(ctx, newCfg(prefixCleanup), Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+		var deleted []string
+		for _, call := range mockCW.deleteAlarmsCalls {
+			deleted = append(deleted, call.AlarmNames...)
+		}
+		slices.Sort(deleted)
+		return deleted
+	}
+
+	if got, want := deletedAlarms(true), []string{"test-cluster-test-service-cpu-high", "test-cluster-test-service-legacy-latency"}; !slices.Equal(got, want) {
+		t.Errorf("prefix-cleanup deleted alarms %v, want %v", got, want)
+	}
+	if got, want := deletedAlarms(false), []string{"test-cluster-test-service-cpu-high"}; !slices.Equal(got, want) {
+		t.Errorf("disable deleted alarms %v, want %v", got, want)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"enable", []string{"--prefix-cleanup"}, "only applies to disable"},
+		{"no alarms", []string{"--prefix-cleanup", "--no-alarms"}, "mutually exclusive"},
+	} {
+		args := testPositionalArgs()
+		args[5] = "false"
+		if tt.name == "enable" {
+			args[5] = "true"
+		}
+		if _, err := parseArgs(append(args, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: parseArgs() error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aas "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+)
+
+// Tag key for the provenance string on the scalable target, with --provenance-tag
+const provenanceTagKey = "ecs-autoscaler:provenance"
+
+// Short hash of the inputs that shape the desired AWS state, so runs with the same inputs hash the same.
+// Credentials, the command and output, logging and wait settings are left out; everything else is included,
+// so a new input counts towards the hash unless it is added to the list below.
+func configHash(cfg *Config) string {
+	v := configView(*cfg)
+	v.KeyID, v.KeySecret, v.Profile, v.Region, v.AllowAnyRegion, v.EndpointURL = "", "", "", "", false, ""
+	v.SessionToken, v.SharedConfigFile, v.SharedCredentialsFile, v.UseFIPSEndpoint, v.UseDualStackEndpoint = "", "", "", false, false
+	v.RegionFromInstanceMetadata = false
+	v.Enabled, v.Command, v.Plan, v.Verify, v.Export, v.Yes, v.Version, v.KeepTarget = false, "", false, false, false, false, false, false
+	v.Describe, v.Selftest, v.Output, v.DetailedExitCode, v.DiffOnlyExitCode = false, false, "", false, false
+	v.LogFormat, v.LogLevel, v.Quiet, v.MetricsFile, v.SummaryFile, v.AuditTopicARN = "", "", false, "", "", ""
+	v.Wait, v.WaitTimeout, v.WaitInterval, v.Interval, v.ShowActivities = false, 0, 0, 0, 0
+	v.ForceRecreate, v.ReconcileAlarms, v.RemovePolicies, v.PurgeUnmanaged = false, false, nil, false
+	v.AllServicesInCluster, v.Exclude, v.ProvenanceTag, v.PrefixCleanup = false, nil, false, false
+
+	// Config only holds strings, numbers, slices and string maps, so this cannot fail; maps marshal sorted by key
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// Provenance string recording which tool version applied which configuration, and when
+func provenance(hash string, now time.Time) string {
+	return fmt.Sprintf("ecs-autoscaler %s %s config=%s", version, now.UTC().Format(time.RFC3339), hash)
+}
+
+// Append the provenance string to an alarm description
+func withProvenance(description, prov string) string {
+	return description + " [" + prov + "]"
+}
+
+var provenanceHashPattern = regexp.MustCompile(`\[ecs-autoscaler \S+ \S+ config=([0-9a-f]+)\]$`)
+
+// The config hash recorded in an alarm description by withProvenance, if any
+func provenanceHash(description string) (string, bool) {
+	m := provenanceHashPattern.FindStringSubmatch(description)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Tags for registering the scalable target: --tags, plus the provenance string with --provenance-tag
+func (r *runner) scalableTargetTags() map[string]string {
+	if !r.cfg.ProvenanceTag {
+		return r.cfg.Tags
+	}
+	tags := maps.Clone(r.cfg.Tags)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	tags[provenanceTagKey] = r.provenance
+	return tags
+}
+
+// Tag the scalable target with the provenance string for --provenance-tag, after an enable run that changed
+// something. Registering an existing target ignores its tags, so the tag is written separately; a target this run
+// registered already carries it. The tag records the run's changes rather than being one, so it bypasses the
+// change count and only its API call is counted.
+func (r *runner) tagProvenance(ctx context.Context) error {
+	if !r.cfg.ProvenanceTag || r.metrics.changes == 0 {
+		return nil
+	}
+	current, targetARN, err := r.targetTags(ctx, "")
+	if err != nil {
+		return err
+	}
+	if targetARN == "" {
+		return fmt.Errorf("scalable target %s not found to tag", r.resource.ID)
+	}
+	if current[provenanceTagKey] == r.provenance {
+		r.log.Debug("scalable target provenance tag up to date")
+		return nil
+	}
+	r.log.Info("tagging scalable target with provenance", "provenance", r.provenance)
+	r.metrics.call("TagResource")
+	if _, err := r.uncountedAAS.TagResource(ctx, &aas.TagResourceInput{
+		ResourceARN: aws.String(targetARN),
+		Tags:        map[string]string{provenanceTagKey: r.provenance},
+	}); err != nil {
+		return fmt.Errorf("failed to tag scalable target with provenance: %w", err)
+	}
+	return nil
+}
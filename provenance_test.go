@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// TestConfigHash tests that identical configs hash the same and only desired-state inputs change the hash
+func TestConfigHash(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			KeyID:        "AKIAEXAMPLE",
+			Cluster:      "test-cluster",
+			Service:      "test-service",
+			MinCapacity:  1,
+			MaxCapacity:  10,
+			TargetCPUOut: 75,
+			Tags:         map[string]string{"team": "platform", "env": "prod"},
+			PoliciesRaw:  `[{"policy_name":"p"}]`,
+		}
+	}
+
+	hash := configHash(base())
+	if len(hash) != 12 {
+		t.Errorf("configHash() = %q, want 12 hex characters", hash)
+	}
+	if got := configHash(base()); got != hash {
+		t.Errorf("configHash() of an identical config = %q, want %q", got, hash)
+	}
+
+	same := base()
+	same.KeyID = "AKIAOTHER"
+	same.Plan = true
+	same.LogLevel = "debug"
+	same.Tags = map[string]string{"env": "prod", "team": "platform"}
+	if got := configHash(same); got != hash {
+		t.Errorf("configHash() with only credentials, mode and logging changed = %q, want %q", got, hash)
+	}
+
+	for name, modify := range map[string]func(*Config){
+		"max capacity": func(c *Config) { c.MaxCapacity = 20 },
+		"threshold":    func(c *Config) { c.TargetCPUOut = 80 },
+		"tags":         func(c *Config) { c.Tags["team"] = "payments" },
+		"policies":     func(c *Config) { c.PoliciesRaw = `[{"policy_name":"q"}]` },
+	} {
+		changed := base()
+		modify(changed)
+		if configHash(changed) == hash {
+			t.Errorf("configHash() unchanged after changing %s", name)
+		}
+	}
+}
+
+// TestProvenanceHash tests that the hash written by withProvenance is read back
+func TestProvenanceHash(t *testing.T) {
+	prov := provenance("0123456789ab", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	if want := "ecs-autoscaler " + version + " 2024-05-01T12:00:00Z config=0123456789ab"; prov != want {
+		t.Errorf("provenance() = %q, want %q", prov, want)
+	}
+	if got, ok := provenanceHash(withProvenance("Scale out when CPU is high", prov)); !ok || got != "0123456789ab" {
+		t.Errorf("provenanceHash() = %q/%v, want 0123456789ab/true", got, ok)
+	}
+	if _, ok := provenanceHash("Scale out when CPU is high"); ok {
+		t.Error("provenanceHash() found a hash in a description without one")
+	}
+}
+
+// TestProvenanceApplied tests the alarm description, the optional target tag and the changed-config log
+func TestProvenanceApplied(t *testing.T) {
+	ctx := context.Background()
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	r := newTestRunner(t, true, nil, mockAAS, mockCW)
+	r.cfg.ProvenanceTag = true
+	r.cfg.Tags = map[string]string{"team": "platform"}
+
+	_ = r.apply(ctx) // the mock cannot describe the policies it was given, so apply stops after putting them
+	if len(mockAAS.registerScalableTargetCalls) != 1 {
+		t.Fatalf("apply() registered %d scalable targets, want 1", len(mockAAS.registerScalableTargetCalls))
+	}
+	tags := mockAAS.registerScalableTargetCalls[0].Tags
+	if tags[provenanceTagKey] != r.provenance || tags["team"] != "platform" {
+		t.Errorf("scalable target tags = %v, want team and %s", tags, provenanceTagKey)
+	}
+	if _, ok := r.cfg.Tags[provenanceTagKey]; ok {
+		t.Error("scalableTargetTags() modified cfg.Tags")
+	}
+
+	alarms, err := r.defaultAlarmInputs("arn:out", "arn:in")
+	if err != nil {
+		t.Fatalf("defaultAlarmInputs() unexpected error: %v", err)
+	}
+	for _, in := range alarms {
+		if got, ok := provenanceHash(aws.ToString(in.AlarmDescription)); !ok || got != r.configHash {
+			t.Errorf("%s description = %q, want config hash %s", aws.ToString(in.AlarmName), aws.ToString(in.AlarmDescription), r.configHash)
+		}
+	}
+
+	// An alarm applied with a different configuration is logged
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	r.log = serviceLogger(r.cfg, r.resource) // the runner keeps the logger it was built with
+	mockCW.describeAlarmsOutput = &cloudwatch.DescribeAlarmsOutput{MetricAlarms: []cwTypes.MetricAlarm{{
+		AlarmName:        alarms[0].AlarmName,
+		AlarmDescription: aws.String(withProvenance("old", provenance("ffffffffffff", time.Now()))),
+	}}}
+	if err := r.ensureAlarm(ctx, alarms[0]); err != nil {
+		t.Fatalf("ensureAlarm() unexpected error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "applied_config_hash=ffffffffffff") {
+		t.Errorf("ensureAlarm() logs = %q, want the changed config hash", logs.String())
+	}
+}
+
+// TestTagProvenance tests that --provenance-tag writes the tag on an existing target after a run that changed
+// something without counting it as a change, and leaves it alone after a run that did not or when it is present
+func TestTagProvenance(t *testing.T) {
+	for _, changes := range []int{0, 1} {
+		mockAAS := &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+				ScalableTargets: []aasTypes.ScalableTarget{{ScalableTargetARN: aws.String("arn:scalable-target")}},
+			},
+		}
+		r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+		r.cfg.ProvenanceTag = true
+		r.metrics.changes = changes
+
+		if err := r.tagProvenance(context.Background()); err != nil {
+			t.Fatalf("tagProvenance() unexpected error: %v", err)
+		}
+		if changes == 0 {
+			if len(mockAAS.tagResourceCalls) != 0 {
+				t.Errorf("tagProvenance() after no changes tagged %+v, want no call", mockAAS.tagResourceCalls)
+			}
+			continue
+		}
+		if len(mockAAS.tagResourceCalls) != 1 {
+			t.Fatalf("tagProvenance() made %d TagResource calls, want 1", len(mockAAS.tagResourceCalls))
+		}
+		call := mockAAS.tagResourceCalls[0]
+		if aws.ToString(call.ResourceARN) != "arn:scalable-target" || call.Tags[provenanceTagKey] != r.provenance {
+			t.Errorf("TagResource input = %+v, want %s=%s on arn:scalable-target", call, provenanceTagKey, r.provenance)
+		}
+		// The tag is counted as an API call but not as a change
+		if r.metrics.changes != 1 || r.metrics.apiCalls["TagResource"] != 1 {
+			t.Errorf("tagProvenance() left %d changes and %d TagResource calls, want 1 and 1", r.metrics.changes, r.metrics.apiCalls["TagResource"])
+		}
+
+		// A target already carrying the tag, such as one this run registered, is not tagged again
+		mockAAS.tagResourceCalls = nil
+		mockAAS.listTagsOutput = map[string]string{provenanceTagKey: r.provenance}
+		if err := r.tagProvenance(context.Background()); err != nil {
+			t.Fatalf("tagProvenance() unexpected error: %v", err)
+		}
+		if len(mockAAS.tagResourceCalls) != 0 {
+			t.Errorf("tagProvenance() with the tag present tagged %+v, want no call", mockAAS.tagResourceCalls)
+		}
+	}
+}
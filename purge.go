@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Names of the scaling policies an apply puts: the custom (and blended or SQS) policies, or the default pair
+func (r *runner) desiredPolicyNames() []string {
+	if len(r.policies) == 0 {
+		return []string{r.scaleOutName, r.scaleInName}
+	}
+	names := make([]string, 0, len(r.policies))
+	for _, p := range r.policies {
+		names = append(names, p.PolicyName)
+	}
+	return names
+}
+
+// Scaling policies on the resource that the desired configuration no longer has, e.g. ones removed from the
+// policies JSON. Only names this tool generates count, so policies added by hand or another tool are left alone.
+func (r *runner) stalePolicies(ctx context.Context) ([]string, error) {
+	prefix, err := r.names.fixedPrefix()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := describeScalingPolicies(ctx, r.aas, r.resource, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scaling policies: %w", err)
+	}
+	desired := r.desiredPolicyNames()
+	var stale []string
+	for _, p := range existing {
+		name := aws.ToString(p.PolicyName)
+		if slices.Contains(desired, name) {
+			continue
+		}
+		if !r.names.generated(name, prefix) {
+			r.log.Debug("skipping scaling policy not named by this tool", "policy_name", name)
+			continue
+		}
+		stale = append(stale, name)
+	}
+	return stale, nil
+}
+
+// Delete the stale policies and the alarms that trigger them, for --purge-unmanaged after an apply
+func (r *runner) purgeUnmanaged(ctx context.Context) error {
+	stale, err := r.stalePolicies(ctx)
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		r.log.Debug("no unmanaged scaling policies to purge")
+		return nil
+	}
+	r.log.Info("purging scaling policies not in the desired configuration", "policies", stale)
+	return r.removePolicies(ctx, stale)
+}
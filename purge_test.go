@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// TestPurgeUnmanaged tests that --purge-unmanaged deletes a policy dropped from the configuration, and the alarm
+// that triggers it, while the desired policy and a policy not named by this tool stay; and that plan lists the
+// deletion
+func TestPurgeUnmanaged(t *testing.T) {
+	ctx := context.Background()
+	newAAS := func() *mockAASClient {
+		return &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+				ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+			},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+				ScalingPolicies: []aasTypes.ScalingPolicy{
+					{PolicyName: aws.String("cpu-target"), PolicyARN: aws.String("arn:cpu-target"), PolicyType: aasTypes.PolicyTypeTargetTrackingScaling},
+					{PolicyName: aws.String("test-cluster-test-service-old-step"), PolicyARN: aws.String("arn:old-step"), PolicyType: aasTypes.PolicyTypeStepScaling},
+					{PolicyName: aws.String("manual-step"), PolicyARN: aws.String("arn:manual-step"), PolicyType: aasTypes.PolicyTypeStepScaling},
+				},
+			},
+		}
+	}
+	newCW := func() *mockCWClient {
+		return &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+			MetricAlarms: []cwTypes.MetricAlarm{
+				{AlarmName: aws.String("test-cluster-test-service-old-step-high"), AlarmActions: []string{"arn:old-step"}},
+				{AlarmName: aws.String("manual-step-alarm"), AlarmActions: []string{"arn:manual-step"}},
+			},
+		}}
+	}
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 300,
+		ScaleInCooldown:  300,
+		AlarmsEnabled:    true,
+		PurgeUnmanaged:   true,
+		PoliciesRaw:      `[{"policy_name": "cpu-target", "policy_type": "TargetTrackingScaling", "target_tracking_configuration": {"target_value": 60, "predefined_metric_specification": "ECSServiceAverageCPUUtilization"}}]`,
+	}
+
+	mockAAS, mockCW := newAAS(), newCW()
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(mockAAS.deleteScalingPolicyCalls) != 1 || aws.ToString(mockAAS.deleteScalingPolicyCalls[0].PolicyName) != "test-cluster-test-service-old-step" {
+		t.Fatalf("DeleteScalingPolicy calls = %+v, want only test-cluster-test-service-old-step", mockAAS.deleteScalingPolicyCalls)
+	}
+	if len(mockCW.deleteAlarmsCalls) != 1 || len(mockCW.deleteAlarmsCalls[0].AlarmNames) != 1 || mockCW.deleteAlarmsCalls[0].AlarmNames[0] != "test-cluster-test-service-old-step-high" {
+		t.Errorf("DeleteAlarms calls = %+v, want the old-step alarm", mockCW.deleteAlarmsCalls)
+	}
+
+	// Without the flag the stale policy is left alone
+	cfg.PurgeUnmanaged = false
+	mockAAS = newAAS()
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: newCW()}, io.Discard); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(mockAAS.deleteScalingPolicyCalls) != 0 {
+		t.Errorf("DeleteScalingPolicy called %d times without purge-unmanaged, want 0", len(mockAAS.deleteScalingPolicyCalls))
+	}
+
+	// Plan shows the deletion
+	cfg.PurgeUnmanaged = true
+	r, err := newRunner(cfg, Clients{AAS: newAAS(), CW: newCW()}, io.Discard)
+	if err != nil {
+		t.Fatalf("newRunner() unexpected error: %v", err)
+	}
+	items, err := r.buildPlan(ctx)
+	if err != nil {
+		t.Fatalf("buildPlan() unexpected error: %v", err)
+	}
+	var deletes []string
+	for _, item := range items {
+		if item.Action == planDelete {
+			deletes = append(deletes, item.Kind+" "+item.Name)
+		}
+	}
+	if len(deletes) != 1 || deletes[0] != "scaling-policy test-cluster-test-service-old-step" {
+		t.Errorf("buildPlan() deletions = %v, want [scaling-policy test-cluster-test-service-old-step]", deletes)
+	}
+}
+
+// TestPurgeDefaultPolicyAlarms tests that purging the default pair, after custom policies replace it, deletes
+// their cpu and memory alarms, whose names do not follow the policy names
+func TestPurgeDefaultPolicyAlarms(t *testing.T) {
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+			ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+		},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+			ScalingPolicies: []aasTypes.ScalingPolicy{
+				{PolicyName: aws.String("cpu-target"), PolicyARN: aws.String("arn:cpu-target"), PolicyType: aasTypes.PolicyTypeTargetTrackingScaling},
+				{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:scale-out"), PolicyType: aasTypes.PolicyTypeStepScaling},
+				{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:scale-in"), PolicyType: aasTypes.PolicyTypeStepScaling},
+			},
+		},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+		MetricAlarms: []cwTypes.MetricAlarm{
+			{AlarmName: aws.String("test-cluster-test-service-cpu-high"), AlarmActions: []string{"arn:scale-out"}},
+			{AlarmName: aws.String("test-cluster-test-service-mem-high"), AlarmActions: []string{"arn:scale-out"}},
+			{AlarmName: aws.String("test-cluster-test-service-cpu-low"), AlarmActions: []string{"arn:scale-in"}},
+			{AlarmName: aws.String("test-cluster-test-service-mem-low"), AlarmActions: []string{"arn:scale-in"}},
+		},
+	}}
+	r := newTestRunner(t, true, []PolicyDef{{
+		PolicyName: "cpu-target",
+		PolicyType: "TargetTrackingScaling",
+	}}, mockAAS, mockCW)
+
+	if err := r.purgeUnmanaged(context.Background()); err != nil {
+		t.Fatalf("purgeUnmanaged() unexpected error: %v", err)
+	}
+	var deleted []string
+	for _, call := range mockCW.deleteAlarmsCalls {
+		deleted = append(deleted, call.AlarmNames...)
+	}
+	want := []string{
+		"test-cluster-test-service-cpu-high", "test-cluster-test-service-mem-high",
+		"test-cluster-test-service-cpu-low", "test-cluster-test-service-mem-low",
+	}
+	if !slices.Equal(deleted, want) {
+		t.Errorf("deleted alarms = %v, want %v", deleted, want)
+	}
+	if len(mockAAS.deleteScalingPolicyCalls) != 2 {
+		t.Errorf("DeleteScalingPolicy called %d times, want 2", len(mockAAS.deleteScalingPolicyCalls))
+	}
+}
+
+// TestPurgeUnmanagedNeedsFixedPrefix tests that --purge-unmanaged rejects a name template with nothing before the
+// suffix, which would make every policy on the target look generated
+func TestPurgeUnmanagedNeedsFixedPrefix(t *testing.T) {
+	cfg := &Config{Cluster: "test-cluster", Service: "test-service", Enabled: true, MinCapacity: 1, MaxCapacity: 10, PurgeUnmanaged: true, NameTemplate: "{{.Suffix}}"}
+	if _, err := newRunner(cfg, Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard); err == nil {
+		t.Error("newRunner() expected an error for a template without a fixed prefix")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aas "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// Delete the named scaling policies and the alarms this tool created for them, leaving the scalable target
+// and every other policy in place. Every policy must exist, so a typo fails before anything is deleted.
+func (r *runner) removePolicies(ctx context.Context, policyNames []string) error {
+	policyNames = deduplicate(policyNames)
+	policies := make([]*aasTypes.ScalingPolicy, 0, len(policyNames))
+	for _, name := range policyNames {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		policy, err := findScalingPolicy(ctx, r.aas, r.resource, name)
+		if err != nil {
+			return fmt.Errorf("failed to describe scaling policy: %w", err)
+		}
+		if policy == nil {
+			return fmt.Errorf("scaling policy %s not found on %s", name, r.resource.ID)
+		}
+		policies = append(policies, policy)
+	}
+
+	for _, policy := range policies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := aws.ToString(policy.PolicyName)
+		if err := r.removePolicyAlarms(ctx, policy); err != nil {
+			return err
+		}
+
+		r.log.Info("deleting scaling policy", "policy_name", name)
+		if _, err := r.aas.DeleteScalingPolicy(ctx, &aas.DeleteScalingPolicyInput{
+			ServiceNamespace:  r.resource.Namespace,
+			ScalableDimension: r.resource.Dimension,
+			ResourceId:        aws.String(r.resource.ID),
+			PolicyName:        aws.String(name),
+		}); err != nil {
+			return fmt.Errorf("failed to delete scaling policy %s: %w", name, err)
+		}
+	}
+
+	r.log.Info("removed scaling policies", "policies", policyNames)
+	return nil
+}
+
+// Delete the alarms that trigger a policy, found by its ARN in their actions since alarm names need not follow
+// the policy name (the default policies' alarms are cpu-high, mem-low and so on). Skipped when the policy's alarms
+// are managed elsewhere, and for target tracking policies, whose alarms AWS deletes along with the policy.
+func (r *runner) removePolicyAlarms(ctx context.Context, policy *aasTypes.ScalingPolicy) error {
+	policyName := aws.ToString(policy.PolicyName)
+	if !r.managesAlarmFor(policyName) || policy.PolicyType == aasTypes.PolicyTypeTargetTrackingScaling {
+		return nil
+	}
+	policyARN := aws.ToString(policy.PolicyARN)
+	if policyARN == "" {
+		return nil
+	}
+	alarmNames, err := alarmsForPolicy(ctx, r.cw, policyARN)
+	if err != nil {
+		return fmt.Errorf("failed to list alarms for policy %s: %w", policyName, err)
+	}
+	if len(alarmNames) == 0 {
+		return nil
+	}
+	r.log.Info("deleting CloudWatch alarms", "policy_name", policyName, "alarm_names", alarmNames)
+	if err := deleteAlarms(ctx, r.cw, alarmNames); err != nil {
+		return fmt.Errorf("failed to delete alarms for policy %s: %w", policyName, err)
+	}
+	return nil
+}
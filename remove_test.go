@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// TestRemovePolicies tests that only the named policy and its alarm are deleted, and that a missing
+// policy fails before anything is deleted
+func TestRemovePolicies(t *testing.T) {
+	tests := []struct {
+		name       string
+		remove     []string
+		wantErr    bool
+		wantPolicy []string
+		wantAlarms [][]string
+	}{
+		{"named policy", []string{"queue-step"}, false, []string{"queue-step"}, [][]string{{"test-cluster-test-service-queue-step"}}},
+		{"missing policy", []string{"queue-step", "typo"}, true, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{
+				describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{
+					ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(1), MaxCapacity: aws.Int32(10)}},
+				},
+				describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+					ScalingPolicies: []aasTypes.ScalingPolicy{
+						{PolicyName: aws.String("queue-step"), PolicyARN: aws.String("arn:queue-step"), PolicyType: aasTypes.PolicyTypeStepScaling},
+						{PolicyName: aws.String("cpu-target"), PolicyARN: aws.String("arn:cpu-target"), PolicyType: aasTypes.PolicyTypeTargetTrackingScaling},
+					},
+				},
+			}
+			mockCW := &mockCWClient{
+				describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+					MetricAlarms: []cwTypes.MetricAlarm{
+						{AlarmName: aws.String("test-cluster-test-service-queue-step"), AlarmActions: []string{"arn:queue-step"}},
+						{AlarmName: aws.String("TargetTracking-cpu-target-AlarmHigh"), AlarmActions: []string{"arn:cpu-target"}},
+					},
+				},
+			}
+			r := newTestRunner(t, true, nil, mockAAS, mockCW)
+			r.cfg.RemovePolicies = tt.remove
+
+			err := Run(context.Background(), r.cfg, Clients{AAS: mockAAS, CW: mockCW}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var deleted []string
+			for _, call := range mockAAS.deleteScalingPolicyCalls {
+				deleted = append(deleted, aws.ToString(call.PolicyName))
+			}
+			if !reflect.DeepEqual(deleted, tt.wantPolicy) {
+				t.Errorf("deleted policies = %v, want %v", deleted, tt.wantPolicy)
+			}
+			var deletedAlarms [][]string
+			for _, call := range mockCW.deleteAlarmsCalls {
+				deletedAlarms = append(deletedAlarms, call.AlarmNames)
+			}
+			if !reflect.DeepEqual(deletedAlarms, tt.wantAlarms) {
+				t.Errorf("deleted alarms = %v, want %v", deletedAlarms, tt.wantAlarms)
+			}
+			if len(mockAAS.registerScalableTargetCalls) != 0 || len(mockAAS.deregisterScalableTargetCalls) != 0 || len(mockAAS.putScalingPolicyCalls) != 0 {
+				t.Errorf("Run() touched the scalable target or other policies: %v", mockAAS.calls)
+			}
+		})
+	}
+}
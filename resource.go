@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// resourceRef identifies one scalable target: its service namespace, resource ID and scalable dimension
+type resourceRef struct {
+	Namespace aasTypes.ServiceNamespace
+	ID        string
+	Dimension aasTypes.ScalableDimension
+}
+
+// Scalable dimensions for DynamoDB tables and their global secondary indexes
+var (
+	dynamoDBTableDimensions = []string{
+		string(aasTypes.ScalableDimensionDynamoDBTableReadCapacityUnits),
+		string(aasTypes.ScalableDimensionDynamoDBTableWriteCapacityUnits),
+	}
+	dynamoDBIndexDimensions = []string{
+		string(aasTypes.ScalableDimensionDynamoDBIndexReadCapacityUnits),
+		string(aasTypes.ScalableDimensionDynamoDBIndexWriteCapacityUnits),
+	}
+)
+
+// The desired count of an ECS service, e.g. service/my-cluster/my-service
+func ecsServiceResource(cluster, service string) (resourceRef, error) {
+	id := fmt.Sprintf("service/%s/%s", cluster, service)
+	// A slash in either name shifts the ID's segments and AWS reports a confusing "not found"
+	if strings.Count(id, "/") != 2 {
+		return resourceRef{}, fmt.Errorf("invalid resource ID %q: cluster-name and service-name must not contain '/'; set resource-id to use an ID verbatim", id)
+	}
+	return ecsResource(id), nil
+}
+
+// The desired count of the ECS service with the given resource ID, used verbatim
+func ecsResource(id string) resourceRef {
+	return resourceRef{
+		Namespace: aasTypes.ServiceNamespaceEcs,
+		ID:        id,
+		Dimension: aasTypes.ScalableDimensionECSServiceDesiredCount,
+	}
+}
+
+// The read or write capacity of a DynamoDB table (table/NAME) or global secondary index (table/NAME/index/GSI)
+func dynamoDBResource(table, index, dimension string) (resourceRef, error) {
+	if table == "" {
+		return resourceRef{}, fmt.Errorf("table-name is required for the dynamodb service namespace")
+	}
+
+	id := "table/" + table
+	valid := dynamoDBTableDimensions
+	if index != "" {
+		id += "/index/" + index
+		valid = dynamoDBIndexDimensions
+	}
+	if !slices.Contains(valid, dimension) {
+		return resourceRef{}, fmt.Errorf("invalid scalable-dimension %q for %s: must be one of %s", dimension, id, strings.Join(valid, ", "))
+	}
+
+	return resourceRef{
+		Namespace: aasTypes.ServiceNamespaceDynamodb,
+		ID:        id,
+		Dimension: aasTypes.ScalableDimension(dimension),
+	}, nil
+}
+
+// Default CloudWatch dimensions identifying this resource in alarms
+func (r resourceRef) alarmDimensions() []cwTypes.Dimension {
+	dim := func(name, value string) cwTypes.Dimension {
+		return cwTypes.Dimension{Name: aws.String(name), Value: aws.String(value)}
+	}
+
+	switch r.Namespace {
+	case aasTypes.ServiceNamespaceEcs:
+		// service/CLUSTER/SERVICE; the service is the last segment, so a --resource-id cluster may contain slashes
+		if cluster, ok := strings.CutPrefix(r.ID, "service/"); ok {
+			if i := strings.LastIndex(cluster, "/"); i > 0 {
+				return []cwTypes.Dimension{dim("ClusterName", cluster[:i]), dim("ServiceName", cluster[i+1:])}
+			}
+		}
+	case aasTypes.ServiceNamespaceDynamodb:
+		// table/TABLE or table/TABLE/index/INDEX
+		parts := strings.Split(r.ID, "/")
+		if len(parts) == 4 {
+			return []cwTypes.Dimension{dim("TableName", parts[1]), dim("GlobalSecondaryIndexName", parts[3])}
+		}
+		if len(parts) == 2 {
+			return []cwTypes.Dimension{dim("TableName", parts[1])}
+		}
+	}
+	return nil
+}
+
+// Name prefix for DynamoDB resources, e.g. orders-read or orders-by-customer-write for an index
+func dynamoDBNamePrefix(r resourceRef) string {
+	name := strings.Replace(strings.TrimPrefix(r.ID, "table/"), "/index/", "-", 1)
+	if strings.HasSuffix(string(r.Dimension), "WriteCapacityUnits") {
+		return name + "-write"
+	}
+	return name + "-read"
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// TestECSResource tests constructed and verbatim ECS resource IDs and their alarm dimensions
+func TestECSResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantID   string
+		wantDims string
+		wantErr  bool
+	}{
+		{
+			name:     "constructed",
+			cfg:      Config{Cluster: "prod", Service: "api"},
+			wantID:   "service/prod/api",
+			wantDims: "[ClusterName=prod,ServiceName=api]",
+		},
+		{
+			name:    "cluster containing a slash",
+			cfg:     Config{Cluster: "team/prod", Service: "api"},
+			wantErr: true,
+		},
+		{
+			name:    "service containing a slash",
+			cfg:     Config{Cluster: "prod", Service: "api/v2"},
+			wantErr: true,
+		},
+		{
+			name:     "override used verbatim",
+			cfg:      Config{Cluster: "team/prod", Service: "api", ResourceID: "service/team/prod/api"},
+			wantID:   "service/team/prod/api",
+			wantDims: "[ClusterName=team/prod,ServiceName=api]",
+		},
+		{
+			name:    "override with dynamodb",
+			cfg:     Config{ServiceNamespace: "dynamodb", TableName: "orders", ScalableDimension: "dynamodb:table:ReadCapacityUnits", ResourceID: "table/orders"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.cfg.resource()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if res.ID != tt.wantID || res.Dimension != aasTypes.ScalableDimensionECSServiceDesiredCount {
+				t.Errorf("resource() = %+v, want ID %s", res, tt.wantID)
+			}
+			if got := alarmDimensionsString(res.alarmDimensions()); got != tt.wantDims {
+				t.Errorf("alarmDimensions() = %s, want %s", got, tt.wantDims)
+			}
+		})
+	}
+}
+
+// TestDynamoDBResource tests resource IDs and dimension validation for tables and indexes
+func TestDynamoDBResource(t *testing.T) {
+	tests := []struct {
+		name       string
+		table      string
+		index      string
+		dimension  string
+		wantID     string
+		wantPrefix string
+		wantDims   map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "table read",
+			table:      "orders",
+			dimension:  "dynamodb:table:ReadCapacityUnits",
+			wantID:     "table/orders",
+			wantPrefix: "orders-read",
+			wantDims:   map[string]string{"TableName": "orders"},
+		},
+		{
+			name:       "index write",
+			table:      "orders",
+			index:      "by-customer",
+			dimension:  "dynamodb:index:WriteCapacityUnits",
+			wantID:     "table/orders/index/by-customer",
+			wantPrefix: "orders-by-customer-write",
+			wantDims:   map[string]string{"TableName": "orders", "GlobalSecondaryIndexName": "by-customer"},
+		},
+		{name: "missing table", dimension: "dynamodb:table:ReadCapacityUnits", wantErr: true},
+		{name: "index dimension on table", table: "orders", dimension: "dynamodb:index:ReadCapacityUnits", wantErr: true},
+		{name: "table dimension on index", table: "orders", index: "gsi", dimension: "dynamodb:table:ReadCapacityUnits", wantErr: true},
+		{name: "missing dimension", table: "orders", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := dynamoDBResource(tt.table, tt.index, tt.dimension)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dynamoDBResource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if res.ID != tt.wantID || res.Namespace != aasTypes.ServiceNamespaceDynamodb || string(res.Dimension) != tt.dimension {
+				t.Errorf("dynamoDBResource() = %+v, want ID %s in dynamodb with dimension %s", res, tt.wantID, tt.dimension)
+			}
+			if got := dynamoDBNamePrefix(res); got != tt.wantPrefix {
+				t.Errorf("dynamoDBNamePrefix() = %q, want %q", got, tt.wantPrefix)
+			}
+			gotDims := map[string]string{}
+			for _, d := range res.alarmDimensions() {
+				gotDims[aws.ToString(d.Name)] = aws.ToString(d.Value)
+			}
+			if !reflect.DeepEqual(gotDims, tt.wantDims) {
+				t.Errorf("alarmDimensions() = %v, want %v", gotDims, tt.wantDims)
+			}
+		})
+	}
+}
+
+// TestRunDynamoDB tests a full apply against a DynamoDB table's write capacity
+func TestRunDynamoDB(t *testing.T) {
+	cfg := &Config{
+		Enabled:           true,
+		MinCapacity:       5,
+		MaxCapacity:       100,
+		TagAlarms:         true,
+		AlarmsEnabled:     true,
+		ServiceNamespace:  "dynamodb",
+		TableName:         "orders",
+		ScalableDimension: "dynamodb:table:WriteCapacityUnits",
+		PoliciesRaw: `[
+			{"policy_name": "write-target", "policy_type": "TargetTrackingScaling",
+			 "target_tracking_configuration": {"target_value": 70, "predefined_metric_specification": "DynamoDBWriteCapacityUtilization"}}
+		]`,
+	}
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+
+	if err := Run(t.Context(), cfg, Clients{AAS: mockAAS, CW: mockCW}, nil); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	if len(mockAAS.registerScalableTargetCalls) != 1 {
+		t.Fatalf("RegisterScalableTarget called %d times, want 1", len(mockAAS.registerScalableTargetCalls))
+	}
+	reg := mockAAS.registerScalableTargetCalls[0]
+	if reg.ServiceNamespace != aasTypes.ServiceNamespaceDynamodb || aws.ToString(reg.ResourceId) != "table/orders" ||
+		reg.ScalableDimension != aasTypes.ScalableDimensionDynamoDBTableWriteCapacityUnits {
+		t.Errorf("RegisterScalableTarget input = %s %s %s", reg.ServiceNamespace, aws.ToString(reg.ResourceId), reg.ScalableDimension)
+	}
+	for _, put := range mockAAS.putScalingPolicyCalls {
+		if put.ServiceNamespace != aasTypes.ServiceNamespaceDynamodb || put.ScalableDimension != aasTypes.ScalableDimensionDynamoDBTableWriteCapacityUnits {
+			t.Errorf("PutScalingPolicy %s targets %s %s", aws.ToString(put.PolicyName), put.ServiceNamespace, put.ScalableDimension)
+		}
+	}
+
+	// Custom step policy alarms use the table's dimensions
+	cfg.PoliciesRaw = `[{"policy_name": "throttles", "policy_type": "StepScaling", "metric_name": "WriteThrottleEvents", "metric_namespace": "AWS/DynamoDB",
+		"adjustment_type": "PercentChangeInCapacity", "cooldown": 60, "step_adjustments": [{"MetricIntervalLowerBound": 0, "ScalingAdjustment": 20}]}]`
+	r, err := newRunner(cfg, Clients{AAS: mockAAS, CW: mockCW}, nil)
+	if err != nil {
+		t.Fatalf("newRunner() unexpected error: %v", err)
+	}
+	alarm, err := r.customAlarmInput(r.policies[0], "arn:throttles")
+	if err != nil {
+		t.Fatalf("customAlarmInput() unexpected error: %v", err)
+	}
+	if aws.ToString(alarm.AlarmName) != "orders-write-throttles" {
+		t.Errorf("alarm name = %q, want orders-write-throttles", aws.ToString(alarm.AlarmName))
+	}
+	if len(alarm.Dimensions) != 1 || aws.ToString(alarm.Dimensions[0].Name) != "TableName" || aws.ToString(alarm.Dimensions[0].Value) != "orders" {
+		t.Errorf("alarm dimensions = %+v, want TableName=orders", alarm.Dimensions)
+	}
+}
+
+// TestRunDynamoDBRequiresPolicies tests that the ECS-only default policies are rejected for DynamoDB
+func TestRunDynamoDBRequiresPolicies(t *testing.T) {
+	cfg := &Config{
+		Enabled:           true,
+		ServiceNamespace:  "dynamodb",
+		TableName:         "orders",
+		ScalableDimension: "dynamodb:table:ReadCapacityUnits",
+	}
+	if _, err := newRunner(cfg, Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, nil); err == nil {
+		t.Error("newRunner() expected error, got nil")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aas "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	cw "github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// selftestResult is one read-only call --selftest made: the IAM action it needs, how long it took and its error
+type selftestResult struct {
+	Action  string
+	Latency time.Duration
+	Err     error
+}
+
+// Make one cheap read-only call per service to confirm the credentials, region and read permissions work:
+// DescribeScalableTargets for the resource and a single-record DescribeAlarms. Nothing is mutated.
+func (r *runner) selftest(ctx context.Context) []selftestResult {
+	checks := []struct {
+		action string
+		call   func(context.Context) error
+	}{
+		{"application-autoscaling:DescribeScalableTargets", func(ctx context.Context) error {
+			_, err := r.aas.DescribeScalableTargets(ctx, &aas.DescribeScalableTargetsInput{
+				ServiceNamespace:  r.resource.Namespace,
+				ScalableDimension: r.resource.Dimension,
+				ResourceIds:       []string{r.resource.ID},
+			})
+			return err
+		}},
+		{"cloudwatch:DescribeAlarms", func(ctx context.Context) error {
+			_, err := r.cw.DescribeAlarms(ctx, &cw.DescribeAlarmsInput{MaxRecords: aws.Int32(1)})
+			return err
+		}},
+	}
+
+	results := make([]selftestResult, 0, len(checks))
+	for _, c := range checks {
+		start := time.Now()
+		err := c.call(ctx)
+		results = append(results, selftestResult{Action: c.action, Latency: time.Since(start), Err: err})
+	}
+	return results
+}
+
+// Print one row per check: ok, or the error's category with the missing permission or the error itself
+func printSelftest(w io.Writer, resourceID string, results []selftestResult) error {
+	fmt.Fprintf(w, "Selftest for %s\n", resourceID)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  CHECK\tRESULT\tLATENCY\tDETAIL")
+	for _, res := range results {
+		result, detail := "ok", ""
+		if res.Err != nil {
+			result = string(classifyAWSError(res.Err))
+			detail = res.Err.Error()
+			if permission, ok := missingPermission(res.Err); ok {
+				detail = "missing " + permission
+			}
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", res.Action, result, res.Latency.Round(time.Millisecond), detail)
+	}
+	return tw.Flush()
+}
+
+// Check AWS connectivity and permissions without changing anything; fails when any check does, with the AWS
+// error so the exit code is exitAWS
+func (r *runner) runSelftest(ctx context.Context) error {
+	results := r.selftest(ctx)
+	if err := printSelftest(r.out, r.resource.ID, results); err != nil {
+		return err
+	}
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Action, res.Err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("selftest failed %d of %d checks: %w", len(errs), len(results), errors.Join(errs...))
+	}
+	r.log.Info("selftest passed", "checks", len(results))
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// TestRunSelftest tests that selftest reports each check, fails with exitAWS when a call fails, classifies a
+// permission error with the missing action, and never mutates anything
+func TestRunSelftest(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		Cluster:       "test-cluster",
+		Service:       "test-service",
+		Enabled:       true,
+		MinCapacity:   1,
+		MaxCapacity:   10,
+		TargetCPUOut:  75,
+		TargetCPUIn:   65,
+		TargetMemOut:  80,
+		TargetMemIn:   70,
+		AlarmsEnabled: true,
+		Command:       commandSelftest,
+	}
+
+	mockAAS := &mockAASClient{describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{}}
+	mockCW := &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	var out bytes.Buffer
+	if err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, &out); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	for _, want := range []string{"Selftest for service/test-cluster/test-service", "application-autoscaling:DescribeScalableTargets  ok", "cloudwatch:DescribeAlarms                        ok"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// A denied call fails the selftest with the AWS exit code and names the missing permission
+	mockAAS = &mockAASClient{describeScalableTargetsError: accessDenied("Application Auto Scaling", "DescribeScalableTargets")}
+	mockCW = &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	out.Reset()
+	err := Run(ctx, cfg, Clients{AAS: mockAAS, CW: mockCW}, &out)
+	if err == nil || !strings.Contains(err.Error(), "selftest failed 1 of 2 checks") {
+		t.Fatalf("Run() error = %v, want the selftest to fail", err)
+	}
+	if got := exitCode(err); got != exitAWS {
+		t.Errorf("exitCode() = %d, want %d", got, exitAWS)
+	}
+	for _, want := range []string{"application-autoscaling:DescribeScalableTargets  access-denied", "missing application-autoscaling:DescribeScalableTargets", "cloudwatch:DescribeAlarms                        ok"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if len(mockAAS.calls) != 0 || len(mockCW.putMetricAlarmCalls) != 0 || len(mockCW.deleteAlarmsCalls) != 0 {
+		t.Errorf("selftest mutated AWS: %v", mockAAS.calls)
+	}
+}
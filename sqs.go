@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// SQS queue names: up to 80 letters, digits, hyphens and underscores, with a .fifo suffix for FIFO queues
+var sqsQueueNamePattern = regexp.MustCompile(`^([A-Za-z0-9_-]{1,80}|[A-Za-z0-9_-]{1,75}\.fifo)$`)
+
+// Check the --sqs-queue settings
+func validateSQSQueue(queue string, messagesPerTask float64) error {
+	if queue == "" {
+		if messagesPerTask != 0 {
+			return errors.New("messages-per-task requires sqs-queue")
+		}
+		return nil
+	}
+	if !sqsQueueNamePattern.MatchString(queue) {
+		return fmt.Errorf("invalid sqs-queue %q: expected a queue name of up to 80 letters, digits, hyphens or underscores, optionally ending in .fifo", queue)
+	}
+	if messagesPerTask <= 0 {
+		return fmt.Errorf("invalid messages-per-task %v: must be positive", messagesPerTask)
+	}
+	return nil
+}
+
+// Build the target-tracking policy for --sqs-queue: scale on the queue's visible messages, with messages-per-task
+// as the target. It goes through the same path as custom policies, so plan, verify and cleanup cover it.
+func buildSQSPolicy(names *resourceNamer, cfg *Config) (PolicyDef, error) {
+	name, err := names.name("sqs-target")
+	if err != nil {
+		return PolicyDef{}, fmt.Errorf("failed to build policy name: %w", err)
+	}
+	return PolicyDef{
+		PolicyName: name,
+		PolicyType: string(aasTypes.PolicyTypeTargetTrackingScaling),
+		TargetTrackingConfiguration: &TargetTrackingConfig{
+			TargetValue: cfg.MessagesPerTask,
+			CustomMetricSpecification: &CustomMetricSpec{
+				Namespace:  "AWS/SQS",
+				MetricName: "ApproximateNumberOfMessagesVisible",
+				Dimensions: map[string]string{"QueueName": cfg.SQSQueue},
+				Statistic:  string(aasTypes.MetricStatisticSum),
+			},
+			ScaleOutCooldown: aws.Int32(cfg.ScaleOutCooldown),
+			ScaleInCooldown:  aws.Int32(cfg.ScaleInCooldown),
+		},
+	}, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// TestValidateSQSQueue tests queue name and messages-per-task validation
+func TestValidateSQSQueue(t *testing.T) {
+	tests := []struct {
+		name            string
+		queue           string
+		messagesPerTask float64
+		wantErr         string
+	}{
+		{"not set", "", 0, ""},
+		{"standard queue", "jobs_high-priority", 10, ""},
+		{"fifo queue", "orders.fifo", 2.5, ""},
+		{"longest name", strings.Repeat("q", 80), 1, ""},
+		{"longest fifo name", strings.Repeat("q", 75) + ".fifo", 1, ""},
+		{"name too long", strings.Repeat("q", 81), 1, "invalid sqs-queue"},
+		{"fifo name too long", strings.Repeat("q", 76) + ".fifo", 1, "invalid sqs-queue"},
+		{"queue URL", "https://sqs.us-east-1.amazonaws.com/123456789012/jobs", 1, "invalid sqs-queue"},
+		{"queue ARN", "arn:aws:sqs:us-east-1:123456789012:jobs", 1, "invalid sqs-queue"},
+		{"missing messages per task", "jobs", 0, "invalid messages-per-task"},
+		{"negative messages per task", "jobs", -1, "invalid messages-per-task"},
+		{"messages per task without queue", "", 10, "requires sqs-queue"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSQSQueue(tt.queue, tt.messagesPerTask)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateSQSQueue() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateSQSQueue() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSQSPolicy tests that --sqs-queue generates a target-tracking policy on the queue's visible messages
+func TestSQSPolicy(t *testing.T) {
+	cfg := &Config{
+		Cluster:          "test-cluster",
+		Service:          "test-service",
+		Enabled:          true,
+		MinCapacity:      1,
+		MaxCapacity:      10,
+		ScaleOutCooldown: 60,
+		ScaleInCooldown:  300,
+		TagAlarms:        true,
+		AlarmsEnabled:    true,
+		SQSQueue:         "jobs",
+		MessagesPerTask:  25,
+	}
+	r, err := newRunner(cfg, Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard)
+	if err != nil {
+		t.Fatalf("newRunner() unexpected error: %v", err)
+	}
+	if len(r.policies) != 1 || r.policies[0].PolicyName != "test-cluster-test-service-sqs-target" {
+		t.Fatalf("newRunner() policies = %+v, want only test-cluster-test-service-sqs-target", r.policies)
+	}
+
+	input, err := buildPolicyInput(r.policies[0], r.resource)
+	if err != nil {
+		t.Fatalf("buildPolicyInput() unexpected error: %v", err)
+	}
+	tt := input.TargetTrackingScalingPolicyConfiguration
+	if input.PolicyType != aasTypes.PolicyTypeTargetTrackingScaling || aws.ToFloat64(tt.TargetValue) != 25 {
+		t.Errorf("policy type/target = %s/%v, want TargetTrackingScaling/25", input.PolicyType, aws.ToFloat64(tt.TargetValue))
+	}
+	if aws.ToInt32(tt.ScaleOutCooldown) != 60 || aws.ToInt32(tt.ScaleInCooldown) != 300 {
+		t.Errorf("cooldowns = %d/%d, want 60/300", aws.ToInt32(tt.ScaleOutCooldown), aws.ToInt32(tt.ScaleInCooldown))
+	}
+	spec := tt.CustomizedMetricSpecification
+	if aws.ToString(spec.Namespace) != "AWS/SQS" || aws.ToString(spec.MetricName) != "ApproximateNumberOfMessagesVisible" || spec.Statistic != aasTypes.MetricStatisticSum {
+		t.Errorf("metric = %s/%s %s, want AWS/SQS/ApproximateNumberOfMessagesVisible Sum",
+			aws.ToString(spec.Namespace), aws.ToString(spec.MetricName), spec.Statistic)
+	}
+	if len(spec.Dimensions) != 1 || aws.ToString(spec.Dimensions[0].Name) != "QueueName" || aws.ToString(spec.Dimensions[0].Value) != "jobs" {
+		t.Errorf("dimensions = %s, want [QueueName=jobs]", dimensionsString(spec.Dimensions))
+	}
+
+	// A custom policy may not take the generated name
+	cfg.PoliciesRaw = `[{"policy_name":"test-cluster-test-service-sqs-target","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"target_value":50,"predefined_metric_specification":"ECSServiceAverageCPUUtilization"}}]`
+	if _, err := newRunner(cfg, Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard); err == nil || !strings.Contains(err.Error(), "clashes") {
+		t.Errorf("newRunner() error = %v, want a name clash", err)
+	}
+}
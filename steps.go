@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// Defaults for --aggressive-scale-out: +1 up to 10 over the threshold, +2 up to 20, +4 beyond
+const (
+	defaultAggressiveStepSize   = 10
+	defaultAggressiveTiers      = 3
+	defaultAggressiveMultiplier = 2
+
+	// AWS accepts at most 20 step adjustments per policy
+	maxAggressiveTiers = 20
+)
+
+// Generate contiguous step adjustments that scale out harder the further the metric breaches the alarm threshold.
+// Each of the tiers spans base metric units above the previous one, the last is unbounded, and the adjustment
+// starts at +1 and is multiplied by multiplier for every tier.
+func generateSteps(base float64, tiers int, multiplier int32) []StepAdj {
+	steps := make([]StepAdj, 0, tiers)
+	adjust := int32(1)
+	for i := range tiers {
+		step := StepAdj{
+			MetricIntervalLowerBound: aws.Float64(base * float64(i)),
+			ScalingAdjustment:        adjust,
+		}
+		if i < tiers-1 {
+			step.MetricIntervalUpperBound = aws.Float64(base * float64(i+1))
+		}
+		steps = append(steps, step)
+		adjust *= multiplier
+	}
+	return steps
+}
+
+// Check the --aggressive-scale-out settings and the steps they generate
+func validateAggressiveSteps(base float64, tiers, multiplier int) error {
+	if base <= 0 {
+		return fmt.Errorf("invalid aggressive-step-size %v: must be positive", base)
+	}
+	if tiers < 1 || tiers > maxAggressiveTiers {
+		return fmt.Errorf("invalid aggressive-tiers %d: must be between 1 and %d", tiers, maxAggressiveTiers)
+	}
+	if multiplier < 1 || multiplier > math.MaxInt32 {
+		return fmt.Errorf("invalid aggressive-multiplier %d: must be a positive number", multiplier)
+	}
+	if math.Pow(float64(multiplier), float64(tiers-1)) > math.MaxInt32 {
+		return fmt.Errorf("aggressive-multiplier %d over %d tiers overflows the scaling adjustment", multiplier, tiers)
+	}
+	if err := validateStepAdjustments(generateSteps(base, tiers, int32(multiplier))); err != nil {
+		return fmt.Errorf("invalid aggressive scale-out steps: %w", err)
+	}
+	return nil
+}
+
+// Step adjustments for the default scale-out policy: a single +1 step, or the generated tiers with --aggressive-scale-out
+func (r *runner) scaleOutSteps() []StepAdj {
+	if r.cfg.AggressiveScaleOut {
+		return generateSteps(r.cfg.AggressiveStepSize, int(r.cfg.AggressiveTiers), r.cfg.AggressiveMultiplier)
+	}
+	return []StepAdj{{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 1}}
+}
+
+// Step adjustments for the default scale-in policy
+func (r *runner) scaleInSteps() []StepAdj {
+	return []StepAdj{{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: -1}}
+}
+
+// Convert step adjustments to their API form
+func stepAdjustments(steps []StepAdj) []aasTypes.StepAdjustment {
+	var sa []aasTypes.StepAdjustment
+	for _, adj := range steps {
+		sa = append(sa, aasTypes.StepAdjustment{
+			MetricIntervalLowerBound: adj.MetricIntervalLowerBound,
+			MetricIntervalUpperBound: adj.MetricIntervalUpperBound,
+			ScalingAdjustment:        aws.Int32(adj.ScalingAdjustment),
+		})
+	}
+	return sa
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// TestGenerateSteps tests the generated tiers, bounds and adjustments
+func TestGenerateSteps(t *testing.T) {
+	tests := []struct {
+		name       string
+		base       float64
+		tiers      int
+		multiplier int32
+		want       []StepAdj
+	}{
+		{"default", 10, 3, 2, []StepAdj{
+			{MetricIntervalLowerBound: aws.Float64(0), MetricIntervalUpperBound: aws.Float64(10), ScalingAdjustment: 1},
+			{MetricIntervalLowerBound: aws.Float64(10), MetricIntervalUpperBound: aws.Float64(20), ScalingAdjustment: 2},
+			{MetricIntervalLowerBound: aws.Float64(20), ScalingAdjustment: 4},
+		}},
+		{"single tier", 5, 1, 3, []StepAdj{
+			{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 1},
+		}},
+		{"triple", 5, 3, 3, []StepAdj{
+			{MetricIntervalLowerBound: aws.Float64(0), MetricIntervalUpperBound: aws.Float64(5), ScalingAdjustment: 1},
+			{MetricIntervalLowerBound: aws.Float64(5), MetricIntervalUpperBound: aws.Float64(10), ScalingAdjustment: 3},
+			{MetricIntervalLowerBound: aws.Float64(10), ScalingAdjustment: 9},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateSteps(tt.base, tt.tiers, tt.multiplier)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("generateSteps() = %s, want %s", stepsString(got), stepsString(tt.want))
+			}
+			if err := validateStepAdjustments(got); err != nil {
+				t.Errorf("generateSteps() steps are not contiguous: %v", err)
+			}
+		})
+	}
+}
+
+// TestValidateAggressiveSteps tests the --aggressive-scale-out setting checks
+func TestValidateAggressiveSteps(t *testing.T) {
+	tests := []struct {
+		name       string
+		base       float64
+		tiers      int
+		multiplier int
+		wantErr    string
+	}{
+		{"defaults", defaultAggressiveStepSize, defaultAggressiveTiers, defaultAggressiveMultiplier, ""},
+		{"zero step size", 0, 3, 2, "aggressive-step-size"},
+		{"zero tiers", 10, 0, 2, "aggressive-tiers"},
+		{"too many tiers", 10, maxAggressiveTiers + 1, 2, "aggressive-tiers"},
+		{"zero multiplier", 10, 3, 0, "aggressive-multiplier"},
+		{"overflowing adjustment", 10, 20, 10, "overflows"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAggressiveSteps(tt.base, tt.tiers, tt.multiplier)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateAggressiveSteps() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateAggressiveSteps() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestAggressiveScaleOut tests that the default scale-out policy gets the generated steps and scale-in is unchanged
+func TestAggressiveScaleOut(t *testing.T) {
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}})
+	r.cfg.AggressiveScaleOut = true
+	r.cfg.AggressiveStepSize = 10
+	r.cfg.AggressiveTiers = 3
+	r.cfg.AggressiveMultiplier = 2
+
+	// The policies cannot be described afterwards, so apply fails once both are put
+	_ = r.applyDefaultPolicies(context.Background())
+	if len(mockAAS.putScalingPolicyCalls) != 2 {
+		t.Fatalf("applyDefaultPolicies() put %d policies, want 2", len(mockAAS.putScalingPolicyCalls))
+	}
+	out := mockAAS.putScalingPolicyCalls[0].StepScalingPolicyConfiguration.StepAdjustments
+	if len(out) != 3 || aws.ToInt32(out[2].ScalingAdjustment) != 4 {
+		t.Errorf("scale-out steps = %d, want 3 ending in +4", len(out))
+	}
+	in := mockAAS.putScalingPolicyCalls[1].StepScalingPolicyConfiguration.StepAdjustments
+	if len(in) != 1 || aws.ToInt32(in[0].ScalingAdjustment) != -1 {
+		t.Errorf("scale-in steps = %d, want a single -1", len(in))
+	}
+}
+
+// TestDefaultStepSigns tests that the default scale-out steps only add capacity and the scale-in steps only remove it
+func TestDefaultStepSigns(t *testing.T) {
+	r := newTestRunner(t, true, nil, &mockAASClient{}, &mockCWClient{})
+	for _, aggressive := range []bool{false, true} {
+		r.cfg.AggressiveScaleOut = aggressive
+		r.cfg.AggressiveStepSize, r.cfg.AggressiveTiers, r.cfg.AggressiveMultiplier = 10, 3, 2
+		if err := checkStepSigns("out", r.scaleOutSteps()); err != nil {
+			t.Errorf("scaleOutSteps() with aggressive=%v: %v", aggressive, err)
+		}
+		if err := checkStepSigns("in", r.scaleInSteps()); err != nil {
+			t.Errorf("scaleInSteps() with aggressive=%v: %v", aggressive, err)
+		}
+	}
+	if got := stepsString(r.scaleInSteps()); got != "[0,<unset>):-1" {
+		t.Errorf("scaleInSteps() = %s, want a single -1", got)
+	}
+	r.cfg.AggressiveScaleOut = false
+	if got := stepsString(r.scaleOutSteps()); got != "[0,<unset>):1" {
+		t.Errorf("scaleOutSteps() = %s, want a single +1", got)
+	}
+}
+
+// Format steps for test failure messages
+func stepsString(steps []StepAdj) string {
+	var parts []string
+	for _, s := range steps {
+		parts = append(parts, "["+ptrString(s.MetricIntervalLowerBound)+","+ptrString(s.MetricIntervalUpperBound)+"):"+ptrString(&s.ScalingAdjustment))
+	}
+	return strings.Join(parts, " ")
+}
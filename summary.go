@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Write the JSON summary of an enable or disable run that ended with runErr to --summary-file: the same document
+// as the audit event, indented. Missing directories are created. Like publishing the audit event this is best
+// effort: a failure is logged as a warning and never changes the run's result.
+func (r *runner) writeSummary(runErr error) {
+	if command := r.cfg.command(); command != commandEnable && command != commandDisable {
+		return
+	}
+	if err := writeSummaryFile(r.cfg.SummaryFile, r.auditEvent(runErr)); err != nil {
+		r.log.Warn("failed to write summary file", "path", r.cfg.SummaryFile, "error", err)
+		return
+	}
+	r.log.Debug("wrote summary file", "path", r.cfg.SummaryFile)
+}
+
+// Write the event to path atomically, so a reader never sees a half-written file
+func writeSummaryFile(path string, event auditEvent) error {
+	data, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// TestRunSummaryFile tests that an enable run writes its JSON summary to a file in a directory that does not exist
+// yet, that a failed run still writes one, and that a file that cannot be written does not fail the run
+func TestRunSummaryFile(t *testing.T) {
+	newClients := func(registerErr error) (*mockAASClient, *mockCWClient) {
+		return &mockAASClient{
+			describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{
+				ScalingPolicies: []aasTypes.ScalingPolicy{
+					{PolicyName: aws.String("test-cluster-test-service-scale-out"), PolicyARN: aws.String("arn:out")},
+					{PolicyName: aws.String("test-cluster-test-service-scale-in"), PolicyARN: aws.String("arn:in")},
+				},
+			},
+			registerScalableTargetError: registerErr,
+		}, &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	}
+	readSummary := func(t *testing.T, path string) auditEvent {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("summary file not written: %v", err)
+		}
+		var event auditEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			t.Fatalf("summary file is not valid JSON: %v\n%s", err, data)
+		}
+		return event
+	}
+	ctx := context.Background()
+
+	mockAAS, mockCW := newClients(nil)
+	r := newTestRunner(t, true, nil, mockAAS, mockCW)
+	r.cfg.SummaryFile = filepath.Join(t.TempDir(), "artifacts", "autoscaling", "summary.json")
+	if err := Run(ctx, r.cfg, Clients{AAS: mockAAS, CW: mockCW}, nil); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	event := readSummary(t, r.cfg.SummaryFile)
+	if event.Command != commandEnable || event.Resource != "service/test-cluster/test-service" || !event.Success {
+		t.Errorf("summary = %+v, want a successful enable of service/test-cluster/test-service", event)
+	}
+	if event.Summary != "applied 7 changes" || event.Changes.Policies["updated"] != 2 || event.Changes.Alarms["created"] != 4 {
+		t.Errorf("summary %q, changes %+v, want 7 changes: 2 policies updated and 4 alarms created", event.Summary, event.Changes)
+	}
+
+	mockAAS, mockCW = newClients(errors.New("access denied"))
+	r = newTestRunner(t, true, nil, mockAAS, mockCW)
+	r.cfg.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+	if err := Run(ctx, r.cfg, Clients{AAS: mockAAS, CW: mockCW}, nil); err == nil {
+		t.Fatal("Run() error = nil, want the register failure")
+	}
+	if event := readSummary(t, r.cfg.SummaryFile); event.Success || !strings.Contains(event.Error, "access denied") {
+		t.Errorf("summary = %+v, want a failed run with its error", event)
+	}
+
+	// A parent that is a file cannot be created as a directory; that is a warning, not a failure
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mockAAS, mockCW = newClients(nil)
+	r = newTestRunner(t, true, nil, mockAAS, mockCW)
+	r.cfg.SummaryFile = filepath.Join(blocker, "summary.json")
+	if err := Run(ctx, r.cfg, Clients{AAS: mockAAS, CW: mockCW}, nil); err != nil {
+		t.Errorf("Run() with an unwritable summary file error = %v, want nil", err)
+	}
+
+	args := append(testPositionalArgs(), "--all-services-in-cluster", "--summary-file=summary.json")
+	args[4] = ""
+	if _, err := parseArgs(args); err == nil || !strings.Contains(err.Error(), "summary-file is not supported with all-services-in-cluster") {
+		t.Errorf("parseArgs() error = %v, want summary-file to be rejected with all-services-in-cluster", err)
+	}
+}
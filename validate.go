@@ -0,0 +1,494 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// AWS region names such as us-east-1, ap-southeast-2 or us-gov-west-1
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// Check a region before the AWS config is loaded; a typo otherwise only shows up as obscure errors on every call.
+// allowAny skips the format check for partitions with non-standard region names.
+func validateRegion(region string, allowAny bool) error {
+	if region == "" {
+		return errors.New("aws-region is required")
+	}
+	if !allowAny && !regionPattern.MatchString(region) {
+		return fmt.Errorf("invalid aws-region %q: expected a region such as us-east-1; set allow-any-region to skip this check", region)
+	}
+	return nil
+}
+
+// The AWS partition a region is in, from its name: aws-cn, aws-us-gov, the isolated aws-iso* partitions, or aws
+func regionPartition(region string) string {
+	for _, p := range []struct{ prefix, partition string }{
+		{"cn-", "aws-cn"},
+		{"us-gov-", "aws-us-gov"},
+		{"us-isob-", "aws-iso-b"},
+		{"us-iso-", "aws-iso"},
+		{"eu-isoe-", "aws-iso-e"},
+		{"us-isof-", "aws-iso-f"},
+	} {
+		if strings.HasPrefix(region, p.prefix) {
+			return p.partition
+		}
+	}
+	return "aws"
+}
+
+// Check --use-fips-endpoint and --use-dual-stack-endpoint against the region's partition: China has no FIPS
+// endpoints and the isolated partitions have no dual-stack ones, so every call would fail to resolve
+func validateEndpointVariants(region string, fips, dualStack bool) error {
+	partition := regionPartition(region)
+	if fips && partition == "aws-cn" {
+		return fmt.Errorf("use-fips-endpoint is not available in region %s: the aws-cn partition has no FIPS endpoints", region)
+	}
+	if dualStack && strings.HasPrefix(partition, "aws-iso") {
+		return fmt.Errorf("use-dual-stack-endpoint is not available in region %s: the %s partition has no dual-stack endpoints", region, partition)
+	}
+	return nil
+}
+
+// Default upper bound for cooldowns, in seconds: one day
+const defaultMaxCooldown = 86400
+
+// Check a cooldown in seconds against [0, max]; max <= 0 uses defaultMaxCooldown.
+// Values in milliseconds are the usual mistake, so the error says so.
+func validateCooldown(name string, seconds, max int32) error {
+	if max <= 0 {
+		max = defaultMaxCooldown
+	}
+	if seconds < 0 {
+		return fmt.Errorf("invalid %s %d: must not be negative", name, seconds)
+	}
+	if seconds > max {
+		return fmt.Errorf("invalid %s %d: must be at most %d seconds (cooldowns are in seconds, not milliseconds; raise max-cooldown if needed)", name, seconds, max)
+	}
+	return nil
+}
+
+// Check every cooldown a policy sets
+func validatePolicyCooldowns(p PolicyDef, max int32) error {
+	cooldowns := map[string]*int32{"cooldown": p.Cooldown}
+	if tt := p.TargetTrackingConfiguration; tt != nil {
+		cooldowns["scale_out_cooldown"] = tt.ScaleOutCooldown
+		cooldowns["scale_in_cooldown"] = tt.ScaleInCooldown
+	}
+	for _, field := range []string{"cooldown", "scale_out_cooldown", "scale_in_cooldown"} {
+		if value := cooldowns[field]; value != nil {
+			if err := validateCooldown(field, *value, max); err != nil {
+				return fmt.Errorf("policy %s: %w", p.PolicyName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Check an alarm period in seconds; CloudWatch accepts 10, 30 or any multiple of 60
+func validateAlarmPeriod(seconds int32) error {
+	if seconds == 10 || seconds == 30 || seconds > 0 && seconds%60 == 0 {
+		return nil
+	}
+	return fmt.Errorf("period must be 10, 30 or a multiple of 60 seconds, got %d", seconds)
+}
+
+// Check an alarm period for a metric in namespace. Periods under a minute need a high-resolution metric, which
+// only a custom metric can be: the AWS/ namespaces publish at most once a minute.
+func validateMetricAlarmPeriod(seconds int32, namespace string) error {
+	if err := validateAlarmPeriod(seconds); err != nil {
+		return err
+	}
+	if seconds < 60 && strings.HasPrefix(namespace, "AWS/") {
+		return fmt.Errorf("a %d-second period needs a high-resolution custom metric; %s publishes at most once a minute", seconds, namespace)
+	}
+	return nil
+}
+
+// --env values: letters, digits, dots, underscores and hyphens, which every AWS name this tool generates allows
+var envPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Percentile extended statistics, p0 through p100 with up to two decimals (e.g. p99, p99.9)
+var percentilePattern = regexp.MustCompile(`^p(100(\.0{1,2})?|\d{1,2}(\.\d{1,2})?)$`)
+
+// Predefined metric types for the supported ECS and DynamoDB scalable dimensions
+var validPredefinedMetricTypes = []string{
+	string(aasTypes.MetricTypeECSServiceAverageCPUUtilization),
+	string(aasTypes.MetricTypeECSServiceAverageMemoryUtilization),
+	string(aasTypes.MetricTypeALBRequestCountPerTarget),
+	string(aasTypes.MetricTypeDynamoDBReadCapacityUtilization),
+	string(aasTypes.MetricTypeDynamoDBWriteCapacityUtilization),
+}
+
+// Convert an SDK enum's values to plain strings for validation and error messages
+func enumStrings[T ~string](values []T) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = string(v)
+	}
+	return out
+}
+
+// Policy types this tool can build
+var supportedPolicyTypes = []string{string(aasTypes.PolicyTypeStepScaling), string(aasTypes.PolicyTypeTargetTrackingScaling), string(aasTypes.PolicyTypePredictiveScaling)}
+
+// The error for a policy_type this tool cannot build, naming the supported types. The comparison stays
+// case-sensitive, as it is in AWS, but a value differing only in case says which type was meant.
+func policyTypeError(policyType string) error {
+	n := len(supportedPolicyTypes)
+	supported := strings.Join(supportedPolicyTypes[:n-1], ", ") + " or " + supportedPolicyTypes[n-1]
+	if policyType == "" {
+		return fmt.Errorf("policy_type is required: %s", supported)
+	}
+	for _, t := range supportedPolicyTypes {
+		if strings.EqualFold(policyType, t) {
+			return fmt.Errorf("unknown policy_type %q: policy_type is case-sensitive, use %q", policyType, t)
+		}
+	}
+	if slices.Contains(enumStrings(aasTypes.PolicyType("").Values()), policyType) {
+		return fmt.Errorf("unsupported policy_type %q: must be %s", policyType, supported)
+	}
+	return fmt.Errorf("unknown policy_type %q: must be %s", policyType, supported)
+}
+
+// Check an optional enum field; empty values are left for AWS to default or reject
+func validateEnum(policyName, field, value string, valid []string) error {
+	if value == "" || slices.Contains(valid, value) {
+		return nil
+	}
+	return fmt.Errorf("policy %s: invalid %s %q: must be one of %s%s", policyName, field, value, strings.Join(valid, ", "), caseHint(value, valid))
+}
+
+// Point out a value that only differs from a valid one in case, e.g. average for Average, since AWS enums are
+// case-sensitive; empty when there is none
+func caseHint(value string, valid []string) string {
+	for _, v := range valid {
+		if strings.EqualFold(value, v) {
+			return fmt.Sprintf(" (values are case-sensitive: did you mean %s?)", v)
+		}
+	}
+	return ""
+}
+
+// Check the fields each policy type requires, by presence in the raw JSON so that an omitted target_value is
+// told apart from an explicit 0. Returns the problems of each policy by index; raw must already unmarshal.
+func checkRequiredFields(raw []byte) [][]error {
+	var policies []map[string]json.RawMessage
+	if len(raw) == 0 || json.Unmarshal(raw, &policies) != nil {
+		return nil
+	}
+
+	problems := make([][]error, len(policies))
+	for i, p := range policies {
+		missing := func(field, policyType string) {
+			problems[i] = append(problems[i], fmt.Errorf("%s is required for %s", field, policyType))
+		}
+		// A field that is absent or of the wrong type is left at its zero value and reported as missing
+		var name, policyType string
+		_ = json.Unmarshal(p["policy_name"], &name)
+		_ = json.Unmarshal(p["policy_type"], &policyType)
+		if name == "" {
+			problems[i] = append(problems[i], errors.New("policy_name is required"))
+		}
+
+		switch policyType {
+		case "StepScaling":
+			if !present(p, "adjustment_type") {
+				missing("adjustment_type", policyType)
+			}
+			var steps []json.RawMessage
+			_ = json.Unmarshal(p["step_adjustments"], &steps)
+			if len(steps) == 0 {
+				missing("step_adjustments", policyType)
+			}
+		case "TargetTrackingScaling":
+			var tt map[string]json.RawMessage
+			_ = json.Unmarshal(p["target_tracking_configuration"], &tt)
+			if tt == nil {
+				missing("target_tracking_configuration", policyType)
+				break
+			}
+			if !present(tt, "target_value") {
+				missing("target_tracking_configuration.target_value", policyType)
+			}
+			if present(tt, "predefined_metric_specification") == present(tt, "custom_metric_specification") {
+				problems[i] = append(problems[i], errors.New("target_tracking_configuration needs exactly one of predefined_metric_specification and custom_metric_specification"))
+			}
+		case "PredictiveScaling":
+			var ps map[string]json.RawMessage
+			_ = json.Unmarshal(p["predictive_scaling_configuration"], &ps)
+			if ps == nil {
+				missing("predictive_scaling_configuration", policyType)
+				break
+			}
+			var specs []json.RawMessage
+			_ = json.Unmarshal(ps["metric_specifications"], &specs)
+			if len(specs) == 0 {
+				missing("predictive_scaling_configuration.metric_specifications", policyType)
+			}
+		default:
+			problems[i] = append(problems[i], policyTypeError(policyType))
+		}
+	}
+	return problems
+}
+
+// Whether a JSON object has a field set to something other than null or ""
+func present(obj map[string]json.RawMessage, field string) bool {
+	v, ok := obj[field]
+	return ok && string(v) != "null" && string(v) != `""`
+}
+
+// Validate the enum fields of a policy definition before anything is sent to AWS
+func validatePolicy(p PolicyDef) error {
+	if err := validateEnum(p.PolicyName, "adjustment_type", p.AdjustmentType, enumStrings(aasTypes.AdjustmentType("").Values())); err != nil {
+		return err
+	}
+	if p.MinAdjustmentMagnitude != nil {
+		if aasTypes.AdjustmentType(p.AdjustmentType) != aasTypes.AdjustmentTypePercentChangeInCapacity {
+			return fmt.Errorf("policy %s: min_adjustment_magnitude requires adjustment_type PercentChangeInCapacity", p.PolicyName)
+		}
+		if *p.MinAdjustmentMagnitude < 1 {
+			return fmt.Errorf("policy %s: min_adjustment_magnitude must be at least 1, got %d", p.PolicyName, *p.MinAdjustmentMagnitude)
+		}
+	}
+	if err := validateEnum(p.PolicyName, "metric_aggregation_type", p.MetricAggregationType, enumStrings(aasTypes.MetricAggregationType("").Values())); err != nil {
+		return err
+	}
+	if err := validateAlarmStatistic(p.Statistic); err != nil {
+		return fmt.Errorf("policy %s: %w", p.PolicyName, err)
+	}
+	if err := validateEnum(p.PolicyName, "unit", p.Unit, enumStrings(cwTypes.StandardUnit("").Values())); err != nil {
+		return err
+	}
+	if err := validateAlarmActions(p.OKActions); err != nil {
+		return fmt.Errorf("policy %s: invalid ok_actions: %w", p.PolicyName, err)
+	}
+	if err := validateAlarmActions(p.InsufficientDataActions); err != nil {
+		return fmt.Errorf("policy %s: invalid insufficient_data_actions: %w", p.PolicyName, err)
+	}
+	for name, value := range p.Dimensions {
+		if name == "" || value == "" {
+			return fmt.Errorf("policy %s: dimensions must have a non-empty name and value, got %q=%q", p.PolicyName, name, value)
+		}
+	}
+	if err := validateAnomalyDetection(p); err != nil {
+		return err
+	}
+	if p.Period != nil {
+		if p.PolicyType != "StepScaling" || p.MetricName == "" {
+			return fmt.Errorf("policy %s: period only applies to the alarm of a StepScaling policy with metric_name", p.PolicyName)
+		}
+		if err := validateMetricAlarmPeriod(*p.Period, p.MetricNamespace); err != nil {
+			return fmt.Errorf("policy %s: invalid period: %w", p.PolicyName, err)
+		}
+	}
+	if err := validateExactCapacity(p); err != nil {
+		return err
+	}
+	if err := validateBidirectional(p); err != nil {
+		return err
+	}
+	if err := validatePredictiveScaling(p); err != nil {
+		return err
+	}
+	if p.PolicyType == "StepScaling" {
+		switch aasTypes.AdjustmentType(p.AdjustmentType) {
+		case aasTypes.AdjustmentTypeChangeInCapacity, aasTypes.AdjustmentTypePercentChangeInCapacity:
+			if err := checkStepSigns(p.ScaleDirection, p.StepAdjustments); err != nil {
+				return fmt.Errorf("policy %s: %w", p.PolicyName, err)
+			}
+		}
+	}
+
+	tt := p.TargetTrackingConfiguration
+	if tt == nil {
+		return nil
+	}
+	if err := validateEnum(p.PolicyName, "predefined_metric_specification", tt.PredefinedMetricSpecification, validPredefinedMetricTypes); err != nil {
+		return err
+	}
+	if cm := tt.CustomMetricSpecification; cm != nil {
+		if err := validateEnum(p.PolicyName, "statistic", cm.Statistic, enumStrings(aasTypes.MetricStatistic("").Values())); err != nil {
+			return err
+		}
+		if err := validateEnum(p.PolicyName, "unit", cm.Unit, enumStrings(cwTypes.StandardUnit("").Values())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check a target-tracking policy's resource_label: ALBRequestCountPerTarget needs one to name the target group,
+// and no other metric takes one. Runs after --load-balancer-arn has filled in the missing labels.
+func validateResourceLabel(p PolicyDef) error {
+	tt := p.TargetTrackingConfiguration
+	if tt == nil {
+		return nil
+	}
+	metric := aasTypes.MetricType(tt.PredefinedMetricSpecification)
+	switch {
+	case metric == aasTypes.MetricTypeALBRequestCountPerTarget && tt.ResourceLabel == "":
+		return fmt.Errorf("policy %s: resource_label is required for predefined metric %s; set it or load-balancer-arn and target-group-arn", p.PolicyName, metric)
+	case metric == "" && tt.ResourceLabel != "":
+		return fmt.Errorf("policy %s: resource_label only applies to predefined metric %s, not to a custom metric", p.PolicyName, aasTypes.MetricTypeALBRequestCountPerTarget)
+	case metric != aasTypes.MetricTypeALBRequestCountPerTarget && tt.ResourceLabel != "":
+		return fmt.Errorf("policy %s: resource_label only applies to predefined metric %s, not %s", p.PolicyName, aasTypes.MetricTypeALBRequestCountPerTarget, metric)
+	}
+	return nil
+}
+
+// Check that step adjustments cover a contiguous range without overlaps or gaps.
+// Steps may be given in any order; only the lowest can omit its lower bound and only the highest its upper bound.
+func validateStepAdjustments(steps []StepAdj) error {
+	sorted := slices.Clone(steps)
+	slices.SortStableFunc(sorted, func(a, b StepAdj) int {
+		return cmp.Compare(lowerBound(a), lowerBound(b))
+	})
+
+	for i, step := range sorted {
+		lower, upper := lowerBound(step), upperBound(step)
+		if lower >= upper {
+			return fmt.Errorf("step adjustment [%s, %s): lower bound must be less than upper bound", ptrString(step.MetricIntervalLowerBound), ptrString(step.MetricIntervalUpperBound))
+		}
+		if i == 0 {
+			continue
+		}
+		prev := sorted[i-1]
+		if step.MetricIntervalLowerBound == nil {
+			return errors.New("only the lowest step adjustment may omit MetricIntervalLowerBound")
+		}
+		if prev.MetricIntervalUpperBound == nil {
+			return errors.New("only the highest step adjustment may omit MetricIntervalUpperBound")
+		}
+		switch prevUpper := upperBound(prev); {
+		case prevUpper > lower:
+			return fmt.Errorf("step adjustments overlap: one ends at %v but the next starts at %v", prevUpper, lower)
+		case prevUpper < lower:
+			return fmt.Errorf("step adjustments leave a gap between %v and %v", prevUpper, lower)
+		}
+	}
+	return nil
+}
+
+// Check that relative step adjustments move capacity the way the policy's scale_direction says: down for "in",
+// up for "out". A wrong sign scales the wrong way without any error from AWS. Zero steps are allowed as a dead
+// band, and without a direction there is nothing to check.
+func checkStepSigns(direction string, steps []StepAdj) error {
+	for _, step := range steps {
+		switch {
+		case direction == "in" && step.ScalingAdjustment > 0:
+			return fmt.Errorf("scale_direction in needs negative ScalingAdjustments, got %+d", step.ScalingAdjustment)
+		case direction == "out" && step.ScalingAdjustment < 0:
+			return fmt.Errorf("scale_direction out needs positive ScalingAdjustments, got %+d", step.ScalingAdjustment)
+		}
+	}
+	return nil
+}
+
+// Check an ExactCapacity step policy: each ScalingAdjustment is the task count to set, so it cannot be negative,
+// and a bidirectional policy cannot split its steps by sign.
+func validateExactCapacity(p PolicyDef) error {
+	if aasTypes.AdjustmentType(p.AdjustmentType) != aasTypes.AdjustmentTypeExactCapacity {
+		return nil
+	}
+	if p.Bidirectional {
+		return fmt.Errorf("policy %s: bidirectional needs a relative adjustment_type; ExactCapacity steps have no sign to pick a direction", p.PolicyName)
+	}
+	for _, step := range p.StepAdjustments {
+		if step.ScalingAdjustment < 0 {
+			return fmt.Errorf("policy %s: adjustment_type ExactCapacity sets the task count, so ScalingAdjustment must not be negative, got %d", p.PolicyName, step.ScalingAdjustment)
+		}
+	}
+	return nil
+}
+
+// Check that an ExactCapacity policy's counts fall within the scalable target's capacity. Application Auto
+// Scaling clamps them to [min, max] without an error, so a count outside it would silently never be reached.
+func validateExactCapacityRange(p PolicyDef, minCapacity, maxCapacity int32) error {
+	if aasTypes.AdjustmentType(p.AdjustmentType) != aasTypes.AdjustmentTypeExactCapacity {
+		return nil
+	}
+	for _, step := range p.StepAdjustments {
+		if step.ScalingAdjustment < minCapacity || step.ScalingAdjustment > maxCapacity {
+			return fmt.Errorf("policy %s: ExactCapacity ScalingAdjustment %d is outside min-capacity %d and max-capacity %d", p.PolicyName, step.ScalingAdjustment, minCapacity, maxCapacity)
+		}
+	}
+	return nil
+}
+
+// Bounds of a step adjustment, with unset bounds as negative or positive infinity
+func lowerBound(s StepAdj) float64 {
+	if s.MetricIntervalLowerBound == nil {
+		return math.Inf(-1)
+	}
+	return *s.MetricIntervalLowerBound
+}
+
+func upperBound(s StepAdj) float64 {
+	if s.MetricIntervalUpperBound == nil {
+		return math.Inf(1)
+	}
+	return *s.MetricIntervalUpperBound
+}
+
+// maxAlarmActions is the CloudWatch limit on actions per alarm state
+const maxAlarmActions = 5
+
+// maxAlarmDimensions is the CloudWatch limit on dimensions per metric
+const maxAlarmDimensions = 30
+
+// Check alarm action ARNs: each must be an ARN, and CloudWatch allows at most five per state
+func validateAlarmActions(actions []string) error {
+	if len(actions) > maxAlarmActions {
+		return fmt.Errorf("too many actions: %d (max %d)", len(actions), maxAlarmActions)
+	}
+	for _, arn := range actions {
+		if !strings.HasPrefix(arn, "arn:") {
+			return fmt.Errorf("invalid action %q: expected an ARN", arn)
+		}
+	}
+	return nil
+}
+
+// Check a default alarm's comparison operator: a static-threshold CloudWatch operator facing the alarm's way,
+// given by prefix (GreaterThan for scale-out, LessThan for scale-in). The anomaly band operators need a
+// ThresholdMetricId the default alarms do not have. Empty means the default.
+func validateAlarmOperator(op, prefix string) error {
+	if op == "" {
+		return nil
+	}
+	var valid []string
+	for _, v := range cwTypes.ComparisonOperator("").Values() {
+		if s := string(v); strings.HasPrefix(s, prefix) && strings.HasSuffix(s, "ToThreshold") || s == prefix+"Threshold" {
+			valid = append(valid, s)
+		}
+	}
+	if !slices.Contains(valid, op) {
+		return fmt.Errorf("invalid comparison operator %q: must be one of %s", op, strings.Join(valid, ", "))
+	}
+	return nil
+}
+
+// Check an alarm statistic: a standard CloudWatch statistic or a percentile such as p99.
+// Empty means the default (Average).
+func validateAlarmStatistic(stat string) error {
+	valid := enumStrings(cwTypes.Statistic("").Values())
+	if stat == "" || slices.Contains(valid, stat) || percentilePattern.MatchString(stat) {
+		return nil
+	}
+	hint := caseHint(stat, valid)
+	if lower := strings.ToLower(stat); hint == "" && lower != stat && percentilePattern.MatchString(lower) {
+		hint = caseHint(stat, []string{lower})
+	}
+	return fmt.Errorf("invalid statistic %q: must be one of %s, or a percentile such as p99%s", stat, strings.Join(valid, ", "), hint)
+}
@@ -0,0 +1,674 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	cwTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// TestValidatePolicy tests rejection of unknown enum values with a list of valid ones
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PolicyDef
+		wantErr string
+	}{
+		{
+			name: "valid step scaling",
+			policy: PolicyDef{
+				PolicyName:            "step",
+				PolicyType:            "StepScaling",
+				AdjustmentType:        "ChangeInCapacity",
+				MetricAggregationType: "Maximum",
+			},
+		},
+		{
+			name: "valid target tracking with custom metric",
+			policy: PolicyDef{
+				PolicyName: "tt",
+				PolicyType: "TargetTrackingScaling",
+				TargetTrackingConfiguration: &TargetTrackingConfig{
+					CustomMetricSpecification: &CustomMetricSpec{Statistic: "Sum", Unit: "Count"},
+				},
+			},
+		},
+		{
+			name: "min adjustment magnitude with percent change",
+			policy: PolicyDef{
+				PolicyName:             "step",
+				PolicyType:             "StepScaling",
+				AdjustmentType:         "PercentChangeInCapacity",
+				MinAdjustmentMagnitude: aws.Int32(2),
+			},
+		},
+		{
+			name:    "min adjustment magnitude with change in capacity",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", AdjustmentType: "ChangeInCapacity", MinAdjustmentMagnitude: aws.Int32(2)},
+			wantErr: "policy step: min_adjustment_magnitude requires adjustment_type PercentChangeInCapacity",
+		},
+		{
+			name:    "invalid ok action",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", OKActions: []string{"ops-topic"}},
+			wantErr: `policy step: invalid ok_actions: invalid action "ops-topic": expected an ARN`,
+		},
+		{
+			name:    "empty dimension value",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", Dimensions: map[string]string{"QueueName": ""}},
+			wantErr: `policy step: dimensions must have a non-empty name and value, got "QueueName"=""`,
+		},
+		{
+			name:    "invalid adjustment type",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", AdjustmentType: "ChangeCapacity"},
+			wantErr: `invalid adjustment_type "ChangeCapacity": must be one of ChangeInCapacity, PercentChangeInCapacity, ExactCapacity`,
+		},
+		{
+			name:    "invalid metric aggregation type",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", MetricAggregationType: "Max"},
+			wantErr: `invalid metric_aggregation_type "Max": must be one of Average, Minimum, Maximum`,
+		},
+		{
+			name: "invalid predefined metric type",
+			policy: PolicyDef{
+				PolicyName:                  "tt",
+				PolicyType:                  "TargetTrackingScaling",
+				TargetTrackingConfiguration: &TargetTrackingConfig{PredefinedMetricSpecification: "ECSServiceAverageCPUUtilisation"},
+			},
+			wantErr: `invalid predefined_metric_specification "ECSServiceAverageCPUUtilisation": must be one of ECSServiceAverageCPUUtilization, ECSServiceAverageMemoryUtilization, ALBRequestCountPerTarget`,
+		},
+		{
+			name:    "invalid alarm statistic",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", Statistic: "p999"},
+			wantErr: `invalid statistic "p999"`,
+		},
+		{
+			name: "invalid statistic",
+			policy: PolicyDef{
+				PolicyName: "tt",
+				PolicyType: "TargetTrackingScaling",
+				TargetTrackingConfiguration: &TargetTrackingConfig{
+					CustomMetricSpecification: &CustomMetricSpec{Statistic: "Avg"},
+				},
+			},
+			wantErr: `invalid statistic "Avg": must be one of Average, Minimum, Maximum, SampleCount, Sum`,
+		},
+		{
+			name: "lowercase statistic",
+			policy: PolicyDef{
+				PolicyName: "tt",
+				PolicyType: "TargetTrackingScaling",
+				TargetTrackingConfiguration: &TargetTrackingConfig{
+					CustomMetricSpecification: &CustomMetricSpec{Statistic: "average"},
+				},
+			},
+			wantErr: `invalid statistic "average": must be one of Average, Minimum, Maximum, SampleCount, Sum (values are case-sensitive: did you mean Average?)`,
+		},
+		{
+			name:    "lowercase alarm statistic",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", Statistic: "sampleCount"},
+			wantErr: `invalid statistic "sampleCount": must be one of SampleCount, Average, Sum, Minimum, Maximum, or a percentile such as p99 (values are case-sensitive: did you mean SampleCount?)`,
+		},
+		{
+			name:    "uppercase percentile",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", Statistic: "P99"},
+			wantErr: `did you mean p99?`,
+		},
+		{
+			name:    "invalid alarm unit",
+			policy:  PolicyDef{PolicyName: "step", PolicyType: "StepScaling", Unit: "Percentage"},
+			wantErr: `policy step: invalid unit "Percentage"`,
+		},
+		{
+			name: "scale-in with a positive adjustment",
+			policy: PolicyDef{
+				PolicyName:      "in",
+				PolicyType:      "StepScaling",
+				AdjustmentType:  "ChangeInCapacity",
+				ScaleDirection:  "in",
+				StepAdjustments: []StepAdj{{MetricIntervalUpperBound: aws.Float64(0), ScalingAdjustment: 1}},
+			},
+			wantErr: "policy in: scale_direction in needs negative ScalingAdjustments, got +1",
+		},
+		{
+			name: "scale-out with a negative adjustment",
+			policy: PolicyDef{
+				PolicyName:      "out",
+				PolicyType:      "StepScaling",
+				AdjustmentType:  "PercentChangeInCapacity",
+				ScaleDirection:  "out",
+				StepAdjustments: []StepAdj{{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 10}, {MetricIntervalLowerBound: aws.Float64(10), ScalingAdjustment: -10}},
+			},
+			wantErr: "policy out: scale_direction out needs positive ScalingAdjustments, got -10",
+		},
+		{
+			name: "scale-in with a zero dead band",
+			policy: PolicyDef{
+				PolicyName:      "in",
+				PolicyType:      "StepScaling",
+				AdjustmentType:  "ChangeInCapacity",
+				ScaleDirection:  "in",
+				StepAdjustments: []StepAdj{{MetricIntervalUpperBound: aws.Float64(-5), ScalingAdjustment: -1}, {MetricIntervalLowerBound: aws.Float64(-5), ScalingAdjustment: 0}},
+			},
+		},
+		{
+			name: "exact capacity is not a direction",
+			policy: PolicyDef{
+				PolicyName:      "in",
+				PolicyType:      "StepScaling",
+				AdjustmentType:  "ExactCapacity",
+				ScaleDirection:  "in",
+				StepAdjustments: []StepAdj{{MetricIntervalUpperBound: aws.Float64(0), ScalingAdjustment: 2}},
+			},
+		},
+		{
+			name: "invalid custom metric unit",
+			policy: PolicyDef{
+				PolicyName: "tt",
+				PolicyType: "TargetTrackingScaling",
+				TargetTrackingConfiguration: &TargetTrackingConfig{
+					CustomMetricSpecification: &CustomMetricSpec{Statistic: "Sum", Unit: "count"},
+				},
+			},
+			wantErr: `policy tt: invalid unit "count"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePolicy(tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePolicy() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParsePoliciesValidates tests that parsePolicies rejects invalid enum values before any apply
+func TestParsePoliciesValidates(t *testing.T) {
+	_, err := parsePolicies(`[{"policy_name":"step","policy_type":"StepScaling","adjustment_type":"Bogus"}]`, "")
+	if err == nil || !strings.Contains(err.Error(), "adjustment_type") {
+		t.Errorf("parsePolicies() error = %v, want invalid adjustment_type", err)
+	}
+}
+
+// TestParsePoliciesRequiredFields tests the fields each policy type requires, reported with the policy's index and name
+func TestParsePoliciesRequiredFields(t *testing.T) {
+	const steps = `"step_adjustments":[{"MetricIntervalLowerBound":0,"ScalingAdjustment":1}]`
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr string
+	}{
+		{"complete step", `{"policy_name":"s","policy_type":"StepScaling","adjustment_type":"ChangeInCapacity",` + steps + `}`, ""},
+		{"complete target tracking", `{"policy_name":"t","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"target_value":0,"predefined_metric_specification":"ECSServiceAverageCPUUtilization"}}`, ""},
+		{"missing policy name", `{"policy_type":"StepScaling","adjustment_type":"ChangeInCapacity",` + steps + `}`, "policies[0]: policy_name is required"},
+		{"missing policy type", `{"policy_name":"p"}`, "policies[0]: policy p: policy_type is required"},
+		{"unknown policy type", `{"policy_name":"p","policy_type":"Step"}`, `policies[0]: policy p: unknown policy_type "Step": must be StepScaling, TargetTrackingScaling or PredictiveScaling`},
+		{"policy type in the wrong case", `{"policy_name":"p","policy_type":"stepScaling"}`, `policies[0]: policy p: unknown policy_type "stepScaling": policy_type is case-sensitive, use "StepScaling"`},
+		{"missing adjustment type", `{"policy_name":"s","policy_type":"StepScaling",` + steps + `}`, "policies[0]: policy s: adjustment_type is required for StepScaling"},
+		{"missing step adjustments", `{"policy_name":"s","policy_type":"StepScaling","adjustment_type":"ChangeInCapacity"}`, "policies[0]: policy s: step_adjustments is required for StepScaling"},
+		{"empty step adjustments", `{"policy_name":"s","policy_type":"StepScaling","adjustment_type":"ChangeInCapacity","step_adjustments":[]}`, "step_adjustments is required"},
+		{"missing target tracking configuration", `{"policy_name":"t","policy_type":"TargetTrackingScaling"}`, "policies[0]: policy t: target_tracking_configuration is required for TargetTrackingScaling"},
+		{"missing target value", `{"policy_name":"t","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"predefined_metric_specification":"ECSServiceAverageCPUUtilization"}}`, "target_tracking_configuration.target_value is required"},
+		{"no metric spec", `{"policy_name":"t","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"target_value":50}}`, "exactly one of predefined_metric_specification and custom_metric_specification"},
+		{"both metric specs", `{"policy_name":"t","policy_type":"TargetTrackingScaling","target_tracking_configuration":{"target_value":50,"predefined_metric_specification":"ECSServiceAverageCPUUtilization","custom_metric_specification":{"statistic":"Sum"}}}`, "exactly one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePolicies("["+tt.policy+"]", "")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parsePolicies() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parsePolicies() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Every problem in every policy is reported at once
+	_, err := parsePolicies(`[
+		{"policy_name":"ok","policy_type":"StepScaling","adjustment_type":"ChangeInCapacity",`+steps+`},
+		{"policy_name":"s","policy_type":"StepScaling","metric_aggregation_type":"Max"},
+		{"policy_name":"t","policy_type":"TargetTrackingScaling","target_tracking_configuration":{}}
+	]`, "")
+	if err == nil {
+		t.Fatal("parsePolicies() expected error, got nil")
+	}
+	for _, want := range []string{
+		"invalid scaling-policies: 5 problem(s)",
+		"policies[1]: policy s: adjustment_type is required",
+		"policies[1]: policy s: step_adjustments is required",
+		`policies[1]: policy s: invalid metric_aggregation_type "Max"`,
+		"policies[2]: policy t: target_tracking_configuration.target_value is required",
+		"policies[2]: policy t: target_tracking_configuration needs exactly one",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("parsePolicies() error = %v, want containing %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "policies[0]") {
+		t.Errorf("parsePolicies() error = %v, reported the valid policy", err)
+	}
+}
+
+// TestValidateAlarmStatistic tests standard statistics and percentile formats
+func TestValidateAlarmStatistic(t *testing.T) {
+	tests := []struct {
+		stat    string
+		wantErr bool
+	}{
+		{"", false},
+		{"Average", false},
+		{"Maximum", false},
+		{"SampleCount", false},
+		{"p99", false},
+		{"p99.9", false},
+		{"p0", false},
+		{"p100", false},
+		{"average", true},
+		{"p", true},
+		{"p101", true},
+		{"p99.999", true},
+		{"P99", true},
+		{"99", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stat, func(t *testing.T) {
+			err := validateAlarmStatistic(tt.stat)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAlarmStatistic(%q) error = %v, wantErr %v", tt.stat, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateAlarmOperator tests that default alarm operators must be static-threshold operators facing the
+// alarm's direction
+func TestValidateAlarmOperator(t *testing.T) {
+	tests := []struct {
+		op, prefix string
+		wantErr    bool
+	}{
+		{"", "GreaterThan", false},
+		{"GreaterThanThreshold", "GreaterThan", false},
+		{"GreaterThanOrEqualToThreshold", "GreaterThan", false},
+		{"LessThanThreshold", "LessThan", false},
+		{"LessThanOrEqualToThreshold", "LessThan", false},
+		{"LessThanThreshold", "GreaterThan", true},
+		{"GreaterThanThreshold", "LessThan", true},
+		{"GreaterThanUpperThreshold", "GreaterThan", true},
+		{"LessThanLowerOrGreaterThanUpperThreshold", "LessThan", true},
+		{"greaterthanthreshold", "GreaterThan", true},
+		{">", "GreaterThan", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix+"/"+tt.op, func(t *testing.T) {
+			err := validateAlarmOperator(tt.op, tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAlarmOperator(%q, %q) error = %v, wantErr %v", tt.op, tt.prefix, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateStepAdjustments tests contiguous, overlapping and gapped step bounds
+func TestValidateStepAdjustments(t *testing.T) {
+	step := func(lower, upper *float64) StepAdj {
+		return StepAdj{MetricIntervalLowerBound: lower, MetricIntervalUpperBound: upper, ScalingAdjustment: 1}
+	}
+	f := aws.Float64
+
+	tests := []struct {
+		name    string
+		steps   []StepAdj
+		wantErr string
+	}{
+		{name: "none", steps: nil},
+		{name: "single open step", steps: []StepAdj{step(f(0), nil)}},
+		{name: "contiguous", steps: []StepAdj{step(nil, f(0)), step(f(0), f(10)), step(f(10), f(20)), step(f(20), nil)}},
+		{name: "contiguous out of order", steps: []StepAdj{step(f(10), nil), step(f(0), f(10))}},
+		{
+			name:    "overlapping",
+			steps:   []StepAdj{step(f(0), f(15)), step(f(10), nil)},
+			wantErr: "step adjustments overlap: one ends at 15 but the next starts at 10",
+		},
+		{
+			name:    "gap",
+			steps:   []StepAdj{step(f(0), f(10)), step(f(20), nil)},
+			wantErr: "step adjustments leave a gap between 10 and 20",
+		},
+		{
+			name:    "two unbounded lower",
+			steps:   []StepAdj{step(nil, f(0)), step(nil, f(10))},
+			wantErr: "only the lowest step adjustment may omit MetricIntervalLowerBound",
+		},
+		{
+			name:    "two unbounded upper",
+			steps:   []StepAdj{step(f(0), nil), step(f(10), nil)},
+			wantErr: "only the highest step adjustment may omit MetricIntervalUpperBound",
+		},
+		{
+			name:    "empty interval",
+			steps:   []StepAdj{step(f(10), f(10))},
+			wantErr: "step adjustment [10, 10): lower bound must be less than upper bound",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStepAdjustments(tt.steps)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateStepAdjustments() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("validateStepAdjustments() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestBuildPolicyInputRejectsOverlappingSteps tests that invalid steps fail before a policy input is built
+func TestBuildPolicyInputRejectsOverlappingSteps(t *testing.T) {
+	p := PolicyDef{
+		PolicyName:     "step",
+		PolicyType:     "StepScaling",
+		AdjustmentType: "ChangeInCapacity",
+		Cooldown:       aws.Int32(60),
+		StepAdjustments: []StepAdj{
+			{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 1},
+			{MetricIntervalLowerBound: aws.Float64(5), ScalingAdjustment: 2},
+		},
+	}
+	if _, err := buildPolicyInput(p, testResource("service/test-cluster/test-service")); err == nil {
+		t.Error("buildPolicyInput() expected error, got nil")
+	}
+}
+
+// TestValidateRegion tests well-formed, empty and malformed regions, and the allow-any-region escape hatch
+func TestValidateRegion(t *testing.T) {
+	tests := []struct {
+		region   string
+		allowAny bool
+		wantErr  bool
+	}{
+		{"us-east-1", false, false},
+		{"ap-southeast-2", false, false},
+		{"us-gov-west-1", false, false},
+		{"cn-northwest-1", false, false},
+		{"", false, true},
+		{"", true, true},
+		{"us-east", false, true},
+		{"US-EAST-1", false, true},
+		{"us-east-1 ", false, true},
+		{"useast1", false, true},
+		{"custom-region", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q allowAny=%v", tt.region, tt.allowAny), func(t *testing.T) {
+			err := validateRegion(tt.region, tt.allowAny)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRegion(%q, %v) error = %v, wantErr %v", tt.region, tt.allowAny, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateResourceLabel tests that ALBRequestCountPerTarget requires a resource_label and every other metric
+// rejects one, and that the check runs after --load-balancer-arn fills in missing labels
+func TestValidateResourceLabel(t *testing.T) {
+	policy := func(metric, label string) PolicyDef {
+		return PolicyDef{PolicyName: "tt", PolicyType: "TargetTrackingScaling", TargetTrackingConfiguration: &TargetTrackingConfig{
+			TargetValue: 50, PredefinedMetricSpecification: metric, ResourceLabel: label,
+		}}
+	}
+	custom := policy("", "app/my-alb/1/targetgroup/my-tg/2")
+	custom.TargetTrackingConfiguration.CustomMetricSpecification = &CustomMetricSpec{MetricName: "QueueDepth", Namespace: "Custom", Statistic: "Average"}
+
+	tests := []struct {
+		name    string
+		policy  PolicyDef
+		wantErr string
+	}{
+		{"alb with label", policy("ALBRequestCountPerTarget", "app/my-alb/1/targetgroup/my-tg/2"), ""},
+		{"cpu without label", policy("ECSServiceAverageCPUUtilization", ""), ""},
+		{"step scaling", PolicyDef{PolicyName: "tt", PolicyType: "StepScaling"}, ""},
+		{"alb without label", policy("ALBRequestCountPerTarget", ""), "policy tt: resource_label is required for predefined metric ALBRequestCountPerTarget"},
+		{"cpu with label", policy("ECSServiceAverageCPUUtilization", "app/my-alb/1/targetgroup/my-tg/2"), "policy tt: resource_label only applies to predefined metric ALBRequestCountPerTarget, not ECSServiceAverageCPUUtilization"},
+		{"memory with label", policy("ECSServiceAverageMemoryUtilization", "label"), "not ECSServiceAverageMemoryUtilization"},
+		{"custom metric with label", custom, "not to a custom metric"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResourceLabel(tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateResourceLabel() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateResourceLabel() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// newRunner rejects a missing label up front, and accepts one filled in from the ARNs
+	cfg := &Config{
+		Cluster:       "test-cluster",
+		Service:       "test-service",
+		Enabled:       true,
+		MinCapacity:   1,
+		MaxCapacity:   10,
+		AlarmsEnabled: true,
+		PoliciesRaw:   `[{"policy_name": "requests", "policy_type": "TargetTrackingScaling", "target_tracking_configuration": {"target_value": 1000, "predefined_metric_specification": "ALBRequestCountPerTarget"}}]`,
+	}
+	if _, err := newRunner(cfg, Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard); err == nil || !strings.Contains(err.Error(), "policy requests: resource_label is required") {
+		t.Errorf("newRunner() error = %v, want policy requests to need a resource_label", err)
+	}
+	cfg.LoadBalancerARN, cfg.TargetGroupARN = testLoadBalancerARN, testTargetGroupARN
+	if _, err := newRunner(cfg, Clients{AAS: &mockAASClient{}, CW: &mockCWClient{}}, io.Discard); err != nil {
+		t.Errorf("newRunner() with the ALB ARNs unexpected error: %v", err)
+	}
+}
+
+// TestValidateEndpointVariants tests that FIPS and dual-stack endpoints are only accepted in partitions that have them
+func TestValidateEndpointVariants(t *testing.T) {
+	tests := []struct {
+		region          string
+		fips, dualStack bool
+		wantErr         string
+	}{
+		{"us-gov-west-1", true, false, ""},
+		{"us-east-1", true, true, ""},
+		{"cn-north-1", false, true, ""},
+		{"cn-north-1", false, false, ""},
+		{"cn-north-1", true, false, "aws-cn partition has no FIPS endpoints"},
+		{"us-iso-east-1", true, false, ""},
+		{"us-iso-east-1", false, true, "aws-iso partition has no dual-stack endpoints"},
+		{"us-isob-east-1", false, true, "aws-iso-b partition has no dual-stack endpoints"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s fips=%v dualstack=%v", tt.region, tt.fips, tt.dualStack), func(t *testing.T) {
+			err := validateEndpointVariants(tt.region, tt.fips, tt.dualStack)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateEndpointVariants() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateEndpointVariants() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidatePolicyCooldowns tests the cooldown range check, including the milliseconds mistake
+func TestValidatePolicyCooldowns(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PolicyDef
+		max     int32
+		wantErr string
+	}{
+		{"step cooldown in range", PolicyDef{PolicyName: "p", Cooldown: aws.Int32(300)}, 0, ""},
+		{"step cooldown in milliseconds", PolicyDef{PolicyName: "p", Cooldown: aws.Int32(86400000)}, 0, "not milliseconds"},
+		{"negative cooldown", PolicyDef{PolicyName: "p", Cooldown: aws.Int32(-1)}, 0, "negative"},
+		{"target tracking scale-in too large", PolicyDef{
+			PolicyName:                  "p",
+			TargetTrackingConfiguration: &TargetTrackingConfig{ScaleOutCooldown: aws.Int32(60), ScaleInCooldown: aws.Int32(90000)},
+		}, 0, "scale_in_cooldown"},
+		{"custom max", PolicyDef{PolicyName: "p", Cooldown: aws.Int32(900)}, 600, "at most 600"},
+		{"unset cooldowns", PolicyDef{PolicyName: "p", TargetTrackingConfiguration: &TargetTrackingConfig{}}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePolicyCooldowns(tt.policy, tt.max)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validatePolicyCooldowns() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validatePolicyCooldowns() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Policy cooldowns are checked before anything is sent to AWS
+	cfg := &Config{Cluster: "c", Service: "s", Enabled: true, PoliciesRaw: `[{"policy_name":"p","policy_type":"StepScaling","adjustment_type":"ChangeInCapacity","cooldown":300000,"step_adjustments":[{"MetricIntervalLowerBound":0,"ScalingAdjustment":1}]}]`}
+	if _, err := newRunner(cfg, Clients{}, nil); err == nil || !strings.Contains(err.Error(), "cooldown") {
+		t.Errorf("newRunner() with a too-large policy cooldown error = %v, want a cooldown error", err)
+	}
+}
+
+// TestValidateExactCapacity tests that ExactCapacity steps are absolute task counts within the target's capacity
+func TestValidateExactCapacity(t *testing.T) {
+	policy := func(steps ...StepAdj) PolicyDef {
+		return PolicyDef{PolicyName: "exact", PolicyType: "StepScaling", AdjustmentType: "ExactCapacity", StepAdjustments: steps}
+	}
+	lower := StepAdj{MetricIntervalUpperBound: aws.Float64(0), ScalingAdjustment: 2}
+	upper := StepAdj{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: 8}
+	bidirectional := policy(lower, upper)
+	bidirectional.Bidirectional, bidirectional.MetricName, bidirectional.MetricNamespace = true, "CPUUtilization", "AWS/ECS"
+	inward := policy(upper)
+	inward.ScaleDirection = "in"
+
+	tests := []struct {
+		name    string
+		policy  PolicyDef
+		wantErr string
+	}{
+		{"valid", policy(lower, upper), ""},
+		{"zero tasks", policy(StepAdj{MetricIntervalLowerBound: aws.Float64(0)}), ""},
+		{"scale direction does not constrain the sign", inward, ""},
+		{"negative count", policy(StepAdj{MetricIntervalLowerBound: aws.Float64(0), ScalingAdjustment: -1}), "must not be negative, got -1"},
+		{"bidirectional", bidirectional, "needs a relative adjustment_type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePolicy(tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePolicy() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := validateExactCapacityRange(policy(lower, upper), 2, 8); err != nil {
+		t.Errorf("validateExactCapacityRange() unexpected error: %v", err)
+	}
+	if err := validateExactCapacityRange(policy(lower, upper), 1, 5); err == nil || !strings.Contains(err.Error(), "ScalingAdjustment 8 is outside min-capacity 1 and max-capacity 5") {
+		t.Errorf("validateExactCapacityRange() error = %v, want 8 outside 1..5", err)
+	}
+
+	// Counts outside the capacity are caught before anything is sent to AWS, but only when enabling
+	cfg := &Config{Cluster: "c", Service: "s", Enabled: true, MinCapacity: 1, MaxCapacity: 5, PoliciesRaw: `[{"policy_name":"p","policy_type":"StepScaling","adjustment_type":"ExactCapacity","step_adjustments":[{"MetricIntervalLowerBound":0,"ScalingAdjustment":10}]}]`}
+	if _, err := newRunner(cfg, Clients{}, nil); err == nil || !strings.Contains(err.Error(), "outside min-capacity") {
+		t.Errorf("newRunner() with an ExactCapacity count above max-capacity error = %v, want a capacity error", err)
+	}
+	cfg.Enabled = false
+	if _, err := newRunner(cfg, Clients{}, nil); err != nil {
+		t.Errorf("newRunner() disabling unexpected error: %v", err)
+	}
+}
+
+// TestValidatePolicyPeriod tests that 10 and 30 second alarm periods are accepted only for custom metrics
+func TestValidatePolicyPeriod(t *testing.T) {
+	policy := func(namespace string, period int32) PolicyDef {
+		return PolicyDef{PolicyName: "queue", PolicyType: "StepScaling", MetricName: "Backlog", MetricNamespace: namespace, Cooldown: aws.Int32(60), Period: aws.Int32(period)}
+	}
+	tracking := PolicyDef{PolicyName: "tt", PolicyType: "TargetTrackingScaling", Period: aws.Int32(60)}
+
+	tests := []struct {
+		name    string
+		policy  PolicyDef
+		wantErr string
+	}{
+		{"10 seconds on a custom metric", policy("MyApp", 10), ""},
+		{"30 seconds on a custom metric", policy("MyApp", 30), ""},
+		{"multiple of 60", policy("AWS/SQS", 120), ""},
+		{"45 seconds", policy("MyApp", 45), "period must be 10, 30 or a multiple of 60 seconds, got 45"},
+		{"10 seconds on an AWS metric", policy("AWS/SQS", 10), "needs a high-resolution custom metric; AWS/SQS publishes at most once a minute"},
+		{"target tracking", tracking, "period only applies to the alarm of a StepScaling policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePolicy(tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePolicy() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The alarm uses period instead of the cooldown, and exports it back only when they differ
+	p := policy("MyApp", 10)
+	r := newTestRunner(t, true, []PolicyDef{p}, &mockAASClient{}, &mockCWClient{})
+	in, err := r.customAlarmInput(p, "arn:policy")
+	if err != nil {
+		t.Fatalf("customAlarmInput() unexpected error: %v", err)
+	}
+	if got := aws.ToInt32(in.Period); got != 10 {
+		t.Errorf("alarm Period = %d, want 10", got)
+	}
+	sp := aasTypes.ScalingPolicy{
+		PolicyName:                     aws.String("queue"),
+		PolicyType:                     aasTypes.PolicyTypeStepScaling,
+		StepScalingPolicyConfiguration: &aasTypes.StepScalingPolicyConfiguration{Cooldown: aws.Int32(60)},
+	}
+	alarm := &cwTypes.MetricAlarm{MetricName: in.MetricName, Namespace: in.Namespace, Period: in.Period}
+	if got := policyDefFromScalingPolicy(sp, alarm, r.resource).Period; aws.ToInt32(got) != 10 {
+		t.Errorf("exported period = %v, want 10", ptrString(got))
+	}
+	alarm.Period = aws.Int32(60)
+	if got := policyDefFromScalingPolicy(sp, alarm, r.resource).Period; got != nil {
+		t.Errorf("exported period = %d, want unset when it matches the cooldown", *got)
+	}
+
+	// The default alarms are on AWS/ECS metrics, so sub-minute periods are rejected there
+	if _, err := parseArgs(append(testPositionalArgs(), "--default-alarm-period=30")); err == nil || !strings.Contains(err.Error(), "high-resolution") {
+		t.Errorf("parseArgs() with default-alarm-period=30 error = %v, want a high-resolution error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build metadata, set at build time with
+// -ldflags "-X main.version=v1.2.3 -X main.commit=abc1234 -X main.buildDate=2024-01-01T00:00:00Z"
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// buildInfo describes the running binary for --version
+type buildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// Collect build metadata, falling back to the VCS details Go embeds when ldflags were not set
+func currentBuildInfo() buildInfo {
+	info := buildInfo{Version: version, Commit: commit, Date: buildDate}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+func (b buildInfo) String() string {
+	return fmt.Sprintf("ecs-autoscaler %s (commit %s, built %s)", b.Version, b.Commit, b.Date)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestVersion tests that --version parses without AWS credentials or other inputs and prints build info
+func TestVersion(t *testing.T) {
+	t.Setenv("ECSAS_ACCESS_KEY_ID", "")
+	t.Setenv("ECSAS_SECRET_ACCESS_KEY", "")
+
+	cfg, err := parseArgs([]string{"--version"})
+	if err != nil {
+		t.Fatalf("parseArgs(--version) unexpected error: %v", err)
+	}
+	if !cfg.Version {
+		t.Fatal("parseArgs(--version) did not set Version")
+	}
+
+	info := currentBuildInfo()
+	if info.Version == "" || info.Commit == "" || info.Date == "" {
+		t.Errorf("currentBuildInfo() = %+v, want every field set", info)
+	}
+	if out := info.String(); !strings.HasPrefix(out, "ecs-autoscaler "+info.Version) {
+		t.Errorf("buildInfo.String() = %q, want ecs-autoscaler %s prefix", out, info.Version)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+)
+
+// Poll until the scalable target and every desired scaling policy can be described, so automation that runs
+// right after the action does not race Application Auto Scaling's eventual consistency.
+func (r *runner) waitUntilVisible(ctx context.Context) error {
+	policyNames := []string{r.scaleOutName, r.scaleInName}
+	if r.cfg.TargetOnly {
+		policyNames = nil
+	} else if len(r.policies) > 0 {
+		policyNames = policyNames[:0]
+		for _, p := range r.policies {
+			policyNames = append(policyNames, p.PolicyName)
+		}
+	}
+
+	r.log.Info("waiting for scalable target and scaling policies", "timeout", r.cfg.WaitTimeout)
+	missing := append([]string{"scalable target"}, policyNames...)
+	if err := r.poll(ctx, missing, func(ctx context.Context) ([]string, error) {
+		return r.missingResources(ctx, policyNames)
+	}); err != nil {
+		return err
+	}
+	r.log.Info("scalable target and scaling policies are visible")
+	return nil
+}
+
+// Poll after deregistering until the scalable target can no longer be described, so automation that runs right
+// after a disable does not still find it
+func (r *runner) waitUntilDeregistered(ctx context.Context) error {
+	r.log.Info("waiting for scalable target to be deregistered", "timeout", r.cfg.WaitTimeout)
+	if err := r.poll(ctx, []string{"scalable target to be deregistered"}, func(ctx context.Context) ([]string, error) {
+		exists, err := scalableTargetExists(ctx, r.aas, r.resource)
+		if err != nil || !exists {
+			return nil, err
+		}
+		return []string{"scalable target to be deregistered"}, nil
+	}); err != nil {
+		return err
+	}
+	r.log.Info("scalable target is deregistered")
+	return nil
+}
+
+// Call check every WaitInterval until it reports nothing pending, for at most WaitTimeout. pending is what the
+// timeout error names until check first succeeds.
+func (r *runner) poll(ctx context.Context, pending []string, check func(context.Context) ([]string, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.WaitTimeout)
+	defer cancel()
+
+	for {
+		// Errors caused by the deadline expiring mid-request are reported as a timeout below
+		current, err := check(ctx)
+		switch {
+		case err == nil && len(current) == 0:
+			return nil
+		case err == nil:
+			pending = current
+		case ctx.Err() == nil:
+			return err
+		}
+		r.log.Debug("still waiting", "missing", pending)
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s waiting for %v", r.cfg.WaitTimeout, pending)
+			}
+			return ctx.Err()
+		case <-time.After(r.cfg.WaitInterval):
+		}
+	}
+}
+
+// List the resources that cannot be described yet: "scalable target" and/or policy names
+func (r *runner) missingResources(ctx context.Context, policyNames []string) ([]string, error) {
+	var missing []string
+	exists, err := scalableTargetExists(ctx, r.aas, r.resource)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		missing = append(missing, "scalable target")
+	}
+
+	policies, err := describeScalingPolicies(ctx, r.aas, r.resource, policyNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scaling policies: %w", err)
+	}
+	for _, name := range policyNames {
+		if !slices.ContainsFunc(policies, func(p aasTypes.ScalingPolicy) bool { return aws.ToString(p.PolicyName) == name }) {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
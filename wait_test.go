@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aasTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// TestWaitUntilVisible tests that polling stops once the target appears, and times out when it never does
+func TestWaitUntilVisible(t *testing.T) {
+	empty := &applicationautoscaling.DescribeScalableTargetsOutput{}
+	registered := &applicationautoscaling.DescribeScalableTargetsOutput{
+		ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+	}
+	policies := &applicationautoscaling.DescribeScalingPoliciesOutput{
+		ScalingPolicies: []aasTypes.ScalingPolicy{
+			{PolicyName: aws.String("test-cluster-test-service-scale-out")},
+			{PolicyName: aws.String("test-cluster-test-service-scale-in")},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		seq       []*applicationautoscaling.DescribeScalableTargetsOutput
+		wantCalls int
+		wantErr   string
+	}{
+		{"visible immediately", []*applicationautoscaling.DescribeScalableTargetsOutput{registered}, 1, ""},
+		{"visible after polling", []*applicationautoscaling.DescribeScalableTargetsOutput{empty, empty, registered}, 3, ""},
+		{"never visible", []*applicationautoscaling.DescribeScalableTargetsOutput{empty}, 0, "timed out after 50ms waiting for [scalable target]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{describeScalableTargetsSeq: tt.seq, describeScalingPoliciesOutput: policies}
+			r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+			r.cfg.WaitTimeout = 50 * time.Millisecond
+			r.cfg.WaitInterval = time.Millisecond
+
+			err := r.waitUntilVisible(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("waitUntilVisible() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("waitUntilVisible() unexpected error: %v", err)
+			}
+			if mockAAS.describeScalableTargetsCalls != tt.wantCalls {
+				t.Errorf("DescribeScalableTargets called %d times, want %d", mockAAS.describeScalableTargetsCalls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestWaitUntilVisibleCanceled tests that a canceled context stops polling
+func TestWaitUntilVisibleCanceled(t *testing.T) {
+	mockAAS := &mockAASClient{
+		describeScalableTargetsOutput: &applicationautoscaling.DescribeScalableTargetsOutput{},
+		describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+	}
+	r := newTestRunner(t, true, nil, mockAAS, &mockCWClient{})
+	r.cfg.WaitTimeout = time.Minute
+	r.cfg.WaitInterval = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.waitUntilVisible(ctx); err != context.Canceled {
+		t.Errorf("waitUntilVisible() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// TestWaitUntilDeregistered tests polling after deregistration until the target stops being described
+func TestWaitUntilDeregistered(t *testing.T) {
+	empty := &applicationautoscaling.DescribeScalableTargetsOutput{}
+	registered := &applicationautoscaling.DescribeScalableTargetsOutput{
+		ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+	}
+
+	tests := []struct {
+		name      string
+		seq       []*applicationautoscaling.DescribeScalableTargetsOutput
+		wantCalls int
+		wantErr   string
+	}{
+		{"gone immediately", []*applicationautoscaling.DescribeScalableTargetsOutput{empty}, 1, ""},
+		{"still described once", []*applicationautoscaling.DescribeScalableTargetsOutput{registered, empty}, 2, ""},
+		{"never gone", []*applicationautoscaling.DescribeScalableTargetsOutput{registered}, 0, "timed out after 50ms waiting for [scalable target to be deregistered]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAAS := &mockAASClient{describeScalableTargetsSeq: tt.seq}
+			r := newTestRunner(t, false, nil, mockAAS, &mockCWClient{})
+			r.cfg.WaitTimeout = 50 * time.Millisecond
+			r.cfg.WaitInterval = time.Millisecond
+
+			err := r.waitUntilDeregistered(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("waitUntilDeregistered() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("waitUntilDeregistered() unexpected error: %v", err)
+			}
+			if mockAAS.describeScalableTargetsCalls != tt.wantCalls {
+				t.Errorf("DescribeScalableTargets called %d times, want %d", mockAAS.describeScalableTargetsCalls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestCleanupWait tests that a disable with --wait polls after deregistering, and that --keep-target skips it
+func TestCleanupWait(t *testing.T) {
+	registered := &applicationautoscaling.DescribeScalableTargetsOutput{
+		ScalableTargets: []aasTypes.ScalableTarget{{MinCapacity: aws.Int32(2), MaxCapacity: aws.Int32(10)}},
+	}
+	for _, keepTarget := range []bool{false, true} {
+		// The first describe is cleanup's own existence check; the target then lingers for one poll
+		mockAAS := &mockAASClient{
+			describeScalableTargetsSeq:    []*applicationautoscaling.DescribeScalableTargetsOutput{registered, registered, {}},
+			describeScalingPoliciesOutput: &applicationautoscaling.DescribeScalingPoliciesOutput{},
+		}
+		r := newTestRunner(t, false, nil, mockAAS, &mockCWClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}})
+		r.cfg.Wait, r.cfg.KeepTarget = true, keepTarget
+		r.cfg.WaitTimeout = time.Second
+		r.cfg.WaitInterval = time.Millisecond
+
+		if err := r.cleanup(context.Background()); err != nil {
+			t.Fatalf("cleanup() keep-target=%v unexpected error: %v", keepTarget, err)
+		}
+		wantCalls := 3
+		if keepTarget {
+			wantCalls = 1
+		}
+		if mockAAS.describeScalableTargetsCalls != wantCalls {
+			t.Errorf("cleanup() keep-target=%v described the target %d times, want %d", keepTarget, mockAAS.describeScalableTargetsCalls, wantCalls)
+		}
+	}
+}